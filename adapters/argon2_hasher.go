@@ -0,0 +1,108 @@
+package adapters
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Prefix = "argon2id$"
+
+var ErrInvalidArgon2Hash = errors.New("invalid argon2id hash")
+
+type Argon2Hasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2Hasher crea un hasher argon2id con los parámetros recomendados por OWASP.
+func NewArgon2Hasher() *Argon2Hasher {
+	return &Argon2Hasher{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func (h *Argon2Hasher) Algorithm() string {
+	return "argon2id"
+}
+
+// Hash genera un hash con el formato argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func (h *Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix,
+		argon2.Version,
+		h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2Hasher) Verify(encoded, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *Argon2Hasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory ||
+		params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism ||
+		uint32(len(key)) != h.KeyLength
+}
+
+func decodeArgon2Hash(encoded string) (*Argon2Hasher, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(encoded, argon2Prefix), "$")
+	if len(parts) != 4 {
+		return nil, nil, nil, ErrInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidArgon2Hash
+	}
+
+	params := &Argon2Hasher{}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidArgon2Hash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidArgon2Hash
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const bcryptPrefix = "bcrypt$"
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Algorithm() string {
+	return "bcrypt"
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return bcryptPrefix + string(hashed), nil
+}
+
+// Verify acepta tanto hashes con prefijo como los bcrypt "en crudo" que se
+// guardaban antes de introducir los prefijos de algoritmo.
+func (h *BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(strings.TrimPrefix(encoded, bcryptPrefix)), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	if !strings.HasPrefix(encoded, bcryptPrefix) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(strings.TrimPrefix(encoded, bcryptPrefix)))
+	return err != nil || cost != h.Cost
+}
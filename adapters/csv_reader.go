@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/drossan/core-api/domain/importer"
+)
+
+// CSVReader lee ficheros CSV separados por comas o por punto y coma, que es
+// lo que exporta Excel con la configuración regional española
+type CSVReader struct{}
+
+func NewCSVReader() *CSVReader {
+	return &CSVReader{}
+}
+
+func (r *CSVReader) ReadRows(input io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectCSVSeparator(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", importer.ErrInvalidFile, err)
+	}
+	return rows, nil
+}
+
+// detectCSVSeparator elige el separador que más aparece en la cabecera
+func detectCSVSeparator(data []byte) rune {
+	header, _ := bufio.NewReader(bytes.NewReader(data)).ReadBytes('\n')
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"html/template"
 	"log"
+	"mime"
 	"net/smtp"
 	"os"
 
@@ -84,3 +85,36 @@ func (e *EmailNotifier) SendNotificationWithTemplate(templatePath string, data i
 	log.Printf("Email sent to %s with template", to)
 	return nil
 }
+
+// SendTemplateTo envía la plantilla HTML renderizada al destinatario indicado
+func (e *EmailNotifier) SendTemplateTo(to, subject, templatePath string, data interface{}) error {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		log.Printf("Failed to parse template: %v", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		log.Printf("Failed to execute template: %v", err)
+		return err
+	}
+
+	msg := "From: " + e.FromEmail + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n" +
+		body.String()
+
+	err = smtp.SendMail(e.SMTPHost+":"+e.SMTPPort, e.auth, e.FromEmail, []string{to}, []byte(msg))
+	if err != nil {
+		log.Printf("Failed to send email with template: %v", err)
+		return err
+	}
+	log.Printf("Email sent to %s with template", to)
+	return nil
+}
+
+// Asegúrate de que EmailNotifier implemente Mailer
+var _ notification.Mailer = &EmailNotifier{}
@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnsupportedSigningKey = errors.New("unsupported signing key: only RSA and Ed25519 PEM keys are allowed")
+
+// NewHMACSigningKey clave HS256 heredada (JWT_SECRET). No tiene kid: solo
+// verifica tokens emitidos sin él y nunca se publica en el JWKS.
+func NewHMACSigningKey(secret string) *security.SigningKey {
+	return &security.SigningKey{
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}
+}
+
+// LoadSigningKey lee una clave RSA (RS256) o Ed25519 (EdDSA) en PEM. Con una
+// clave privada sirve para firmar; con una pública solo para verificar. Si id
+// está vacío se deriva de la huella de la clave pública.
+func LoadSigningKey(id, path string) (*security.SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSigningKey(id, data)
+}
+
+func ParseSigningKey(id string, data []byte) (*security.SigningKey, error) {
+	key := &security.SigningKey{ID: id}
+
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, private, &private.PublicKey
+	} else if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	} else if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		key.Method, key.Public = jwt.SigningMethodRS256, public
+	} else if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		key.Method, key.Public = jwt.SigningMethodEdDSA, public
+	} else {
+		return nil, ErrUnsupportedSigningKey
+	}
+
+	if key.ID == "" {
+		thumbprint, err := keyThumbprint(key.Public)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = thumbprint
+	}
+	return key, nil
+}
+
+// keyThumbprint identificador estable de una clave pública para usarlo como kid
+func keyThumbprint(public interface{}) (string, error) {
+	switch public.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return "", ErrUnsupportedSigningKey
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
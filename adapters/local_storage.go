@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/drossan/core-api/domain/storage"
+)
+
+// LocalStorage guarda los ficheros en disco, normalmente dentro de public/
+// para que los sirva el propio router.
+type LocalStorage struct {
+	baseDir string
+	baseURL string
+}
+
+func NewLocalStorage(baseDir, baseURL string) *LocalStorage {
+	return &LocalStorage{baseDir: baseDir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *LocalStorage) Save(key string, content io.Reader, contentType string) (string, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", err
+	}
+
+	// Se escribe en un temporal y se renombra para no servir ficheros a medias
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", err
+	}
+
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path convierte la clave en una ruta dentro de baseDir rechazando las que
+// intentan salir de él.
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || path.IsAbs(key) || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return "", storage.ErrInvalidKey
+	}
+	return filepath.Join(s.baseDir, filepath.FromSlash(key)), nil
+}
+
+var _ storage.FileStorage = (*LocalStorage)(nil)
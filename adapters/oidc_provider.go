@@ -0,0 +1,394 @@
+package adapters
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drossan/core-api/domain/identity"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limita cuántas veces se vuelve a descargar el JWKS
+// cuando llega un id_token firmado con una clave desconocida
+const jwksRefreshInterval = time.Minute
+
+// OIDCConfig datos del cliente registrado en el proveedor de identidad. Los
+// endpoints se obtienen del documento de discovery del emisor, pero cada uno
+// puede fijarse a mano (proveedores sin discovery, tests...).
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// GroupsClaim nombre del claim del id_token con los grupos del usuario
+	GroupsClaim string
+
+	DiscoveryURL string
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string
+}
+
+type oidcEndpoints struct {
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	JWKSURL  string `json:"jwks_uri"`
+}
+
+// OIDCProvider implementa el flujo authorization code + PKCE de OpenID Connect
+// y verifica los id_token con las claves públicas (JWKS) del proveedor.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	endpoints   *oidcEndpoints
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	if config.DiscoveryURL == "" && config.Issuer != "" {
+		config.DiscoveryURL = config.Issuer + "/.well-known/openid-configuration"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	return &OIDCProvider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	endpoints, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(endpoints.AuthURL, "?") {
+		separator = "&"
+	}
+	return endpoints.AuthURL + separator + params.Encode(), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*identity.Identity, error) {
+	endpoints, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoints.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.getJSON(req, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, fmt.Errorf("%w: missing id_token", identity.ErrInvalidIDToken)
+	}
+
+	return p.verify(tokenResponse.IDToken, endpoints.Issuer, nonce)
+}
+
+// verify comprueba firma, emisor, audiencia, expiración y nonce del id_token
+func (p *OIDCProvider) verify(rawIDToken, issuer, nonce string) (*identity.Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", identity.ErrInvalidIDToken, err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", identity.ErrInvalidIDToken)
+	}
+	// Con varias audiencias el cliente autorizado (azp) debe ser este
+	if azp, ok := claims["azp"].(string); ok && azp != p.config.ClientID {
+		return nil, fmt.Errorf("%w: unexpected authorized party", identity.ErrInvalidIDToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", identity.ErrInvalidIDToken)
+	}
+
+	result := &identity.Identity{Subject: subject, Groups: stringList(claims[p.config.GroupsClaim])}
+	result.Email, _ = claims["email"].(string)
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+	result.Name, _ = claims["name"].(string)
+	result.Username, _ = claims["preferred_username"].(string)
+	return result, nil
+}
+
+func (p *OIDCProvider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := p.key(kid, false)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		// Puede que el proveedor haya rotado las claves
+		if key, err = p.key(kid, true); err != nil {
+			return nil, err
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// key busca la clave en la caché del JWKS y lo descarga si aún no se tenía o
+// si se pide refrescarlo. Sin kid solo se acepta un JWKS con una única clave.
+func (p *OIDCProvider) key(kid string, refresh bool) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys == nil || (refresh && time.Since(p.keysFetched) >= jwksRefreshInterval) {
+		if err := p.fetchKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+	return p.keys[kid], nil
+}
+
+// fetchKeys descarga el JWKS; debe llamarse con el mutex bloqueado
+func (p *OIDCProvider) fetchKeys() error {
+	jwksURL := p.config.JWKSURL
+	if p.endpoints != nil {
+		jwksURL = p.endpoints.JWKSURL
+	}
+
+	req, err := http.NewRequest(http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return err
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(req, &jwks); err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Se ignoran los tipos de clave que no se soportan
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetched = time.Now()
+	return nil
+}
+
+// discover obtiene los endpoints del proveedor una sola vez. Los endpoints
+// configurados a mano tienen prioridad sobre los publicados.
+func (p *OIDCProvider) discover() (*oidcEndpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	endpoints := &oidcEndpoints{}
+	needsDiscovery := p.config.AuthURL == "" || p.config.TokenURL == "" || p.config.JWKSURL == "" || p.config.Issuer == ""
+	if needsDiscovery {
+		if p.config.DiscoveryURL == "" {
+			return nil, errors.New("oidc: no discovery url and incomplete endpoint configuration")
+		}
+		req, err := http.NewRequest(http.MethodGet, p.config.DiscoveryURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.getJSON(req, endpoints); err != nil {
+			return nil, fmt.Errorf("oidc discovery: %w", err)
+		}
+	}
+
+	if p.config.Issuer != "" {
+		endpoints.Issuer = p.config.Issuer
+	}
+	if p.config.AuthURL != "" {
+		endpoints.AuthURL = p.config.AuthURL
+	}
+	if p.config.TokenURL != "" {
+		endpoints.TokenURL = p.config.TokenURL
+	}
+	if p.config.JWKSURL != "" {
+		endpoints.JWKSURL = p.config.JWKSURL
+	}
+	if endpoints.Issuer == "" || endpoints.AuthURL == "" || endpoints.TokenURL == "" || endpoints.JWKSURL == "" {
+		return nil, errors.New("oidc: incomplete provider configuration")
+	}
+
+	p.endpoints = endpoints
+	return endpoints, nil
+}
+
+func (p *OIDCProvider) getJSON(req *http.Request, target interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, target)
+}
+
+// jsonWebKey clave pública de un JWKS (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// stringList admite claims de grupos como lista o como cadena única
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+)
+
+// commonPasswords lista incluida en el binario con las contraseñas más
+// habituales (también en español)
+//
+//go:embed data/common_passwords.txt
+var commonPasswords []byte
+
+// PasswordBlocklist contraseñas prohibidas por ser comunes o haber aparecido
+// en filtraciones. La comparación no distingue mayúsculas ni espacios al
+// principio o al final.
+type PasswordBlocklist struct {
+	passwords map[string]struct{}
+}
+
+func NewPasswordBlocklist(passwords ...string) *PasswordBlocklist {
+	b := &PasswordBlocklist{passwords: make(map[string]struct{}, len(passwords))}
+	for _, password := range passwords {
+		b.add(password)
+	}
+	return b
+}
+
+// DefaultPasswordBlocklist carga la lista incluida y, si se indica, un fichero
+// local adicional con una contraseña por línea (las que empiezan por # son
+// comentarios)
+func DefaultPasswordBlocklist(extraFile string) (*PasswordBlocklist, error) {
+	b := NewPasswordBlocklist()
+	if err := b.load(bytes.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+
+	if extraFile != "" {
+		file, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if err := b.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *PasswordBlocklist) Contains(password string) bool {
+	_, ok := b.passwords[normalizePassword(password)]
+	return ok
+}
+
+func (b *PasswordBlocklist) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		b.add(line)
+	}
+	return scanner.Err()
+}
+
+func (b *PasswordBlocklist) add(password string) {
+	if password = normalizePassword(password); password != "" {
+		b.passwords[password] = struct{}{}
+	}
+}
+
+func normalizePassword(password string) string {
+	return strings.ToLower(strings.TrimSpace(password))
+}
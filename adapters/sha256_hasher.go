@@ -0,0 +1,37 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+const sha256Prefix = "sha256$"
+
+// SHA256Hasher solo existe para verificar las contraseñas heredadas, guardadas
+// como el digest SHA-256 en hexadecimal sin sal. No debe usarse como algoritmo actual.
+type SHA256Hasher struct{}
+
+func NewSHA256Hasher() *SHA256Hasher {
+	return &SHA256Hasher{}
+}
+
+func (h *SHA256Hasher) Algorithm() string {
+	return "sha256"
+}
+
+func (h *SHA256Hasher) Hash(password string) (string, error) {
+	sum := sha256.Sum256([]byte(password))
+	return sha256Prefix + hex.EncodeToString(sum[:]), nil
+}
+
+func (h *SHA256Hasher) Verify(encoded, password string) (bool, error) {
+	sum := sha256.Sum256([]byte(password))
+	expected := strings.ToLower(strings.TrimPrefix(encoded, sha256Prefix))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(hex.EncodeToString(sum[:]))) == 1, nil
+}
+
+func (h *SHA256Hasher) NeedsRehash(encoded string) bool {
+	return true
+}
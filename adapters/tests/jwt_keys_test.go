@@ -0,0 +1,74 @@
+package adapters_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePEM(t *testing.T, blockType string, key interface{}) []byte {
+	var (
+		der []byte
+		err error
+	)
+	if blockType == "PUBLIC KEY" {
+		der, err = x509.MarshalPKIXPublicKey(key)
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func TestLoadSigningKey_RSA(t *testing.T) {
+	private, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	assert.NoError(t, os.WriteFile(path, encodePEM(t, "PRIVATE KEY", private), 0o600))
+
+	key, err := adapters.LoadSigningKey("", path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, jwt.SigningMethodRS256, key.Method)
+		assert.NotNil(t, key.Private)
+		assert.Len(t, key.ID, 16)
+	}
+
+	// La huella de la clave pública coincide con la de la privada
+	public, err := adapters.ParseSigningKey("", encodePEM(t, "PUBLIC KEY", &private.PublicKey))
+	if assert.NoError(t, err) {
+		assert.Nil(t, public.Private)
+		assert.Equal(t, key.ID, public.ID)
+	}
+}
+
+func TestParseSigningKey_Ed25519(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	key, err := adapters.ParseSigningKey("ed-1", encodePEM(t, "PRIVATE KEY", privateKey))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ed-1", key.ID)
+		assert.Equal(t, jwt.SigningMethodEdDSA, key.Method)
+		assert.Equal(t, publicKey, key.Public)
+	}
+
+	key, err = adapters.ParseSigningKey("", encodePEM(t, "PUBLIC KEY", publicKey))
+	if assert.NoError(t, err) {
+		assert.Nil(t, key.Private)
+		assert.NotEmpty(t, key.ID)
+	}
+}
+
+func TestParseSigningKey_Unsupported(t *testing.T) {
+	_, err := adapters.ParseSigningKey("", []byte("not a key"))
+	assert.ErrorIs(t, err, adapters.ErrUnsupportedSigningKey)
+}
@@ -0,0 +1,41 @@
+package adapters_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage_SaveAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	fileStorage := adapters.NewLocalStorage(dir, "/uploads/")
+
+	url, err := fileStorage.Save("avatars/1/picture.jpg", strings.NewReader("content"), "image/jpeg")
+	assert.NoError(t, err)
+	assert.Equal(t, "/uploads/avatars/1/picture.jpg", url)
+
+	content, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "picture.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	assert.NoError(t, fileStorage.Delete("avatars/1/picture.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "1", "picture.jpg"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Borrar un fichero que no existe no es un error
+	assert.NoError(t, fileStorage.Delete("avatars/1/picture.jpg"))
+}
+
+func TestLocalStorage_RejectsKeysOutsideBaseDir(t *testing.T) {
+	fileStorage := adapters.NewLocalStorage(t.TempDir(), "/uploads")
+
+	for _, key := range []string{"", "../secret", "avatars/../../secret", "/etc/passwd", "avatars\\..\\secret"} {
+		_, err := fileStorage.Save(key, strings.NewReader("content"), "text/plain")
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,121 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/identity"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOIDCProvider(idp *utils.FakeIdP) *adapters.OIDCProvider {
+	return adapters.NewOIDCProvider(adapters.OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	})
+}
+
+func authorize(t *testing.T, idp *utils.FakeIdP, provider *adapters.OIDCProvider, nonce, verifier string) string {
+	authURL, err := provider.AuthCodeURL("state", nonce, helpers.PKCEChallenge(verifier))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	code, state := idp.Authorize(t, authURL)
+	assert.Equal(t, "state", state)
+	return code
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	idp := utils.NewFakeIdP(t, "core-api")
+	idp.Claims = jwt.MapClaims{
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "jane",
+		"groups":             []string{"staff", "admins"},
+	}
+	provider := newOIDCProvider(idp)
+
+	authURL, err := provider.AuthCodeURL("state", "nonce", helpers.PKCEChallenge("verifier"))
+	assert.NoError(t, err)
+	parsed, _ := url.Parse(authURL)
+	assert.Equal(t, idp.Issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, "http://localhost/callback", parsed.Query().Get("redirect_uri"))
+
+	code := authorize(t, idp, provider, "nonce", "verifier")
+	verified, err := provider.Exchange(context.Background(), code, "verifier", "nonce")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "subject-1", verified.Subject)
+	assert.Equal(t, "jane@example.com", verified.Email)
+	assert.True(t, verified.EmailVerified)
+	assert.Equal(t, "Jane Doe", verified.Name)
+	assert.Equal(t, "jane", verified.Username)
+	assert.Equal(t, []string{"staff", "admins"}, verified.Groups)
+}
+
+func TestOIDCProvider_RejectsInvalidExchanges(t *testing.T) {
+	idp := utils.NewFakeIdP(t, "core-api")
+	provider := newOIDCProvider(idp)
+
+	// PKCE: el verifier no corresponde al challenge
+	code := authorize(t, idp, provider, "nonce", "verifier")
+	_, err := provider.Exchange(context.Background(), code, "other-verifier", "nonce")
+	assert.Error(t, err)
+
+	// Nonce distinto al de la petición
+	code = authorize(t, idp, provider, "nonce", "verifier")
+	_, err = provider.Exchange(context.Background(), code, "verifier", "other-nonce")
+	assert.True(t, errors.Is(err, identity.ErrInvalidIDToken))
+
+	// id_token emitido para otro cliente
+	idp.Claims = jwt.MapClaims{"aud": "other-client"}
+	code = authorize(t, idp, provider, "nonce", "verifier")
+	_, err = provider.Exchange(context.Background(), code, "verifier", "nonce")
+	assert.True(t, errors.Is(err, identity.ErrInvalidIDToken))
+
+	// id_token de otro emisor
+	idp.Claims = jwt.MapClaims{"iss": "https://evil.example.com"}
+	code = authorize(t, idp, provider, "nonce", "verifier")
+	_, err = provider.Exchange(context.Background(), code, "verifier", "nonce")
+	assert.True(t, errors.Is(err, identity.ErrInvalidIDToken))
+}
+
+func TestOIDCProvider_ExplicitEndpoints(t *testing.T) {
+	idp := utils.NewFakeIdP(t, "core-api")
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "groups": "staff"}
+
+	// Sin discovery: todos los endpoints vienen de la configuración
+	provider := adapters.NewOIDCProvider(adapters.OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		RedirectURL:  "http://localhost/callback",
+		DiscoveryURL: idp.Issuer() + "/missing",
+		AuthURL:      idp.Issuer() + "/custom-authorize",
+		TokenURL:     idp.Issuer() + "/token",
+		JWKSURL:      idp.Issuer() + "/jwks",
+	})
+
+	authURL, err := provider.AuthCodeURL("state", "nonce", helpers.PKCEChallenge("verifier"))
+	assert.NoError(t, err)
+	parsed, _ := url.Parse(authURL)
+	assert.Equal(t, "/custom-authorize", parsed.Path)
+
+	code, _ := idp.Authorize(t, authURL)
+	verified, err := provider.Exchange(context.Background(), code, "verifier", "nonce")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, verified.EmailVerified)
+	assert.Equal(t, []string{"staff"}, verified.Groups)
+}
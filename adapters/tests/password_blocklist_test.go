@@ -0,0 +1,37 @@
+package adapters_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPasswordBlocklist(t *testing.T) {
+	blocklist, err := adapters.DefaultPasswordBlocklist("")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.True(t, blocklist.Contains("123456"))
+	assert.True(t, blocklist.Contains(" Password "))
+	assert.False(t, blocklist.Contains("Correcta-Caballo-Bateria"))
+}
+
+func TestDefaultPasswordBlocklist_ExtraFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# empresa\nIntranet2024\n\n"), 0o600))
+
+	blocklist, err := adapters.DefaultPasswordBlocklist(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, blocklist.Contains("intranet2024"))
+	assert.False(t, blocklist.Contains("# empresa"))
+	assert.True(t, blocklist.Contains("qwerty"))
+
+	_, err = adapters.DefaultPasswordBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
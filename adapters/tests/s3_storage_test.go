@@ -0,0 +1,96 @@
+package adapters_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 es un servidor S3 mínimo que guarda los objetos en memoria y
+// comprueba las cabeceras de la firma V4
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	credential := "AWS4-HMAC-SHA256 Credential=access/" + time.Now().UTC().Format("20060102") + "/eu-west-1/s3/aws4_request"
+	if !strings.HasPrefix(r.Header.Get("Authorization"), credential) || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = string(body)
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: map[string]string{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestS3Storage_SaveAndDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fileStorage := adapters.NewS3Storage(adapters.S3Config{
+		Endpoint:  server.URL,
+		Region:    "eu-west-1",
+		Bucket:    "intranet",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PublicURL: "https://cdn.example.com/",
+	})
+
+	url, err := fileStorage.Save("avatars/1/picture.jpg", strings.NewReader("content"), "image/jpeg")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/avatars/1/picture.jpg", url)
+	assert.Equal(t, "content", fake.objects["/intranet/avatars/1/picture.jpg"])
+	assert.Equal(t, "image/jpeg", fake.types["/intranet/avatars/1/picture.jpg"])
+
+	assert.NoError(t, fileStorage.Delete("avatars/1/picture.jpg"))
+	assert.NotContains(t, fake.objects, "/intranet/avatars/1/picture.jpg")
+}
+
+func TestS3Storage_ReturnsErrorsFromServer(t *testing.T) {
+	_, server := newFakeS3(t)
+	fileStorage := adapters.NewS3Storage(adapters.S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "intranet",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	// La región no coincide y el servidor rechaza la firma
+	_, err := fileStorage.Save("avatars/1/picture.jpg", strings.NewReader("content"), "image/jpeg")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package adapters_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/importer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVReader_DetectsSeparator(t *testing.T) {
+	reader := adapters.NewCSVReader()
+
+	rows, err := reader.ReadRows(strings.NewReader("\xef\xbb\xbfusername;email;fullname;level\nana;ana@example.com;\"Ana; López\";Usuario\n"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, [][]string{
+		{"username", "email", "fullname", "level"},
+		{"ana", "ana@example.com", "Ana; López", "Usuario"},
+	}, rows)
+
+	rows, err = reader.ReadRows(strings.NewReader("email,fullname,level\nluis@example.com,Luis,Admin\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"luis@example.com", "Luis", "Admin"}, rows[1])
+
+	_, err = reader.ReadRows(strings.NewReader("email,fullname\n\"unterminated,x\n"))
+	assert.ErrorIs(t, err, importer.ErrInvalidFile)
+}
+
+// buildXLSX genera un libro mínimo con una hoja como los que guarda Excel
+func buildXLSX(t *testing.T, sharedStrings, sheet string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Usuarios" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml":     sharedStrings,
+		"xl/worksheets/sheet1.xml": sheet,
+	}
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestXLSXReader_ReadsFirstSheet(t *testing.T) {
+	sharedStrings := `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="4" uniqueCount="4">
+<si><t>email</t></si><si><t>fullname</t></si><si><t>level</t></si>
+<si><r><t>Ana </t></r><r><t>López</t></r></si>
+</sst>`
+	sheet := `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>ana@example.com</t></is></c><c r="B2" t="s"><v>3</v></c><c r="C2" t="str"><v>Usuario</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>luis@example.com</t></is></c><c r="C4"><v>42</v></c></row>
+</sheetData></worksheet>`
+
+	rows, err := adapters.NewXLSXReader().ReadRows(bytes.NewReader(buildXLSX(t, sharedStrings, sheet)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// La fila 3 no existe en el XML y las celdas que faltan quedan vacías
+	assert.Equal(t, [][]string{
+		{"email", "fullname", "level"},
+		{"ana@example.com", "Ana López", "Usuario"},
+		nil,
+		{"luis@example.com", "", "42"},
+	}, rows)
+}
+
+func TestXLSXReader_RejectsInvalidFiles(t *testing.T) {
+	reader := adapters.NewXLSXReader()
+
+	_, err := reader.ReadRows(strings.NewReader("email,fullname,level\n"))
+	assert.ErrorIs(t, err, importer.ErrInvalidFile)
+
+	sheet := `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>7</v></c></row></sheetData></worksheet>`
+	_, err = reader.ReadRows(bytes.NewReader(buildXLSX(t, `<sst></sst>`, sheet)))
+	assert.ErrorIs(t, err, importer.ErrInvalidFile)
+}
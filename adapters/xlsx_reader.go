@@ -0,0 +1,216 @@
+package adapters
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/drossan/core-api/domain/importer"
+)
+
+// xlsxMaxPartSize límite de cada XML descomprimido para no cargar en memoria
+// ficheros manipulados que se expanden sin control
+const xlsxMaxPartSize = 64 << 20
+
+// XLSXReader lee la primera hoja de un libro de Excel (Office Open XML) sin
+// dependencias externas: solo entiende los valores de las celdas, no fórmulas
+// ni formatos
+type XLSXReader struct{}
+
+func NewXLSXReader() *XLSXReader {
+	return &XLSXReader{}
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelationID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func (r *XLSXReader) ReadRows(input io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", importer.ErrInvalidFile, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// Las filas vacías no aparecen en el XML: se rellenan para conservar la
+		// numeración de la hoja
+		number := row.Number
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number <= len(rows) || number-len(rows) > xlsxMaxGap+1 {
+			return nil, fmt.Errorf("%w: invalid row number %d", importer.ErrInvalidFile, number)
+		}
+		for len(rows) < number-1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				if column, err = xlsxColumn(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(values) < column {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("%w: invalid shared string in %s", importer.ErrInvalidFile, cell.Ref)
+				}
+				value = sharedStrings.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+			if column < len(values) {
+				values[column] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// xlsxMaxGap filas vacías seguidas que se admiten entre dos filas con datos
+const xlsxMaxGap = 1000
+
+// firstSheetPath resuelve la ruta de la primera hoja del libro
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: the workbook has no sheets", importer.ErrInvalidFile)
+	}
+
+	var relationships xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return "", err
+	}
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID != workbook.Sheets[0].RelationID {
+			continue
+		}
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/"), nil
+		}
+		return path.Join("xl", relationship.Target), nil
+	}
+	return "", fmt.Errorf("%w: the first sheet is missing", importer.ErrInvalidFile)
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, target interface{}) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", importer.ErrInvalidFile, name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", importer.ErrInvalidFile, err)
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, xlsxMaxPartSize)).Decode(target); err != nil {
+		return fmt.Errorf("%w: %s: %v", importer.ErrInvalidFile, name, err)
+	}
+	return nil
+}
+
+// xlsxColumn convierte la referencia de una celda (B7) en el índice de su
+// columna empezando por 0
+func xlsxColumn(ref string) (int, error) {
+	column := 0
+	for _, char := range ref {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+		column = column*26 + int(char-'A') + 1
+	}
+	if column == 0 || column > 16384 {
+		return 0, fmt.Errorf("%w: invalid cell reference %s", importer.ErrInvalidFile, ref)
+	}
+	return column - 1, nil
+}
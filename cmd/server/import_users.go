@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/usecase"
+)
+
+// runImportUsers subcomando import-users: importa usuarios desde un CSV o XLSX
+// con las mismas validaciones que POST /users/import
+//
+//	server import-users [-dry-run] [-invite] [-as id] fichero.xlsx
+func runImportUsers(args []string, importUseCase *usecase.UserImportUseCase) error {
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only validate the file")
+	invite := flags.Bool("invite", false, "send invitations instead of creating the accounts")
+	actorID := flags.Uint("as", 0, "ID of the user recorded as the author of the import")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: server import-users [-dry-run] [-invite] [-as id] <file.csv|file.xlsx>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("a csv or xlsx file is required")
+	}
+
+	path := flags.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options := model.UserImportOptions{DryRun: *dryRun, Invite: *invite}
+	result, err := importUseCase.Import(uint(*actorID), filepath.Ext(path), file, options, nil)
+	if result != nil {
+		for _, rowErr := range result.Errors {
+			fmt.Printf("row %d: %s: %s\n", rowErr.Row, rowErr.Field, rowErr.Error)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.DryRun && len(result.Errors) > 0:
+		return fmt.Errorf("%d of %d rows are valid", result.Total-countRows(result.Errors), result.Total)
+	case result.DryRun:
+		fmt.Printf("%d rows are valid, nothing was imported\n", result.Total)
+	case options.Invite:
+		fmt.Printf("%d invitations created, %d emails sent\n", result.Total, result.Invited)
+	default:
+		fmt.Printf("%d users created\n", result.Created)
+	}
+	return nil
+}
+
+// countRows número de filas distintas con algún error
+func countRows(errs []model.UserImportError) int {
+	rows := make(map[int]struct{}, len(errs))
+	for _, rowErr := range errs {
+		rows[rowErr.Row] = struct{}{}
+	}
+	return len(rows)
+}
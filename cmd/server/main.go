@@ -4,15 +4,26 @@ import (
 	"github.com/drossan/core-api/adapters"
 	"github.com/drossan/core-api/config"
 	_ "github.com/drossan/core-api/docs"
+	"github.com/drossan/core-api/domain/importer"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/domain/storage"
+	"github.com/drossan/core-api/helpers"
 	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
 	"github.com/drossan/core-api/infrastructure/router"
 	"github.com/drossan/core-api/interfaces/api"
 	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/seeder"
 	"github.com/drossan/core-api/service"
 	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"log"
+	"os"
+	"strings"
 )
 
 // @title Core API
@@ -56,41 +67,149 @@ func main() {
 	slackNotifier := adapters.NewSlackNotifier()
 	notificationService.RegisterNotifier("slack", slackNotifier)
 
+	// Configurar el hash de contraseñas: el algoritmo actual genera los hashes
+	// nuevos y el resto solo se usa para verificar contraseñas antiguas
+	passwordHasher := newPasswordHasher(cfg.Security.PasswordHashAlgorithm)
+
 	// Sembrar datos
-	seeder.Seed()
+	seeder.Seed(passwordHasher)
 
 	// Crear instancias de los repositorios
 	userRepo := db.NewUserRepository(dbConn)
 	formRepo := db.NewFormRepository(dbConn)
 	levelRepo := db.NewLevelRepository(dbConn)
+	authorizationCache := newAuthorizationCache(cfg.Security, levelRepo)
+	if authorizationCache != nil {
+		levelRepo = authorizationCache
+	}
 	levelPrivilegesRepo := db.NewLevelPrivilegesRepository(dbConn)
 	menuTreeRepo := db.NewMenuTreeRepository(dbConn)
+	refreshTokenRepo := db.NewRefreshTokenRepository(dbConn)
+	revocationStore := newRevocationStore(cfg.Security.RevocationStore, dbConn)
+	recoveryCodeRepo := db.NewRecoveryCodeRepository(dbConn)
+	apiKeyRepo := db.NewAPIKeyRepository(dbConn)
+	oidcStateRepo := db.NewOIDCStateRepository(dbConn)
+	sessionRepo := db.NewSessionRepository(dbConn)
+	passwordHistoryRepo := db.NewPasswordHistoryRepository(dbConn)
+	invitationRepo := db.NewInvitationRepository(dbConn)
+	auditRepo := db.NewAuditRepository(dbConn)
 
 	// Inicializar casos de uso
-	userUseCase := usecase.NewUserUseCase(userRepo)
-	formUseCase := usecase.NewFormUseCase(formRepo)
+	permissionResolver := service.NewPermissionResolver(levelRepo)
+	tokenUseCase := usecase.NewTokenUseCase(refreshTokenRepo, sessionRepo, userRepo, revocationStore, cfg.Server.AccessTokenTTL, cfg.Server.RefreshTokenTTL)
+	if cfg.Security.TokenPermissions {
+		tokenUseCase.EnablePermissionSnapshots(levelRepo)
+	}
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, userRepo, tokenUseCase, cfg.Server.RefreshTokenTTL)
+	lockoutPolicy := security.LockoutPolicy{
+		MaxFailures:  cfg.Security.MaxLoginFailures,
+		BaseDuration: cfg.Security.LockoutBaseDuration,
+		MaxDuration:  cfg.Security.LockoutMaxDuration,
+	}
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, recoveryCodeRepo, tokenUseCase, lockoutPolicy, cfg.Security.MFAIssuer, cfg.Security.MFAPendingTokenTTL, permissionResolver)
+	passwordPolicyUseCase := newPasswordPolicyUseCase(cfg.Security, passwordHistoryRepo, levelRepo, passwordHasher)
+	userUseCase := usecase.NewUserUseCase(userRepo, passwordHasher, passwordPolicyUseCase, tokenUseCase, mfaUseCase, lockoutPolicy, permissionResolver)
+	fileStorage := newFileStorage(cfg.Storage)
+	profileUseCase := usecase.NewProfileUseCase(userRepo, passwordHasher, passwordPolicyUseCase, tokenUseCase, fileStorage, cfg.Storage.MaxPictureSize)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo, permissionResolver, cfg.Security.APIKeyMaxTTL)
+	impersonationUseCase := usecase.NewImpersonationUseCase(userRepo, permissionResolver, cfg.Security.ImpersonationTTL)
+	oidcUseCase := newOIDCUseCase(cfg.OIDC, oidcStateRepo, userRepo, levelRepo, passwordHasher, tokenUseCase, mfaUseCase)
+	passwordResetUseCase := usecase.NewPasswordResetUseCase(userRepo, passwordPolicyUseCase, tokenUseCase, emailNotifier, cfg.App.FrontendURL+"/reset-password", cfg.Security.PasswordResetTTL)
+	invitationUseCase := usecase.NewInvitationUseCase(invitationRepo, userRepo, levelRepo, passwordPolicyUseCase, emailNotifier, cfg.App.FrontendURL+"/accept-invitation", cfg.Security.InvitationTTL, permissionResolver)
+	userImportReaders := map[string]importer.Reader{
+		"csv":  adapters.NewCSVReader(),
+		"xlsx": adapters.NewXLSXReader(),
+	}
+	userImportUseCase := usecase.NewUserImportUseCase(userRepo, levelRepo, invitationRepo, invitationUseCase, passwordHasher, userImportReaders, cfg.Security.UserImportMaxRows, cfg.Security.UserImportMaxSize, permissionResolver)
+	privacyUseCase := usecase.NewPrivacyUseCase(userRepo, sessionRepo, apiKeyRepo, invitationRepo, auditRepo, tokenUseCase, fileStorage)
+	formUseCase := usecase.NewFormUseCase(formRepo, levelRepo)
 	levelUseCase := usecase.NewLevelUseCase(levelRepo)
-	levelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(levelPrivilegesRepo)
+	levelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(levelPrivilegesRepo, levelRepo)
 	menuTreeUseCase := usecase.NewMenuTreeUseCase(menuTreeRepo)
+	authorizationUseCase := usecase.NewAuthorizationUseCase(authorizationCache, permissionResolver)
+
+	// Claves de firma de los JWT
+	keyManager := newKeyManager(cfg.Server)
+	helpers.SetTokenKeys(keyManager)
+
+	// Subcomando de línea de comandos: importa usuarios y termina sin levantar
+	// el servidor
+	if len(os.Args) > 1 && os.Args[1] == "import-users" {
+		if err := runImportUsers(os.Args[2:], userImportUseCase); err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		return
+	}
 
 	// Iniciar rutas
-	e, r, a, prefix := router.NewEchoRouter(cfg.Server.JWTSecret)
-	r.Use(middleware.NewAuthorizationMiddleware(levelRepo, formRepo, levelPrivilegesRepo, prefix))
+	e, r, a, prefix := router.NewEchoRouter(keyManager, apiKeyUseCase, router.NotRevoked(tokenUseCase), router.SessionActive(sessionUseCase), router.MFACompleted(), router.PasswordChanged(), router.NotInvitation(), router.AccountActive(userUseCase))
+
+	// Auditoría de las suplantaciones; se añade antes de crear el grupo s para
+	// que se aplique también a él
+	r.Use(middleware.ImpersonationAudit())
+
+	// Grupo autenticado sin comprobación de privilegios: debe crearse antes de
+	// añadir el middleware de autorización al grupo restringido. Las API keys
+	// no pueden usarlo: solo acceden a rutas con comprobación de privilegios.
+	// Sus rutas que no se pueden usar suplantando a un usuario lo declaran con
+	// middleware.DenyImpersonation
+	s := r.Group("", middleware.SessionOnly())
+	routePermissions := security.NewRoutePermissions()
+	// Las rutas protegidas declaran en su permiso si se pueden usar mientras
+	// se suplanta a un usuario
+	r.Use(middleware.ImpersonationGuard(routePermissions))
+	r.Use(middleware.NewAuthorizationMiddleware(levelRepo, formRepo, levelPrivilegesRepo, routePermissions, prefix))
+	// Las rutas con comprobación de privilegios declaran el permiso que exigen
+	p := api.NewProtectedGroup(r, routePermissions)
 
 	// Inicializar manejadores y registrar rutas
-	userHandler := api.NewUserHandler(e, userUseCase)
+	userHandler := api.NewUserHandler(e, userUseCase, tokenUseCase)
+	passwordResetHandler := api.NewPasswordResetHandler(e, passwordResetUseCase)
+	mfaHandler := api.NewMFAHandler(e, mfaUseCase)
+	profileHandler := api.NewProfileHandler(e, profileUseCase)
+	apiKeyHandler := api.NewAPIKeyHandler(e, apiKeyUseCase)
+	sessionHandler := api.NewSessionHandler(e, sessionUseCase)
+	impersonationHandler := api.NewImpersonationHandler(e, impersonationUseCase)
+	invitationHandler := api.NewInvitationHandler(e, invitationUseCase)
+	userImportHandler := api.NewUserImportHandler(e, userImportUseCase)
+	privacyHandler := api.NewPrivacyHandler(e, privacyUseCase)
 	formHandler := api.NewFormHandler(e, formUseCase)
 	levelHandler := api.NewLevelHandler(e, levelUseCase)
 	levelPrivilegesHandler := api.NewLevelPrivilegesHandler(e, levelPrivilegesUseCase)
 	menuTreeHandler := api.NewMenuTreeHandler(e, menuTreeUseCase)
+	authorizationHandler := api.NewAuthorizationHandler(e, authorizationUseCase)
 
 	// Registro de rutas
+	api.NewJWKSHandler(e, keyManager).WellKnownRoutes(e)
 	userHandler.AuthRoutes(a)
-	userHandler.RegisterRoutes(r)
-	formHandler.RegisterRoutes(r)
-	levelHandler.RegisterRoutes(r)
-	levelPrivilegesHandler.RegisterRoutes(r)
-	menuTreeHandler.RegisterRoutes(r)
+	userHandler.SessionRoutes(s)
+	passwordResetHandler.AuthRoutes(a)
+	invitationHandler.AuthRoutes(a)
+	if oidcUseCase != nil {
+		api.NewOIDCHandler(e, oidcUseCase).AuthRoutes(a)
+	}
+	mfaHandler.AuthRoutes(a)
+	mfaHandler.SessionRoutes(s)
+	profileHandler.SessionRoutes(s)
+	apiKeyHandler.SessionRoutes(s)
+	sessionHandler.SessionRoutes(s)
+	privacyHandler.SessionRoutes(s)
+	authorizationHandler.SessionRoutes(s)
+	openRoutes := e.Routes()
+	userHandler.RegisterRoutes(p)
+	mfaHandler.RegisterRoutes(p)
+	apiKeyHandler.RegisterRoutes(p)
+	sessionHandler.RegisterRoutes(p)
+	impersonationHandler.RegisterRoutes(p)
+	invitationHandler.RegisterRoutes(p)
+	userImportHandler.RegisterRoutes(p)
+	privacyHandler.RegisterRoutes(p)
+	formHandler.RegisterRoutes(p)
+	levelHandler.RegisterRoutes(p)
+	levelPrivilegesHandler.RegisterRoutes(p)
+	menuTreeHandler.RegisterRoutes(p)
+	authorizationHandler.RegisterRoutes(p)
+	checkRoutePermissions(e.Routes(), openRoutes, routePermissions, formRepo)
 
 	if cfg.App.Env != "production" {
 		e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -108,3 +227,181 @@ func main() {
 	// Iniciar el servidor
 	log.Fatal(e.Start(cfg.Server.Address))
 }
+
+// checkRoutePermissions detiene el arranque si alguna ruta con comprobación de
+// privilegios no declara su permiso y avisa de las rutas cuyo recurso no
+// aparece en el PathAPI de ningún formulario, a las que nadie podrá acceder
+func checkRoutePermissions(routes []*echo.Route, openRoutes []*echo.Route, routePermissions *security.RoutePermissions, formRepo repository.FormRepository) {
+	open := make(map[string]bool, len(openRoutes))
+	for _, route := range openRoutes {
+		open[route.Method+" "+route.Path] = true
+	}
+	for _, route := range routes {
+		if open[route.Method+" "+route.Path] || route.Method == echo.RouteNotFound {
+			continue
+		}
+		if _, ok := routePermissions.Lookup(route.Method, route.Path); !ok {
+			log.Fatalf("Route %s %s has no permission mapping", route.Method, route.Path)
+		}
+	}
+
+	forms, err := formRepo.GetAll(nil)
+	if err != nil {
+		log.Printf("Failed to check route permissions against forms: %v", err)
+		return
+	}
+	pathAPIs := make([]string, 0, len(forms))
+	for _, form := range forms {
+		pathAPIs = append(pathAPIs, form.PathAPI)
+	}
+	for _, route := range routePermissions.Ungranted(pathAPIs) {
+		log.Printf("Warning: no form grants %q, route %s %s is not accessible", route.Permission.Resource, route.Method, route.Path)
+	}
+}
+
+// newAuthorizationCache crea la caché de privilegios que envuelve al
+// repositorio de niveles; devuelve nil si está desactivada
+func newAuthorizationCache(cfg config.SecurityConfig, levelRepo repository.LevelRepository) repository.AuthorizationCache {
+	if cfg.AuthzCacheTTL <= 0 || cfg.AuthzCacheSize <= 0 {
+		return nil
+	}
+	return memory.NewAuthorizationCache(levelRepo, cfg.AuthzCacheTTL, cfg.AuthzCacheSize)
+}
+
+// newPasswordPolicyUseCase política de contraseñas de la configuración con la
+// lista de contraseñas comunes incluida más el fichero opcional
+func newPasswordPolicyUseCase(cfg config.SecurityConfig, historyRepo repository.PasswordHistoryRepository, levelRepo repository.LevelRepository, passwordHasher *service.PasswordService) *usecase.PasswordPolicyUseCase {
+	blocklist, err := adapters.DefaultPasswordBlocklist(cfg.PasswordBlocklistFile)
+	if err != nil {
+		log.Fatalf("Failed to load password blocklist: %v", err)
+	}
+
+	policy := security.PasswordPolicy{
+		MinLength:  cfg.PasswordMinLength,
+		MinClasses: cfg.PasswordMinClasses,
+		History:    cfg.PasswordHistory,
+		MaxAge:     cfg.PasswordMaxAge,
+	}
+	return usecase.NewPasswordPolicyUseCase(policy, blocklist, historyRepo, levelRepo, passwordHasher, cfg.PasswordChangeTokenTTL)
+}
+
+func newPasswordHasher(algorithm string) *service.PasswordService {
+	bcryptHasher := adapters.NewBcryptHasher(bcrypt.DefaultCost)
+	argon2Hasher := adapters.NewArgon2Hasher()
+	legacyHasher := adapters.NewSHA256Hasher()
+
+	switch algorithm {
+	case bcryptHasher.Algorithm():
+		return service.NewPasswordService(bcryptHasher, argon2Hasher, legacyHasher)
+	case argon2Hasher.Algorithm():
+		return service.NewPasswordService(argon2Hasher, bcryptHasher, legacyHasher)
+	default:
+		log.Fatalf("Unsupported password hash algorithm: %s", algorithm)
+		return nil
+	}
+}
+
+// newKeyManager carga la clave de firma de los JWT y las anteriores que siguen
+// verificando tokens. Sin clave asimétrica se firma con HS256 y JWT_SECRET; si
+// hay clave y también JWT_SECRET, el secreto solo verifica los tokens antiguos.
+func newKeyManager(cfg config.ServerConfig) *service.KeyManager {
+	var verification []*security.SigningKey
+	signing := adapters.NewHMACSigningKey(cfg.JWTSecret)
+
+	if cfg.JWTSigningKeyFile != "" {
+		if cfg.JWTSecret != "" {
+			verification = append(verification, &security.SigningKey{Method: signing.Method, Public: signing.Public})
+		}
+
+		key, err := adapters.LoadSigningKey(cfg.JWTSigningKeyID, cfg.JWTSigningKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing key: %v", err)
+		}
+		signing = key
+	} else if cfg.JWTSecret == "" {
+		log.Fatalf("JWT_SECRET or JWT_SIGNING_KEY_FILE must be set")
+	}
+
+	for _, entry := range cfg.JWTVerificationKeys {
+		id, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			id, path = "", entry
+		}
+		key, err := adapters.LoadSigningKey(id, path)
+		if err != nil {
+			log.Fatalf("Failed to load JWT verification key %s: %v", path, err)
+		}
+		// Solo se conserva la parte pública: las claves retiradas no firman
+		key.Private = nil
+		verification = append(verification, key)
+	}
+
+	keyManager, err := service.NewKeyManager(signing, verification...)
+	if err != nil {
+		log.Fatalf("Invalid JWT keys: %v", err)
+	}
+	return keyManager
+}
+
+func newRevocationStore(store string, dbConn *gorm.DB) repository.RevocationStore {
+	switch store {
+	case "database":
+		return db.NewRevocationStore(dbConn)
+	case "memory":
+		return memory.NewRevocationStore()
+	default:
+		log.Fatalf("Unsupported token revocation store: %s", store)
+		return nil
+	}
+}
+
+func newFileStorage(cfg config.StorageConfig) storage.FileStorage {
+	switch cfg.Driver {
+	case "local":
+		return adapters.NewLocalStorage(cfg.LocalDir, cfg.LocalURL)
+	case "s3":
+		return adapters.NewS3Storage(adapters.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+		})
+	default:
+		log.Fatalf("Unsupported storage driver: %s", cfg.Driver)
+		return nil
+	}
+}
+
+// newOIDCUseCase configura el login con el proveedor de identidad; devuelve
+// nil si no está configurado y solo se usan contraseñas locales
+func newOIDCUseCase(cfg config.OIDCConfig, stateRepo repository.OIDCStateRepository, userRepo repository.UserRepository, levelRepo repository.LevelRepository, passwordHasher *service.PasswordService, tokenUseCase *usecase.TokenUseCase, mfaUseCase *usecase.MFAUseCase) *usecase.OIDCUseCase {
+	if cfg.ClientID == "" {
+		return nil
+	}
+
+	mapping := usecase.OIDCLevelMapping{DefaultLevel: cfg.DefaultLevel}
+	for _, pair := range cfg.GroupLevels {
+		group, level, ok := strings.Cut(pair, "=")
+		if !ok || group == "" || level == "" {
+			log.Fatalf("Invalid OIDC group level mapping: %s", pair)
+		}
+		mapping.Groups = append(mapping.Groups, usecase.GroupLevel{Group: group, Level: level})
+	}
+
+	provider := adapters.NewOIDCProvider(adapters.OIDCConfig{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+		GroupsClaim:  cfg.GroupsClaim,
+		DiscoveryURL: cfg.DiscoveryURL,
+		AuthURL:      cfg.AuthURL,
+		TokenURL:     cfg.TokenURL,
+		JWKSURL:      cfg.JWKSURL,
+	})
+
+	return usecase.NewOIDCUseCase(provider, stateRepo, userRepo, levelRepo, passwordHasher, tokenUseCase, mfaUseCase, mapping, cfg.StateTTL)
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,11 +15,22 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Email    EmailConfig
+	Security SecurityConfig
+	Storage  StorageConfig
+	OIDC     OIDCConfig
 }
 
 type ServerConfig struct {
 	Address   string
 	JWTSecret string
+	// JWTSigningKeyFile clave privada RSA o Ed25519 (PEM) con la que se firman
+	// los JWT; sin ella se sigue firmando con HS256 y JWTSecret
+	JWTSigningKeyFile string
+	JWTSigningKeyID   string
+	// JWTVerificationKeys claves anteriores aún válidas, "kid=ruta" o "ruta"
+	JWTVerificationKeys []string
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
 }
 
 type DatabaseConfig struct {
@@ -24,7 +38,8 @@ type DatabaseConfig struct {
 }
 
 type AppConfig struct {
-	Env string
+	Env         string
+	FrontendURL string
 }
 
 type EmailConfig struct {
@@ -35,6 +50,70 @@ type EmailConfig struct {
 	FromEmail    string
 }
 
+type SecurityConfig struct {
+	PasswordHashAlgorithm string
+	RevocationStore       string
+	PasswordResetTTL      time.Duration
+	MaxLoginFailures      int
+	LockoutBaseDuration   time.Duration
+	LockoutMaxDuration    time.Duration
+	MFAIssuer             string
+	MFAPendingTokenTTL    time.Duration
+	APIKeyMaxTTL          time.Duration
+	ImpersonationTTL      time.Duration
+	// Política de contraseñas general; cada nivel puede endurecerla
+	PasswordMinLength      int
+	PasswordMinClasses     int
+	PasswordHistory        int
+	PasswordMaxAge         time.Duration
+	PasswordBlocklistFile  string
+	PasswordChangeTokenTTL time.Duration
+	InvitationTTL          time.Duration
+	// Límites de la importación masiva de usuarios
+	UserImportMaxRows int
+	UserImportMaxSize int64
+	// TokenPermissions incluye los privilegios del nivel en el access token
+	// para no consultarlos en cada petición
+	TokenPermissions bool
+	// Caché en memoria de los privilegios de los niveles; TTL 0 la desactiva
+	AuthzCacheTTL  time.Duration
+	AuthzCacheSize int
+}
+
+// StorageConfig dónde se guardan los ficheros subidos (avatares...)
+type StorageConfig struct {
+	Driver         string
+	LocalDir       string
+	LocalURL       string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PublicURL    string
+	MaxPictureSize int64
+}
+
+// OIDCConfig login con el proveedor de identidad de la empresa. Se activa al
+// indicar el client id; los endpoints se descubren a partir del emisor salvo
+// los que se fijen a mano.
+type OIDCConfig struct {
+	Issuer       string
+	DiscoveryURL string
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+	// GroupLevels pares "grupo=nivel" por orden de prioridad
+	GroupLevels  []string
+	DefaultLevel string
+	StateTTL     time.Duration
+}
+
 func LoadConfig() *Config {
 	// Cargar variables de entorno desde el archivo .env si está en local
 	if err := godotenv.Load(".env"); err != nil {
@@ -43,11 +122,17 @@ func LoadConfig() *Config {
 
 	config := &Config{
 		App: AppConfig{
-			Env: os.Getenv("ENV"),
+			Env:         os.Getenv("ENV"),
+			FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
 		Server: ServerConfig{
-			Address:   os.Getenv("SERVER_ADDRESS"),
-			JWTSecret: os.Getenv("JWT_SECRET"),
+			Address:             os.Getenv("SERVER_ADDRESS"),
+			JWTSecret:           os.Getenv("JWT_SECRET"),
+			JWTSigningKeyFile:   os.Getenv("JWT_SIGNING_KEY_FILE"),
+			JWTSigningKeyID:     os.Getenv("JWT_SIGNING_KEY_ID"),
+			JWTVerificationKeys: getList("JWT_VERIFICATION_KEYS", nil),
+			AccessTokenTTL:      getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:     getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
 		},
 		Database: DatabaseConfig{
 			URL: os.Getenv("DATABASE_URL"),
@@ -59,7 +144,120 @@ func LoadConfig() *Config {
 			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
 			FromEmail:    os.Getenv("FROM_EMAIL"),
 		},
+		Security: SecurityConfig{
+			PasswordHashAlgorithm:  getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			RevocationStore:        getEnv("TOKEN_REVOCATION_STORE", "database"),
+			PasswordResetTTL:       getDuration("PASSWORD_RESET_TTL", time.Hour),
+			MaxLoginFailures:       getInt("MAX_LOGIN_FAILURES", 5),
+			LockoutBaseDuration:    getDuration("LOCKOUT_BASE_DURATION", time.Minute),
+			LockoutMaxDuration:     getDuration("LOCKOUT_MAX_DURATION", time.Hour),
+			MFAIssuer:              getEnv("MFA_ISSUER", "Intranet"),
+			MFAPendingTokenTTL:     getDuration("MFA_PENDING_TOKEN_TTL", 5*time.Minute),
+			APIKeyMaxTTL:           getDuration("API_KEY_MAX_TTL", 365*24*time.Hour),
+			ImpersonationTTL:       getDuration("IMPERSONATION_TTL", 15*time.Minute),
+			PasswordMinLength:      getInt("PASSWORD_MIN_LENGTH", 10),
+			PasswordMinClasses:     getInt("PASSWORD_MIN_CLASSES", 2),
+			PasswordHistory:        getInt("PASSWORD_HISTORY", 5),
+			PasswordMaxAge:         getDuration("PASSWORD_MAX_AGE", 0),
+			PasswordBlocklistFile:  os.Getenv("PASSWORD_BLOCKLIST_FILE"),
+			PasswordChangeTokenTTL: getDuration("PASSWORD_CHANGE_TOKEN_TTL", 10*time.Minute),
+			InvitationTTL:          getDuration("INVITATION_TTL", 72*time.Hour),
+			UserImportMaxRows:      getInt("USER_IMPORT_MAX_ROWS", 1000),
+			UserImportMaxSize:      int64(getInt("USER_IMPORT_MAX_SIZE", 5*1024*1024)),
+			TokenPermissions:       getBool("AUTHZ_TOKEN_PERMISSIONS", false),
+			AuthzCacheTTL:          getDuration("AUTHZ_CACHE_TTL", 30*time.Second),
+			AuthzCacheSize:         getInt("AUTHZ_CACHE_SIZE", 1000),
+		},
+		Storage: StorageConfig{
+			Driver:         getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:       getEnv("STORAGE_LOCAL_DIR", "public/uploads"),
+			LocalURL:       getEnv("STORAGE_LOCAL_URL", "/uploads"),
+			S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+			S3Region:       getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:       os.Getenv("S3_BUCKET"),
+			S3AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+			S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+			S3PublicURL:    os.Getenv("S3_PUBLIC_URL"),
+			MaxPictureSize: int64(getInt("MAX_PICTURE_SIZE", 5*1024*1024)),
+		},
+		OIDC: OIDCConfig{
+			Issuer:       os.Getenv("OIDC_ISSUER"),
+			DiscoveryURL: os.Getenv("OIDC_DISCOVERY_URL"),
+			AuthURL:      os.Getenv("OIDC_AUTH_URL"),
+			TokenURL:     os.Getenv("OIDC_TOKEN_URL"),
+			JWKSURL:      os.Getenv("OIDC_JWKS_URL"),
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       getList("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			GroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			GroupLevels:  getList("OIDC_GROUP_LEVELS", nil),
+			DefaultLevel: os.Getenv("OIDC_DEFAULT_LEVEL"),
+			StateTTL:     getDuration("OIDC_STATE_TTL", 10*time.Minute),
+		},
 	}
 
 	return config
 }
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %v, using %s", key, err, fallback)
+		return fallback
+	}
+	return duration
+}
+
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid number for %s: %v, using %d", key, err, fallback)
+		return fallback
+	}
+	return number
+}
+
+func getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %v, using %t", key, err, fallback)
+		return fallback
+	}
+	return enabled
+}
+
+// getList lee una lista separada por comas ignorando los elementos vacíos
+func getList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,28 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Identity datos del usuario verificados por el proveedor de identidad
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	Groups        []string
+}
+
+// Provider es el puerto hacia un proveedor de identidad externo con el flujo
+// authorization code + PKCE (OpenID Connect).
+type Provider interface {
+	// AuthCodeURL devuelve la URL del proveedor a la que se redirige al usuario
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	// Exchange canjea el código por los tokens del proveedor y devuelve la
+	// identidad del id_token tras verificar su firma, emisor, audiencia y nonce
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
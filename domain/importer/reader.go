@@ -0,0 +1,15 @@
+package importer
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrInvalidFile = errors.New("the file cannot be read")
+
+// Reader es el puerto hacia un formato de hoja de cálculo. Devuelve todas las
+// filas de la primera hoja, con la cabecera incluida, y las celdas vacías como
+// cadenas vacías.
+type Reader interface {
+	ReadRows(r io.Reader) ([][]string, error)
+}
@@ -4,12 +4,40 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Claim Token de user
+// Claim Token de user. El identificador único del token (jti) y la fecha de
+// emisión (iat) viajan en RegisteredClaims y se usan para revocarlo.
+// MFAPending marca los tokens emitidos tras la contraseña que solo sirven para
+// completar el segundo factor. APIKeyID y Scopes solo se rellenan cuando la
+// petición se autentica con una API key en lugar de un JWT. SessionID enlaza
+// el token con la sesión (dispositivo) que lo obtuvo. Actor solo existe en los
+// tokens de suplantación e identifica al administrador que los pidió.
+// PasswordExpired marca el token que solo permite cambiar una contraseña
+// caducada tras el login. InvitationID solo existe en los enlaces de
+// invitación, que únicamente sirven para aceptarla. Permissions es la copia de
+// los privilegios de los niveles en la versión PermissionVersion; solo existe
+// si está activado y evita consultarlos en cada petición. LevelIDs son los
+// niveles adicionales del usuario al emitir el token.
 type Claim struct {
-	UserID  uint   `json:"user_id"`
-	Email   string `json:"email"`
-	LevelID uint   `json:"level_id"`
-	Token   string `json:"token"`
-	Admin   uint
+	UserID          uint   `json:"user_id"`
+	Email           string `json:"email"`
+	LevelID         uint   `json:"level_id"`
+	Token           string `json:"token"`
+	Admin           uint
+	MFAPending      bool     `json:"mfa_pending,omitempty"`
+	APIKeyID        uint     `json:"api_key_id,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+	SessionID       uint     `json:"sid,omitempty"`
+	Actor           *Actor   `json:"act,omitempty"`
+	PasswordExpired bool     `json:"password_expired,omitempty"`
+	InvitationID    uint     `json:"inv,omitempty"`
+	LevelIDs        []uint   `json:"lvls,omitempty"`
+	// Permisos "<PathAPI>:<acciones>" de los niveles al emitir el token
+	Permissions       []string `json:"perm,omitempty"`
+	PermissionVersion uint     `json:"pv,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// AllLevelIDs devuelve el nivel principal seguido de los adicionales
+func (c *Claim) AllLevelIDs() []uint {
+	return append([]uint{c.LevelID}, c.LevelIDs...)
+}
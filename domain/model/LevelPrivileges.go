@@ -1,8 +1,15 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"strings"
 
-// LevelPrivileges Model
+	"github.com/drossan/core-api/domain/security"
+	"gorm.io/gorm"
+)
+
+// LevelPrivileges Model. Cada acción sobre el formulario se concede por
+// separado. Write se mantiene por compatibilidad: al guardarlo equivale a
+// crear, modificar y eliminar, y vale true cuando el nivel tiene las tres.
 type LevelPrivileges struct {
 	gorm.Model
 	LevelID uint
@@ -10,4 +17,61 @@ type LevelPrivileges struct {
 	Form    Form
 	Read    bool `json:"read,omitempty" gorm:"not null"`
 	Write   bool `json:"write,omitempty" gorm:"not null"`
+	Create  bool `json:"create,omitempty" gorm:"not null;default:false"`
+	Update  bool `json:"update,omitempty" gorm:"not null;default:false"`
+	Delete  bool `json:"delete,omitempty" gorm:"not null;default:false"`
+	Export  bool `json:"export,omitempty" gorm:"not null;default:false"`
+	Approve bool `json:"approve,omitempty" gorm:"not null;default:false"`
+}
+
+// Allows indica si el privilegio permite la acción (security.PermissionRead,
+// security.PermissionCreate...)
+func (p *LevelPrivileges) Allows(action string) bool {
+	switch action {
+	case security.PermissionRead:
+		return p.Read
+	case security.PermissionCreate:
+		return p.Create
+	case security.PermissionUpdate:
+		return p.Update
+	case security.PermissionDelete:
+		return p.Delete
+	case security.PermissionExport:
+		return p.Export
+	case security.PermissionApprove:
+		return p.Approve
+	}
+	return false
+}
+
+// Actions devuelve las acciones que permite el privilegio ("rcud")
+func (p *LevelPrivileges) Actions() string {
+	var actions strings.Builder
+	for _, action := range []string{security.PermissionRead, security.PermissionCreate, security.PermissionUpdate, security.PermissionDelete, security.PermissionExport, security.PermissionApprove} {
+		if p.Allows(action) {
+			actions.WriteString(action)
+		}
+	}
+	return actions.String()
+}
+
+// NormalizeWrite traduce el Write de los clientes anteriores a crear,
+// modificar y eliminar y vuelve a calcularlo a partir de ellas
+func (p *LevelPrivileges) NormalizeWrite() {
+	if p.Write && !p.Create && !p.Update && !p.Delete {
+		p.Create, p.Update, p.Delete = true, true, true
+	}
+	p.Write = p.Create && p.Update && p.Delete
+}
+
+// Merge añade las acciones que concede otro privilegio sobre el mismo
+// formulario
+func (p *LevelPrivileges) Merge(other LevelPrivileges) {
+	p.Read = p.Read || other.Read
+	p.Create = p.Create || other.Create
+	p.Update = p.Update || other.Update
+	p.Delete = p.Delete || other.Delete
+	p.Export = p.Export || other.Export
+	p.Approve = p.Approve || other.Approve
+	p.Write = p.Create && p.Update && p.Delete
 }
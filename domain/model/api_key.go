@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey clave de acceso de un usuario para scripts y servicios internos. Solo
+// se guarda el hash de la clave; Prefix permite identificarla en los listados.
+// Scopes limita las rutas a las que da acceso con entradas "<path>:read" o
+// "<path>:write" ("*" vale para cualquier path del nivel del usuario).
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16)"`
+	KeyHash    string     `json:"-" gorm:"not null;type:varchar(64);uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// APIKeyRequest datos para crear una clave. Sin ExpiresAt caduca tras la
+// duración máxima configurada.
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyCreated clave recién creada; Key solo se devuelve en este momento
+type APIKeyCreated struct {
+	*APIKey
+	Key string `json:"key"`
+}
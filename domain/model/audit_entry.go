@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Acciones registradas en la auditoría
+const (
+	AuditUserDataExport = "user.data_export"
+	AuditUserErasure    = "user.erasure"
+)
+
+// AuditEntry acción administrativa sobre un usuario. Solo guarda IDs para que
+// el registro sobreviva al borrado de los datos personales del usuario.
+type AuditEntry struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	ActorID   uint      `json:"actor_id" gorm:"index"`
+	SubjectID uint      `json:"subject_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"not null;type:varchar(64)"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package model
+
+// AuthorizationCacheStats métricas de la caché de privilegios de los niveles
+// que usa la autorización. Solo se cuentan las consultas desde el arranque.
+type AuthorizationCacheStats struct {
+	Enabled       bool    `json:"enabled"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Evictions     uint64  `json:"evictions"`
+	Invalidations uint64  `json:"invalidations"`
+	Size          int     `json:"size"`
+	MaxSize       int     `json:"max_size"`
+	TTLSeconds    int64   `json:"ttl_seconds"`
+}
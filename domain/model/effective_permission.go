@@ -0,0 +1,44 @@
+package model
+
+// PermissionActions acciones que permiten los privilegios sobre un formulario
+type PermissionActions struct {
+	Read    bool `json:"read"`
+	Create  bool `json:"create"`
+	Update  bool `json:"update"`
+	Delete  bool `json:"delete"`
+	Export  bool `json:"export"`
+	Approve bool `json:"approve"`
+}
+
+// EffectivePermission acciones que los niveles del usuario, con los niveles
+// de los que heredan, permiten sobre un formulario. Condition es la condición
+// de acceso a filas del formulario.
+type EffectivePermission struct {
+	FormID    uint              `json:"form_id"`
+	Title     string            `json:"title"`
+	Link      string            `json:"link"`
+	PathAPI   string            `json:"path_api"`
+	Condition string            `json:"condition,omitempty"`
+	Actions   PermissionActions `json:"actions"`
+}
+
+// AuthorizationCheck pregunta si el usuario puede hacer la acción ("read",
+// "update"...) sobre el recurso que declaran las rutas ("user", "level"...)
+type AuthorizationCheck struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// AuthorizationCheckRequest lote de preguntas de POST /authz/check
+type AuthorizationCheckRequest struct {
+	Checks []AuthorizationCheck `json:"checks"`
+}
+
+// AuthorizationCheckResult respuesta a una pregunta; Reason explica por qué
+// se deniega: "privilege" si los niveles no lo permiten y "scope" si no lo
+// permiten los scopes de la API key
+type AuthorizationCheckResult struct {
+	AuthorizationCheck
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
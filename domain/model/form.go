@@ -2,7 +2,11 @@ package model
 
 import "gorm.io/gorm"
 
-// Form Model
+// Form Model. PathAPI lista separadas por "|" las claves de permiso que
+// declaran las rutas protegidas ("user|users"); los privilegios del nivel
+// sobre el formulario dan acceso a todas ellas. Condition es la condición de
+// acceso a filas (security.ParseRowPolicy) que limita los registros a los que
+// acceden esos niveles; vacía no limita nada.
 type Form struct {
 	gorm.Model
 	Title            string `json:"title,omitempty" gorm:"not null;"`
@@ -0,0 +1,16 @@
+package model
+
+// Actor quien actúa realmente cuando un token suplanta a otro usuario. Viaja
+// en el claim "act" del token del usuario suplantado.
+type Actor struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// ImpersonationToken token de corta duración para actuar como otro usuario.
+// No tiene refresh token: al caducar hay que volver a pedirlo.
+type ImpersonationToken struct {
+	AccessToken string `json:"token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	User        *User  `json:"user"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invitation alta pendiente de un usuario. La cuenta se crea cuando el
+// invitado acepta la invitación y elige su contraseña. Del enlace firmado solo
+// se guarda el hash, que cambia al reenviarlo para invalidar los anteriores.
+type Invitation struct {
+	gorm.Model
+	Email      string     `json:"email" gorm:"not null;index"`
+	Username   string     `json:"username" gorm:"not null"`
+	FullName   string     `json:"fullname" gorm:"not null"`
+	LevelID    uint       `json:"level_id" gorm:"not null"`
+	InvitedBy  uint       `json:"invited_by"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);index"`
+	SentAt     *time.Time `json:"sent_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	UserID     *uint      `json:"user_id,omitempty"`
+}
+
+// InvitationRequest datos del usuario invitado. Sin Username se usa el email.
+type InvitationRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	FullName string `json:"fullname"`
+	LevelID  uint   `json:"level_id"`
+}
+
+// InvitationAcceptance contraseña elegida por el invitado con el token del enlace
+type InvitationAcceptance struct {
+	Token string `json:"token"`
+	Password
+}
@@ -0,0 +1,20 @@
+package model
+
+// JWK clave pública publicada para que otros servicios verifiquen nuestros JWT
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 (OKP)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet documento servido en /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -2,10 +2,22 @@ package model
 
 import "gorm.io/gorm"
 
-// Level Model
+// Level Model. Los campos Password* endurecen la política de contraseñas de
+// los usuarios del nivel; a cero se usa la configuración general.
+// PermissionVersion cambia cada vez que cambian sus privilegios o los de los
+// niveles de los que hereda, para detectar los tokens con una copia de los
+// permisos anterior. ParentID es el nivel opcional del que hereda los
+// privilegios.
 type Level struct {
 	gorm.Model
-	Level           string `json:"level,omitempty" gorm:"not null;unique"`
-	Description     string `json:"description,omitempty" gorm:"not null;unique"`
-	LevelPrivileges []LevelPrivileges
+	Level              string `json:"level,omitempty" gorm:"not null;unique"`
+	Description        string `json:"description,omitempty" gorm:"not null;unique"`
+	RequireMFA         bool   `json:"require_mfa"`
+	PasswordMinLength  int    `json:"password_min_length"`
+	PasswordMinClasses int    `json:"password_min_classes"`
+	PasswordHistory    int    `json:"password_history"`
+	PasswordMaxAgeDays int    `json:"password_max_age_days"`
+	PermissionVersion  uint   `json:"permission_version" gorm:"not null;default:1"`
+	ParentID           *uint  `json:"parent_id,omitempty" gorm:"index"`
+	LevelPrivileges    []LevelPrivileges
 }
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// RecoveryCode código de un solo uso para entrar sin la app de autenticación.
+// Solo se guarda el hash del código.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"not null;index;type:varchar(64)"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+// MFAEnrollment secreto TOTP y URI para generar el código QR
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAChallenge respuesta del login cuando falta el segundo factor
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+}
+
+// MFAVerification código TOTP o de recuperación enviado para completar el login
+type MFAVerification struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// MFALoginResponse tokens emitidos tras el segundo factor. Si el usuario acaba
+// de activar el 2FA incluye sus códigos de recuperación.
+type MFALoginResponse struct {
+	*TokenPair
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
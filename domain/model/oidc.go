@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// OIDCState datos de un login OIDC en curso. Se guardan en el servidor para
+// que el code verifier de PKCE no viaje por el navegador y cada state solo
+// pueda usarse una vez.
+type OIDCState struct {
+	ID           uint      `gorm:"primarykey"`
+	StateHash    string    `gorm:"not null;type:varchar(64);uniqueIndex"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// PasswordHistory hash de una contraseña usada por el usuario, para no
+// permitir que vuelva a usar las últimas
+type PasswordHistory struct {
+	ID           uint      `gorm:"primarykey"`
+	UserID       uint      `gorm:"not null;index"`
+	PasswordHash string    `gorm:"not null;type:varchar(256)"`
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+// FieldError error de validación asociado a un campo de la petición
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordExpiredChallenge respuesta del login cuando la contraseña ha
+// caducado: el token solo sirve para cambiarla en /login/password
+type PasswordExpiredChallenge struct {
+	PasswordExpired bool   `json:"password_expired"`
+	PasswordToken   string `json:"password_token"`
+}
+
+// ExpiredPasswordChange nueva contraseña enviada tras un login con la
+// contraseña caducada
+type ExpiredPasswordChange struct {
+	PasswordToken string `json:"password_token"`
+	Password
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// UserErasureRequest petición de supresión de los datos de un usuario
+type UserErasureRequest struct {
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// UserDataExport todo lo que se guarda de un usuario, para responder a las
+// solicitudes de acceso. Las notificaciones no aparecen porque no se guardan:
+// se envían y se descartan.
+type UserDataExport struct {
+	ExportedAt   time.Time     `json:"exported_at"`
+	Profile      *User         `json:"profile"`
+	Sessions     []*Session    `json:"sessions"`
+	APIKeys      []*APIKey     `json:"api_keys"`
+	Invitations  []*Invitation `json:"invitations"`
+	AuditEntries []*AuditEntry `json:"audit_entries"`
+}
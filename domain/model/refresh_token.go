@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken guarda el hash de un refresh token opaco. Todos los tokens
+// obtenidos al rotar a partir del mismo login comparten FamilyID, de forma que
+// si se detecta la reutilización de uno ya rotado se revoca la familia completa.
+type RefreshToken struct {
+	gorm.Model
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"family_id" gorm:"not null;index;type:varchar(64)"`
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex;type:varchar(64)"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uint      `json:"replaced_by,omitempty"`
+}
+
+// TokenPair es la respuesta de login y refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedToken es un access token invalidado antes de expirar (logout). Solo es
+// necesario guardarlo hasta ExpiresAt, después el propio JWT deja de ser válido.
+type RevokedToken struct {
+	gorm.Model
+	JTI       string    `json:"jti" gorm:"not null;uniqueIndex;type:varchar(64)"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+// UserTokenRevocation invalida todos los tokens de un usuario emitidos antes de RevokedBefore
+type UserTokenRevocation struct {
+	UserID        uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `json:"revoked_before" gorm:"not null"`
+	UpdatedAt     time.Time
+}
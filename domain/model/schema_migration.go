@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// SchemaMigration registra las migraciones de datos que solo deben ejecutarse
+// una vez, porque repetirlas desharía los cambios posteriores de los usuarios
+type SchemaMigration struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(100)"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
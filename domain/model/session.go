@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session dispositivo con una sesión abierta. Se crea en cada login y agrupa
+// la familia de refresh tokens de ese login; los access tokens llevan su ID
+// para poder cerrarla desde otro dispositivo.
+type Session struct {
+	gorm.Model
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"not null;uniqueIndex;type:varchar(64)"`
+	UserAgent  string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IP         string     `json:"ip" gorm:"type:varchar(45)"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Current marca la sesión desde la que se hace la petición
+	Current bool `json:"current" gorm:"-"`
+}
+
+// ClientInfo datos del dispositivo que inicia sesión
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
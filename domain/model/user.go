@@ -5,26 +5,105 @@ import (
 	"time"
 )
 
+// RecoverPass petición para restablecer la contraseña con el token recibido por email
 type RecoverPass struct {
-	Token string
+	Token string `json:"token"`
+	Password
 }
 
+// Password nueva contraseña y su confirmación
 type Password struct {
-	Password string
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirmPassword"`
 }
 
-// User Model
+// Profile datos que el propio usuario puede modificar desde /me
+type Profile struct {
+	FullName string `json:"fullname"`
+	Picture  string `json:"picture"`
+	Language string `json:"language"`
+}
+
+// ProfilePicture URLs del avatar subido y de sus miniaturas por tamaño
+type ProfilePicture struct {
+	Picture    string         `json:"picture"`
+	Thumbnails map[int]string `json:"thumbnails"`
+}
+
+// PasswordChange cambio de contraseña del propio usuario
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword"`
+	Password
+}
+
+// Estados de la cuenta de un usuario
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
+// UserStatusChange petición para cambiar el estado de un usuario
+type UserStatusChange struct {
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// UserLevelsChange petición para cambiar los niveles adicionales de un usuario
+type UserLevelsChange struct {
+	ID       uint   `json:"id"`
+	LevelIDs []uint `json:"level_ids"`
+}
+
+// User Model. LevelID es el nivel principal, el que decide la política de
+// contraseñas; Levels son los niveles adicionales, que suman privilegios. El
+// 2FA es obligatorio si lo exige cualquiera de los niveles o de los que heredan.
 type User struct {
 	gorm.Model
-	Username        string `json:"username,omitempty" gorm:"not null;unique"`
-	Email           string `json:"email,omitempty" gorm:"not null;unique"`
-	FullName        string `json:"fullname,omitempty" gorm:"not null"`
-	Password        string `json:"password,omitempty" gorm:"not null;type:varchar(256)"`
-	ConfirmPassword string `json:"confirmPassword,omitempty" gorm:"-"`
-	Picture         string `json:"picture,omitempty"`
-	LevelID         uint
-	Level           Level
-	Token           string    `json:"token,omitempty"`
-	Failure         int       `json:"failure,omitempty" gorm:"default:0"`
-	CreatedAt       time.Time `gorm:"type:datetime"`
+	Username          string `json:"username,omitempty" gorm:"not null;unique"`
+	Email             string `json:"email,omitempty" gorm:"not null;unique"`
+	FullName          string `json:"fullname,omitempty" gorm:"not null"`
+	Password          string `json:"password,omitempty" gorm:"not null;type:varchar(256)"`
+	ConfirmPassword   string `json:"confirmPassword,omitempty" gorm:"-"`
+	Picture           string `json:"picture,omitempty"`
+	Language          string `json:"language,omitempty" gorm:"type:varchar(10)"`
+	LevelID           uint
+	Level             Level
+	Levels            []Level    `json:"levels,omitempty" gorm:"many2many:user_levels"`
+	Token             string     `json:"-"`
+	TokenExpiresAt    *time.Time `json:"-"`
+	Failure           int        `json:"failure,omitempty" gorm:"default:0"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+	MFAEnabled        bool       `json:"mfa_enabled"`
+	MFASecret         string     `json:"-"`
+	MFALastStep       int64      `json:"-" gorm:"default:0"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	Status            string     `json:"status" gorm:"type:varchar(16);default:active;index"`
+	StatusReason      string     `json:"status_reason,omitempty"`
+	StatusChangedAt   *time.Time `json:"status_changed_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"type:datetime"`
+	// Impersonated y ImpersonatedBy solo se rellenan en GET /user para que el
+	// frontend muestre el aviso de suplantación
+	Impersonated   bool   `json:"impersonated,omitempty" gorm:"-"`
+	ImpersonatedBy *Actor `json:"impersonated_by,omitempty" gorm:"-"`
+	// Privileges solo se rellena en GET /user con los privilegios efectivos
+	// de todos sus niveles y de los niveles de los que heredan
+	Privileges []LevelPrivileges `json:"privileges,omitempty" gorm:"-"`
+}
+
+// LevelIDs devuelve el nivel principal seguido de los adicionales, sin repetir
+func (u *User) LevelIDs() []uint {
+	ids := []uint{u.LevelID}
+	for _, level := range u.Levels {
+		if level.ID != u.LevelID {
+			ids = append(ids, level.ID)
+		}
+	}
+	return ids
+}
+
+// IsActive indica si la cuenta puede entrar; las cuentas anteriores al campo
+// de estado no lo tienen relleno y están activas
+func (u *User) IsActive() bool {
+	return u.Status == "" || u.Status == UserStatusActive
 }
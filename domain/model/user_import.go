@@ -0,0 +1,35 @@
+package model
+
+// UserImportRow fila del fichero de importación de usuarios. Row es el número
+// de fila en la hoja, contando la cabecera como la 1.
+type UserImportRow struct {
+	Row      int    `json:"row"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	FullName string `json:"fullname"`
+	Level    string `json:"level"`
+}
+
+// UserImportOptions DryRun solo valida el fichero; Invite envía invitaciones en
+// lugar de crear las cuentas directamente
+type UserImportOptions struct {
+	DryRun bool `json:"dry_run"`
+	Invite bool `json:"invite"`
+}
+
+// UserImportError error de validación de un campo de una fila
+type UserImportError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// UserImportResult resumen de la importación. Si hay errores no se aplica
+// ninguna fila.
+type UserImportResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Invited int               `json:"invited"`
+	Errors  []UserImportError `json:"errors"`
+}
@@ -0,0 +1,6 @@
+package notification
+
+// Mailer envía un email HTML generado a partir de una plantilla a un destinatario concreto
+type Mailer interface {
+	SendTemplateTo(to, subject, templatePath string, data interface{}) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+)
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	GetByHash(keyHash string) (*model.APIKey, error)
+	GetByUser(userID uint) ([]*model.APIKey, error)
+	// Revoke revoca la clave solo si pertenece al usuario y seguía activa
+	Revoke(id uint, userID uint, revokedAt time.Time) (bool, error)
+	RevokeByUser(userID uint, revokedAt time.Time) error
+	TouchLastUsed(id uint, usedAt time.Time) error
+}
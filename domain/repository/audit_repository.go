@@ -0,0 +1,9 @@
+package repository
+
+import "github.com/drossan/core-api/domain/model"
+
+type AuditRepository interface {
+	Create(entry *model.AuditEntry) error
+	// GetByUser devuelve las entradas en las que el usuario actúa o es el afectado
+	GetByUser(userID uint) ([]*model.AuditEntry, error)
+}
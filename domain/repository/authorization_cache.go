@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/drossan/core-api/domain/model"
+
+// AuthorizationCache caché de los niveles con sus privilegios. Se invalida
+// sola al modificar niveles, privilegios o formularios a través del
+// LevelRepository al que envuelve.
+type AuthorizationCache interface {
+	LevelRepository
+	// Stats devuelve las métricas de aciertos y fallos de la caché
+	Stats() model.AuthorizationCacheStats
+}
@@ -1,10 +1,24 @@
 package repository
 
-import "github.com/drossan/core-api/domain/model"
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
 
 type FormRepository interface {
-	CreateOrUpdate(form *model.Form) error
-	GetAll() ([]*model.Form, error)
-	Paginate(page int, pageSize int) ([]*model.Form, int, error)
+	// CreateOrUpdate guarda el registro; con condiciones de acceso a filas
+	// deshace el cambio y devuelve security.ErrRowAccessDenied si el registro
+	// guardado no las cumple
+	CreateOrUpdate(form *model.Form, filter *security.RowFilter) error
+	// GetAll y Paginate solo devuelven los registros que cumplen las
+	// condiciones de acceso a filas; un filtro nil no restringe nada
+	GetAll(filter *security.RowFilter) ([]*model.Form, error)
+	Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.Form, int, error)
+	// MatchesFilter indica si el registro existe y cumple las condiciones de
+	// acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
 	Delete(form *model.Form) error
+	// UnknownColumns devuelve las columnas que no existen en la tabla de
+	// alguno de los recursos del PathAPI que filtran filas
+	UnknownColumns(pathAPI string, columns []string) ([]string, error)
 }
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
+
+// InvitationRepository las invitaciones son usuarios futuros: se les aplican
+// las condiciones de acceso a filas de los usuarios que usan columnas que
+// también tienen (email, username, full_name y level_id); el resto de
+// condiciones no se cumplen.
+type InvitationRepository interface {
+	// Create guarda la invitación; con condiciones de acceso a filas deshace
+	// el cambio y devuelve security.ErrRowAccessDenied si no las cumple
+	Create(invitation *model.Invitation, filter *security.RowFilter) error
+	// CreateBatch crea todas las invitaciones en una transacción, y ninguna si
+	// alguna no cumple las condiciones de acceso a filas
+	CreateBatch(invitations []*model.Invitation, filter *security.RowFilter) error
+	GetByID(id uint) (*model.Invitation, error)
+	// MatchesFilter indica si la invitación existe y cumple las condiciones de
+	// acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
+	// GetByUser devuelve las invitaciones aceptadas por el usuario o enviadas a su email
+	GetByUser(userID uint, email string) ([]*model.Invitation, error)
+	// GetPending devuelve las invitaciones sin aceptar ni revocar, caducadas o
+	// no, que cumplen las condiciones de acceso a filas
+	GetPending(filter *security.RowFilter) ([]*model.Invitation, error)
+	// GetPendingByEmail devuelve la invitación sin aceptar ni revocar del email
+	GetPendingByEmail(email string) (*model.Invitation, error)
+	// UpdateToken guarda el enlace enviado y su caducidad
+	UpdateToken(id uint, tokenHash string, sentAt time.Time, expiresAt time.Time) error
+	// Accept marca la invitación como aceptada solo si seguía pendiente
+	Accept(id uint, userID uint, acceptedAt time.Time) (bool, error)
+	// Revoke revoca la invitación solo si seguía pendiente
+	Revoke(id uint, revokedAt time.Time) (bool, error)
+}
@@ -1,9 +1,21 @@
 package repository
 
-import "github.com/drossan/core-api/domain/model"
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
 
 type LevelPrivilegesRepository interface {
-	CreateOrUpdate(levelPrivileges *model.LevelPrivileges) error
-	GetAll() ([]*model.LevelPrivileges, error)
+	// CreateOrUpdate guarda el registro; con condiciones de acceso a filas
+	// deshace el cambio y devuelve security.ErrRowAccessDenied si el registro
+	// guardado no las cumple
+	CreateOrUpdate(levelPrivileges *model.LevelPrivileges, filter *security.RowFilter) error
+	GetByID(id uint) (*model.LevelPrivileges, error)
+	// GetAll solo devuelve los registros que cumplen las condiciones de
+	// acceso a filas; un filtro nil no restringe nada
+	GetAll(filter *security.RowFilter) ([]*model.LevelPrivileges, error)
+	// MatchesFilter indica si el registro existe y cumple las condiciones de
+	// acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
 	Delete(levelPrivileges *model.LevelPrivileges) error
 }
@@ -1,11 +1,29 @@
 package repository
 
-import "github.com/drossan/core-api/domain/model"
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
 
 type LevelRepository interface {
-	CreateOrUpdate(level *model.Level) error
+	// CreateOrUpdate guarda el registro; con condiciones de acceso a filas
+	// deshace el cambio y devuelve security.ErrRowAccessDenied si el registro
+	// guardado no las cumple
+	CreateOrUpdate(level *model.Level, filter *security.RowFilter) error
 	GetByID(id uint) (*model.Level, error)
-	GetAll() ([]*model.Level, error)
-	Paginate(page int, pageSize int) ([]*model.Level, int, error)
+	// GetAll y Paginate solo devuelven los registros que cumplen las
+	// condiciones de acceso a filas; un filtro nil no restringe nada
+	GetAll(filter *security.RowFilter) ([]*model.Level, error)
+	Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.Level, int, error)
+	// MatchesFilter indica si el registro existe y cumple las condiciones de
+	// acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
 	Delete(level *model.Level) error
+	// GetPermissionVersion devuelve la versión de los privilegios del nivel
+	GetPermissionVersion(id uint) (uint, error)
+	// BumpPermissionVersion incrementa la versión de los privilegios de los niveles
+	BumpPermissionVersion(ids ...uint) error
+	// BumpPermissionVersionByForm incrementa la versión de los niveles con
+	// privilegios sobre el formulario
+	BumpPermissionVersionByForm(formID uint) error
 }
@@ -1,10 +1,21 @@
 package repository
 
-import "github.com/drossan/core-api/domain/model"
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
 
 type MenuTreeRepository interface {
-	CreateOrUpdate(menuTree *model.MenuTree) error
-	GetAll() ([]*model.MenuTree, error)
-	Paginate(page int, pageSize int) ([]*model.MenuTree, int, error)
+	// CreateOrUpdate guarda el registro; con condiciones de acceso a filas
+	// deshace el cambio y devuelve security.ErrRowAccessDenied si el registro
+	// guardado no las cumple
+	CreateOrUpdate(menuTree *model.MenuTree, filter *security.RowFilter) error
+	// GetAll y Paginate solo devuelven los registros que cumplen las
+	// condiciones de acceso a filas; un filtro nil no restringe nada
+	GetAll(filter *security.RowFilter) ([]*model.MenuTree, error)
+	Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.MenuTree, int, error)
+	// MatchesFilter indica si el registro existe y cumple las condiciones de
+	// acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
 	Delete(menuTree *model.MenuTree) error
 }
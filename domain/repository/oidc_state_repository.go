@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+)
+
+type OIDCStateRepository interface {
+	Create(state *model.OIDCState) error
+	// Consume devuelve el state y lo borra; solo una petición puede obtenerlo
+	Consume(stateHash string) (*model.OIDCState, error)
+	DeleteExpired(before time.Time) error
+}
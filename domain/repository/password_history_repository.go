@@ -0,0 +1,11 @@
+package repository
+
+import "github.com/drossan/core-api/domain/model"
+
+type PasswordHistoryRepository interface {
+	Create(entry *model.PasswordHistory) error
+	// GetRecent devuelve las últimas contraseñas del usuario, la más reciente primero
+	GetRecent(userID uint, limit int) ([]*model.PasswordHistory, error)
+	// Prune borra las contraseñas del usuario salvo las keep más recientes
+	Prune(userID uint, keep int) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "time"
+
+type RecoveryCodeRepository interface {
+	// ReplaceForUser borra los códigos del usuario y guarda los nuevos hashes
+	ReplaceForUser(userID uint, codeHashes []string) error
+	// Consume marca el código como usado solo si existía y no se había usado
+	Consume(userID uint, codeHash string, usedAt time.Time) (bool, error)
+	DeleteByUser(userID uint) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *model.RefreshToken) error
+	GetByHash(tokenHash string) (*model.RefreshToken, error)
+	// Revoke marca el token como usado/revocado solo si aún no lo estaba y
+	// devuelve false si otra petición se adelantó.
+	Revoke(id uint, revokedAt time.Time, replacedBy *uint) (bool, error)
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	RevokeByUser(userID uint, revokedAt time.Time) error
+}
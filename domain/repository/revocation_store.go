@@ -0,0 +1,17 @@
+package repository
+
+import "time"
+
+// RevocationStore guarda los access tokens revocados antes de su expiración.
+// Un token queda revocado si su jti está en la lista o si se emitió antes del
+// corte fijado para su usuario con RevokeUser. El iat de los JWT solo tiene
+// segundos, así que el corte se trunca al segundo y los tokens emitidos en ese
+// mismo segundo siguen siendo válidos: de lo contrario los tokens emitidos
+// justo después de revocar (cambio de contraseña, reactivación) se
+// rechazarían. Los de ese segundo emitidos antes del corte los invalida el
+// cierre de sus sesiones.
+type RevocationStore interface {
+	Revoke(jti string, userID uint, expiresAt time.Time) error
+	RevokeUser(userID uint, revokedBefore time.Time) error
+	IsRevoked(jti string, userID uint, issuedAt time.Time) (bool, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+)
+
+type SessionRepository interface {
+	Create(session *model.Session) error
+	GetByID(id uint) (*model.Session, error)
+	GetByFamily(familyID string) (*model.Session, error)
+	// GetByUser devuelve todas las sesiones del usuario, también las cerradas
+	GetByUser(userID uint) ([]*model.Session, error)
+	// GetActiveByUser devuelve las sesiones sin revocar usadas después de seenAfter
+	GetActiveByUser(userID uint, seenAfter time.Time) ([]*model.Session, error)
+	Touch(id uint, seenAt time.Time) error
+	// Revoke revoca la sesión solo si pertenece al usuario y seguía activa
+	Revoke(id uint, userID uint, revokedAt time.Time) (bool, error)
+	RevokeByUser(userID uint, revokedAt time.Time) error
+}
@@ -1,14 +1,69 @@
 package repository
 
-import "github.com/drossan/core-api/domain/model"
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+)
 
 type UserRepository interface {
-	Create(user *model.User) error
-	Update(user *model.User) error
+	// Create y Update guardan el usuario; con condiciones de acceso a filas
+	// deshacen el cambio y devuelven security.ErrRowAccessDenied si el usuario
+	// guardado no las cumple
+	Create(user *model.User, filter *security.RowFilter) error
+	// CreateBatch crea todos los usuarios en una transacción: o todos o
+	// ninguno, y ninguno si alguno no cumple las condiciones de acceso a filas
+	CreateBatch(users []*model.User, filter *security.RowFilter) error
+	Update(user *model.User, filter *security.RowFilter) error
 	GetByID(id uint) (*model.User, error)
+	// GetByIDUnscoped devuelve el usuario aunque esté eliminado de forma lógica
+	GetByIDUnscoped(id uint) (*model.User, error)
 	GetByEmail(email string) (*model.User, error)
-	GetAll() ([]*model.User, error)
-	Paginate(page int, pageSize int) ([]*model.User, int, error)
+	GetByToken(token string) (*model.User, error)
+	// GetTakenIdentifiers devuelve, en minúsculas, los nombres de usuario y
+	// emails de la lista que ya usa alguna cuenta, también las eliminadas
+	GetTakenIdentifiers(usernames []string, emails []string) ([]string, []string, error)
+	// GetAll y Paginate solo devuelven los registros que cumplen las
+	// condiciones de acceso a filas; un filtro nil no restringe nada
+	GetAll(filter *security.RowFilter) ([]*model.User, error)
+	Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error)
+	// MatchesFilter indica si el registro existe, aunque esté eliminado, y
+	// cumple las condiciones de acceso a filas
+	MatchesFilter(id uint, filter *security.RowFilter) (bool, error)
+	// Delete marca el usuario como eliminado y lo borra de forma lógica
 	Delete(user *model.User) error
-	Login(email, password string) (string, error)
+	// GetStatus devuelve el estado de la cuenta, también de las eliminadas
+	GetStatus(id uint) (string, error)
+	// SetStatus cambia el estado de la cuenta con su motivo
+	SetStatus(id uint, status string, reason string, changedAt time.Time) error
+	// SetLevels sustituye los niveles adicionales del usuario
+	SetLevels(id uint, levelIDs []uint) error
+	// PaginateDeleted lista los usuarios eliminados de forma lógica que cumplen
+	// las condiciones de acceso a filas
+	PaginateDeleted(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error)
+	// Restore recupera un usuario eliminado; devuelve false si no lo estaba
+	Restore(id uint, restoredAt time.Time) (bool, error)
+	// Purge borra definitivamente un usuario eliminado y sus datos asociados;
+	// devuelve false si no estaba eliminado
+	Purge(id uint) (bool, error)
+	// Erase anonimiza los datos personales del usuario, también si está
+	// eliminado, conservando la fila para no romper las referencias, y guarda
+	// la entrada de auditoría en la misma transacción
+	Erase(id uint, erasedAt time.Time, audit *model.AuditEntry) error
+	// IncrementFailure suma un fallo de login de forma atómica y devuelve el total
+	IncrementFailure(id uint) (int, error)
+	LockUntil(id uint, until time.Time) error
+	ResetFailures(id uint) error
+	// UpdateProfile modifica solo los campos que el usuario puede cambiar de sí mismo
+	UpdateProfile(id uint, profile *model.Profile) error
+	UpdatePassword(id uint, hashedPassword string) error
+	// ResetPassword guarda la contraseña y consume el token de recuperación en
+	// una sola escritura; devuelve false si el token ya se ha usado o ha caducado
+	ResetPassword(id uint, token string, hashedPassword string, changedAt time.Time) (bool, error)
+	// UpdateMFA guarda el secreto TOTP y si el 2FA está activo
+	UpdateMFA(id uint, secret string, enabled bool) error
+	// MarkMFAStepUsed registra el intervalo TOTP usado y devuelve false si ya se
+	// había usado ese código o uno posterior
+	MarkMFAStepUsed(id uint, step int64) (bool, error)
 }
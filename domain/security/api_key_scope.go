@@ -0,0 +1,54 @@
+package security
+
+import "strings"
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	// ScopeAnyPath permite la acción en cualquier path al que tenga acceso el nivel
+	ScopeAnyPath = "*"
+)
+
+// scopeActions acciones de los privilegios que cubre cada acción de scope.
+// read y write agrupan las acciones como antes de separarlas; el resto
+// limitan la clave a una sola acción.
+var scopeActions = map[string]string{
+	ScopeRead:  PermissionRead + PermissionExport,
+	ScopeWrite: PermissionCreate + PermissionUpdate + PermissionDelete + PermissionApprove,
+	"create":   PermissionCreate,
+	"update":   PermissionUpdate,
+	"delete":   PermissionDelete,
+	"export":   PermissionExport,
+	"approve":  PermissionApprove,
+}
+
+// ParseScope separa un scope "<path>:<acción>" en sus dos partes
+func ParseScope(scope string) (path string, action string, ok bool) {
+	path, action, found := strings.Cut(strings.TrimSpace(scope), ":")
+	if _, valid := scopeActions[action]; !found || path == "" || !valid {
+		return "", "", false
+	}
+	return path, action, true
+}
+
+// ScopeActions devuelve las acciones de los privilegios que cubre la acción
+// del scope
+func ScopeActions(scopeAction string) string {
+	return scopeActions[scopeAction]
+}
+
+// ScopesAllow indica si alguno de los scopes permite la acción de los
+// privilegios (PermissionRead, PermissionCreate...) sobre el path.
+// Escritura no implica lectura: cada acción necesita su propio scope.
+func ScopesAllow(scopes []string, path string, action string) bool {
+	for _, scope := range scopes {
+		scopePath, scopeAction, ok := ParseScope(scope)
+		if !ok || !strings.Contains(scopeActions[scopeAction], action) {
+			continue
+		}
+		if scopePath == ScopeAnyPath || scopePath == path {
+			return true
+		}
+	}
+	return false
+}
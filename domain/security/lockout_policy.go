@@ -0,0 +1,34 @@
+package security
+
+import "time"
+
+// maxLockoutShift limita el exponente para que la duración no desborde
+const maxLockoutShift = 20
+
+// LockoutPolicy define cuándo se bloquea una cuenta tras fallos de login
+// consecutivos y durante cuánto tiempo. A partir de MaxFailures cada fallo
+// adicional duplica la duración del bloqueo hasta llegar a MaxDuration.
+type LockoutPolicy struct {
+	MaxFailures  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+// LockDuration devuelve cuánto tiempo debe bloquearse la cuenta tras el número
+// de fallos indicado, o 0 si todavía no hay que bloquearla.
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+
+	shift := failures - p.MaxFailures
+	if shift > maxLockoutShift {
+		shift = maxLockoutShift
+	}
+
+	duration := p.BaseDuration << shift
+	if p.MaxDuration > 0 && (duration > p.MaxDuration || duration <= 0) {
+		return p.MaxDuration
+	}
+	return duration
+}
@@ -0,0 +1,16 @@
+package security
+
+// PasswordHasher es el puerto para generar y verificar hashes de contraseñas.
+// Los hashes generados llevan como prefijo el identificador del algoritmo
+// (por ejemplo "argon2id$..." o "bcrypt$...") para poder migrar de algoritmo
+// sin invalidar las contraseñas ya almacenadas.
+type PasswordHasher interface {
+	// Algorithm devuelve el identificador del algoritmo usado al generar hashes.
+	Algorithm() string
+	// Hash genera el hash con prefijo de algoritmo de la contraseña.
+	Hash(password string) (string, error)
+	// Verify comprueba si la contraseña corresponde al hash almacenado.
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash indica si el hash almacenado debe regenerarse con los parámetros actuales.
+	NeedsRehash(encoded string) bool
+}
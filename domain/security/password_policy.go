@@ -0,0 +1,93 @@
+package security
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos de los incumplimientos de la política de contraseñas
+const (
+	PasswordTooShort         = "too_short"
+	PasswordCharacterClasses = "character_classes"
+	PasswordCommon           = "common"
+	PasswordReused           = "reused"
+)
+
+// PasswordPolicy requisitos de las contraseñas. MinClasses es cuántos tipos de
+// carácter distintos (minúsculas, mayúsculas, dígitos y símbolos) debe tener;
+// History cuántas contraseñas anteriores no se pueden repetir y MaxAge cada
+// cuánto hay que cambiarla. Los valores a cero desactivan cada regla.
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	History    int
+	MaxAge     time.Duration
+}
+
+// PasswordBlocklist es el puerto con las contraseñas comunes o filtradas que
+// no se pueden usar.
+type PasswordBlocklist interface {
+	Contains(password string) bool
+}
+
+// Merge aplica sobre la política los valores distintos de cero de override,
+// por ejemplo los configurados en un nivel concreto
+func (p PasswordPolicy) Merge(override PasswordPolicy) PasswordPolicy {
+	if override.MinLength > 0 {
+		p.MinLength = override.MinLength
+	}
+	if override.MinClasses > 0 {
+		p.MinClasses = override.MinClasses
+	}
+	if override.History > 0 {
+		p.History = override.History
+	}
+	if override.MaxAge > 0 {
+		p.MaxAge = override.MaxAge
+	}
+	return p
+}
+
+// Check comprueba la longitud y los tipos de carácter y devuelve los códigos
+// de las reglas que no se cumplen. La longitud se cuenta en caracteres, no en
+// bytes.
+func (p PasswordPolicy) Check(password string) []string {
+	var violations []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordTooShort)
+	}
+	if characterClasses(password) < p.MinClasses {
+		violations = append(violations, PasswordCharacterClasses)
+	}
+	return violations
+}
+
+// Expired indica si una contraseña cambiada en changedAt ha caducado
+func (p PasswordPolicy) Expired(changedAt time.Time, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(changedAt) > p.MaxAge
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
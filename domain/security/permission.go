@@ -0,0 +1,85 @@
+package security
+
+import "strings"
+
+// Acciones de los privilegios de un nivel sobre un formulario. En el token
+// cada permiso es "<PathAPI>:<acciones>", por ejemplo "user|users:rcu".
+const (
+	PermissionRead    = "r"
+	PermissionCreate  = "c"
+	PermissionUpdate  = "u"
+	PermissionDelete  = "d"
+	PermissionExport  = "e"
+	PermissionApprove = "a"
+)
+
+// ValidPermissionAction indica si la acción es una de las acciones de los
+// privilegios
+func ValidPermissionAction(action string) bool {
+	switch action {
+	case PermissionRead, PermissionCreate, PermissionUpdate, PermissionDelete, PermissionExport, PermissionApprove:
+		return true
+	}
+	return false
+}
+
+// permissionActionNames nombre de cada acción en el API
+var permissionActionNames = map[string]string{
+	"read":    PermissionRead,
+	"create":  PermissionCreate,
+	"update":  PermissionUpdate,
+	"delete":  PermissionDelete,
+	"export":  PermissionExport,
+	"approve": PermissionApprove,
+}
+
+// PermissionActionByName devuelve la acción de los privilegios que corresponde
+// al nombre ("read", "create"...)
+func PermissionActionByName(name string) (string, bool) {
+	action, ok := permissionActionNames[name]
+	return action, ok
+}
+
+// PermissionSnapshot copia de los privilegios de un nivel que viaja en el
+// access token. Version es la versión de los privilegios del nivel al
+// emitirlo; si ya no coincide la copia está obsoleta.
+type PermissionSnapshot struct {
+	Version     uint
+	Permissions []string
+}
+
+// PathAPIGrants indica si el PathAPI de un formulario da acceso al recurso que
+// declaran las rutas. PathAPI lista las claves de permiso del formulario
+// separadas por "|" ("user|users" o "smtp-config").
+func PathAPIGrants(pathAPI string, resource string) bool {
+	for _, key := range strings.Split(pathAPI, "|") {
+		if key != "" && key == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatPermission codifica el permiso de un formulario con las acciones que
+// permite; devuelve "" si no permite ninguna
+func FormatPermission(pathAPI string, actions string) string {
+	if actions == "" {
+		return ""
+	}
+	return pathAPI + ":" + actions
+}
+
+// PermissionsAllow indica si alguno de los permisos del token permite la
+// acción sobre el recurso
+func PermissionsAllow(permissions []string, resource string, action string) bool {
+	for _, permission := range permissions {
+		separator := strings.LastIndex(permission, ":")
+		if separator < 0 {
+			continue
+		}
+		if PathAPIGrants(permission[:separator], resource) && strings.Contains(permission[separator+1:], action) {
+			return true
+		}
+	}
+	return false
+}
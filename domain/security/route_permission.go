@@ -0,0 +1,144 @@
+package security
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RoutePermission permiso que exige una ruta protegida: la clave del recurso,
+// que debe aparecer en el PathAPI de algún formulario, y la acción
+// (PermissionRead, PermissionCreate...). NoImpersonation impide usar la ruta
+// mientras se suplanta a otro usuario.
+type RoutePermission struct {
+	Resource        string
+	Action          string
+	NoImpersonation bool
+}
+
+// WithoutImpersonation devuelve el mismo permiso para una ruta que no se puede
+// usar mientras se suplanta a otro usuario (credenciales, niveles, privilegios...)
+func (p RoutePermission) WithoutImpersonation() RoutePermission {
+	p.NoImpersonation = true
+	return p
+}
+
+// Read permiso de lectura sobre el recurso
+func Read(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionRead}
+}
+
+// Create permiso para crear en el recurso
+func Create(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionCreate}
+}
+
+// Update permiso para modificar el recurso
+func Update(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionUpdate}
+}
+
+// Delete permiso para eliminar en el recurso
+func Delete(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionDelete}
+}
+
+// Export permiso para exportar los datos del recurso
+func Export(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionExport}
+}
+
+// Approve permiso para aprobar en el recurso
+func Approve(resource string) RoutePermission {
+	return RoutePermission{Resource: resource, Action: PermissionApprove}
+}
+
+// RoutePermissions registro central de los permisos que declara cada ruta
+// protegida, por método y ruta tal como se registró ("/api/v1/users/:page").
+// El middleware de autorización deniega las rutas que no aparecen en él.
+type RoutePermissions struct {
+	mutex  sync.RWMutex
+	routes map[string]DeclaredRoute
+}
+
+// DeclaredRoute ruta del registro con el permiso que exige
+type DeclaredRoute struct {
+	Method     string
+	Path       string
+	Permission RoutePermission
+}
+
+func NewRoutePermissions() *RoutePermissions {
+	return &RoutePermissions{routes: make(map[string]DeclaredRoute)}
+}
+
+// Register anota el permiso de la ruta. Falla si el permiso no es válido o si
+// la ruta ya estaba declarada con otro permiso.
+func (r *RoutePermissions) Register(method string, path string, permission RoutePermission) error {
+	if permission.Resource == "" || !ValidPermissionAction(permission.Action) {
+		return fmt.Errorf("route %s %s declares an invalid permission %q:%q", method, path, permission.Resource, permission.Action)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := method + " " + path
+	if existing, ok := r.routes[key]; ok && existing.Permission != permission {
+		return fmt.Errorf("route %s %s is already declared with permission %s:%s", method, path, existing.Permission.Resource, existing.Permission.Action)
+	}
+	r.routes[key] = DeclaredRoute{Method: method, Path: path, Permission: permission}
+	return nil
+}
+
+// Lookup devuelve el permiso que exige la ruta
+func (r *RoutePermissions) Lookup(method string, path string) (RoutePermission, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	route, ok := r.routes[method+" "+path]
+	return route.Permission, ok
+}
+
+// BlocksImpersonation indica si la ruta está declarada como no utilizable
+// mientras se suplanta a otro usuario
+func (r *RoutePermissions) BlocksImpersonation(method string, path string) bool {
+	permission, ok := r.Lookup(method, path)
+	return ok && permission.NoImpersonation
+}
+
+// Routes devuelve las rutas declaradas ordenadas por ruta y método
+func (r *RoutePermissions) Routes() []DeclaredRoute {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	routes := make([]DeclaredRoute, 0, len(r.routes))
+	for _, route := range r.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Ungranted devuelve las rutas cuyo recurso no aparece en ninguno de los
+// PathAPI: nadie puede acceder a ellas
+func (r *RoutePermissions) Ungranted(pathAPIs []string) []DeclaredRoute {
+	var ungranted []DeclaredRoute
+	for _, route := range r.Routes() {
+		granted := false
+		for _, pathAPI := range pathAPIs {
+			if PathAPIGrants(pathAPI, route.Permission.Resource) {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			ungranted = append(ungranted, route)
+		}
+	}
+	return ungranted
+}
@@ -0,0 +1,385 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Condiciones de acceso a filas (Form.Condition). Una condición compara
+// columnas del registro con atributos del usuario o con literales:
+//
+//	owner_id == user.id
+//	level_id == user.level_id && status != 'deleted'
+//	(id == user.id || level_id == 3)
+//
+// Solo admite == y !=, && y || (&& tiene prioridad) y paréntesis. Las columnas
+// van a la izquierda de la comparación y los valores a la derecha: atributos
+// user.<atributo>, cadenas entre comillas simples, enteros, true y false.
+// Las condiciones se traducen a SQL con los valores como parámetros, así que
+// nunca se concatena texto del usuario en la consulta.
+
+// Atributos del usuario que pueden usar las condiciones
+const (
+	RowSubjectID      = "id"
+	RowSubjectEmail   = "email"
+	RowSubjectLevelID = "level_id"
+)
+
+var rowSubjectAttributes = map[string]bool{
+	RowSubjectID:      true,
+	RowSubjectEmail:   true,
+	RowSubjectLevelID: true,
+}
+
+// maxRowPolicyDepth limita el anidamiento de paréntesis de una condición
+const maxRowPolicyDepth = 16
+
+var ErrInvalidRowPolicy = errors.New("invalid row policy")
+
+// ErrRowAccessDenied el registro no cumple las condiciones de acceso a filas
+var ErrRowAccessDenied = errors.New("access to this record is not allowed")
+
+// RowSubject valores de los atributos del usuario que hace la petición
+type RowSubject map[string]interface{}
+
+// RowPolicy condición de acceso a filas ya validada
+type RowPolicy struct {
+	root rowExpression
+}
+
+// ParseRowPolicy valida la condición; la condición vacía no restringe nada y
+// devuelve nil
+func ParseRowPolicy(condition string) (*RowPolicy, error) {
+	if strings.TrimSpace(condition) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenizeRowPolicy(condition)
+	if err != nil {
+		return nil, err
+	}
+	parser := &rowPolicyParser{tokens: tokens}
+	root, err := parser.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !parser.done() {
+		return nil, parser.errorf("unexpected %q", parser.peek().text)
+	}
+	return &RowPolicy{root: root}, nil
+}
+
+// Columns devuelve las columnas que usa la condición, sin repetir y en el
+// orden en que aparecen
+func (p *RowPolicy) Columns() []string {
+	var columns []string
+	p.root.columns(func(column string) {
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	})
+	return columns
+}
+
+// RowFilter condiciones de acceso a filas de una petición: el registro es
+// accesible si cumple alguna. Un RowFilter nil no restringe nada y uno sin
+// condiciones no deja acceder a ningún registro.
+type RowFilter struct {
+	policies []*RowPolicy
+	subject  RowSubject
+}
+
+func NewRowFilter(subject RowSubject, policies ...*RowPolicy) *RowFilter {
+	return &RowFilter{policies: policies, subject: subject}
+}
+
+// WithColumns devuelve el filtro solo con las condiciones que usan únicamente
+// esas columnas, para aplicarlo a otra tabla que las comparte. Las demás
+// condiciones no se cumplen: sin ninguna no se accede a ningún registro.
+func (f *RowFilter) WithColumns(columns ...string) *RowFilter {
+	if f == nil {
+		return nil
+	}
+	policies := make([]*RowPolicy, 0, len(f.policies))
+	for _, policy := range f.policies {
+		supported := true
+		for _, column := range policy.Columns() {
+			if !slices.Contains(columns, column) {
+				supported = false
+				break
+			}
+		}
+		if supported {
+			policies = append(policies, policy)
+		}
+	}
+	return NewRowFilter(f.subject, policies...)
+}
+
+// Where devuelve la condición SQL y sus parámetros. quote escapa los nombres
+// de columna según la base de datos.
+func (f *RowFilter) Where(quote func(string) string) (string, []interface{}) {
+	if len(f.policies) == 0 {
+		return "1 = 0", nil
+	}
+	var clauses []string
+	var args []interface{}
+	for _, policy := range f.policies {
+		clause, policyArgs := policy.root.where(quote, f.subject)
+		clauses = append(clauses, "("+clause+")")
+		args = append(args, policyArgs...)
+	}
+	return strings.Join(clauses, " OR "), args
+}
+
+type rowExpression interface {
+	where(quote func(string) string, subject RowSubject) (string, []interface{})
+	columns(add func(string))
+}
+
+type rowLogical struct {
+	operator    string
+	left, right rowExpression
+}
+
+func (e *rowLogical) where(quote func(string) string, subject RowSubject) (string, []interface{}) {
+	left, leftArgs := e.left.where(quote, subject)
+	right, rightArgs := e.right.where(quote, subject)
+	operator := "AND"
+	if e.operator == "||" {
+		operator = "OR"
+	}
+	return "(" + left + ") " + operator + " (" + right + ")", append(leftArgs, rightArgs...)
+}
+
+func (e *rowLogical) columns(add func(string)) {
+	e.left.columns(add)
+	e.right.columns(add)
+}
+
+type rowComparison struct {
+	column    string
+	operator  string
+	attribute string
+	literal   interface{}
+}
+
+func (e *rowComparison) where(quote func(string) string, subject RowSubject) (string, []interface{}) {
+	value := e.literal
+	if e.attribute != "" {
+		value = subject[e.attribute]
+	}
+	operator := "="
+	if e.operator == "!=" {
+		operator = "<>"
+	}
+	// Un atributo sin valor no coincide con ninguna fila
+	if value == nil {
+		return "1 = 0", nil
+	}
+	return quote(e.column) + " " + operator + " ?", []interface{}{value}
+}
+
+func (e *rowComparison) columns(add func(string)) {
+	add(e.column)
+}
+
+type rowTokenKind int
+
+const (
+	rowTokenIdentifier rowTokenKind = iota
+	rowTokenString
+	rowTokenNumber
+	rowTokenOperator
+)
+
+type rowToken struct {
+	kind rowTokenKind
+	text string
+}
+
+func tokenizeRowPolicy(condition string) ([]rowToken, error) {
+	var tokens []rowToken
+	for i := 0; i < len(condition); {
+		c := condition[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, rowToken{rowTokenOperator, string(c)})
+			i++
+		case strings.HasPrefix(condition[i:], "==") || strings.HasPrefix(condition[i:], "!=") ||
+			strings.HasPrefix(condition[i:], "&&") || strings.HasPrefix(condition[i:], "||"):
+			tokens = append(tokens, rowToken{rowTokenOperator, condition[i : i+2]})
+			i += 2
+		case c == '\'':
+			end := strings.IndexByte(condition[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidRowPolicy)
+			}
+			tokens = append(tokens, rowToken{rowTokenString, condition[i+1 : i+1+end]})
+			i += end + 2
+		case c == '-' || isDigit(c):
+			start := i
+			for i++; i < len(condition) && isDigit(condition[i]); i++ {
+			}
+			tokens = append(tokens, rowToken{rowTokenNumber, condition[start:i]})
+		case c == '_' || isLetter(c):
+			start := i
+			for i++; i < len(condition) && isIdentifierByte(condition[i]); i++ {
+			}
+			tokens = append(tokens, rowToken{rowTokenIdentifier, condition[start:i]})
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q", ErrInvalidRowPolicy, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '.' || isDigit(c) || isLetter(c)
+}
+
+type rowPolicyParser struct {
+	tokens   []rowToken
+	position int
+}
+
+func (p *rowPolicyParser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *rowPolicyParser) peek() rowToken {
+	if p.done() {
+		return rowToken{}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *rowPolicyParser) next() (rowToken, error) {
+	if p.done() {
+		return rowToken{}, p.errorf("unexpected end of condition")
+	}
+	token := p.tokens[p.position]
+	p.position++
+	return token, nil
+}
+
+func (p *rowPolicyParser) accept(operator string) bool {
+	if token := p.peek(); token.kind == rowTokenOperator && token.text == operator {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *rowPolicyParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRowPolicy, fmt.Sprintf(format, args...))
+}
+
+func (p *rowPolicyParser) parseOr(depth int) (rowExpression, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &rowLogical{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *rowPolicyParser) parseAnd(depth int) (rowExpression, error) {
+	left, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parsePrimary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &rowLogical{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *rowPolicyParser) parsePrimary(depth int) (rowExpression, error) {
+	if p.accept("(") {
+		if depth >= maxRowPolicyDepth {
+			return nil, p.errorf("too many nested parentheses")
+		}
+		expression, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		return expression, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *rowPolicyParser) parseComparison() (rowExpression, error) {
+	column, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if column.kind != rowTokenIdentifier || strings.Contains(column.text, ".") || isRowKeyword(column.text) {
+		return nil, p.errorf("expected a column, got %q", column.text)
+	}
+
+	operator, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if operator.kind != rowTokenOperator || operator.text != "==" && operator.text != "!=" {
+		return nil, p.errorf("expected == or != after %q", column.text)
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	comparison := &rowComparison{column: column.text, operator: operator.text}
+	switch value.kind {
+	case rowTokenString:
+		comparison.literal = value.text
+	case rowTokenNumber:
+		number, err := strconv.ParseInt(value.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", value.text)
+		}
+		comparison.literal = number
+	case rowTokenIdentifier:
+		switch {
+		case value.text == "true" || value.text == "false":
+			comparison.literal = value.text == "true"
+		case strings.HasPrefix(value.text, "user.") && rowSubjectAttributes[strings.TrimPrefix(value.text, "user.")]:
+			comparison.attribute = strings.TrimPrefix(value.text, "user.")
+		default:
+			return nil, p.errorf("unknown value %q", value.text)
+		}
+	default:
+		return nil, p.errorf("expected a value after %q", operator.text)
+	}
+	return comparison, nil
+}
+
+func isRowKeyword(text string) bool {
+	return text == "true" || text == "false" || text == "user"
+}
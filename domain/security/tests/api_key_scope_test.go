@@ -0,0 +1,46 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopesAllow(t *testing.T) {
+	scopes := []string{"user:read", "level:write", "*:read", "broken"}
+
+	assert.True(t, security.ScopesAllow(scopes, "user", security.PermissionRead))
+	assert.True(t, security.ScopesAllow(scopes, "user", security.PermissionExport))
+	assert.True(t, security.ScopesAllow(scopes, "level", security.PermissionCreate))
+	assert.True(t, security.ScopesAllow(scopes, "level", security.PermissionDelete))
+	assert.True(t, security.ScopesAllow(scopes, "form", security.PermissionRead))
+	assert.False(t, security.ScopesAllow(scopes, "user", security.PermissionUpdate))
+	assert.False(t, security.ScopesAllow(nil, "user", security.PermissionRead))
+}
+
+func TestScopesAllow_GranularScope(t *testing.T) {
+	scopes := []string{"user:update"}
+
+	assert.True(t, security.ScopesAllow(scopes, "user", security.PermissionUpdate))
+	assert.False(t, security.ScopesAllow(scopes, "user", security.PermissionDelete))
+	assert.False(t, security.ScopesAllow(scopes, "user", security.PermissionRead))
+}
+
+func TestParseScope(t *testing.T) {
+	path, action, ok := security.ParseScope(" users:write ")
+	assert.True(t, ok)
+	assert.Equal(t, "users", path)
+	assert.Equal(t, security.ScopeWrite, action)
+
+	_, action, ok = security.ParseScope("users:delete")
+	assert.True(t, ok)
+	assert.Equal(t, "delete", action)
+
+	_, _, ok = security.ParseScope("users")
+	assert.False(t, ok)
+	_, _, ok = security.ParseScope(":read")
+	assert.False(t, ok)
+	_, _, ok = security.ParseScope("users:admin")
+	assert.False(t, ok)
+}
@@ -0,0 +1,30 @@
+package security_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicy_LockDuration(t *testing.T) {
+	policy := security.LockoutPolicy{
+		MaxFailures:  3,
+		BaseDuration: time.Minute,
+		MaxDuration:  10 * time.Minute,
+	}
+
+	assert.Equal(t, time.Duration(0), policy.LockDuration(2))
+	assert.Equal(t, time.Minute, policy.LockDuration(3))
+	assert.Equal(t, 2*time.Minute, policy.LockDuration(4))
+	assert.Equal(t, 8*time.Minute, policy.LockDuration(6))
+	assert.Equal(t, 10*time.Minute, policy.LockDuration(7))
+	assert.Equal(t, 10*time.Minute, policy.LockDuration(500))
+}
+
+func TestLockoutPolicy_Disabled(t *testing.T) {
+	policy := security.LockoutPolicy{BaseDuration: time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.LockDuration(100))
+}
@@ -0,0 +1,37 @@
+package security_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := security.PasswordPolicy{MinLength: 10, MinClasses: 3}
+
+	assert.Empty(t, policy.Check("Correcta-Caballo"))
+	assert.Equal(t, []string{security.PasswordTooShort}, policy.Check("Ab1-cd"))
+	assert.Equal(t, []string{security.PasswordCharacterClasses}, policy.Check("solominusculas"))
+	assert.Equal(t, []string{security.PasswordTooShort, security.PasswordCharacterClasses}, policy.Check("abc"))
+	// La longitud se cuenta en caracteres, no en bytes
+	assert.Equal(t, []string{security.PasswordTooShort}, policy.Check("Ñandú-1ñ"))
+}
+
+func TestPasswordPolicy_Merge(t *testing.T) {
+	base := security.PasswordPolicy{MinLength: 10, MinClasses: 2, History: 5}
+
+	merged := base.Merge(security.PasswordPolicy{MinLength: 14, MaxAge: 24 * time.Hour})
+	assert.Equal(t, security.PasswordPolicy{MinLength: 14, MinClasses: 2, History: 5, MaxAge: 24 * time.Hour}, merged)
+	assert.Equal(t, base, base.Merge(security.PasswordPolicy{}))
+}
+
+func TestPasswordPolicy_Expired(t *testing.T) {
+	now := time.Now()
+	policy := security.PasswordPolicy{MaxAge: 90 * 24 * time.Hour}
+
+	assert.False(t, policy.Expired(now.Add(-89*24*time.Hour), now))
+	assert.True(t, policy.Expired(now.Add(-91*24*time.Hour), now))
+	assert.False(t, security.PasswordPolicy{}.Expired(now.Add(-10*365*24*time.Hour), now))
+}
@@ -0,0 +1,28 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPermission(t *testing.T) {
+	assert.Equal(t, "user|users:rcud", security.FormatPermission("user|users", "rcud"))
+	assert.Equal(t, "level|levels:r", security.FormatPermission("level|levels", "r"))
+	assert.Equal(t, "", security.FormatPermission("menu|menus", ""))
+}
+
+func TestPermissionsAllow(t *testing.T) {
+	permissions := []string{"user|users:rcu", "level|levels:r", "broken", "single:rd"}
+
+	assert.True(t, security.PermissionsAllow(permissions, "user", security.PermissionRead))
+	assert.True(t, security.PermissionsAllow(permissions, "users", security.PermissionUpdate))
+	assert.False(t, security.PermissionsAllow(permissions, "users", security.PermissionDelete))
+	assert.True(t, security.PermissionsAllow(permissions, "levels", security.PermissionRead))
+	assert.False(t, security.PermissionsAllow(permissions, "level", security.PermissionCreate))
+	assert.False(t, security.PermissionsAllow(permissions, "form", security.PermissionRead))
+	// Un PathAPI con una sola clave también da acceso
+	assert.True(t, security.PermissionsAllow(permissions, "single", security.PermissionRead))
+	assert.False(t, security.PermissionsAllow(nil, "user", security.PermissionRead))
+}
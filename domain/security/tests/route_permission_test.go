@@ -0,0 +1,60 @@
+package security_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathAPIGrants(t *testing.T) {
+	assert.True(t, security.PathAPIGrants("user|users", "user"))
+	assert.True(t, security.PathAPIGrants("user|users", "users"))
+	assert.True(t, security.PathAPIGrants("smtp-config", "smtp-config"))
+	assert.False(t, security.PathAPIGrants("user|users", "level"))
+	assert.False(t, security.PathAPIGrants("", ""))
+}
+
+func TestRoutePermissions_Register(t *testing.T) {
+	permissions := security.NewRoutePermissions()
+
+	assert.NoError(t, permissions.Register(http.MethodGet, "/api/v1/users/:page", security.Read("user")))
+	// Declarar otra vez la misma ruta con el mismo permiso no es un error
+	assert.NoError(t, permissions.Register(http.MethodGet, "/api/v1/users/:page", security.Read("user")))
+	assert.Error(t, permissions.Register(http.MethodGet, "/api/v1/users/:page", security.Update("user")))
+	assert.Error(t, permissions.Register(http.MethodPost, "/api/v1/user", security.Update("")))
+	assert.Error(t, permissions.Register(http.MethodPost, "/api/v1/user", security.RoutePermission{Resource: "user", Action: "x"}))
+
+	permission, ok := permissions.Lookup(http.MethodGet, "/api/v1/users/:page")
+	assert.True(t, ok)
+	assert.Equal(t, security.Read("user"), permission)
+	_, ok = permissions.Lookup(http.MethodPost, "/api/v1/users/:page")
+	assert.False(t, ok)
+}
+
+func TestRoutePermissions_BlocksImpersonation(t *testing.T) {
+	permissions := security.NewRoutePermissions()
+
+	assert.NoError(t, permissions.Register(http.MethodPost, "/api/v1/user/unlock", security.Update("user").WithoutImpersonation()))
+	assert.NoError(t, permissions.Register(http.MethodPost, "/api/v1/expanses-menus", security.Create("expanses-menu")))
+	// La misma ruta no se puede declarar también como utilizable suplantando
+	assert.Error(t, permissions.Register(http.MethodPost, "/api/v1/user/unlock", security.Update("user")))
+
+	assert.True(t, permissions.BlocksImpersonation(http.MethodPost, "/api/v1/user/unlock"))
+	assert.False(t, permissions.BlocksImpersonation(http.MethodPost, "/api/v1/expanses-menus"))
+	assert.False(t, permissions.BlocksImpersonation(http.MethodPost, "/api/v1/unknown"))
+}
+
+func TestRoutePermissions_Ungranted(t *testing.T) {
+	permissions := security.NewRoutePermissions()
+	assert.NoError(t, permissions.Register(http.MethodGet, "/api/v1/users/:page", security.Read("user")))
+	assert.NoError(t, permissions.Register(http.MethodGet, "/api/v1/smtp-config", security.Read("smtp-config")))
+	assert.NoError(t, permissions.Register(http.MethodPost, "/api/v1/level", security.Update("level")))
+
+	ungranted := permissions.Ungranted([]string{"user|users", "smtp-config"})
+	if assert.Len(t, ungranted, 1) {
+		assert.Equal(t, "/api/v1/level", ungranted[0].Path)
+		assert.Equal(t, "level", ungranted[0].Permission.Resource)
+	}
+}
@@ -0,0 +1,101 @@
+package security_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func quoteColumn(column string) string {
+	return "`" + column + "`"
+}
+
+func TestParseRowPolicy_Valid(t *testing.T) {
+	subject := security.RowSubject{security.RowSubjectID: uint(7), security.RowSubjectLevelID: uint(2)}
+	cases := []struct {
+		condition string
+		where     string
+		args      []interface{}
+	}{
+		{"owner_id == user.id", "(`owner_id` = ?)", []interface{}{uint(7)}},
+		{"status != 'deleted'", "(`status` <> ?)", []interface{}{"deleted"}},
+		{"level_id == user.level_id && active == true", "((`level_id` = ?) AND (`active` = ?))", []interface{}{uint(2), true}},
+		{"id == user.id || level_id == -1 && order == 3", "((`id` = ?) OR ((`level_id` = ?) AND (`order` = ?)))", []interface{}{uint(7), int64(-1), int64(3)}},
+		{"(id == user.id || level_id == 1) && status == ''", "(((`id` = ?) OR (`level_id` = ?)) AND (`status` = ?))", []interface{}{uint(7), int64(1), ""}},
+	}
+
+	for _, tc := range cases {
+		policy, err := security.ParseRowPolicy(tc.condition)
+		if !assert.NoError(t, err, tc.condition) {
+			continue
+		}
+		where, args := security.NewRowFilter(subject, policy).Where(quoteColumn)
+		assert.Equal(t, tc.where, where, tc.condition)
+		assert.Equal(t, tc.args, args, tc.condition)
+	}
+}
+
+func TestParseRowPolicy_Invalid(t *testing.T) {
+	conditions := []string{
+		"owner_id",
+		"owner_id = user.id",
+		"owner_id == user.password",
+		"owner_id == department",
+		"user.id == owner_id",
+		"owner_id == 'open",
+		"owner_id == user.id &&",
+		"(owner_id == user.id",
+		"owner_id == user.id)",
+		"owner_id == user.id; DROP TABLE users",
+		"owner_id > 3",
+		"users.owner_id == 3",
+		"owner_id == 99999999999999999999",
+		"((((((((((((((((((id == 1))))))))))))))))))",
+	}
+
+	for _, condition := range conditions {
+		policy, err := security.ParseRowPolicy(condition)
+		assert.True(t, errors.Is(err, security.ErrInvalidRowPolicy), condition)
+		assert.Nil(t, policy, condition)
+	}
+}
+
+func TestRowFilter_Where(t *testing.T) {
+	// La condición vacía no restringe
+	policy, err := security.ParseRowPolicy("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	// Varias condiciones se cumplen con cualquiera de ellas
+	own, _ := security.ParseRowPolicy("owner_id == user.id")
+	level, _ := security.ParseRowPolicy("level_id == user.level_id")
+	where, args := security.NewRowFilter(security.RowSubject{security.RowSubjectID: uint(7), security.RowSubjectLevelID: uint(2)}, own, level).Where(quoteColumn)
+	assert.Equal(t, "(`owner_id` = ?) OR (`level_id` = ?)", where)
+	assert.Equal(t, []interface{}{uint(7), uint(2)}, args)
+
+	// Sin condiciones o sin el atributo del usuario no coincide ninguna fila
+	where, args = security.NewRowFilter(nil).Where(quoteColumn)
+	assert.Equal(t, "1 = 0", where)
+	assert.Empty(t, args)
+	where, _ = security.NewRowFilter(security.RowSubject{}, own).Where(quoteColumn)
+	assert.Equal(t, "(1 = 0)", where)
+}
+
+func TestRowFilter_WithColumns(t *testing.T) {
+	own, _ := security.ParseRowPolicy("owner_id == user.id")
+	level, _ := security.ParseRowPolicy("level_id == user.level_id && email != ''")
+	filter := security.NewRowFilter(security.RowSubject{security.RowSubjectID: uint(7), security.RowSubjectLevelID: uint(2)}, own, level)
+
+	// Solo quedan las condiciones cuyas columnas tiene la otra tabla
+	where, args := filter.WithColumns("email", "level_id").Where(quoteColumn)
+	assert.Equal(t, "((`level_id` = ?) AND (`email` <> ?))", where)
+	assert.Equal(t, []interface{}{uint(2), ""}, args)
+
+	where, _ = filter.WithColumns("email").Where(quoteColumn)
+	assert.Equal(t, "1 = 0", where)
+
+	var unrestricted *security.RowFilter
+	assert.Nil(t, unrestricted.WithColumns("email"))
+}
@@ -0,0 +1,32 @@
+package security
+
+import "github.com/golang-jwt/jwt/v5"
+
+// SigningKey clave con la que se firman o verifican los JWT del API. ID viaja
+// en la cabecera kid del token; las claves HMAC heredadas no tienen ID y solo
+// se usan con tokens sin kid.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private clave de firma; nil en las claves que ya solo sirven para verificar
+	Private interface{}
+	// Public clave de verificación (la propia clave secreta en HMAC)
+	Public interface{}
+}
+
+// Symmetric indica si la clave es un secreto compartido que no puede publicarse
+func (k *SigningKey) Symmetric() bool {
+	_, ok := k.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// TokenKeys es el puerto con las claves de los JWT del API: firma con la clave
+// activa y verifica con cualquiera de las claves que siguen en rotación.
+type TokenKeys interface {
+	// Sign firma los claims con la clave activa indicando su kid.
+	Sign(claims jwt.Claims) (string, error)
+	// Keyfunc elige la clave de verificación a partir del kid del token.
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	// Methods algoritmos aceptados al verificar.
+	Methods() []string
+}
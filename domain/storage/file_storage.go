@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid file key")
+
+// FileStorage guarda ficheros subidos por los usuarios. Las claves son rutas
+// relativas con "/" como separador (por ejemplo "avatars/1/abc-512.jpg") y Save
+// devuelve la URL pública del fichero.
+type FileStorage interface {
+	Save(key string, content io.Reader, contentType string) (string, error)
+	Delete(key string) error
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// SquareThumbnail recorta el centro de la imagen en un cuadrado y lo escala a
+// size x size píxeles sobre fondo blanco, para poder guardarlo como JPEG
+// aunque el original tenga transparencia.
+func SquareThumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	// No se amplían imágenes más pequeñas que la miniatura
+	if side < size {
+		size = side
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, xdraw.Over, nil)
+	return dst
+}
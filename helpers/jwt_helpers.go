@@ -2,21 +2,113 @@ package helpers
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"os"
 	"time"
 )
 
+// tokenKeys claves con las que se firman y verifican los JWT. Mientras no se
+// configuren se usa HS256 con JWT_SECRET, como hasta ahora.
+var tokenKeys security.TokenKeys
+
+// SetTokenKeys configura las claves de los JWT del API; se llama al arrancar
+func SetTokenKeys(keys security.TokenKeys) {
+	tokenKeys = keys
+}
+
 func GetCurrentUser(c echo.Context) uint {
+	return GetCurrentClaims(c).UserID
+}
+
+func GetCurrentClaims(c echo.Context) *model.Claim {
 	token := c.Get("user").(*jwt.Token)
-	claims := token.Claims.(*model.Claim)
-	return claims.UserID
+	return token.Claims.(*model.Claim)
+}
+
+// GetClientInfo devuelve el navegador/dispositivo y la IP de la petición
+func GetClientInfo(c echo.Context) model.ClientInfo {
+	return model.ClientInfo{UserAgent: c.Request().UserAgent(), IP: c.RealIP()}
+}
+
+// tokenOptions datos opcionales de los distintos tipos de token
+type tokenOptions struct {
+	mfaPending      bool
+	passwordExpired bool
+	sessionID       uint
+	actor           *model.Actor
+	invitationID    uint
+	permissions     *security.PermissionSnapshot
+}
+
+func GenerateJWT(user *model.User, expiresIn time.Duration) (string, error) {
+	return generateJWT(user, expiresIn, tokenOptions{})
+}
+
+// GenerateSessionJWT genera el access token de una sesión concreta. Si se
+// indica, el token lleva la copia de los privilegios del nivel.
+func GenerateSessionJWT(user *model.User, expiresIn time.Duration, sessionID uint, permissions *security.PermissionSnapshot) (string, error) {
+	return generateJWT(user, expiresIn, tokenOptions{sessionID: sessionID, permissions: permissions})
+}
+
+// GenerateMFAToken genera el token de "mfa pendiente" que se entrega tras
+// validar la contraseña y que solo permite completar el segundo factor.
+func GenerateMFAToken(user *model.User, expiresIn time.Duration) (string, error) {
+	return generateJWT(user, expiresIn, tokenOptions{mfaPending: true})
 }
 
-func GenerateJWT(user *model.User) (string, error) {
+// GeneratePasswordExpiredToken genera el token que se entrega en el login
+// cuando la contraseña ha caducado y que solo permite cambiarla.
+func GeneratePasswordExpiredToken(user *model.User, expiresIn time.Duration) (string, error) {
+	return generateJWT(user, expiresIn, tokenOptions{passwordExpired: true})
+}
+
+// GenerateImpersonationJWT genera un token del usuario suplantado que lleva en
+// el claim act al administrador que lo ha pedido
+func GenerateImpersonationJWT(user *model.User, actor *model.Actor, expiresIn time.Duration) (string, error) {
+	return generateJWT(user, expiresIn, tokenOptions{actor: actor})
+}
+
+// GenerateInvitationToken genera el enlace firmado de una invitación. Aún no
+// hay usuario: el token lleva el email y el nivel de la invitación y solo
+// sirve para aceptarla.
+func GenerateInvitationToken(invitation *model.Invitation, expiresIn time.Duration) (string, error) {
+	invitee := &model.User{Email: invitation.Email, LevelID: invitation.LevelID}
+	return generateJWT(invitee, expiresIn, tokenOptions{invitationID: invitation.ID})
+}
+
+// ParseJWT valida la firma y la expiración de un token emitido por el API y
+// devuelve sus claims.
+func ParseJWT(tokenString string) (*model.Claim, error) {
+	var (
+		token *jwt.Token
+		err   error
+	)
+	if tokenKeys != nil {
+		token, err = jwt.ParseWithClaims(tokenString, new(model.Claim), tokenKeys.Keyfunc, jwt.WithValidMethods(tokenKeys.Methods()))
+	} else {
+		token, err = jwt.ParseWithClaims(tokenString, new(model.Claim), func(token *jwt.Token) (interface{}, error) {
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token.Claims.(*model.Claim), nil
+}
+
+func generateJWT(user *model.User, expiresIn time.Duration, options tokenOptions) (string, error) {
+	jti, err := GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	registeredClaims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
+		ID:        jti,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 		Issuer:    "Intranet API - Generate by IslaIT",
 	}
 
@@ -25,10 +117,23 @@ func GenerateJWT(user *model.User) (string, error) {
 		Email:            user.Email,
 		Token:            user.Token,
 		LevelID:          user.LevelID,
+		LevelIDs:         user.LevelIDs()[1:],
 		Admin:            user.LevelID,
+		MFAPending:       options.mfaPending,
+		PasswordExpired:  options.passwordExpired,
+		SessionID:        options.sessionID,
+		Actor:            options.actor,
+		InvitationID:     options.invitationID,
 		RegisteredClaims: registeredClaims,
 	}
+	if options.permissions != nil {
+		claims.Permissions = options.permissions.Permissions
+		claims.PermissionVersion = options.permissions.Version
+	}
 
+	if tokenKeys != nil {
+		return tokenKeys.Sign(claims)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 }
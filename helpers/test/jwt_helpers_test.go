@@ -1,8 +1,12 @@
 package helpers_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -52,7 +56,7 @@ func TestGenerateJWT(t *testing.T) {
 	}
 
 	// Generar el token JWT
-	tokenString, err := helpers.GenerateJWT(user)
+	tokenString, err := helpers.GenerateJWT(user, time.Hour*72)
 
 	// Verificar que no hubo errores
 	assert.NoError(t, err)
@@ -74,5 +78,36 @@ func TestGenerateJWT(t *testing.T) {
 	assert.Equal(t, user.LevelID, claims.LevelID)
 	assert.Equal(t, user.LevelID, claims.Admin)
 	assert.Equal(t, "Intranet API - Generate by IslaIT", claims.Issuer)
+	assert.NotEmpty(t, claims.ID)
+	assert.WithinDuration(t, time.Now(), claims.IssuedAt.Time, time.Minute)
 	assert.WithinDuration(t, time.Now().Add(time.Hour*72), claims.ExpiresAt.Time, time.Minute)
 }
+
+func TestGenerateJWT_WithTokenKeys(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := service.NewKeyManager(&security.SigningKey{
+		ID:      "ed-1",
+		Method:  jwt.SigningMethodEdDSA,
+		Private: private,
+		Public:  private.Public(),
+	})
+	assert.NoError(t, err)
+
+	helpers.SetTokenKeys(keys)
+	t.Cleanup(func() { helpers.SetTokenKeys(nil) })
+
+	user := &model.User{Model: gorm.Model{ID: 1}, Email: "test@example.com", LevelID: 1}
+	tokenString, err := helpers.GenerateSessionJWT(user, time.Hour, 9, nil)
+	assert.NoError(t, err)
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &model.Claim{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ed-1", token.Header["kid"])
+	assert.Equal(t, "EdDSA", token.Header["alg"])
+
+	claims, err := helpers.ParseJWT(tokenString)
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Equal(t, uint(9), claims.SessionID)
+	}
+}
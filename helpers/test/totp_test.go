@@ -0,0 +1,60 @@
+package helpers_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+// Secreto "12345678901234567890" de los vectores de prueba del RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := helpers.TOTPCode(rfcSecret, time.Unix(unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := helpers.GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	previous, _ := helpers.TOTPCode(secret, now.Add(-30*time.Second))
+	old, _ := helpers.TOTPCode(secret, now.Add(-90*time.Second))
+
+	step, ok := helpers.ValidateTOTP(secret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, helpers.TOTPStep(now)-1, step)
+
+	_, ok = helpers.ValidateTOTP(secret, old, now, 1)
+	assert.False(t, ok)
+
+	_, ok = helpers.ValidateTOTP(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := helpers.TOTPProvisioningURI("Intranet", "test@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.True(t, strings.HasSuffix(parsed.Path, "Intranet:test@example.com"))
+	assert.Equal(t, rfcSecret, parsed.Query().Get("secret"))
+	assert.Equal(t, "Intranet", parsed.Query().Get("issuer"))
+}
@@ -1,17 +1,51 @@
 package helpers
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
 )
 
-func GetToken(n int) string {
+// GetToken genera una cadena alfanumérica aleatoria de n caracteres usando crypto/rand
+func GetToken(n int) (string, error) {
 	var letter = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
 	b := make([]rune, n)
+	max := big.NewInt(int64(len(letter)))
 
 	for i := range b {
-		b[i] = letter[rand.Intn(len(letter))]
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = letter[idx.Int64()]
 	}
 
-	return string(b)
+	return string(b), nil
+}
+
+// GenerateSecureToken genera un token opaco de n bytes aleatorios (crypto/rand)
+// codificado en base64 apto para URLs.
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken devuelve el SHA-256 en hexadecimal de un token opaco. Los tokens
+// son aleatorios y largos, así que no necesitan sal ni un hash lento.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// PKCEChallenge calcula el code challenge S256 de PKCE (RFC 7636) a partir
+// del code verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
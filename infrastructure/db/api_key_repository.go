@@ -0,0 +1,57 @@
+package db
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByUser(userID uint) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(id uint, userID uint, revokedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *apiKeyRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	return r.db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) repository.AuditRepository {
+	return &auditRepository{db}
+}
+
+func (r *auditRepository) Create(entry *model.AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *auditRepository) GetByUser(userID uint) ([]*model.AuditEntry, error) {
+	var entries []*model.AuditEntry
+	err := r.db.Where("actor_id = ? OR subject_id = ?", userID, userID).Order("id").Find(&entries).Error
+	return entries, err
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 )
 
@@ -14,11 +15,14 @@ func NewFormRepository(db *gorm.DB) repository.FormRepository {
 	return &formRepository{db}
 }
 
-func (r *formRepository) CreateOrUpdate(form *model.Form) error {
-	if form.ID != 0 {
-		return r.db.Save(form).Error
-	}
-	return r.db.Create(form).Error
+func (r *formRepository) CreateOrUpdate(form *model.Form, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.Form{}, filter, func(tx *gorm.DB) (uint, error) {
+		if form.ID != 0 {
+			return form.ID, tx.Save(form).Error
+		}
+		err := tx.Create(form).Error
+		return form.ID, err
+	})
 }
 
 func (r *formRepository) GetByID(id uint) (*model.Form, error) {
@@ -29,28 +33,36 @@ func (r *formRepository) GetByID(id uint) (*model.Form, error) {
 	return &form, nil
 }
 
-func (r *formRepository) GetAll() ([]*model.Form, error) {
+func (r *formRepository) GetAll(filter *security.RowFilter) ([]*model.Form, error) {
 	var forms []*model.Form
-	if err := r.db.Find(&forms).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Find(&forms).Error; err != nil {
 		return nil, err
 	}
 	return forms, nil
 }
 
-func (r *formRepository) Paginate(page int, pageSize int) ([]*model.Form, int, error) {
+func (r *formRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.Form, int, error) {
 	var forms []*model.Form
 	var total int64
 
-	r.db.Model(&model.Form{}).Count(&total)
+	withRowFilter(r.db.Model(&model.Form{}), filter).Count(&total)
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Limit(pageSize).Offset(offset).Find(&forms).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Limit(pageSize).Offset(offset).Find(&forms).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return forms, int(total), nil
 }
 
+func (r *formRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db, &model.Form{}, id, filter)
+}
+
+func (r *formRepository) UnknownColumns(pathAPI string, columns []string) ([]string, error) {
+	return unknownColumns(r.db, pathAPI, columns)
+}
+
 func (r *formRepository) Delete(form *model.Form) error {
 	return r.db.Delete(form).Error
 }
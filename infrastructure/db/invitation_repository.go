@@ -0,0 +1,110 @@
+package db
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"gorm.io/gorm"
+)
+
+// invitationRowColumns columnas de los usuarios que las invitaciones también
+// tienen con el mismo significado
+var invitationRowColumns = []string{"email", "username", "full_name", "level_id"}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) repository.InvitationRepository {
+	return &invitationRepository{db}
+}
+
+func (r *invitationRepository) Create(invitation *model.Invitation, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.Invitation{}, filter.WithColumns(invitationRowColumns...), func(tx *gorm.DB) (uint, error) {
+		err := tx.Create(invitation).Error
+		return invitation.ID, err
+	})
+}
+
+func (r *invitationRepository) CreateBatch(invitations []*model.Invitation, filter *security.RowFilter) error {
+	filter = filter.WithColumns(invitationRowColumns...)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, invitation := range invitations {
+			if err := tx.Create(invitation).Error; err != nil {
+				return err
+			}
+			matches, err := matchesRowFilter(tx, &model.Invitation{}, invitation.ID, filter)
+			if err != nil {
+				return err
+			}
+			if !matches {
+				return security.ErrRowAccessDenied
+			}
+		}
+		return nil
+	})
+}
+
+func (r *invitationRepository) GetByID(id uint) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.First(&invitation, id).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db, &model.Invitation{}, id, filter.WithColumns(invitationRowColumns...))
+}
+
+func (r *invitationRepository) GetByUser(userID uint, email string) ([]*model.Invitation, error) {
+	var invitations []*model.Invitation
+	err := r.db.Where("user_id = ? OR email = ?", userID, email).Order("id").Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *invitationRepository) GetPending(filter *security.RowFilter) ([]*model.Invitation, error) {
+	var invitations []*model.Invitation
+	err := withRowFilter(r.db.Where("accepted_at IS NULL AND revoked_at IS NULL"), filter.WithColumns(invitationRowColumns...)).
+		Order("id").Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *invitationRepository) GetPendingByEmail(email string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := r.db.Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", email).First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) UpdateToken(id uint, tokenHash string, sentAt time.Time, expiresAt time.Time) error {
+	return r.db.Model(&model.Invitation{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"token_hash": tokenHash,
+		"sent_at":    sentAt,
+		"expires_at": expiresAt,
+	}).Error
+}
+
+func (r *invitationRepository) Accept(id uint, userID uint, acceptedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		UpdateColumns(map[string]interface{}{"accepted_at": acceptedAt, "user_id": userID})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *invitationRepository) Revoke(id uint, revokedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 )
 
@@ -14,21 +15,36 @@ func NewLevelPrivilegesRepository(db *gorm.DB) repository.LevelPrivilegesReposit
 	return &levelPrivilegesRepository{db}
 }
 
-func (r *levelPrivilegesRepository) CreateOrUpdate(levelPrivileges *model.LevelPrivileges) error {
-	if levelPrivileges.ID != 0 {
-		return r.db.Save(levelPrivileges).Error
+func (r *levelPrivilegesRepository) CreateOrUpdate(levelPrivileges *model.LevelPrivileges, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.LevelPrivileges{}, filter, func(tx *gorm.DB) (uint, error) {
+		if levelPrivileges.ID != 0 {
+			return levelPrivileges.ID, tx.Save(levelPrivileges).Error
+		}
+		err := tx.Create(levelPrivileges).Error
+		return levelPrivileges.ID, err
+	})
+}
+
+func (r *levelPrivilegesRepository) GetByID(id uint) (*model.LevelPrivileges, error) {
+	var levelPrivileges model.LevelPrivileges
+	if err := r.db.First(&levelPrivileges, id).Error; err != nil {
+		return nil, err
 	}
-	return r.db.Create(levelPrivileges).Error
+	return &levelPrivileges, nil
 }
 
-func (r *levelPrivilegesRepository) GetAll() ([]*model.LevelPrivileges, error) {
+func (r *levelPrivilegesRepository) GetAll(filter *security.RowFilter) ([]*model.LevelPrivileges, error) {
 	var levelPrivileges []*model.LevelPrivileges
-	if err := r.db.Find(&levelPrivileges).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Find(&levelPrivileges).Error; err != nil {
 		return nil, err
 	}
 	return levelPrivileges, nil
 }
 
+func (r *levelPrivilegesRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db, &model.LevelPrivileges{}, id, filter)
+}
+
 func (r *levelPrivilegesRepository) Delete(levelPrivileges *model.LevelPrivileges) error {
 	return r.db.Delete(levelPrivileges).Error
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 )
 
@@ -14,11 +15,15 @@ func NewLevelRepository(db *gorm.DB) repository.LevelRepository {
 	return &levelRepository{db}
 }
 
-func (r *levelRepository) CreateOrUpdate(level *model.Level) error {
-	if level.ID != 0 {
-		return r.db.Save(level).Error
-	}
-	return r.db.Create(level).Error
+func (r *levelRepository) CreateOrUpdate(level *model.Level, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.Level{}, filter, func(tx *gorm.DB) (uint, error) {
+		// La versión de los privilegios solo cambia con BumpPermissionVersion
+		if level.ID != 0 {
+			return level.ID, tx.Omit("permission_version").Save(level).Error
+		}
+		err := tx.Create(level).Error
+		return level.ID, err
+	})
 }
 
 func (r *levelRepository) GetByID(id uint) (*model.Level, error) {
@@ -27,28 +32,109 @@ func (r *levelRepository) GetByID(id uint) (*model.Level, error) {
 	return &level, err
 }
 
-func (r *levelRepository) GetAll() ([]*model.Level, error) {
+func (r *levelRepository) GetAll(filter *security.RowFilter) ([]*model.Level, error) {
 	var levels []*model.Level
-	if err := r.db.Find(&levels).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Find(&levels).Error; err != nil {
 		return nil, err
 	}
 	return levels, nil
 }
 
-func (r *levelRepository) Paginate(page int, pageSize int) ([]*model.Level, int, error) {
+func (r *levelRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.Level, int, error) {
 	var levels []*model.Level
 	var total int64
 
-	r.db.Model(&model.Level{}).Count(&total)
+	withRowFilter(r.db.Model(&model.Level{}), filter).Count(&total)
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Preload("LevelPrivileges.Form").Limit(pageSize).Offset(offset).Find(&levels).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Preload("LevelPrivileges.Form").Limit(pageSize).Offset(offset).Find(&levels).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return levels, int(total), nil
 }
 
+func (r *levelRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db, &model.Level{}, id, filter)
+}
+
+// Delete borra el nivel, lo quita de los usuarios que lo tenían como nivel
+// adicional y deja sin padre a los niveles que heredaban de él, cuyos
+// privilegios cambian de versión
 func (r *levelRepository) Delete(level *model.Level) error {
-	return r.db.Delete(level).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		descendants, err := withDescendants(tx, level.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(level).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("user_levels").Where("level_id = ?", level.ID).Delete(nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Level{}).Where("parent_id = ?", level.ID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		return bumpPermissionVersion(tx, descendants[1:])
+	})
+}
+
+func (r *levelRepository) GetPermissionVersion(id uint) (uint, error) {
+	var level model.Level
+	if err := r.db.Select("id", "permission_version").First(&level, id).Error; err != nil {
+		return 0, err
+	}
+	return level.PermissionVersion, nil
+}
+
+// BumpPermissionVersion también cambia la versión de los niveles que heredan
+// de los indicados
+func (r *levelRepository) BumpPermissionVersion(ids ...uint) error {
+	levelIDs, err := withDescendants(r.db, ids...)
+	if err != nil {
+		return err
+	}
+	return bumpPermissionVersion(r.db, levelIDs)
+}
+
+func (r *levelRepository) BumpPermissionVersionByForm(formID uint) error {
+	var levelIDs []uint
+	if err := r.db.Unscoped().Model(&model.LevelPrivileges{}).Where("form_id = ?", formID).Distinct().Pluck("level_id", &levelIDs).Error; err != nil {
+		return err
+	}
+	return r.BumpPermissionVersion(levelIDs...)
+}
+
+func bumpPermissionVersion(db *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Model(&model.Level{}).Where("id IN ?", ids).
+		UpdateColumn("permission_version", gorm.Expr("permission_version + 1")).Error
+}
+
+// withDescendants devuelve los niveles indicados seguidos de todos los que
+// heredan de ellos, sin repetir aunque la herencia tenga un ciclo
+func withDescendants(db *gorm.DB, ids ...uint) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var levelIDs []uint
+	for pending := ids; len(pending) > 0; {
+		var parents []uint
+		for _, id := range pending {
+			if !seen[id] {
+				seen[id] = true
+				levelIDs = append(levelIDs, id)
+				parents = append(parents, id)
+			}
+		}
+		if len(parents) == 0 {
+			break
+		}
+		pending = nil
+		if err := db.Model(&model.Level{}).Where("parent_id IN ?", parents).Pluck("id", &pending).Error; err != nil {
+			return nil, err
+		}
+	}
+	return levelIDs, nil
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 )
 
@@ -14,35 +15,42 @@ func NewMenuTreeRepository(db *gorm.DB) repository.MenuTreeRepository {
 	return &menuTreeRepository{db}
 }
 
-func (r *menuTreeRepository) CreateOrUpdate(menuTree *model.MenuTree) error {
-	if menuTree.ID != 0 {
-		return r.db.Save(menuTree).Error
-	}
-	return r.db.Create(menuTree).Error
+func (r *menuTreeRepository) CreateOrUpdate(menuTree *model.MenuTree, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.MenuTree{}, filter, func(tx *gorm.DB) (uint, error) {
+		if menuTree.ID != 0 {
+			return menuTree.ID, tx.Save(menuTree).Error
+		}
+		err := tx.Create(menuTree).Error
+		return menuTree.ID, err
+	})
 }
 
-func (r *menuTreeRepository) GetAll() ([]*model.MenuTree, error) {
+func (r *menuTreeRepository) GetAll(filter *security.RowFilter) ([]*model.MenuTree, error) {
 	var menuTrees []*model.MenuTree
-	if err := r.db.Find(&menuTrees).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Find(&menuTrees).Error; err != nil {
 		return nil, err
 	}
 	return menuTrees, nil
 }
 
-func (r *menuTreeRepository) Paginate(page int, pageSize int) ([]*model.MenuTree, int, error) {
+func (r *menuTreeRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.MenuTree, int, error) {
 	var menuTrees []*model.MenuTree
 	var total int64
 
-	r.db.Model(&model.MenuTree{}).Count(&total)
+	withRowFilter(r.db.Model(&model.MenuTree{}), filter).Count(&total)
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Limit(pageSize).Offset(offset).Find(&menuTrees).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Limit(pageSize).Offset(offset).Find(&menuTrees).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return menuTrees, int(total), nil
 }
 
+func (r *menuTreeRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db, &model.MenuTree{}, id, filter)
+}
+
 func (r *menuTreeRepository) Delete(menuTree *model.MenuTree) error {
 	return r.db.Delete(menuTree).Error
 }
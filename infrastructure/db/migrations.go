@@ -2,8 +2,10 @@ package db
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 	"log"
+	"time"
 )
 
 func Migrate(db *gorm.DB) {
@@ -13,8 +15,124 @@ func Migrate(db *gorm.DB) {
 		&model.MenuTree{},
 		&model.Form{},
 		&model.LevelPrivileges{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.UserTokenRevocation{},
+		&model.RecoveryCode{},
+		&model.APIKey{},
+		&model.OIDCState{},
+		&model.Session{},
+		&model.PasswordHistory{},
+		&model.Invitation{},
+		&model.AuditEntry{},
+		&model.SchemaMigration{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	if err := MigrateLevelPrivilegeActions(db); err != nil {
+		log.Fatalf("Failed to migrate level privilege actions: %v", err)
+	}
+	if err := MigrateFormConditions(db); err != nil {
+		log.Fatalf("Failed to migrate form conditions: %v", err)
+	}
+	if err := MigrateLevelPrivilegeForms(db); err != nil {
+		log.Fatalf("Failed to migrate level privilege forms: %v", err)
+	}
+}
+
+// MigrateLevelPrivilegeActions traduce los privilegios con escritura anteriores
+// a las acciones de crear, modificar y eliminar. Solo toca los privilegios que
+// todavía no tienen ninguna de las tres, por lo que se puede ejecutar en cada
+// arranque; los niveles afectados cambian de versión para que los tokens
+// emitidos con los permisos anteriores dejen de estar al día.
+func MigrateLevelPrivilegeActions(db *gorm.DB) error {
+	legacy := map[string]interface{}{"write": true, "create": false, "update": false, "delete": false}
+	var levelIDs []uint
+	if err := db.Unscoped().Model(&model.LevelPrivileges{}).Where(legacy).Distinct().Pluck("level_id", &levelIDs).Error; err != nil {
+		return err
+	}
+	if len(levelIDs) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.LevelPrivileges{}).Where(legacy).
+			Updates(map[string]interface{}{"create": true, "update": true, "delete": true}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Level{}).Where("id IN ?", levelIDs).
+			UpdateColumn("permission_version", gorm.Expr("permission_version + 1")).Error
+	})
+}
+
+// MigrateFormConditions vacía las condiciones de los formularios que no son
+// condiciones de acceso a filas válidas. Antes no se usaban y podían tener
+// cualquier texto, que ahora denegaría el acceso; vaciarlas mantiene el acceso
+// que tenían. Los formularios guardados por el API ya están validados, así que
+// se puede ejecutar en cada arranque.
+func MigrateFormConditions(db *gorm.DB) error {
+	var forms []model.Form
+	if err := db.Unscoped().Select("id", "condition").Where("`condition` <> ''").Find(&forms).Error; err != nil {
+		return err
+	}
+	for _, form := range forms {
+		if _, err := security.ParseRowPolicy(form.Condition); err == nil {
+			continue
+		}
+		log.Printf("Clearing invalid condition %q of form %d", form.Condition, form.ID)
+		if err := db.Unscoped().Model(&model.Form{}).Where("id = ?", form.ID).UpdateColumn("condition", "").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// levelPrivilegeKeys claves de permiso de las rutas de privilegios de niveles
+const levelPrivilegeKeys = "level-privilege|level-privileges"
+
+// MigrateLevelPrivilegeForms añade las claves de los privilegios de niveles a
+// los formularios que dan acceso a los niveles. Las rutas de privilegios
+// declaran su propio recurso y sin esto nadie podría acceder a ellas. Se
+// ejecuta una sola vez, para no volver a añadir las claves que un
+// administrador haya quitado después; los niveles con privilegios sobre los
+// formularios cambiados cambian de versión para que los tokens reciban el
+// permiso nuevo.
+func MigrateLevelPrivilegeForms(db *gorm.DB) error {
+	return runOnce(db, "level-privilege-forms", func(tx *gorm.DB) error {
+		var forms []model.Form
+		if err := tx.Unscoped().Select("id", "path_api").Find(&forms).Error; err != nil {
+			return err
+		}
+		for _, form := range forms {
+			if !security.PathAPIGrants(form.PathAPI, "level") || security.PathAPIGrants(form.PathAPI, "level-privilege") {
+				continue
+			}
+			if err := tx.Unscoped().Model(&model.Form{}).Where("id = ?", form.ID).
+				UpdateColumn("path_api", form.PathAPI+"|"+levelPrivilegeKeys).Error; err != nil {
+				return err
+			}
+			if err := (&levelRepository{db: tx}).BumpPermissionVersionByForm(form.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runOnce ejecuta la migración y la registra en la misma transacción; si ya
+// estaba registrada no hace nada
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var applied int64
+		if err := tx.Model(&model.SchemaMigration{}).Where("name = ?", name).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			return nil
+		}
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		return tx.Create(&model.SchemaMigration{Name: name, AppliedAt: time.Now()}).Error
+	})
 }
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+)
+
+type oidcStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository(db *gorm.DB) repository.OIDCStateRepository {
+	return &oidcStateRepository{db}
+}
+
+func (r *oidcStateRepository) Create(state *model.OIDCState) error {
+	return r.db.Create(state).Error
+}
+
+func (r *oidcStateRepository) Consume(stateHash string) (*model.OIDCState, error) {
+	var state model.OIDCState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.OIDCState{}, state.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *oidcStateRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&model.OIDCState{}).Error
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+)
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) repository.PasswordHistoryRepository {
+	return &passwordHistoryRepository{db}
+}
+
+func (r *passwordHistoryRepository) Create(entry *model.PasswordHistory) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *passwordHistoryRepository) GetRecent(userID uint, limit int) ([]*model.PasswordHistory, error) {
+	var entries []*model.PasswordHistory
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *passwordHistoryRepository) Prune(userID uint, keep int) error {
+	var kept []uint
+	err := r.db.Model(&model.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").Limit(keep).Pluck("id", &kept).Error
+	if err != nil {
+		return err
+	}
+
+	query := r.db.Where("user_id = ?", userID)
+	if len(kept) > 0 {
+		query = query.Where("id NOT IN ?", kept)
+	}
+	return query.Delete(&model.PasswordHistory{}).Error
+}
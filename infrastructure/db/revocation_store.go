@@ -0,0 +1,58 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type revocationStore struct {
+	db *gorm.DB
+}
+
+func NewRevocationStore(db *gorm.DB) repository.RevocationStore {
+	return &revocationStore{db}
+}
+
+func (s *revocationStore) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	// Los tokens que ya han expirado por sí mismos no necesitan seguir en la lista
+	if err := s.db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&model.RevokedToken{}).Error; err != nil {
+		return err
+	}
+
+	revoked := &model.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(revoked).Error
+}
+
+func (s *revocationStore) RevokeUser(userID uint, revokedBefore time.Time) error {
+	revocation := &model.UserTokenRevocation{UserID: userID, RevokedBefore: revokedBefore.Truncate(time.Second)}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+	}).Create(revocation).Error
+}
+
+func (s *revocationStore) IsRevoked(jti string, userID uint, issuedAt time.Time) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	var revocation model.UserTokenRevocation
+	err := s.db.First(&revocation, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt.Before(revocation.RevokedBefore), nil
+}
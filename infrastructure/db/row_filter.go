@@ -0,0 +1,97 @@
+package db
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// rowFilterModels modelos cuya tabla filtran las rutas con condiciones de
+// acceso a filas, por el recurso que declaran las rutas. /impersonate aplica
+// las condiciones al usuario suplantado.
+var rowFilterModels = map[string]interface{}{
+	"user":            &model.User{},
+	"impersonate":     &model.User{},
+	"level":           &model.Level{},
+	"level-privilege": &model.LevelPrivileges{},
+	"form":            &model.Form{},
+	"expanses-menu":   &model.MenuTree{},
+}
+
+// withRowFilter añade a la consulta las condiciones de acceso a filas; sin
+// filtro la deja como está
+func withRowFilter(db *gorm.DB, filter *security.RowFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	clause, args := filter.Where(func(column string) string {
+		return db.Statement.Quote(column)
+	})
+	return db.Where(clause, args...)
+}
+
+// matchesRowFilter indica si el registro id de la tabla del modelo existe y
+// cumple las condiciones de acceso a filas
+func matchesRowFilter(db *gorm.DB, model interface{}, id uint, filter *security.RowFilter) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	var count int64
+	err := withRowFilter(db.Model(model).Where("id = ?", id), filter).Count(&count).Error
+	return count > 0, err
+}
+
+// saveMatchingRowFilter guarda el registro con save, que devuelve su id, y
+// comprueba en la misma transacción que el registro guardado cumple las
+// condiciones de acceso a filas. Si no las cumple deshace el cambio y devuelve
+// security.ErrRowAccessDenied, así nadie crea ni deja un registro fuera de lo
+// que puede ver.
+func saveMatchingRowFilter(db *gorm.DB, model interface{}, filter *security.RowFilter, save func(tx *gorm.DB) (uint, error)) error {
+	if filter == nil {
+		_, err := save(db)
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		id, err := save(tx)
+		if err != nil {
+			return err
+		}
+		matches, err := matchesRowFilter(tx, model, id, filter)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			return security.ErrRowAccessDenied
+		}
+		return nil
+	})
+}
+
+// unknownColumns devuelve las columnas que no existen en la tabla de alguno de
+// los recursos del PathAPI. Los recursos sin condiciones de acceso a filas no
+// tienen tabla y no se comprueban.
+func unknownColumns(db *gorm.DB, pathAPI string, columns []string) ([]string, error) {
+	var tables []*schema.Schema
+	for resource, model := range rowFilterModels {
+		if !security.PathAPIGrants(pathAPI, resource) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		tables = append(tables, stmt.Schema)
+	}
+
+	var unknown []string
+	for _, column := range columns {
+		for _, table := range tables {
+			if _, ok := table.FieldsByDBName[column]; !ok {
+				unknown = append(unknown, column)
+				break
+			}
+		}
+	}
+	return unknown, nil
+}
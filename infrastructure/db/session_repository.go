@@ -0,0 +1,74 @@
+package db
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) repository.SessionRepository {
+	return &sessionRepository{db}
+}
+
+func (r *sessionRepository) Create(session *model.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uint) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByFamily(familyID string) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByUser(userID uint) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) GetActiveByUser(userID uint, seenAfter time.Time) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, seenAfter).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(id uint, seenAt time.Time) error {
+	return r.db.Model(&model.Session{}).Where("id = ?", id).Update("last_seen_at", seenAt).Error
+}
+
+func (r *sessionRepository) Revoke(id uint, userID uint, revokedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *sessionRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	return r.db.Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
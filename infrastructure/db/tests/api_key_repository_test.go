@@ -0,0 +1,50 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRepository_CreateAndRevoke(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewAPIKeyRepository(database)
+
+	key := &model.APIKey{UserID: 1, Name: "sync", Prefix: "ak_abc", KeyHash: "hash-a", Scopes: []string{"user:read"}, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, repo.Create(key))
+	assert.NoError(t, repo.Create(&model.APIKey{UserID: 1, Name: "backup", KeyHash: "hash-b", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	found, err := repo.GetByHash("hash-a")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, []string{"user:read"}, found.Scopes)
+
+	// Solo el propietario puede revocarla y solo una vez
+	revoked, err := repo.Revoke(key.ID, 2, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = repo.Revoke(key.ID, 1, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = repo.Revoke(key.ID, 1, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, repo.RevokeByUser(1, time.Now()))
+	keys, err := repo.GetByUser(1)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.NotNil(t, keys[0].RevokedAt)
+		assert.NotNil(t, keys[1].RevokedAt)
+	}
+}
@@ -0,0 +1,30 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository_GetByUser(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewAuditRepository(database)
+
+	assert.NoError(t, repo.Create(&model.AuditEntry{ActorID: 1, SubjectID: 2, Action: model.AuditUserDataExport}))
+	assert.NoError(t, repo.Create(&model.AuditEntry{ActorID: 2, SubjectID: 3, Action: model.AuditUserErasure}))
+	assert.NoError(t, repo.Create(&model.AuditEntry{ActorID: 1, SubjectID: 3, Action: model.AuditUserDataExport}))
+
+	// Aparecen tanto las acciones del usuario como las que le afectan
+	entries, err := repo.GetByUser(2)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, uint(2), entries[0].SubjectID)
+		assert.Equal(t, uint(2), entries[1].ActorID)
+		assert.False(t, entries[0].CreatedAt.IsZero())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/infrastructure/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -19,7 +20,7 @@ func TestFormRepository_CreateOrUpdate(t *testing.T) {
 		Title: "Test Form",
 	}
 
-	err := repo.CreateOrUpdate(form)
+	err := repo.CreateOrUpdate(form, nil)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, form.ID)
 }
@@ -36,12 +37,12 @@ func TestFormRepository_GetAll(t *testing.T) {
 		Title: "Test Form 2",
 	}
 
-	err := repo.CreateOrUpdate(form1)
+	err := repo.CreateOrUpdate(form1, nil)
 	assert.Nil(t, err)
-	err = repo.CreateOrUpdate(form2)
+	err = repo.CreateOrUpdate(form2, nil)
 	assert.Nil(t, err)
 
-	forms, err := repo.GetAll()
+	forms, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, forms, 2)
 }
@@ -55,11 +56,11 @@ func TestFormRepository_Paginate(t *testing.T) {
 		form := &model.Form{
 			Title: fmt.Sprintf("Test Form %d", i),
 		}
-		err := repo.CreateOrUpdate(form)
+		err := repo.CreateOrUpdate(form, nil)
 		assert.Nil(t, err)
 	}
 
-	forms, total, err := repo.Paginate(1, 10)
+	forms, total, err := repo.Paginate(1, 10, nil)
 	assert.Nil(t, err)
 	assert.Len(t, forms, 10)
 	assert.Equal(t, 25, total)
@@ -74,13 +75,81 @@ func TestFormRepository_Delete(t *testing.T) {
 		Title: "Test Form",
 	}
 
-	err := repo.CreateOrUpdate(form)
+	err := repo.CreateOrUpdate(form, nil)
 	assert.Nil(t, err)
 
 	err = repo.Delete(form)
 	assert.Nil(t, err)
 
-	forms, err := repo.GetAll()
+	forms, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, forms, 0) // Verifica que la base de datos esté vacía
 }
+
+func TestFormRepository_RowFilterQuotesColumns(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewFormRepository(database)
+
+	for i := 1; i <= 3; i++ {
+		assert.Nil(t, repo.CreateOrUpdate(&model.Form{Title: fmt.Sprintf("Form %d", i), Order: i}, nil))
+	}
+
+	// order es una palabra reservada de SQL
+	policy, err := security.ParseRowPolicy("order != 2")
+	assert.Nil(t, err)
+	filter := security.NewRowFilter(nil, policy)
+
+	forms, total, err := repo.Paginate(1, 10, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	if assert.Len(t, forms, 2) {
+		assert.Equal(t, 1, forms[0].Order)
+		assert.Equal(t, 3, forms[1].Order)
+	}
+	matches, err := repo.MatchesFilter(forms[0].ID+1, filter)
+	assert.Nil(t, err)
+	assert.False(t, matches)
+}
+
+func TestMigrateFormConditions(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+
+	legacy := &model.Form{Title: "Legacy", Condition: "active"}
+	valid := &model.Form{Title: "Valid", Condition: "owner_id == user.id"}
+	empty := &model.Form{Title: "Empty"}
+	assert.Nil(t, database.Create([]*model.Form{legacy, valid, empty}).Error)
+
+	assert.Nil(t, db.MigrateFormConditions(database))
+
+	var migrated, untouched model.Form
+	database.First(&migrated, legacy.ID)
+	database.First(&untouched, valid.ID)
+	assert.Empty(t, migrated.Condition)
+	assert.Equal(t, "owner_id == user.id", untouched.Condition)
+}
+
+func TestFormRepository_UnknownColumns(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	repo := db.NewFormRepository(database)
+
+	cases := []struct {
+		pathAPI string
+		columns []string
+		unknown []string
+	}{
+		{"user|users", []string{"id", "level_id", "status"}, nil},
+		{"user|users", []string{"owner_id", "id", "LevelID"}, []string{"owner_id", "LevelID"}},
+		{"level|levels", []string{"require_mfa", "status"}, []string{"status"}},
+		{"user|level", []string{"id", "status"}, []string{"status"}},
+		{"expanses-menu", []string{"title"}, nil},
+		// Los recursos sin condiciones de acceso a filas no tienen tabla
+		{"smtp-config", []string{"owner_id"}, nil},
+	}
+	for _, tc := range cases {
+		unknown, err := repo.UnknownColumns(tc.pathAPI, tc.columns)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.unknown, unknown, tc.pathAPI, tc.columns)
+	}
+}
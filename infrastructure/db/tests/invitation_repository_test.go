@@ -0,0 +1,100 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationRepository_PendingLifecycle(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewInvitationRepository(database)
+
+	now := time.Now()
+	first := &model.Invitation{Email: "first@example.com", Username: "first", FullName: "First", LevelID: 1}
+	second := &model.Invitation{Email: "second@example.com", Username: "second", FullName: "Second", LevelID: 1}
+	assert.NoError(t, repo.Create(first, nil))
+	assert.NoError(t, repo.Create(second, nil))
+
+	assert.NoError(t, repo.UpdateToken(first.ID, "hash", now, now.Add(time.Hour)))
+	found, err := repo.GetPendingByEmail("first@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", found.TokenHash)
+	assert.WithinDuration(t, now.Add(time.Hour), found.ExpiresAt, time.Second)
+
+	accepted, err := repo.Accept(first.ID, 7, now)
+	assert.NoError(t, err)
+	assert.True(t, accepted)
+	accepted, err = repo.Accept(first.ID, 8, now)
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+
+	revoked, err := repo.Revoke(first.ID, now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.Revoke(second.ID, now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	pending, err := repo.GetPending(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	_, err = repo.GetPendingByEmail("first@example.com")
+	assert.Error(t, err)
+
+	stored, err := repo.GetByID(first.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.UserID) {
+		assert.Equal(t, uint(7), *stored.UserID)
+	}
+}
+
+func TestInvitationRepository_RowFilter(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewInvitationRepository(database)
+
+	// Solo se gestionan los usuarios, y las invitaciones, del nivel propio
+	policy, _ := security.ParseRowPolicy("level_id == user.level_id")
+	filter := security.NewRowFilter(security.RowSubject{security.RowSubjectLevelID: uint(1)}, policy)
+
+	own := &model.Invitation{Email: "own@example.com", Username: "own", FullName: "Own", LevelID: 1}
+	other := &model.Invitation{Email: "other@example.com", Username: "other", FullName: "Other", LevelID: 2}
+	assert.NoError(t, repo.Create(own, filter))
+	assert.ErrorIs(t, repo.Create(other, filter), security.ErrRowAccessDenied)
+	_, err := repo.GetPendingByEmail("other@example.com")
+	assert.Error(t, err)
+
+	batch := []*model.Invitation{
+		{Email: "ana@example.com", Username: "ana", FullName: "Ana", LevelID: 1},
+		{Email: "luis@example.com", Username: "luis", FullName: "Luis", LevelID: 2},
+	}
+	assert.ErrorIs(t, repo.CreateBatch(batch, filter), security.ErrRowAccessDenied)
+	_, err = repo.GetPendingByEmail("ana@example.com")
+	assert.Error(t, err)
+
+	other.ID = 0
+	assert.NoError(t, repo.Create(other, nil))
+	pending, err := repo.GetPending(filter)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, own.ID, pending[0].ID)
+	}
+	matches, err := repo.MatchesFilter(other.ID, filter)
+	assert.NoError(t, err)
+	assert.False(t, matches)
+
+	// Las condiciones sobre columnas que las invitaciones no tienen no se cumplen
+	active, _ := security.ParseRowPolicy("status == 'active'")
+	pending, err = repo.GetPending(security.NewRowFilter(nil, active))
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -19,7 +19,7 @@ func TestLevelPrivilegesRepository_CreateOrUpdate(t *testing.T) {
 		Write: true,
 	}
 
-	err := repo.CreateOrUpdate(levelPrivileges)
+	err := repo.CreateOrUpdate(levelPrivileges, nil)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, levelPrivileges.ID)
 }
@@ -38,12 +38,12 @@ func TestLevelPrivilegesRepository_GetAll(t *testing.T) {
 		Write: false,
 	}
 
-	err := repo.CreateOrUpdate(levelPrivileges1)
+	err := repo.CreateOrUpdate(levelPrivileges1, nil)
 	assert.Nil(t, err)
-	err = repo.CreateOrUpdate(levelPrivileges2)
+	err = repo.CreateOrUpdate(levelPrivileges2, nil)
 	assert.Nil(t, err)
 
-	levelPrivileges, err := repo.GetAll()
+	levelPrivileges, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, levelPrivileges, 2)
 }
@@ -58,13 +58,80 @@ func TestLevelPrivilegesRepository_Delete(t *testing.T) {
 		Write: true,
 	}
 
-	err := repo.CreateOrUpdate(levelPrivileges)
+	err := repo.CreateOrUpdate(levelPrivileges, nil)
 	assert.Nil(t, err)
 
 	err = repo.Delete(levelPrivileges)
 	assert.Nil(t, err)
 
-	levelPrivilegesList, err := repo.GetAll()
+	levelPrivilegesList, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, levelPrivilegesList, 0)
 }
+
+func TestMigrateLevelPrivilegeActions(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+
+	writer := &model.Level{Level: "Writer", Description: "Writer"}
+	reader := &model.Level{Level: "Reader", Description: "Reader"}
+	assert.NoError(t, database.Create(writer).Error)
+	assert.NoError(t, database.Create(reader).Error)
+	legacy := &model.LevelPrivileges{LevelID: writer.ID, FormID: 1, Read: true, Write: true}
+	granular := &model.LevelPrivileges{LevelID: writer.ID, FormID: 2, Read: true, Update: true}
+	readOnly := &model.LevelPrivileges{LevelID: reader.ID, FormID: 1, Read: true}
+	assert.NoError(t, database.Create([]*model.LevelPrivileges{legacy, granular, readOnly}).Error)
+
+	assert.NoError(t, db.MigrateLevelPrivilegeActions(database))
+
+	var migrated, untouched, stillReadOnly model.LevelPrivileges
+	database.First(&migrated, legacy.ID)
+	database.First(&untouched, granular.ID)
+	database.First(&stillReadOnly, readOnly.ID)
+	assert.Equal(t, "rcud", migrated.Actions())
+	assert.Equal(t, "ru", untouched.Actions())
+	assert.Equal(t, "r", stillReadOnly.Actions())
+
+	levelRepo := db.NewLevelRepository(database)
+	version, _ := levelRepo.GetPermissionVersion(writer.ID)
+	assert.Equal(t, uint(2), version)
+	version, _ = levelRepo.GetPermissionVersion(reader.ID)
+	assert.Equal(t, uint(1), version)
+
+	// Una segunda ejecución no cambia nada
+	assert.NoError(t, db.MigrateLevelPrivilegeActions(database))
+	version, _ = levelRepo.GetPermissionVersion(writer.ID)
+	assert.Equal(t, uint(2), version)
+}
+
+func TestMigrateLevelPrivilegeForms(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+
+	identities := &model.Form{Title: "Identidades", PathAPI: "level|levels"}
+	users := &model.Form{Title: "Usuarios", PathAPI: "user|users"}
+	assert.NoError(t, database.Create([]*model.Form{identities, users}).Error)
+	admin := &model.Level{Level: "Admin", Description: "Admin"}
+	assert.NoError(t, database.Create(admin).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: admin.ID, FormID: identities.ID, Read: true}).Error)
+
+	assert.NoError(t, db.MigrateLevelPrivilegeForms(database))
+
+	var migrated, untouched model.Form
+	database.First(&migrated, identities.ID)
+	database.First(&untouched, users.ID)
+	assert.Equal(t, "level|levels|level-privilege|level-privileges", migrated.PathAPI)
+	assert.Equal(t, "user|users", untouched.PathAPI)
+	levelRepo := db.NewLevelRepository(database)
+	version, _ := levelRepo.GetPermissionVersion(admin.ID)
+	assert.Equal(t, uint(2), version)
+
+	// Una segunda ejecución no vuelve a añadir las claves que un
+	// administrador ha quitado después
+	assert.NoError(t, database.Model(&model.Form{}).Where("id = ?", identities.ID).UpdateColumn("path_api", "level|levels").Error)
+	assert.NoError(t, db.MigrateLevelPrivilegeForms(database))
+	database.First(&migrated, identities.ID)
+	assert.Equal(t, "level|levels", migrated.PathAPI)
+	version, _ = levelRepo.GetPermissionVersion(admin.ID)
+	assert.Equal(t, uint(2), version)
+}
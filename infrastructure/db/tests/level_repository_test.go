@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/infrastructure/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -20,11 +21,34 @@ func TestLevelRepository_CreateOrUpdate(t *testing.T) {
 		Description: "Test Description",
 	}
 
-	err := repo.CreateOrUpdate(level)
+	err := repo.CreateOrUpdate(level, nil)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, level.ID)
 }
 
+func TestLevelRepository_CreateOrUpdateChecksRowFilter(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewLevelRepository(database)
+
+	policy, err := security.ParseRowPolicy("require_mfa == true")
+	assert.Nil(t, err)
+	filter := security.NewRowFilter(security.RowSubject{}, policy)
+
+	assert.ErrorIs(t, repo.CreateOrUpdate(&model.Level{Level: "Open", Description: "Open"}, filter), security.ErrRowAccessDenied)
+	var count int64
+	database.Model(&model.Level{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	level := &model.Level{Level: "Secure", Description: "Secure", RequireMFA: true}
+	assert.Nil(t, repo.CreateOrUpdate(level, filter))
+	level.RequireMFA = false
+	assert.ErrorIs(t, repo.CreateOrUpdate(level, filter), security.ErrRowAccessDenied)
+	stored, err := repo.GetByID(level.ID)
+	assert.Nil(t, err)
+	assert.True(t, stored.RequireMFA)
+}
+
 func TestLevelRepository_GetByID(t *testing.T) {
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
@@ -35,7 +59,7 @@ func TestLevelRepository_GetByID(t *testing.T) {
 		Description: "Test Description",
 	}
 
-	err := repo.CreateOrUpdate(level)
+	err := repo.CreateOrUpdate(level, nil)
 	assert.Nil(t, err)
 
 	foundLevel, err := repo.GetByID(level.ID)
@@ -59,12 +83,12 @@ func TestLevelRepository_GetAll(t *testing.T) {
 		Description: "Test Description 2",
 	}
 
-	err := repo.CreateOrUpdate(level1)
+	err := repo.CreateOrUpdate(level1, nil)
 	assert.Nil(t, err)
-	err = repo.CreateOrUpdate(level2)
+	err = repo.CreateOrUpdate(level2, nil)
 	assert.Nil(t, err)
 
-	levels, err := repo.GetAll()
+	levels, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, levels, 2)
 }
@@ -79,11 +103,11 @@ func TestLevelRepository_Paginate(t *testing.T) {
 			Level:       fmt.Sprintf("Test Level %d", i),
 			Description: fmt.Sprintf("Test Description %d", i),
 		}
-		err := repo.CreateOrUpdate(level)
+		err := repo.CreateOrUpdate(level, nil)
 		assert.Nil(t, err)
 	}
 
-	levels, total, err := repo.Paginate(1, 10)
+	levels, total, err := repo.Paginate(1, 10, nil)
 	assert.Nil(t, err)
 	assert.Len(t, levels, 10)
 	assert.Equal(t, 25, total)
@@ -99,7 +123,7 @@ func TestLevelRepository_Delete(t *testing.T) {
 		Description: "Test Description",
 	}
 
-	err := repo.CreateOrUpdate(level)
+	err := repo.CreateOrUpdate(level, nil)
 	assert.Nil(t, err)
 
 	err = repo.Delete(level)
@@ -108,3 +132,78 @@ func TestLevelRepository_Delete(t *testing.T) {
 	_, err = repo.GetByID(level.ID)
 	assert.NotNil(t, err)
 }
+
+func TestLevelRepository_PermissionVersion(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewLevelRepository(database)
+
+	level := &model.Level{Level: "Version Level", Description: "Version Description"}
+	other := &model.Level{Level: "Other Level", Description: "Other Description"}
+	assert.Nil(t, repo.CreateOrUpdate(level, nil))
+	assert.Nil(t, repo.CreateOrUpdate(other, nil))
+
+	version, err := repo.GetPermissionVersion(level.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), version)
+
+	assert.Nil(t, repo.BumpPermissionVersion(level.ID))
+	version, _ = repo.GetPermissionVersion(level.ID)
+	assert.Equal(t, uint(2), version)
+
+	// Guardar el nivel no cambia la versión
+	level.PermissionVersion = 0
+	assert.Nil(t, repo.CreateOrUpdate(level, nil))
+	version, _ = repo.GetPermissionVersion(level.ID)
+	assert.Equal(t, uint(2), version)
+
+	// Solo cambian los niveles con privilegios sobre el formulario
+	form := &model.Form{Title: "Version Form", PathAPI: "user|users"}
+	assert.Nil(t, database.Create(form).Error)
+	assert.Nil(t, database.Create(&model.LevelPrivileges{LevelID: level.ID, FormID: form.ID, Read: true}).Error)
+
+	assert.Nil(t, repo.BumpPermissionVersionByForm(form.ID))
+	version, _ = repo.GetPermissionVersion(level.ID)
+	assert.Equal(t, uint(3), version)
+	version, _ = repo.GetPermissionVersion(other.ID)
+	assert.Equal(t, uint(1), version)
+}
+
+func TestLevelRepository_Inheritance(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewLevelRepository(database)
+	userRepo := db.NewUserRepository(database)
+
+	root := &model.Level{Level: "Root", Description: "Root"}
+	assert.Nil(t, repo.CreateOrUpdate(root, nil))
+	child := &model.Level{Level: "Child", Description: "Child", ParentID: &root.ID}
+	assert.Nil(t, repo.CreateOrUpdate(child, nil))
+	grandChild := &model.Level{Level: "Grandchild", Description: "Grandchild", ParentID: &child.ID}
+	assert.Nil(t, repo.CreateOrUpdate(grandChild, nil))
+	other := &model.Level{Level: "Other", Description: "Other"}
+	assert.Nil(t, repo.CreateOrUpdate(other, nil))
+
+	// La versión de los niveles que heredan sigue a la de sus ascendientes
+	assert.Nil(t, repo.BumpPermissionVersion(root.ID))
+	for _, level := range []*model.Level{root, child, grandChild} {
+		version, _ := repo.GetPermissionVersion(level.ID)
+		assert.Equal(t, uint(2), version, level.Level)
+	}
+	version, _ := repo.GetPermissionVersion(other.ID)
+	assert.Equal(t, uint(1), version)
+
+	// Al borrar un nivel sus hijos se quedan sin padre y los usuarios pierden
+	// el nivel adicional
+	user := &model.User{Username: "multi", Email: "multi@example.com", Password: "password", LevelID: other.ID}
+	assert.Nil(t, userRepo.Create(user, nil))
+	assert.Nil(t, userRepo.SetLevels(user.ID, []uint{child.ID}))
+
+	assert.Nil(t, repo.Delete(child))
+	foundGrandChild, err := repo.GetByID(grandChild.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundGrandChild.ParentID)
+	assert.Equal(t, uint(3), foundGrandChild.PermissionVersion)
+	foundUser, _ := userRepo.GetByID(user.ID)
+	assert.Empty(t, foundUser.Levels)
+}
@@ -19,7 +19,7 @@ func TestMenuTreeRepository_CreateOrUpdate(t *testing.T) {
 		Title: "Test Menu",
 	}
 
-	err := repo.CreateOrUpdate(menu)
+	err := repo.CreateOrUpdate(menu, nil)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, menu.ID)
 }
@@ -36,12 +36,12 @@ func TestMenuTreeRepository_GetAll(t *testing.T) {
 		Title: "Test Menu 2",
 	}
 
-	err := repo.CreateOrUpdate(menu1)
+	err := repo.CreateOrUpdate(menu1, nil)
 	assert.Nil(t, err)
-	err = repo.CreateOrUpdate(menu2)
+	err = repo.CreateOrUpdate(menu2, nil)
 	assert.Nil(t, err)
 
-	menus, err := repo.GetAll()
+	menus, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, menus, 2)
 }
@@ -55,11 +55,11 @@ func TestMenuTreeRepository_Paginate(t *testing.T) {
 		menu := &model.MenuTree{
 			Title: fmt.Sprintf("Test Menu %d", i),
 		}
-		err := repo.CreateOrUpdate(menu)
+		err := repo.CreateOrUpdate(menu, nil)
 		assert.Nil(t, err)
 	}
 
-	menus, total, err := repo.Paginate(1, 10)
+	menus, total, err := repo.Paginate(1, 10, nil)
 	assert.Nil(t, err)
 	assert.Len(t, menus, 10)
 	assert.Equal(t, 25, total)
@@ -74,13 +74,13 @@ func TestMenuTreeRepository_Delete(t *testing.T) {
 		Title: "Test Menu",
 	}
 
-	err := repo.CreateOrUpdate(menu)
+	err := repo.CreateOrUpdate(menu, nil)
 	assert.Nil(t, err)
 
 	err = repo.Delete(menu)
 	assert.Nil(t, err)
 
-	menus, err := repo.GetAll()
+	menus, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, menus, 0) // Verifica que la base de datos esté vacía
 }
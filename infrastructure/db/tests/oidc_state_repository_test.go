@@ -0,0 +1,32 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCStateRepository_ConsumeOnce(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewOIDCStateRepository(database)
+
+	assert.NoError(t, repo.Create(&model.OIDCState{StateHash: "hash-a", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}))
+	assert.NoError(t, repo.Create(&model.OIDCState{StateHash: "hash-b", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	state, err := repo.Consume("hash-a")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "verifier", state.CodeVerifier)
+	}
+	_, err = repo.Consume("hash-a")
+	assert.Error(t, err)
+
+	assert.NoError(t, repo.DeleteExpired(time.Now()))
+	_, err = repo.Consume("hash-b")
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHistoryRepository_RecentAndPrune(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewPasswordHistoryRepository(database)
+
+	now := time.Now()
+	for i, hash := range []string{"hash-1", "hash-2", "hash-3"} {
+		entry := &model.PasswordHistory{UserID: 1, PasswordHash: hash, CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		assert.NoError(t, repo.Create(entry))
+	}
+	assert.NoError(t, repo.Create(&model.PasswordHistory{UserID: 2, PasswordHash: "other-user"}))
+
+	recent, err := repo.GetRecent(1, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, recent, 2) {
+		assert.Equal(t, "hash-3", recent[0].PasswordHash)
+		assert.Equal(t, "hash-2", recent[1].PasswordHash)
+	}
+
+	assert.NoError(t, repo.Prune(1, 1))
+	recent, err = repo.GetRecent(1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, recent, 1) {
+		assert.Equal(t, "hash-3", recent[0].PasswordHash)
+	}
+
+	// Las contraseñas de otros usuarios no se tocan
+	others, err := repo.GetRecent(2, 10)
+	assert.NoError(t, err)
+	assert.Len(t, others, 1)
+}
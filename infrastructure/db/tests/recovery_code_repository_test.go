@@ -0,0 +1,55 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodeRepository_ConsumeOnce(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewRecoveryCodeRepository(database)
+
+	assert.NoError(t, repo.ReplaceForUser(1, []string{"hash-a", "hash-b"}))
+
+	consumed, err := repo.Consume(1, "hash-a", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+
+	consumed, err = repo.Consume(1, "hash-a", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+
+	// Los códigos son de cada usuario
+	consumed, err = repo.Consume(2, "hash-b", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+}
+
+func TestRecoveryCodeRepository_ReplaceForUser(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewRecoveryCodeRepository(database)
+
+	assert.NoError(t, repo.ReplaceForUser(1, []string{"old-a", "old-b"}))
+	assert.NoError(t, repo.ReplaceForUser(1, []string{"new-a"}))
+
+	consumed, err := repo.Consume(1, "old-a", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+
+	var count int64
+	database.Model(&model.RecoveryCode{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, repo.DeleteByUser(1))
+	database.Model(&model.RecoveryCode{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,73 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_CreateAndGetByHash(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewRefreshTokenRepository(database)
+
+	token := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.Nil(t, repo.Create(token))
+	assert.NotEqual(t, uint(0), token.ID)
+
+	found, err := repo.GetByHash("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, token.ID, found.ID)
+	assert.Equal(t, "family", found.FamilyID)
+
+	_, err = repo.GetByHash("other")
+	assert.NotNil(t, err)
+}
+
+func TestRefreshTokenRepository_RevokeOnlyOnce(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewRefreshTokenRepository(database)
+
+	token := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.Nil(t, repo.Create(token))
+
+	ok, err := repo.Revoke(token.ID, time.Now(), nil)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = repo.Revoke(token.ID, time.Now(), nil)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestRefreshTokenRepository_RevokeFamilyAndUser(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewRefreshTokenRepository(database)
+
+	tokens := []*model.RefreshToken{
+		{UserID: 1, FamilyID: "a", TokenHash: "hash1", ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 1, FamilyID: "a", TokenHash: "hash2", ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 1, FamilyID: "b", TokenHash: "hash3", ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 2, FamilyID: "c", TokenHash: "hash4", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	for _, token := range tokens {
+		assert.Nil(t, repo.Create(token))
+	}
+
+	assert.Nil(t, repo.RevokeFamily("a", time.Now()))
+
+	var active int64
+	database.Model(&model.RefreshToken{}).Where("revoked_at IS NULL").Count(&active)
+	assert.Equal(t, int64(2), active)
+
+	assert.Nil(t, repo.RevokeByUser(1, time.Now()))
+
+	database.Model(&model.RefreshToken{}).Where("revoked_at IS NULL").Count(&active)
+	assert.Equal(t, int64(1), active)
+}
@@ -0,0 +1,83 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationStore_RevokeToken(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	store := db.NewRevocationStore(database)
+
+	assert.Nil(t, store.Revoke("jti-1", 1, time.Now().Add(time.Hour)))
+	// Revocar dos veces el mismo token no es un error
+	assert.Nil(t, store.Revoke("jti-1", 1, time.Now().Add(time.Hour)))
+
+	revoked, err := store.IsRevoked("jti-1", 1, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked("jti-2", 1, time.Now())
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevocationStore_PurgesExpiredTokens(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	store := db.NewRevocationStore(database)
+
+	assert.Nil(t, store.Revoke("expired", 1, time.Now().Add(-time.Minute)))
+	assert.Nil(t, store.Revoke("active", 1, time.Now().Add(time.Hour)))
+
+	var count int64
+	database.Unscoped().Model(&model.RevokedToken{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRevocationStore_RevokeUser(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	store := db.NewRevocationStore(database)
+
+	cutoff := time.Now()
+	assert.Nil(t, store.RevokeUser(1, cutoff.Add(-time.Hour)))
+	// La segunda llamada actualiza el corte existente
+	assert.Nil(t, store.RevokeUser(1, cutoff))
+
+	revoked, err := store.IsRevoked("jti", 1, cutoff.Add(-time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked("jti", 1, cutoff.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = store.IsRevoked("jti", 2, cutoff.Add(-time.Minute))
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevocationStore_RevokeUserThenIssue(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	store := db.NewRevocationStore(database)
+
+	// Un token emitido justo después del corte tiene el mismo iat en segundos
+	revokedAt := time.Date(2024, 5, 1, 10, 0, 0, 400*int(time.Millisecond), time.UTC)
+	assert.Nil(t, store.RevokeUser(1, revokedAt))
+
+	revoked, err := store.IsRevoked("jti", 1, revokedAt.Add(5*time.Millisecond).Truncate(time.Second))
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = store.IsRevoked("jti", 1, revokedAt.Add(-time.Second).Truncate(time.Second))
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+}
@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_ActiveSessions(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewSessionRepository(database)
+
+	now := time.Now()
+	recent := &model.Session{UserID: 1, FamilyID: "family-a", LastSeenAt: now}
+	stale := &model.Session{UserID: 1, FamilyID: "family-b", LastSeenAt: now.Add(-48 * time.Hour)}
+	assert.NoError(t, repo.Create(recent))
+	assert.NoError(t, repo.Create(stale))
+
+	sessions, err := repo.GetActiveByUser(1, now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, recent.ID, sessions[0].ID)
+	}
+
+	assert.NoError(t, repo.Touch(stale.ID, now))
+	found, err := repo.GetByFamily("family-b")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now, found.LastSeenAt, time.Second)
+
+	revoked, err := repo.Revoke(recent.ID, 2, now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.Revoke(recent.ID, 1, now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, repo.RevokeByUser(1, now))
+	sessions, err = repo.GetActiveByUser(1, now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
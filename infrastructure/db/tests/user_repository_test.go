@@ -3,9 +3,12 @@ package db_test
 import (
 	"fmt"
 	"github.com/drossan/core-api/utils"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/infrastructure/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -21,7 +24,7 @@ func TestUserRepository_Create(t *testing.T) {
 		Password: "password",
 	}
 
-	err := repo.Create(user)
+	err := repo.Create(user, nil)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, user.ID)
 }
@@ -37,7 +40,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 		Password: "password",
 	}
 
-	err := repo.Create(user)
+	err := repo.Create(user, nil)
 	assert.Nil(t, err)
 
 	foundUser, err := repo.GetByID(user.ID)
@@ -58,7 +61,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 		Password: "password",
 	}
 
-	err := repo.Create(user)
+	err := repo.Create(user, nil)
 	assert.Nil(t, err)
 
 	foundUser, err := repo.GetByEmail(user.Email)
@@ -84,12 +87,12 @@ func TestUserRepository_GetAll(t *testing.T) {
 		Password: "password",
 	}
 
-	err := repo.Create(user1)
+	err := repo.Create(user1, nil)
 	assert.Nil(t, err)
-	err = repo.Create(user2)
+	err = repo.Create(user2, nil)
 	assert.Nil(t, err)
 
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Len(t, users, 2)
 }
@@ -105,11 +108,11 @@ func TestUserRepository_Paginate(t *testing.T) {
 			Email:    fmt.Sprintf("test%d@example.com", i),
 			Password: "password",
 		}
-		err := repo.Create(user)
+		err := repo.Create(user, nil)
 		assert.Nil(t, err)
 	}
 
-	users, total, err := repo.Paginate(1, 10)
+	users, total, err := repo.Paginate(1, 10, nil)
 	assert.Nil(t, err)
 	assert.Len(t, users, 10)
 	assert.Equal(t, 25, total)
@@ -126,7 +129,7 @@ func TestUserRepository_Delete(t *testing.T) {
 		Password: "password",
 	}
 
-	err := repo.Create(user)
+	err := repo.Create(user, nil)
 	assert.Nil(t, err)
 
 	err = repo.Delete(user)
@@ -135,3 +138,396 @@ func TestUserRepository_Delete(t *testing.T) {
 	_, err = repo.GetByID(user.ID)
 	assert.NotNil(t, err)
 }
+
+func TestUserRepository_FailuresAndLockout(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password",
+	}
+	assert.Nil(t, repo.Create(user, nil))
+
+	for expected := 1; expected <= 3; expected++ {
+		failures, err := repo.IncrementFailure(user.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, failures)
+	}
+
+	until := time.Now().Add(time.Minute)
+	assert.Nil(t, repo.LockUntil(user.ID, until))
+
+	foundUser, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, foundUser.Failure)
+	if assert.NotNil(t, foundUser.LockedUntil) {
+		assert.WithinDuration(t, until, *foundUser.LockedUntil, time.Second)
+	}
+
+	assert.Nil(t, repo.ResetFailures(user.ID))
+
+	foundUser, err = repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, foundUser.Failure)
+	assert.Nil(t, foundUser.LockedUntil)
+}
+
+func TestUserRepository_MFA(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password",
+	}
+	assert.Nil(t, repo.Create(user, nil))
+	assert.Nil(t, repo.UpdateMFA(user.ID, "SECRET", true))
+
+	used, err := repo.MarkMFAStepUsed(user.ID, 100)
+	assert.Nil(t, err)
+	assert.True(t, used)
+
+	// Un código ya usado, o uno anterior, no vale
+	used, err = repo.MarkMFAStepUsed(user.ID, 100)
+	assert.Nil(t, err)
+	assert.False(t, used)
+	used, err = repo.MarkMFAStepUsed(user.ID, 99)
+	assert.Nil(t, err)
+	assert.False(t, used)
+
+	// Update no toca los datos del 2FA
+	foundUser, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	foundUser.MFAEnabled = false
+	foundUser.MFASecret = ""
+	assert.Nil(t, repo.Update(foundUser, nil))
+
+	foundUser, err = repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.True(t, foundUser.MFAEnabled)
+	assert.Equal(t, "SECRET", foundUser.MFASecret)
+	assert.Equal(t, int64(100), foundUser.MFALastStep)
+}
+
+func TestUserRepository_ResetPassword(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	expiresAt := time.Now().Add(time.Hour)
+	user := &model.User{
+		Username:       "testuser",
+		Email:          "test@example.com",
+		Password:       "password",
+		Token:          "hashed-token",
+		TokenExpiresAt: &expiresAt,
+	}
+	assert.Nil(t, repo.Create(user, nil))
+
+	reset, err := repo.ResetPassword(user.ID, "hashed-token", "new-hash", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, reset)
+
+	// El token ya se ha consumido: una segunda petición no cambia nada
+	reset, err = repo.ResetPassword(user.ID, "hashed-token", "other-hash", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, reset)
+
+	foundUser, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "new-hash", foundUser.Password)
+	assert.Empty(t, foundUser.Token)
+	assert.Nil(t, foundUser.TokenExpiresAt)
+	assert.NotNil(t, foundUser.PasswordChangedAt)
+
+	// Un token caducado tampoco vale
+	expiredAt := time.Now().Add(-time.Minute)
+	database.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{"token": "expired-token", "token_expires_at": expiredAt})
+	reset, err = repo.ResetPassword(user.ID, "expired-token", "other-hash", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, reset)
+}
+
+func TestUserRepository_Status(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password",
+	}
+	assert.Nil(t, repo.Create(user, nil))
+
+	status, err := repo.GetStatus(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.UserStatusActive, status)
+
+	now := time.Now()
+	assert.Nil(t, repo.SetStatus(user.ID, model.UserStatusSuspended, "abuse", now))
+
+	// Guardar el usuario desde el CRUD no puede pisar el estado
+	foundUser, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	foundUser.FullName = "Renamed"
+	assert.Nil(t, repo.Update(foundUser, nil))
+
+	foundUser, err = repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.UserStatusSuspended, foundUser.Status)
+	assert.Equal(t, "abuse", foundUser.StatusReason)
+	assert.False(t, foundUser.IsActive())
+	if assert.NotNil(t, foundUser.StatusChangedAt) {
+		assert.WithinDuration(t, now, *foundUser.StatusChangedAt, time.Second)
+	}
+
+	_, err = repo.GetStatus(9999)
+	assert.NotNil(t, err)
+}
+
+func TestUserRepository_RestoreAndPurge(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password",
+	}
+	assert.Nil(t, repo.Create(user, nil))
+
+	// Solo se restauran o purgan usuarios eliminados
+	restored, err := repo.Restore(user.ID, time.Now())
+	assert.Nil(t, err)
+	assert.False(t, restored)
+	purged, err := repo.Purge(user.ID)
+	assert.Nil(t, err)
+	assert.False(t, purged)
+
+	assert.Nil(t, repo.Delete(user))
+	status, err := repo.GetStatus(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.UserStatusDeleted, status)
+
+	deleted, total, err := repo.PaginateDeleted(1, 10, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, deleted, 1) {
+		assert.Equal(t, user.ID, deleted[0].ID)
+	}
+
+	restored, err = repo.Restore(user.ID, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, restored)
+	foundUser, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.UserStatusActive, foundUser.Status)
+
+	assert.Nil(t, database.Create(&model.PasswordHistory{UserID: user.ID, PasswordHash: "old"}).Error)
+	assert.Nil(t, repo.Delete(user))
+	purged, err = repo.Purge(user.ID)
+	assert.Nil(t, err)
+	assert.True(t, purged)
+
+	var count int64
+	database.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Model(&model.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUserRepository_SetLevels(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	primary := &model.Level{Level: "Main", Description: "Main"}
+	guest := &model.Level{Level: "Guest", Description: "Guest"}
+	support := &model.Level{Level: "Support", Description: "Support"}
+	for _, level := range []*model.Level{primary, guest, support} {
+		assert.Nil(t, database.Create(level).Error)
+	}
+	user := &model.User{Username: "testuser", Email: "test@example.com", Password: "password", LevelID: primary.ID}
+	assert.Nil(t, repo.Create(user, nil))
+
+	assert.Nil(t, repo.SetLevels(user.ID, []uint{guest.ID, support.ID}))
+	foundUser, err := repo.GetByEmail(user.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint{primary.ID, guest.ID, support.ID}, foundUser.LevelIDs())
+
+	// Guardar el usuario no toca sus niveles adicionales
+	foundUser.Levels = nil
+	assert.Nil(t, repo.Update(foundUser, nil))
+	foundUser, _ = repo.GetByID(user.ID)
+	assert.Len(t, foundUser.Levels, 2)
+
+	assert.Nil(t, repo.SetLevels(user.ID, []uint{support.ID}))
+	foundUser, _ = repo.GetByID(user.ID)
+	assert.Equal(t, []uint{primary.ID, support.ID}, foundUser.LevelIDs())
+
+	// Purgar el usuario borra sus niveles adicionales
+	assert.Nil(t, repo.Delete(user))
+	purged, err := repo.Purge(user.ID)
+	assert.Nil(t, err)
+	assert.True(t, purged)
+	var count int64
+	database.Table("user_levels").Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUserRepository_RowFilter(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	repo := db.NewUserRepository(database)
+
+	var users []*model.User
+	for i, levelID := range []uint{1, 2, 2} {
+		user := &model.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "password", LevelID: levelID}
+		assert.Nil(t, repo.Create(user, nil))
+		users = append(users, user)
+	}
+	assert.Nil(t, repo.SetStatus(users[2].ID, model.UserStatusSuspended, "", time.Now()))
+	subject := security.RowSubject{
+		security.RowSubjectID:      users[0].ID,
+		security.RowSubjectEmail:   users[0].Email,
+		security.RowSubjectLevelID: uint(2),
+	}
+
+	cases := []struct {
+		conditions []string
+		allowed    []uint
+	}{
+		{[]string{"id == user.id"}, []uint{users[0].ID}},
+		{[]string{"email == user.email"}, []uint{users[0].ID}},
+		{[]string{"id != user.id"}, []uint{users[1].ID, users[2].ID}},
+		{[]string{"level_id == user.level_id"}, []uint{users[1].ID, users[2].ID}},
+		{[]string{"level_id == user.level_id && status == 'active'"}, []uint{users[1].ID}},
+		{[]string{"(id == user.id || level_id == 2) && status != 'suspended'"}, []uint{users[0].ID, users[1].ID}},
+		{[]string{"id == user.id", "status == 'suspended'"}, []uint{users[0].ID, users[2].ID}},
+		{[]string{"level_id == 3"}, nil},
+		{nil, nil},
+	}
+
+	for _, tc := range cases {
+		var policies []*security.RowPolicy
+		for _, condition := range tc.conditions {
+			policy, err := security.ParseRowPolicy(condition)
+			assert.Nil(t, err)
+			policies = append(policies, policy)
+		}
+		filter := security.NewRowFilter(subject, policies...)
+
+		all, err := repo.GetAll(filter)
+		assert.Nil(t, err, tc.conditions)
+		page, total, err := repo.Paginate(1, 10, filter)
+		assert.Nil(t, err, tc.conditions)
+		assert.Equal(t, len(tc.allowed), total, tc.conditions)
+
+		var allIDs, pageIDs []uint
+		for _, user := range all {
+			allIDs = append(allIDs, user.ID)
+		}
+		for _, user := range page {
+			pageIDs = append(pageIDs, user.ID)
+		}
+		assert.Equal(t, tc.allowed, allIDs, tc.conditions)
+		assert.Equal(t, tc.allowed, pageIDs, tc.conditions)
+
+		for _, user := range users {
+			matches, err := repo.MatchesFilter(user.ID, filter)
+			assert.Nil(t, err)
+			assert.Equal(t, slices.Contains(tc.allowed, user.ID), matches, tc.conditions, user.ID)
+		}
+	}
+
+	// Sin filtro no se restringe nada
+	all, err := repo.GetAll(nil)
+	assert.Nil(t, err)
+	assert.Len(t, all, 3)
+	matches, err := repo.MatchesFilter(users[2].ID, nil)
+	assert.Nil(t, err)
+	assert.True(t, matches)
+}
+
+func TestUserRepository_SaveChecksRowFilter(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	// Solo se pueden guardar usuarios del nivel 1
+	policy, err := security.ParseRowPolicy("level_id == 1")
+	assert.Nil(t, err)
+	filter := security.NewRowFilter(security.RowSubject{}, policy)
+
+	denied := &model.User{Username: "denied", Email: "denied@example.com", Password: "password", LevelID: 2}
+	assert.ErrorIs(t, repo.Create(denied, filter), security.ErrRowAccessDenied)
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	user := &model.User{Username: "allowed", Email: "allowed@example.com", Password: "password", LevelID: 1}
+	assert.Nil(t, repo.Create(user, filter))
+
+	// Tampoco se puede sacar un usuario de las condiciones al modificarlo
+	user.LevelID = 2
+	user.FullName = "Moved"
+	assert.ErrorIs(t, repo.Update(user, filter), security.ErrRowAccessDenied)
+	stored, err := repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), stored.LevelID)
+	assert.Empty(t, stored.FullName)
+
+	user.LevelID = 1
+	assert.Nil(t, repo.Update(user, filter))
+	stored, err = repo.GetByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Moved", stored.FullName)
+}
+
+func TestUserRepository_CreateBatchAndTakenIdentifiers(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	repo := db.NewUserRepository(database)
+
+	deleted := &model.User{Username: "Deleted", Email: "Deleted@example.com", Password: "password"}
+	assert.Nil(t, repo.Create(deleted, nil))
+	assert.Nil(t, repo.Delete(deleted))
+
+	// Los eliminados siguen ocupando el usuario y el email
+	usernames, emails, err := repo.GetTakenIdentifiers([]string{"deleted", "free"}, []string{"deleted@example.com", "free@example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deleted"}, usernames)
+	assert.Equal(t, []string{"deleted@example.com"}, emails)
+
+	// Si falla una fila no se crea ninguna
+	err = repo.CreateBatch([]*model.User{
+		{Username: "first", Email: "first@example.com", Password: "password"},
+		{Username: "Deleted", Email: "other@example.com", Password: "password"},
+	}, nil)
+	assert.NotNil(t, err)
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	assert.Nil(t, repo.CreateBatch([]*model.User{
+		{Username: "first", Email: "first@example.com", Password: "password"},
+		{Username: "second", Email: "second@example.com", Password: "password"},
+	}, nil))
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
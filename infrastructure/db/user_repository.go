@@ -1,12 +1,12 @@
 package db
 
 import (
-	"crypto/sha256"
-	"errors"
 	"fmt"
+	"time"
+
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
-	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/domain/security"
 	"gorm.io/gorm"
 )
 
@@ -18,17 +18,53 @@ func NewUserRepository(db *gorm.DB) repository.UserRepository {
 	return &userRepository{db}
 }
 
-func (r *userRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+// Create y CreateBatch no guardan los niveles adicionales, que solo cambian
+// con SetLevels
+func (r *userRepository) Create(user *model.User, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.User{}, filter, func(tx *gorm.DB) (uint, error) {
+		err := tx.Omit("Levels").Create(user).Error
+		return user.ID, err
+	})
+}
+
+func (r *userRepository) CreateBatch(users []*model.User, filter *security.RowFilter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if err := tx.Omit("Levels").Create(user).Error; err != nil {
+				return err
+			}
+			matches, err := matchesRowFilter(tx, &model.User{}, user.ID, filter)
+			if err != nil {
+				return err
+			}
+			if !matches {
+				return security.ErrRowAccessDenied
+			}
+		}
+		return nil
+	})
 }
 
-func (r *userRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+// Update guarda el usuario salvo los datos del 2FA, que solo cambian con
+// UpdateMFA, el estado de la cuenta, que solo cambia con SetStatus, y los
+// niveles adicionales, que solo cambian con SetLevels
+func (r *userRepository) Update(user *model.User, filter *security.RowFilter) error {
+	return saveMatchingRowFilter(r.db, &model.User{}, filter, func(tx *gorm.DB) (uint, error) {
+		return user.ID, tx.Omit("mfa_enabled", "mfa_secret", "mfa_last_step", "status", "status_reason", "status_changed_at", "Levels").Save(user).Error
+	})
 }
 
 func (r *userRepository) GetByID(id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.Preload("Level.LevelPrivileges.Form").First(&user, id).Error; err != nil {
+	if err := r.db.Preload("Level.LevelPrivileges.Form").Preload("Levels").First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByIDUnscoped(id uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.Unscoped().Preload("Level").Preload("Levels").First(&user, id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -36,56 +72,299 @@ func (r *userRepository) GetByID(id uint) (*model.User, error) {
 
 func (r *userRepository) GetByEmail(email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.Preload("Level").Preload("Levels").Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByToken(token string) (*model.User, error) {
+	var user model.User
+	if err := r.db.Where("token = ?", token).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetAll() ([]*model.User, error) {
+// GetTakenIdentifiers busca sin distinguir mayúsculas e incluye los usuarios
+// eliminados de forma lógica, que siguen ocupando los índices únicos
+func (r *userRepository) GetTakenIdentifiers(usernames []string, emails []string) ([]string, []string, error) {
+	var takenUsernames, takenEmails []string
+	if len(usernames) > 0 {
+		err := r.db.Unscoped().Model(&model.User{}).Where("LOWER(username) IN ?", usernames).Pluck("LOWER(username)", &takenUsernames).Error
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(emails) > 0 {
+		err := r.db.Unscoped().Model(&model.User{}).Where("LOWER(email) IN ?", emails).Pluck("LOWER(email)", &takenEmails).Error
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return takenUsernames, takenEmails, nil
+}
+
+func (r *userRepository) GetAll(filter *security.RowFilter) ([]*model.User, error) {
 	var users []*model.User
-	if err := r.db.Find(&users).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *userRepository) Paginate(page int, pageSize int) ([]*model.User, int, error) {
+func (r *userRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
 	var users []*model.User
 	var total int64
 
-	r.db.Model(&model.User{}).Count(&total)
+	withRowFilter(r.db.Model(&model.User{}), filter).Count(&total)
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
+	if err := withRowFilter(r.db, filter).Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return users, int(total), nil
 }
 
+// MatchesFilter también comprueba los usuarios eliminados, que se pueden
+// recuperar o purgar
+func (r *userRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return matchesRowFilter(r.db.Unscoped(), &model.User{}, id, filter)
+}
+
 func (r *userRepository) Delete(user *model.User) error {
-	return r.db.Delete(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"status":            model.UserStatusDeleted,
+			"status_changed_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+}
+
+func (r *userRepository) GetStatus(id uint) (string, error) {
+	var user model.User
+	if err := r.db.Unscoped().Select("id", "status").First(&user, id).Error; err != nil {
+		return "", err
+	}
+	if user.Status == "" {
+		return model.UserStatusActive, nil
+	}
+	return user.Status, nil
 }
 
-func (r *userRepository) Login(email, password string) (string, error) {
-	user, err := r.GetByEmail(email)
+func (r *userRepository) SetStatus(id uint, status string, reason string, changedAt time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": changedAt,
+	}).Error
+}
+
+func (r *userRepository) SetLevels(id uint, levelIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("user_levels").Where("user_id = ?", id).Delete(nil).Error; err != nil {
+			return err
+		}
+		if len(levelIDs) == 0 {
+			return nil
+		}
+		rows := make([]map[string]interface{}, 0, len(levelIDs))
+		for _, levelID := range levelIDs {
+			rows = append(rows, map[string]interface{}{"user_id": id, "level_id": levelID})
+		}
+		return tx.Table("user_levels").Create(rows).Error
+	})
+}
+
+func (r *userRepository) PaginateDeleted(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	var users []*model.User
+	var total int64
+
+	deleted := withRowFilter(r.db.Unscoped().Model(&model.User{}).Where("deleted_at IS NOT NULL"), filter)
+	if err := deleted.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := withRowFilter(r.db.Unscoped().Where("deleted_at IS NOT NULL"), filter).Order("deleted_at DESC").
+		Limit(pageSize).Offset(offset).Find(&users).Error
 	if err != nil {
-		return "", errors.New("invalid email or password")
+		return nil, 0, err
 	}
 
-	ps := sha256.Sum256([]byte(password))
-	pwd := fmt.Sprintf("%x", ps)
+	return users, int(total), nil
+}
+
+func (r *userRepository) Restore(id uint, restoredAt time.Time) (bool, error) {
+	result := r.db.Unscoped().Model(&model.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at":        nil,
+			"status":            model.UserStatusActive,
+			"status_reason":     "",
+			"status_changed_at": restoredAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Purge borra también las credenciales y sesiones del usuario. Las
+// revocaciones de tokens se mantienen hasta que caducan.
+func (r *userRepository) Purge(id uint) (bool, error) {
+	purged := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&model.User{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		purged = true
+
+		for _, related := range []interface{}{
+			&model.APIKey{}, &model.RecoveryCode{}, &model.PasswordHistory{},
+			&model.RefreshToken{}, &model.Session{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Table("user_levels").Where("user_id = ?", id).Delete(nil).Error
+	})
+	return purged, err
+}
+
+// Erase sustituye los datos identificativos por valores que no se pueden
+// relacionar con la persona y que mantienen únicos el usuario y el email. Las
+// sesiones se conservan sin IP ni navegador y se borran las credenciales.
+func (r *userRepository) Erase(id uint, erasedAt time.Time, audit *model.AuditEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Unscoped().First(&user, id).Error; err != nil {
+			return err
+		}
+
+		erasedName := fmt.Sprintf("erased-%d", id)
+		erasedEmail := erasedName + "@erased.invalid"
+		deletedAt := user.DeletedAt
+		if !deletedAt.Valid {
+			deletedAt = gorm.DeletedAt{Time: erasedAt, Valid: true}
+		}
+		err := tx.Unscoped().Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"username":          erasedName,
+			"email":             erasedEmail,
+			"full_name":         "Erased user",
+			"password":          "",
+			"picture":           "",
+			"language":          "",
+			"token":             "",
+			"token_expires_at":  nil,
+			"mfa_enabled":       false,
+			"mfa_secret":        "",
+			"status":            model.UserStatusDeleted,
+			"status_reason":     "erased",
+			"status_changed_at": erasedAt,
+			"deleted_at":        deletedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&model.Invitation{}).Where("user_id = ? OR email = ?", id, user.Email).UpdateColumns(map[string]interface{}{
+			"email":      erasedEmail,
+			"username":   erasedName,
+			"full_name":  "Erased user",
+			"token_hash": "",
+		}).Error
+		if err != nil {
+			return err
+		}
 
-	if pwd == user.Password {
-		token, err := helpers.GenerateJWT(user)
+		err = tx.Unscoped().Model(&model.Session{}).Where("user_id = ?", id).UpdateColumns(map[string]interface{}{
+			"user_agent": "",
+			"ip":         "",
+			"revoked_at": gorm.Expr("COALESCE(revoked_at, ?)", erasedAt),
+		}).Error
 		if err != nil {
-			return "", err
+			return err
 		}
 
-		return token, nil
+		for _, related := range []interface{}{
+			&model.APIKey{}, &model.RecoveryCode{}, &model.PasswordHistory{}, &model.RefreshToken{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(audit).Error
+	})
+}
+
+func (r *userRepository) IncrementFailure(id uint) (int, error) {
+	var failure int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", id).
+			UpdateColumn("failure", gorm.Expr("failure + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", id).Select("failure").Scan(&failure).Error
+	})
+	return failure, err
+}
+
+func (r *userRepository) LockUntil(id uint, until time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).UpdateColumn("locked_until", until).Error
+}
 
+func (r *userRepository) ResetFailures(id uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"failure": 0, "locked_until": nil}).Error
+}
+
+func (r *userRepository) UpdateProfile(id uint, profile *model.Profile) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"full_name": profile.FullName, "picture": profile.Picture, "language": profile.Language}).Error
+}
+
+// UpdatePassword guarda el nuevo hash y la fecha del cambio, de la que depende la caducidad
+func (r *userRepository) UpdatePassword(id uint, hashedPassword string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"password": hashedPassword, "password_changed_at": time.Now()}).Error
+}
+
+// ResetPassword solo cambia la fila si sigue teniendo el token sin caducar: de
+// dos peticiones con el mismo token solo una lo consume
+func (r *userRepository) ResetPassword(id uint, token string, hashedPassword string, changedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND token = ? AND token_expires_at > ?", id, token, changedAt).
+		UpdateColumns(map[string]interface{}{
+			"password":            hashedPassword,
+			"password_changed_at": changedAt,
+			"token":               "",
+			"token_expires_at":    nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
 	}
+	return result.RowsAffected == 1, nil
+}
 
-	return "", errors.New("invalid email or password")
+func (r *userRepository) UpdateMFA(id uint, secret string, enabled bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"mfa_secret": secret, "mfa_enabled": enabled, "mfa_last_step": 0}).Error
+}
+
+func (r *userRepository) MarkMFAStepUsed(id uint, step int64) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		UpdateColumn("mfa_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
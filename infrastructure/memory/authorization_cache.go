@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+)
+
+// AuthorizationCache envuelve un LevelRepository y guarda en memoria los
+// niveles con sus privilegios durante ttl, hasta maxEntries niveles. Cualquier
+// escritura a través del repositorio (niveles, cambios de versión de los
+// privilegios de un nivel o de un formulario) invalida las entradas afectadas,
+// incluidos los niveles que heredan de otro, cuya versión sigue a la de sus
+// ascendientes.
+// Con varias instancias del API cada una solo invalida su propia caché y el
+// resto sigue con los privilegios anteriores como mucho durante ttl.
+type AuthorizationCache struct {
+	repository.LevelRepository
+	ttl        time.Duration
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[uint]authorizationCacheEntry
+	// generation cambia con cada invalidación; una carga que empezó antes no
+	// se guarda para no volver a cachear privilegios ya modificados
+	generation    uint64
+	hits          uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+}
+
+type authorizationCacheEntry struct {
+	level     *model.Level
+	expiresAt time.Time
+}
+
+func NewAuthorizationCache(levelRepo repository.LevelRepository, ttl time.Duration, maxEntries int) *AuthorizationCache {
+	return &AuthorizationCache{
+		LevelRepository: levelRepo,
+		ttl:             ttl,
+		maxEntries:      maxEntries,
+		entries:         make(map[uint]authorizationCacheEntry),
+	}
+}
+
+// GetByID devuelve una copia del nivel cacheado para que quien lo reciba
+// pueda modificarlo sin alterar la caché
+func (c *AuthorizationCache) GetByID(id uint) (*model.Level, error) {
+	c.mutex.Lock()
+	if level, ok := c.lookup(id, time.Now()); ok {
+		c.hits++
+		c.mutex.Unlock()
+		return copyLevel(level), nil
+	}
+	c.misses++
+	generation := c.generation
+	c.mutex.Unlock()
+
+	level, err := c.LevelRepository.GetByID(id)
+	if err != nil {
+		return level, err
+	}
+
+	c.mutex.Lock()
+	if generation == c.generation {
+		c.store(id, copyLevel(level), time.Now())
+	}
+	c.mutex.Unlock()
+	return level, nil
+}
+
+// GetPermissionVersion usa la versión del nivel cacheado si lo está
+func (c *AuthorizationCache) GetPermissionVersion(id uint) (uint, error) {
+	c.mutex.Lock()
+	if level, ok := c.lookup(id, time.Now()); ok {
+		c.hits++
+		c.mutex.Unlock()
+		return level.PermissionVersion, nil
+	}
+	c.mutex.Unlock()
+	return c.LevelRepository.GetPermissionVersion(id)
+}
+
+func (c *AuthorizationCache) CreateOrUpdate(level *model.Level, filter *security.RowFilter) error {
+	defer c.Invalidate(level.ID)
+	return c.LevelRepository.CreateOrUpdate(level, filter)
+}
+
+// Delete también invalida los niveles que heredan de otro, porque los que
+// heredaban del borrado se quedan sin padre
+func (c *AuthorizationCache) Delete(level *model.Level) error {
+	defer c.InvalidateInheriting(level.ID)
+	return c.LevelRepository.Delete(level)
+}
+
+func (c *AuthorizationCache) BumpPermissionVersion(ids ...uint) error {
+	defer c.InvalidateInheriting(ids...)
+	return c.LevelRepository.BumpPermissionVersion(ids...)
+}
+
+// BumpPermissionVersionByForm vacía toda la caché: no se sabe qué niveles
+// tienen privilegios sobre el formulario sin consultarlo
+func (c *AuthorizationCache) BumpPermissionVersionByForm(formID uint) error {
+	defer c.InvalidateAll()
+	return c.LevelRepository.BumpPermissionVersionByForm(formID)
+}
+
+// Invalidate elimina de la caché los niveles indicados
+func (c *AuthorizationCache) Invalidate(ids ...uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.invalidations++
+	for _, id := range ids {
+		delete(c.entries, id)
+	}
+}
+
+// InvalidateInheriting elimina de la caché los niveles indicados y todos los
+// que heredan de otro nivel: sin consultar la base de datos no se sabe si
+// alguno de sus ascendientes está entre los indicados
+func (c *AuthorizationCache) InvalidateInheriting(ids ...uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.invalidations++
+	for _, id := range ids {
+		delete(c.entries, id)
+	}
+	for id, entry := range c.entries {
+		if entry.level.ParentID != nil {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// InvalidateAll vacía la caché
+func (c *AuthorizationCache) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.invalidations++
+	c.entries = make(map[uint]authorizationCacheEntry)
+}
+
+func (c *AuthorizationCache) Stats() model.AuthorizationCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := model.AuthorizationCacheStats{
+		Enabled:       true,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Invalidations: c.invalidations,
+		Size:          len(c.entries),
+		MaxSize:       c.maxEntries,
+		TTLSeconds:    int64(c.ttl.Seconds()),
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRatio = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// lookup debe llamarse con el mutex tomado
+func (c *AuthorizationCache) lookup(id uint, now time.Time) (*model.Level, bool) {
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(c.entries, id)
+		c.evictions++
+		return nil, false
+	}
+	return entry.level, true
+}
+
+// store debe llamarse con el mutex tomado. Si la caché está llena se
+// descartan primero las entradas caducadas y, si no basta, la que antes caduca.
+func (c *AuthorizationCache) store(id uint, level *model.Level, now time.Time) {
+	if _, ok := c.entries[id]; !ok && len(c.entries) >= c.maxEntries {
+		var oldestID uint
+		var oldest time.Time
+		for entryID, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, entryID)
+				c.evictions++
+				continue
+			}
+			if oldest.IsZero() || entry.expiresAt.Before(oldest) {
+				oldestID, oldest = entryID, entry.expiresAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldestID)
+			c.evictions++
+		}
+	}
+	c.entries[id] = authorizationCacheEntry{level: level, expiresAt: now.Add(c.ttl)}
+}
+
+// copyLevel copia el nivel y su lista de privilegios
+func copyLevel(level *model.Level) *model.Level {
+	levelCopy := *level
+	levelCopy.LevelPrivileges = append([]model.LevelPrivileges(nil), level.LevelPrivileges...)
+	if level.ParentID != nil {
+		parentID := *level.ParentID
+		levelCopy.ParentID = &parentID
+	}
+	return &levelCopy
+}
+
+// Asegúrate de que AuthorizationCache implemente repository.AuthorizationCache
+var _ repository.AuthorizationCache = &AuthorizationCache{}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/drossan/core-api/domain/repository"
+)
+
+// RevocationStore mantiene la lista de revocación en memoria. Solo es válido
+// cuando hay una única instancia del API; con varias instancias hay que usar
+// el almacén de base de datos.
+type RevocationStore struct {
+	mutex         sync.RWMutex
+	revokedTokens map[string]time.Time
+	revokedUsers  map[uint]time.Time
+}
+
+func NewRevocationStore() *RevocationStore {
+	return &RevocationStore{
+		revokedTokens: make(map[string]time.Time),
+		revokedUsers:  make(map[uint]time.Time),
+	}
+}
+
+func (s *RevocationStore) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.purgeExpired(time.Now())
+	s.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (s *RevocationStore) RevokeUser(userID uint, revokedBefore time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.revokedUsers[userID] = revokedBefore.Truncate(time.Second)
+	return nil
+}
+
+func (s *RevocationStore) IsRevoked(jti string, userID uint, issuedAt time.Time) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if _, ok := s.revokedTokens[jti]; ok {
+		return true, nil
+	}
+	if revokedBefore, ok := s.revokedUsers[userID]; ok && issuedAt.Before(revokedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// purgeExpired debe llamarse con el mutex de escritura tomado
+func (s *RevocationStore) purgeExpired(now time.Time) {
+	for jti, expiresAt := range s.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(s.revokedTokens, jti)
+		}
+	}
+}
+
+// Asegúrate de que RevocationStore implemente repository.RevocationStore
+var _ repository.RevocationStore = &RevocationStore{}
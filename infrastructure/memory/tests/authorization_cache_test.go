@@ -0,0 +1,170 @@
+package memory_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func cachedLevel(id uint, version uint) *model.Level {
+	return &model.Level{
+		Model:             gorm.Model{ID: id},
+		PermissionVersion: version,
+		LevelPrivileges:   []model.LevelPrivileges{{Form: model.Form{PathAPI: "user|users"}, Read: true}},
+	}
+}
+
+func TestAuthorizationCache_HitsAndMisses(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(cachedLevel(1, 3), nil).Once()
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 10)
+
+	for i := 0; i < 3; i++ {
+		level, err := cache.GetByID(1)
+		assert.NoError(t, err)
+		assert.Len(t, level.LevelPrivileges, 1)
+	}
+	version, err := cache.GetPermissionVersion(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+
+	stats := cache.Stats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 0.75, stats.HitRatio)
+	assert.Equal(t, 1, stats.Size)
+	levelRepo.AssertExpectations(t)
+}
+
+func TestAuthorizationCache_ReturnsCopies(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(cachedLevel(1, 1), nil).Once()
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 10)
+
+	level, _ := cache.GetByID(1)
+	level.LevelPrivileges[0].Write = true
+	level.Description = "changed"
+
+	level, _ = cache.GetByID(1)
+	assert.False(t, level.LevelPrivileges[0].Write)
+	assert.Empty(t, level.Description)
+}
+
+func TestAuthorizationCache_InvalidatesOnWrites(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(cachedLevel(1, 1), nil)
+	levelRepo.On("GetByID", uint(2)).Return(cachedLevel(2, 1), nil)
+	levelRepo.On("BumpPermissionVersion", []uint{1}).Return(nil)
+	levelRepo.On("BumpPermissionVersionByForm", uint(4)).Return(nil)
+	levelRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
+	levelRepo.On("Delete", mock.Anything).Return(nil)
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 10)
+
+	load := func() {
+		_, _ = cache.GetByID(1)
+		_, _ = cache.GetByID(2)
+	}
+
+	// Cambiar los privilegios de un nivel solo invalida ese nivel
+	load()
+	assert.NoError(t, cache.BumpPermissionVersion(1))
+	assert.Equal(t, 1, cache.Stats().Size)
+
+	// Cambiar un formulario invalida todos los niveles
+	load()
+	assert.NoError(t, cache.BumpPermissionVersionByForm(4))
+	assert.Equal(t, 0, cache.Stats().Size)
+
+	load()
+	assert.NoError(t, cache.CreateOrUpdate(cachedLevel(2, 1), nil))
+	assert.NoError(t, cache.Delete(cachedLevel(1, 1)))
+	assert.Equal(t, 0, cache.Stats().Size)
+	assert.Equal(t, uint64(4), cache.Stats().Invalidations)
+	levelRepo.AssertNumberOfCalls(t, "GetByID", 5)
+}
+
+func TestAuthorizationCache_ExpiresAndLimitsSize(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	for id := uint(1); id <= 3; id++ {
+		levelRepo.On("GetByID", id).Return(cachedLevel(id, 1), nil)
+	}
+
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 2)
+	for id := uint(1); id <= 3; id++ {
+		_, _ = cache.GetByID(id)
+	}
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, uint64(1), stats.Evictions)
+
+	expiring := memory.NewAuthorizationCache(levelRepo, time.Millisecond, 2)
+	_, _ = expiring.GetByID(1)
+	time.Sleep(5 * time.Millisecond)
+	_, _ = expiring.GetByID(1)
+	assert.Equal(t, uint64(2), expiring.Stats().Misses)
+}
+
+func TestAuthorizationCache_ConcurrentAccess(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	for id := uint(1); id <= 5; id++ {
+		levelRepo.On("GetByID", id).Return(cachedLevel(id, 1), nil)
+		levelRepo.On("GetPermissionVersion", id).Return(uint(1), nil)
+	}
+	levelRepo.On("BumpPermissionVersion", mock.Anything).Return(nil)
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 3)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 20; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := uint((worker+i)%5 + 1)
+				level, err := cache.GetByID(id)
+				assert.NoError(t, err)
+				assert.Equal(t, id, level.ID)
+				_, _ = cache.GetPermissionVersion(id)
+				if i%10 == 0 {
+					_ = cache.BumpPermissionVersion(id)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.LessOrEqual(t, stats.Size, 3)
+	assert.GreaterOrEqual(t, stats.Hits+stats.Misses, uint64(20*50))
+}
+
+func TestAuthorizationCache_BumpInvalidatesInheritingLevels(t *testing.T) {
+	parentID := uint(1)
+	child := cachedLevel(2, 1)
+	child.ParentID = &parentID
+
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(cachedLevel(1, 1), nil)
+	levelRepo.On("GetByID", uint(2)).Return(child, nil)
+	levelRepo.On("GetByID", uint(3)).Return(cachedLevel(3, 1), nil)
+	levelRepo.On("BumpPermissionVersion", []uint{1}).Return(nil)
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 10)
+
+	for id := uint(1); id <= 3; id++ {
+		_, _ = cache.GetByID(id)
+	}
+
+	// El hijo hereda la versión del nivel cambiado; el nivel 3 no hereda
+	assert.NoError(t, cache.BumpPermissionVersion(1))
+	assert.Equal(t, 1, cache.Stats().Size)
+	level, _ := cache.GetByID(3)
+	assert.Equal(t, uint(3), level.ID)
+	levelRepo.AssertNumberOfCalls(t, "GetByID", 3)
+}
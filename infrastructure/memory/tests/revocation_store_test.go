@@ -0,0 +1,77 @@
+package memory_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationStore_RevokeToken(t *testing.T) {
+	store := memory.NewRevocationStore()
+
+	assert.Nil(t, store.Revoke("jti-1", 1, time.Now().Add(time.Hour)))
+
+	revoked, err := store.IsRevoked("jti-1", 1, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked("jti-2", 1, time.Now())
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevocationStore_RevokeUser(t *testing.T) {
+	store := memory.NewRevocationStore()
+
+	cutoff := time.Now()
+	assert.Nil(t, store.RevokeUser(1, cutoff))
+
+	revoked, _ := store.IsRevoked("jti", 1, cutoff.Add(-time.Minute))
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked("jti", 1, cutoff.Add(time.Minute))
+	assert.False(t, revoked)
+
+	revoked, _ = store.IsRevoked("jti", 2, cutoff.Add(-time.Minute))
+	assert.False(t, revoked)
+}
+
+func TestRevocationStore_ConcurrentAccess(t *testing.T) {
+	store := memory.NewRevocationStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_ = store.Revoke(string(rune('a'+i%26)), uint(i), time.Now().Add(time.Hour))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = store.IsRevoked(string(rune('a'+i%26)), uint(i), time.Now())
+		}(i)
+	}
+	wg.Wait()
+
+	revoked, _ := store.IsRevoked("a", 0, time.Now())
+	assert.True(t, revoked)
+}
+
+func TestRevocationStore_RevokeUserThenIssue(t *testing.T) {
+	store := memory.NewRevocationStore()
+
+	// Un token emitido justo después del corte tiene el mismo iat en segundos
+	revokedAt := time.Date(2024, 5, 1, 10, 0, 0, 400*int(time.Millisecond), time.UTC)
+	assert.Nil(t, store.RevokeUser(1, revokedAt))
+
+	revoked, err := store.IsRevoked("jti", 1, revokedAt.Add(5*time.Millisecond).Truncate(time.Second))
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = store.IsRevoked("jti", 1, revokedAt.Add(-time.Second).Truncate(time.Second))
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+}
@@ -1,7 +1,9 @@
 package router
 
 import (
+	"errors"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -9,11 +11,28 @@ import (
 	"net/http"
 )
 
+// TokenValidator se ejecuta tras verificar la firma y la expiración del JWT y
+// permite rechazar tokens válidos criptográficamente (revocados, etc.).
+type TokenValidator func(c echo.Context, claims *model.Claim) error
+
+// APIKeyAuthenticator valida las API keys que llegan en lugar de un JWT y
+// devuelve los claims de su propietario
+type APIKeyAuthenticator interface {
+	IsAPIKey(credential string) bool
+	Authenticate(rawKey string) (*model.Claim, error)
+}
+
+// tokenLookup acepta el JWT como Bearer y las API keys en "Authorization: ApiKey"
+// o en la cabecera X-API-Key
+const tokenLookup = "header:Authorization:Bearer ,header:Authorization:ApiKey ,header:X-API-Key"
+
 func accessible(c echo.Context) error {
 	return c.HTML(http.StatusOK, "<h1>API</h1>")
 }
 
-func NewEchoRouter(JWTSecret string) (*echo.Echo, *echo.Group, *echo.Group, string) {
+// NewEchoRouter crea el router; los JWT se verifican con la clave de keys que
+// indique su kid
+func NewEchoRouter(keys security.TokenKeys, apiKeys APIKeyAuthenticator, validators ...TokenValidator) (*echo.Echo, *echo.Group, *echo.Group, string) {
 	e := echo.New()
 
 	prefix := "api/v1"
@@ -21,6 +40,8 @@ func NewEchoRouter(JWTSecret string) (*echo.Echo, *echo.Group, *echo.Group, stri
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete},
+		// Aviso de privilegios obsoletos en el access token
+		ExposeHeaders: []string{"X-Permissions-Stale"},
 	}))
 
 	// Middleware
@@ -43,9 +64,41 @@ func NewEchoRouter(JWTSecret string) (*echo.Echo, *echo.Group, *echo.Group, stri
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(model.Claim)
 		},
-		SigningKey: []byte(JWTSecret),
+		TokenLookup: tokenLookup,
 	}
+	config.ParseTokenFunc = parseTokenFunc(config, keys, apiKeys, validators)
 	r.Use(echojwt.WithConfig(config))
 
 	return e, r, a, prefix
 }
+
+func parseTokenFunc(config echojwt.Config, keys security.TokenKeys, apiKeys APIKeyAuthenticator, validators []TokenValidator) func(c echo.Context, auth string) (interface{}, error) {
+	return func(c echo.Context, auth string) (interface{}, error) {
+		// Las API keys no son JWT: se guardan en el contexto como un token ya
+		// validado para que el resto de middlewares las traten igual
+		if apiKeys != nil && apiKeys.IsAPIKey(auth) {
+			claims, err := apiKeys.Authenticate(auth)
+			if err != nil {
+				return nil, err
+			}
+			return &jwt.Token{Claims: claims, Valid: true}, nil
+		}
+
+		token, err := jwt.ParseWithClaims(auth, config.NewClaimsFunc(c), keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+		if err != nil {
+			return nil, &echojwt.TokenError{Token: token, Err: err}
+		}
+		if !token.Valid {
+			return nil, &echojwt.TokenError{Token: token, Err: errors.New("invalid token")}
+		}
+
+		claims := token.Claims.(*model.Claim)
+		for _, validate := range validators {
+			if err := validate(c, claims); err != nil {
+				return nil, &echojwt.TokenError{Token: token, Err: err}
+			}
+		}
+
+		return token, nil
+	}
+}
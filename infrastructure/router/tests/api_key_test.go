@@ -0,0 +1,94 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKeyAuthenticator map[string]*model.Claim
+
+func (a apiKeyAuthenticator) IsAPIKey(credential string) bool {
+	return len(credential) > 3 && credential[:3] == "ak_"
+}
+
+func (a apiKeyAuthenticator) Authenticate(rawKey string) (*model.Claim, error) {
+	if claims, ok := a[rawKey]; ok {
+		return claims, nil
+	}
+	return nil, errors.New("invalid api key")
+}
+
+func TestNewEchoRouter_APIKeys(t *testing.T) {
+	newLevelRepo := func() *mocks.MockLevelRepository {
+		levelRepo := new(mocks.MockLevelRepository)
+		levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+			{Form: model.Form{PathAPI: "user|users"}, Read: true, Write: true, Create: true, Update: true, Delete: true},
+		}}, nil)
+		return levelRepo
+	}
+
+	forEachLevelRepository(t, newLevelRepo, func(t *testing.T, _ *mocks.MockLevelRepository, levels repository.LevelRepository) {
+		keys := apiKeyAuthenticator{
+			"ak_reader": {UserID: 1, LevelID: 1, APIKeyID: 1, Scopes: []string{"users:read"}},
+		}
+		e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), keys, router.MFACompleted())
+		s := r.Group("", middleware.SessionOnly())
+		routePermissions := security.NewRoutePermissions()
+		r.Use(middleware.NewAuthorizationMiddleware(levels, nil, nil, routePermissions, prefix))
+		p := api.NewProtectedGroup(r, routePermissions)
+
+		ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+		s.GET("/me", ok)
+		p.GET("/users/:page", ok, security.Read("user"))
+		p.POST("/users/:page", ok, security.Update("user"))
+
+		request := func(method, path, header, value string) int {
+			req := httptest.NewRequest(method, "/"+prefix+path, nil)
+			req.Header.Set(header, value)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users/1", echo.HeaderAuthorization, "ApiKey ak_reader"))
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users/1", "X-API-Key", "ak_reader"))
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/users/1", "X-API-Key", "ak_unknown"))
+
+		// El nivel permite escribir pero la clave solo tiene scope de lectura
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/users/1", "X-API-Key", "ak_reader"))
+
+		// Las rutas de la propia cuenta no aceptan API keys
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/me", "X-API-Key", "ak_reader"))
+
+		// Un JWT sigue funcionando en las rutas de sesión
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/me", echo.HeaderAuthorization, "Bearer "+signToken(t, "jti", "test_secret")))
+	})
+}
+
+// forEachLevelRepository ejecuta el test de autorización sin caché de
+// privilegios y con ella, con un repositorio de niveles nuevo en cada caso
+func forEachLevelRepository(t *testing.T, newLevelRepo func() *mocks.MockLevelRepository, test func(t *testing.T, levelRepo *mocks.MockLevelRepository, levels repository.LevelRepository)) {
+	t.Run("without cache", func(t *testing.T) {
+		levelRepo := newLevelRepo()
+		test(t, levelRepo, levelRepo)
+	})
+	t.Run("with cache", func(t *testing.T) {
+		levelRepo := newLevelRepo()
+		test(t, levelRepo, memory.NewAuthorizationCache(levelRepo, time.Minute, 10))
+	})
+}
@@ -0,0 +1,117 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationCheck_AgreesWithMiddleware(t *testing.T) {
+	// El nivel puede leer y modificar usuarios y leer niveles (versión 2)
+	newLevelRepo := func() *mocks.MockLevelRepository {
+		levelRepo := new(mocks.MockLevelRepository)
+		levelRepo.On("GetPermissionVersion", uint(1)).Return(uint(2), nil)
+		levelRepo.On("GetByID", uint(1)).Return(&model.Level{PermissionVersion: 2, LevelPrivileges: []model.LevelPrivileges{
+			{FormID: 1, Form: model.Form{PathAPI: "user|users"}, Read: true, Update: true},
+			{FormID: 2, Form: model.Form{PathAPI: "level|levels"}, Read: true},
+		}}, nil)
+		return levelRepo
+	}
+
+	forEachLevelRepository(t, newLevelRepo, func(t *testing.T, levelRepo *mocks.MockLevelRepository, levels repository.LevelRepository) {
+		e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+		s := r.Group("", middleware.SessionOnly())
+		routePermissions := security.NewRoutePermissions()
+		r.Use(middleware.NewAuthorizationMiddleware(levels, nil, nil, routePermissions, prefix))
+		p := api.NewProtectedGroup(r, routePermissions)
+
+		ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+		routes := []struct {
+			method, path, resource, action string
+		}{
+			{http.MethodGet, "/users/1", "user", "read"},
+			{http.MethodPut, "/user", "user", "update"},
+			{http.MethodDelete, "/user", "user", "delete"},
+			{http.MethodGet, "/levels/1", "level", "read"},
+			{http.MethodPost, "/level", "level", "create"},
+		}
+		p.GET("/users/:page", ok, security.Read("user"))
+		p.PUT("/user", ok, security.Update("user"))
+		p.DELETE("/user", ok, security.Delete("user"))
+		p.GET("/levels/:page", ok, security.Read("level"))
+		p.POST("/level", ok, security.Create("level"))
+		handler := api.NewAuthorizationHandler(e, usecase.NewAuthorizationUseCase(nil, service.NewPermissionResolver(levels)))
+		handler.SessionRoutes(s)
+
+		request := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/"+prefix+path, strings.NewReader(body))
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		checks := model.AuthorizationCheckRequest{}
+		for _, route := range routes {
+			checks.Checks = append(checks.Checks, model.AuthorizationCheck{Resource: route.resource, Action: route.action})
+		}
+		body, err := json.Marshal(checks)
+		assert.NoError(t, err)
+
+		// Token vigente con menos permisos que el nivel, token obsoleto y
+		// token sin copia de permisos
+		for name, token := range map[string]string{
+			"current": signPermissionToken(t, []string{"user|users:r", "level|levels:r"}, 2),
+			"stale":   signPermissionToken(t, []string{"user|users:rcud"}, 1),
+			"none":    signPermissionToken(t, nil, 0),
+		} {
+			rec := request(http.MethodPost, "/authz/check", token, string(body))
+			if !assert.Equal(t, http.StatusOK, rec.Code, name) {
+				continue
+			}
+			var response struct {
+				Data []model.AuthorizationCheckResult `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			if !assert.Len(t, response.Data, len(routes), name) {
+				continue
+			}
+			stale := rec.Header().Get(middleware.PermissionsStaleHeader)
+			assert.Equal(t, name == "stale", stale == "true", name)
+
+			for i, route := range routes {
+				routeRec := request(route.method, route.path, token, "")
+				assert.Equal(t, routeRec.Code == http.StatusOK, response.Data[i].Allowed, "%s %s %s", name, route.method, route.path)
+				assert.Equal(t, stale, routeRec.Header().Get(middleware.PermissionsStaleHeader), name)
+			}
+		}
+
+		// Los privilegios efectivos salen de los niveles
+		rec := request(http.MethodGet, "/me/permissions", signPermissionToken(t, nil, 0), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data []model.EffectivePermission `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		if assert.Len(t, response.Data, 2) {
+			assert.Equal(t, model.PermissionActions{Read: true, Update: true}, response.Data[0].Actions)
+			assert.Equal(t, model.PermissionActions{Read: true}, response.Data[1].Actions)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type revocationChecker struct {
+	store *memory.RevocationStore
+}
+
+func (r revocationChecker) IsRevoked(claims *model.Claim) (bool, error) {
+	return r.store.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt.Time)
+}
+
+func signToken(t *testing.T, jti string, secret string) string {
+	claims := model.Claim{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+func TestNewEchoRouter_RejectsRevokedTokens(t *testing.T) {
+	store := memory.NewRevocationStore()
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.NotRevoked(revocationChecker{store}))
+	r.GET("/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/"+prefix+"/ping", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	valid := signToken(t, "valid", "test_secret")
+	revoked := signToken(t, "revoked", "test_secret")
+	assert.NoError(t, store.Revoke("revoked", 1, time.Now().Add(time.Hour)))
+
+	assert.Equal(t, http.StatusOK, request(valid))
+	assert.Equal(t, http.StatusUnauthorized, request(revoked))
+	assert.Equal(t, http.StatusUnauthorized, request(signToken(t, "other", "wrong_secret")))
+}
+
+func TestNewEchoRouter_RejectsMFAPendingTokens(t *testing.T) {
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.MFACompleted())
+	r.GET("/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+
+	claims := model.Claim{
+		UserID:     1,
+		MFAPending: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	pending, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/"+prefix+"/ping", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+pending)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+type sessionChecker map[uint]bool
+
+func (s sessionChecker) IsSessionActive(claims *model.Claim) (bool, error) {
+	if claims.SessionID == 0 {
+		return true, nil
+	}
+	return s[claims.SessionID], nil
+}
+
+func TestNewEchoRouter_RejectsClosedSessions(t *testing.T) {
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.SessionActive(sessionChecker{1: true, 2: false}))
+	r.GET("/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+
+	request := func(sessionID uint) int {
+		claims := model.Claim{
+			UserID:    1,
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/"+prefix+"/ping", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(1))
+	assert.Equal(t, http.StatusUnauthorized, request(2))
+	assert.Equal(t, http.StatusUnauthorized, request(3))
+}
+
+type accountChecker map[uint]bool
+
+func (a accountChecker) IsAccountActive(userID uint) (bool, error) {
+	return a[userID], nil
+}
+
+func TestNewEchoRouter_RejectsInactiveAccounts(t *testing.T) {
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.AccountActive(accountChecker{1: true, 2: false}))
+	r.GET("/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+
+	request := func(userID uint) int {
+		claims := model.Claim{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/"+prefix+"/ping", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(1))
+	assert.Equal(t, http.StatusUnauthorized, request(2))
+}
@@ -0,0 +1,99 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationMiddleware_MultipleLevelsAndInheritance(t *testing.T) {
+	// Invitado (1) lee usuarios; Soporte (2) hereda de Invitado y modifica
+	// usuarios; Auditor (3) exporta niveles
+	newLevelRepo := func() *mocks.MockLevelRepository {
+		guestID := uint(1)
+		levelRepo := new(mocks.MockLevelRepository)
+		levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+			{Form: model.Form{PathAPI: "user|users"}, Read: true},
+		}}, nil)
+		levelRepo.On("GetByID", uint(2)).Return(&model.Level{PermissionVersion: 4, ParentID: &guestID, LevelPrivileges: []model.LevelPrivileges{
+			{Form: model.Form{PathAPI: "user|users"}, Update: true},
+		}}, nil)
+		levelRepo.On("GetByID", uint(3)).Return(&model.Level{PermissionVersion: 2, LevelPrivileges: []model.LevelPrivileges{
+			{Form: model.Form{PathAPI: "level|levels"}, Export: true},
+		}}, nil)
+		levelRepo.On("GetPermissionVersion", uint(2)).Return(uint(4), nil)
+		levelRepo.On("GetPermissionVersion", uint(3)).Return(uint(2), nil)
+		return levelRepo
+	}
+
+	forEachLevelRepository(t, newLevelRepo, func(t *testing.T, levelRepo *mocks.MockLevelRepository, levels repository.LevelRepository) {
+		e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+		routePermissions := security.NewRoutePermissions()
+		r.Use(middleware.NewAuthorizationMiddleware(levels, nil, nil, routePermissions, prefix))
+		p := api.NewProtectedGroup(r, routePermissions)
+
+		ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+		p.GET("/users/:page", ok, security.Read("user"))
+		p.POST("/user", ok, security.Update("user"))
+		p.POST("/user/delete", ok, security.Delete("user"))
+		p.GET("/levels/export", ok, security.Export("level"))
+
+		sign := func(levelID uint, levelIDs []uint, permissions []string, version uint) string {
+			claims := model.Claim{
+				UserID:            1,
+				LevelID:           levelID,
+				LevelIDs:          levelIDs,
+				Permissions:       permissions,
+				PermissionVersion: version,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "jti",
+					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+			assert.NoError(t, err)
+			return token
+		}
+		request := func(method, path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/"+prefix+path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Soporte lee usuarios gracias a Invitado y exporta niveles como Auditor
+		token := sign(2, []uint{3}, nil, 0)
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users/1", token).Code)
+		assert.Equal(t, http.StatusOK, request(http.MethodPost, "/user", token).Code)
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/levels/export", token).Code)
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/user/delete", token).Code)
+
+		// Sin el nivel adicional no puede exportar
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/levels/export", sign(2, nil, nil, 0)).Code)
+
+		// La copia de los permisos es vigente si coincide la suma de las versiones
+		current := sign(2, []uint{3}, []string{"user|users:ru"}, 6)
+		rec := request(http.MethodGet, "/users/1", current)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(middleware.PermissionsStaleHeader))
+		stale := sign(2, []uint{3}, []string{"user|users:ru"}, 5)
+		rec = request(http.MethodGet, "/users/1", stale)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(middleware.PermissionsStaleHeader))
+	})
+}
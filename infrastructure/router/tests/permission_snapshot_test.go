@@ -0,0 +1,91 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func signPermissionToken(t *testing.T, permissions []string, version uint) string {
+	claims := model.Claim{
+		UserID:            1,
+		LevelID:           1,
+		Permissions:       permissions,
+		PermissionVersion: version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+	assert.NoError(t, err)
+	return token
+}
+
+func TestAuthorizationMiddleware_PermissionSnapshot(t *testing.T) {
+	// En la base de datos el nivel ya solo puede leer usuarios (versión 2)
+	newLevelRepo := func() *mocks.MockLevelRepository {
+		levelRepo := new(mocks.MockLevelRepository)
+		levelRepo.On("GetPermissionVersion", uint(1)).Return(uint(2), nil)
+		levelRepo.On("GetByID", uint(1)).Return(&model.Level{PermissionVersion: 2, LevelPrivileges: []model.LevelPrivileges{
+			{Form: model.Form{PathAPI: "user|users"}, Read: true},
+		}}, nil)
+		return levelRepo
+	}
+
+	forEachLevelRepository(t, newLevelRepo, func(t *testing.T, levelRepo *mocks.MockLevelRepository, levels repository.LevelRepository) {
+		e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+		routePermissions := security.NewRoutePermissions()
+		r.Use(middleware.NewAuthorizationMiddleware(levels, nil, nil, routePermissions, prefix))
+		p := api.NewProtectedGroup(r, routePermissions)
+
+		ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+		p.GET("/users/:page", ok, security.Read("user"))
+		p.POST("/user", ok, security.Update("user"))
+		p.GET("/levels/:page", ok, security.Read("level"))
+
+		request := func(method, path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/"+prefix+path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Token con la versión vigente: se autoriza con sus permisos
+		current := signPermissionToken(t, []string{"user|users:r", "level|levels:r"}, 2)
+		rec := request(http.MethodGet, "/levels/1", current)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(middleware.PermissionsStaleHeader))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/user", current).Code)
+		levelRepo.AssertNotCalled(t, "GetByID", uint(1))
+
+		// Token obsoleto: se avisa y se autoriza contra la base de datos
+		stale := signPermissionToken(t, []string{"user|users:rcud"}, 1)
+		rec = request(http.MethodPost, "/user", stale)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(middleware.PermissionsStaleHeader))
+		rec = request(http.MethodGet, "/users/1", stale)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(middleware.PermissionsStaleHeader))
+
+		// Sin copia de permisos se sigue consultando la base de datos
+		rec = request(http.MethodGet, "/users/1", signPermissionToken(t, nil, 0))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(middleware.PermissionsStaleHeader))
+	})
+}
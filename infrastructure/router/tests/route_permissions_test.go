@@ -0,0 +1,146 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthorizationMiddleware_RoutePermissions(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+		{Form: model.Form{PathAPI: "smtp-config"}, Read: true},
+		{Form: model.Form{PathAPI: "user|users"}, Read: true, Write: true, Create: true, Update: true, Delete: true},
+	}}, nil)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.NewAuthorizationMiddleware(levelRepo, nil, nil, routePermissions, prefix))
+	p := api.NewProtectedGroup(r, routePermissions)
+
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	p.GET("/smtp-config", ok, security.Read("smtp-config"))
+	p.POST("/smtp-config", ok, security.Update("smtp-config"))
+	// El recurso no depende del nombre de la ruta
+	p.GET("/people/:page", ok, security.Read("user"))
+	// Ruta añadida sin declarar su permiso
+	r.GET("/undeclared", ok)
+
+	token := signPermissionToken(t, nil, 0)
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/"+prefix+path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Los formularios con una sola clave en PathAPI ya dan acceso
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/smtp-config").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/smtp-config").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/people/1").Code)
+
+	rec := request(http.MethodGet, "/undeclared")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "04 - Access denied", response["error"])
+}
+
+func TestProtectedGroup_RejectsConflictingDeclarations(t *testing.T) {
+	e := echo.New()
+	p := api.NewProtectedGroup(e.Group("/api/v1"), security.NewRoutePermissions())
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+
+	p.GET("/users", ok, security.Read("user"))
+	assert.Panics(t, func() { p.GET("/users", ok, security.Read("level")) })
+	assert.Panics(t, func() { p.POST("/user", ok, security.Update("")) })
+}
+
+func TestAuthorizationMiddleware_GranularActions(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+		{Form: model.Form{PathAPI: "user|users"}, Read: true, Update: true},
+	}}, nil)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.NewAuthorizationMiddleware(levelRepo, nil, nil, routePermissions, prefix))
+	p := api.NewProtectedGroup(r, routePermissions)
+
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	p.POST("/user", ok, security.Update("user"))
+	p.POST("/user/delete", ok, security.Delete("user"))
+	p.GET("/user/:id/export", ok, security.Export("user"))
+
+	token := signPermissionToken(t, nil, 0)
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, "/"+prefix+path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Modificar no implica eliminar ni exportar
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/user"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/user/delete"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/user/1/export"))
+}
+
+func TestAuthorizationMiddleware_SaveRoutesSplitCreateAndUpdate(t *testing.T) {
+	// El nivel 1 solo puede crear niveles y el nivel 2 solo modificarlos
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+		{Form: model.Form{PathAPI: "level|levels"}, Create: true},
+	}}, nil)
+	levelRepo.On("GetByID", uint(2)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+		{Form: model.Form{PathAPI: "level|levels"}, Update: true},
+	}}, nil)
+	levelRepo.On("GetByID", uint(5)).Return(&model.Level{Level: "Existing"}, nil)
+	levelRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.NewAuthorizationMiddleware(levelRepo, nil, nil, routePermissions, prefix))
+	api.NewLevelHandler(e, usecase.NewLevelUseCase(levelRepo)).RegisterRoutes(api.NewProtectedGroup(r, routePermissions))
+
+	request := func(levelID uint, method, body string) int {
+		claims := model.Claim{UserID: 1, LevelID: levelID, RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+		assert.NoError(t, err)
+		req := httptest.NewRequest(method, "/"+prefix+"/level", strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Crear no permite modificar
+	assert.Equal(t, http.StatusCreated, request(1, http.MethodPost, `{"level": "New"}`))
+	assert.Equal(t, http.StatusForbidden, request(1, http.MethodPut, `{"id": 5, "level": "Renamed"}`))
+	// Un POST con ID no sirve para modificar con el privilegio de crear
+	assert.Equal(t, http.StatusBadRequest, request(1, http.MethodPost, `{"id": 5, "level": "Renamed"}`))
+
+	// Modificar no permite crear
+	assert.Equal(t, http.StatusCreated, request(2, http.MethodPut, `{"id": 5, "level": "Renamed"}`))
+	assert.Equal(t, http.StatusForbidden, request(2, http.MethodPost, `{"level": "New"}`))
+	assert.Equal(t, http.StatusBadRequest, request(2, http.MethodPut, `{"level": "New"}`))
+}
@@ -0,0 +1,81 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationMiddleware_RowFilter(t *testing.T) {
+	// El nivel 1 solo ve sus propios usuarios y puede ver todos los niveles
+	newLevelRepo := func() *mocks.MockLevelRepository {
+		levelRepo := new(mocks.MockLevelRepository)
+		levelRepo.On("GetByID", uint(1)).Return(&model.Level{LevelPrivileges: []model.LevelPrivileges{
+			{FormID: 1, Form: model.Form{PathAPI: "user|users", Condition: "id == user.id"}, Read: true},
+			{FormID: 2, Form: model.Form{PathAPI: "level|levels"}, Read: true},
+		}}, nil)
+		return levelRepo
+	}
+
+	forEachLevelRepository(t, newLevelRepo, func(t *testing.T, levelRepo *mocks.MockLevelRepository, levels repository.LevelRepository) {
+		e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+		routePermissions := security.NewRoutePermissions()
+		r.Use(middleware.NewAuthorizationMiddleware(levels, nil, nil, routePermissions, prefix))
+		p := api.NewProtectedGroup(r, routePermissions)
+
+		where := func(c echo.Context) error {
+			filter, err := middleware.RowFilter(c)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, err.Error())
+			}
+			if filter == nil {
+				return c.String(http.StatusOK, "")
+			}
+			clause, args := filter.Where(func(column string) string { return column })
+			assert.Equal(t, []interface{}{uint(7)}, args)
+			return c.String(http.StatusOK, clause)
+		}
+		p.GET("/users/:page", where, security.Read("user"))
+		p.GET("/levels/:page", where, security.Read("level"))
+
+		claims := model.Claim{
+			UserID:  7,
+			LevelID: 1,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "jti",
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+		assert.NoError(t, err)
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/"+prefix+path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec := request("/users/1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "(id = ?)", rec.Body.String())
+
+		rec = request("/levels/1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+}
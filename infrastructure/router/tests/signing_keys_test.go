@@ -0,0 +1,86 @@
+package router_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEdKey(t *testing.T, id string) *security.SigningKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return &security.SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: public}
+}
+
+func TestNewEchoRouter_SelectsKeyByKid(t *testing.T) {
+	current, previous := newEdKey(t, "current"), newEdKey(t, "previous")
+	previousSigner, _ := service.NewKeyManager(previous)
+	retired := *previous
+	retired.Private = nil
+	keys, err := service.NewKeyManager(current, &retired)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	e, r, _, prefix := router.NewEchoRouter(keys, nil)
+	r.GET("/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+	api.NewJWKSHandler(e, keys).WellKnownRoutes(e)
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/"+prefix+"/ping", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	claims := model.Claim{
+		UserID:           1,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}
+
+	token, err := keys.Sign(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request(token))
+
+	token, err = previousSigner.Sign(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request(token))
+
+	// Firmado con otra clave que dice ser la activa
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	forged.Header["kid"] = "current"
+	token, _ = forged.SignedString(newEdKey(t, "other").Private)
+	assert.Equal(t, http.StatusUnauthorized, request(token))
+
+	// Los tokens HS256 con JWT_SECRET ya no se aceptan sin la clave heredada
+	assert.Equal(t, http.StatusUnauthorized, request(signToken(t, "jti", "test_secret")))
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var jwks model.JWKSet
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "current", jwks.Keys[0].Kid)
+		assert.Equal(t, "previous", jwks.Keys[1].Kid)
+	}
+}
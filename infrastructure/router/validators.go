@@ -0,0 +1,114 @@
+package router
+
+import (
+	"errors"
+	"log"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrTokenRevoked    = errors.New("token has been revoked")
+	ErrMFAPending      = errors.New("two-factor authentication has not been completed")
+	ErrSessionEnded    = errors.New("session has been closed")
+	ErrPasswordExpired = errors.New("password has expired and must be changed")
+	ErrInvitationToken = errors.New("invitation links cannot be used as access tokens")
+	ErrAccountInactive = errors.New("account is suspended or deleted")
+)
+
+// RevocationChecker es la parte del caso de uso de tokens que necesita el router
+type RevocationChecker interface {
+	IsRevoked(claims *model.Claim) (bool, error)
+}
+
+// NotRevoked rechaza los tokens que han sido revocados (logout, usuario
+// eliminado...). Si el almacén de revocación falla se rechaza el token.
+func NotRevoked(checker RevocationChecker) TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		revoked, err := checker.IsRevoked(claims)
+		if err != nil {
+			log.Printf("Failed to check token revocation: %v", err)
+			return ErrTokenRevoked
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+		return nil
+	}
+}
+
+// SessionChecker es la parte del caso de uso de sesiones que necesita el router
+type SessionChecker interface {
+	IsSessionActive(claims *model.Claim) (bool, error)
+}
+
+// SessionActive rechaza los tokens cuya sesión se ha cerrado desde otro
+// dispositivo o por un administrador
+func SessionActive(checker SessionChecker) TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		active, err := checker.IsSessionActive(claims)
+		if err != nil {
+			log.Printf("Failed to check session: %v", err)
+			return ErrSessionEnded
+		}
+		if !active {
+			return ErrSessionEnded
+		}
+		return nil
+	}
+}
+
+// AccountChecker es la parte del caso de uso de usuarios que necesita el router
+type AccountChecker interface {
+	IsAccountActive(userID uint) (bool, error)
+}
+
+// AccountActive rechaza los tokens de cuentas suspendidas o eliminadas aunque
+// todavía no hayan caducado
+func AccountActive(checker AccountChecker) TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		active, err := checker.IsAccountActive(claims.UserID)
+		if err != nil {
+			log.Printf("Failed to check account status of user %d: %v", claims.UserID, err)
+			return ErrAccountInactive
+		}
+		if !active {
+			return ErrAccountInactive
+		}
+		return nil
+	}
+}
+
+// MFACompleted rechaza los tokens de "mfa pendiente", que solo sirven para
+// completar el segundo factor en /login/mfa.
+func MFACompleted() TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		if claims.MFAPending {
+			return ErrMFAPending
+		}
+		return nil
+	}
+}
+
+// PasswordChanged rechaza los tokens de contraseña caducada, que solo sirven
+// para cambiarla en /login/password.
+func PasswordChanged() TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		if claims.PasswordExpired {
+			return ErrPasswordExpired
+		}
+		return nil
+	}
+}
+
+// NotInvitation rechaza los enlaces de invitación, que solo sirven para
+// aceptarla en /invitations/accept.
+func NotInvitation() TokenValidator {
+	return func(c echo.Context, claims *model.Claim) error {
+		if claims.InvitationID != 0 {
+			return ErrInvitationToken
+		}
+		return nil
+	}
+}
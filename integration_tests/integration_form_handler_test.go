@@ -21,7 +21,7 @@ func TestFormHandler_CreateOrUpdateForm_Integration(t *testing.T) {
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
 	formRepo := db.NewFormRepository(database)
-	formUseCase := usecase.NewFormUseCase(formRepo)
+	formUseCase := usecase.NewFormUseCase(formRepo, db.NewLevelRepository(database))
 	handler := api.NewFormHandler(e, formUseCase)
 
 	mockForm := &model.Form{
@@ -59,7 +59,7 @@ func TestFormHandler_GetAllForm_Integration(t *testing.T) {
 	utils.ResetTestDB(database, t)
 
 	formRepo := db.NewFormRepository(database)
-	formUseCase := usecase.NewFormUseCase(formRepo)
+	formUseCase := usecase.NewFormUseCase(formRepo, db.NewLevelRepository(database))
 	formHandler := api.NewFormHandler(e, formUseCase)
 
 	// Crear datos iniciales
@@ -91,7 +91,7 @@ func TestFormHandler_PaginateForms_Integration(t *testing.T) {
 	utils.ResetTestDB(database, t)
 
 	formRepo := db.NewFormRepository(database)
-	formUseCase := usecase.NewFormUseCase(formRepo)
+	formUseCase := usecase.NewFormUseCase(formRepo, db.NewLevelRepository(database))
 	formHandler := api.NewFormHandler(e, formUseCase)
 
 	// Crear datos iniciales
@@ -128,7 +128,7 @@ func TestFormHandler_DeleteForm_Integration(t *testing.T) {
 	utils.ResetTestDB(database, t)
 
 	formRepo := db.NewFormRepository(database)
-	formUseCase := usecase.NewFormUseCase(formRepo)
+	formUseCase := usecase.NewFormUseCase(formRepo, db.NewLevelRepository(database))
 	formHandler := api.NewFormHandler(e, formUseCase)
 
 	// Crear un formulario inicial
@@ -0,0 +1,119 @@
+package integration_tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationHandler_Impersonate_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	// Soporte puede suplantar y gestionar usuarios; el usuario normal solo
+	// puede leer usuarios
+	supportLevel := &model.Level{Level: "Support", Description: "Support"}
+	guestLevel := &model.Level{Level: "Guest", Description: "Guest"}
+	database.Create(supportLevel)
+	database.Create(guestLevel)
+	impersonateForm := &model.Form{Title: "Impersonate", PathAPI: "impersonate|impersonations"}
+	userForm := &model.Form{Title: "Users", PathAPI: "user|users"}
+	database.Create(impersonateForm)
+	database.Create(userForm)
+	database.Create(&model.LevelPrivileges{LevelID: supportLevel.ID, FormID: impersonateForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true})
+	database.Create(&model.LevelPrivileges{LevelID: supportLevel.ID, FormID: userForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true})
+	database.Create(&model.LevelPrivileges{LevelID: guestLevel.ID, FormID: userForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true})
+
+	support := &model.User{Username: "support", Email: "support@example.com", FullName: "Support", Password: "hash", LevelID: supportLevel.ID}
+	user := &model.User{Username: "user", Email: "user@example.com", FullName: "User", Password: "hash", LevelID: guestLevel.ID}
+	database.Create(support)
+	database.Create(user)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.MFACompleted())
+	r.Use(middleware.ImpersonationAudit())
+	s := r.Group("", middleware.SessionOnly())
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.ImpersonationGuard(routePermissions))
+	r.Use(middleware.NewAuthorizationMiddleware(db.NewLevelRepository(database), db.NewFormRepository(database), db.NewLevelPrivilegesRepository(database), routePermissions, prefix))
+	p := api.NewProtectedGroup(r, routePermissions)
+
+	s.POST("/me/password", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{"status": 200})
+	}, middleware.DenyImpersonation())
+	api.NewImpersonationHandler(e, usecase.NewImpersonationUseCase(db.NewUserRepository(database), service.NewPermissionResolver(db.NewLevelRepository(database)), 5*time.Minute)).RegisterRoutes(p)
+	newUserHandler(e, database).RegisterRoutes(p)
+
+	do := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/"+prefix+path, bytes.NewBuffer(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	supportToken, _ := helpers.GenerateJWT(support, time.Hour)
+	userToken, _ := helpers.GenerateJWT(user, time.Hour)
+
+	// Sin el privilegio no se puede suplantar
+	rec := do(http.MethodPost, "/impersonate", userToken, map[string]uint{"id": support.ID})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do(http.MethodPost, "/impersonate", supportToken, map[string]uint{"id": user.ID})
+	var impersonation struct {
+		Data model.ImpersonationToken `json:"data"`
+	}
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		t.FailNow()
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &impersonation))
+	token := impersonation.Data.AccessToken
+
+	// GET /user devuelve al usuario suplantado con el aviso
+	rec = do(http.MethodGet, "/user", token, nil)
+	var current model.User
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &current))
+		assert.Equal(t, user.ID, current.ID)
+		assert.True(t, current.Impersonated)
+		if assert.NotNil(t, current.ImpersonatedBy) {
+			assert.Equal(t, support.ID, current.ImpersonatedBy.UserID)
+		}
+	}
+
+	// El token de soporte no lleva el aviso
+	rec = do(http.MethodGet, "/user", supportToken, nil)
+	assert.NotContains(t, rec.Body.String(), "impersonated")
+
+	// No se pueden cambiar contraseñas ni usuarios mientras se suplanta
+	rec = do(http.MethodPost, "/me/password", token, map[string]string{"password": "x"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = do(http.MethodPost, "/user", token, map[string]interface{}{"id": user.ID, "LevelID": supportLevel.ID})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	// Las rutas protegidas lo declaran en el registro de permisos
+	rec = do(http.MethodPost, "/user/unlock", token, map[string]uint{"id": support.ID})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "impersonating")
+	rec = do(http.MethodPost, "/me/password", supportToken, map[string]string{"password": "x"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
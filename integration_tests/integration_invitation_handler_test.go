@@ -0,0 +1,124 @@
+package integration_tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInvitationHandler_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	// El administrador gestiona usuarios; el lector solo puede consultarlos
+	adminLevel := &model.Level{Level: "Admin", Description: "Admin"}
+	readerLevel := &model.Level{Level: "Reader", Description: "Reader"}
+	database.Create(adminLevel)
+	database.Create(readerLevel)
+	userForm := &model.Form{Title: "Users", PathAPI: "user|users"}
+	database.Create(userForm)
+	database.Create(&model.LevelPrivileges{LevelID: adminLevel.ID, FormID: userForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true})
+	database.Create(&model.LevelPrivileges{LevelID: readerLevel.ID, FormID: userForm.ID, Read: true})
+
+	admin := &model.User{Username: "admin", Email: "admin@example.com", FullName: "Admin", Password: "hash", LevelID: adminLevel.ID}
+	reader := &model.User{Username: "reader", Email: "reader@example.com", FullName: "Reader", Password: "hash", LevelID: readerLevel.ID}
+	database.Create(admin)
+	database.Create(reader)
+
+	mailer := new(mocks.MockMailer)
+	mailer.On("SendTemplateTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	hasher := utils.NewTestPasswordHasher()
+	passwordPolicy := utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher)
+	invitationUseCase := usecase.NewInvitationUseCase(db.NewInvitationRepository(database), db.NewUserRepository(database), db.NewLevelRepository(database), passwordPolicy, mailer, "https://intranet.test/accept-invitation", time.Hour, service.NewPermissionResolver(db.NewLevelRepository(database)))
+
+	e, r, a, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.NotInvitation())
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.NewAuthorizationMiddleware(db.NewLevelRepository(database), db.NewFormRepository(database), db.NewLevelPrivilegesRepository(database), routePermissions, prefix))
+	p := api.NewProtectedGroup(r, routePermissions)
+	handler := api.NewInvitationHandler(e, invitationUseCase)
+	handler.AuthRoutes(a)
+	handler.RegisterRoutes(p)
+	newUserHandler(e, database).RegisterRoutes(p)
+
+	do := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/"+prefix+path, bytes.NewBuffer(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	adminToken, _ := helpers.GenerateJWT(admin, time.Hour)
+	readerToken, _ := helpers.GenerateJWT(reader, time.Hour)
+	request := model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: readerLevel.ID}
+
+	// Invitar requiere escribir sobre usuarios
+	rec := do(http.MethodPost, "/user/invitations", readerToken, request)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do(http.MethodPost, "/user/invitations", adminToken, request)
+	if !assert.Equal(t, http.StatusCreated, rec.Code) {
+		t.FailNow()
+	}
+	var created struct {
+		Data model.Invitation `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Empty(t, created.Data.TokenHash)
+
+	rec = do(http.MethodPost, "/user/invitations", adminToken, request)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(http.MethodGet, "/user/invitations", readerToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "new@example.com")
+
+	rec = do(http.MethodPost, fmt.Sprintf("/user/invitations/%d/resend", created.Data.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	data := mailer.Calls[len(mailer.Calls)-1].Arguments.Get(3).(map[string]interface{})
+	link, _ := url.Parse(data["Link"].(string))
+	token := link.Query().Get("token")
+
+	// El enlace de invitación no sirve como token de acceso
+	rec = do(http.MethodGet, "/user/invitations", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(http.MethodPost, "/invitations/accept", "", map[string]string{"token": token, "password": "qwerty", "confirmPassword": "qwerty"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "fields")
+
+	rec = do(http.MethodPost, "/invitations/accept", "", map[string]string{"token": token, "password": "new-password", "confirmPassword": "new-password"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"password"`)
+
+	rec = do(http.MethodDelete, fmt.Sprintf("/user/invitations/%d", created.Data.ID), adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
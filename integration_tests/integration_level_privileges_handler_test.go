@@ -21,7 +21,7 @@ func TestLevelPrivilegesHandler_CreateOrUpdateLevelPrivileges_Integration(t *tes
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
 	LevelPrivilegesRepo := db.NewLevelPrivilegesRepository(database)
-	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo)
+	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo, db.NewLevelRepository(database))
 	handler := api.NewLevelPrivilegesHandler(e, LevelPrivilegesUseCase)
 
 	mockLevelPrivilege := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
@@ -49,7 +49,7 @@ func TestLevelPrivilegesHandler_GetAllLevelPrivileges_Integration(t *testing.T)
 	utils.ResetTestDB(database, t)
 
 	LevelPrivilegesRepo := db.NewLevelPrivilegesRepository(database)
-	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo)
+	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo, db.NewLevelRepository(database))
 	LevelPrivilegesHandler := api.NewLevelPrivilegesHandler(e, LevelPrivilegesUseCase)
 
 	// Crear datos iniciales
@@ -81,7 +81,7 @@ func TestLevelPrivilegesHandler_DeleteLevelPrivileges_Integration(t *testing.T)
 	utils.ResetTestDB(database, t)
 
 	LevelPrivilegesRepo := db.NewLevelPrivilegesRepository(database)
-	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo)
+	LevelPrivilegesUseCase := usecase.NewLevelPrivilegesUseCase(LevelPrivilegesRepo, db.NewLevelRepository(database))
 	LevelPrivilegesHandler := api.NewLevelPrivilegesHandler(e, LevelPrivilegesUseCase)
 
 	// Crear un item inicial
@@ -0,0 +1,99 @@
+package integration_tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCHandler_LoginFlow_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	assert.NoError(t, database.Create(&model.Level{Level: "Invitado", Description: "Invitado"}).Error)
+
+	idp := utils.NewFakeIdP(t, "core-api")
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "email_verified": true, "name": "Jane Doe"}
+	provider := adapters.NewOIDCProvider(adapters.OIDCConfig{
+		Issuer:      idp.Issuer(),
+		ClientID:    idp.ClientID,
+		RedirectURL: "http://localhost/api/v1/oidc/callback",
+	})
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(database), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	oidcUseCase := usecase.NewOIDCUseCase(provider, db.NewOIDCStateRepository(database), userRepo, db.NewLevelRepository(database), utils.NewTestPasswordHasher(), tokenUseCase, mfaUseCase, usecase.OIDCLevelMapping{DefaultLevel: "Invitado"}, 10*time.Minute)
+
+	e := echo.New()
+	api.NewOIDCHandler(e, oidcUseCase).AuthRoutes(e.Group("/api/v1"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if !assert.Equal(t, http.StatusFound, rec.Code) {
+		t.FailNow()
+	}
+
+	code, state := idp.Authorize(t, rec.Header().Get(echo.HeaderLocation))
+	callback := "/api/v1/oidc/callback?" + url.Values{"code": {code}, "state": {state}}.Encode()
+
+	req = httptest.NewRequest(http.MethodGet, callback, nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data model.TokenPair `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Data.AccessToken)
+	assert.NotEmpty(t, response.Data.RefreshToken)
+
+	// El state ya se ha usado
+	req = httptest.NewRequest(http.MethodGet, callback, nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Si el nivel exige 2FA se devuelve el token para activarlo en lugar de
+	// los tokens de la sesión
+	assert.NoError(t, database.Model(&model.Level{}).Where("level = ?", "Invitado").Update("require_mfa", true).Error)
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	code, state = idp.Authorize(t, rec.Header().Get(echo.HeaderLocation))
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var challenge struct {
+		Data model.MFAChallenge `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+	assert.True(t, challenge.Data.EnrollmentRequired)
+	assert.NotEmpty(t, challenge.Data.MFAToken)
+	assert.NotContains(t, rec.Body.String(), "refresh_token")
+
+	// El usuario canceló en el proveedor
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?error=access_denied", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,121 @@
+package integration_tests_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivacyHandler_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	adminLevel := &model.Level{Level: "Admin", Description: "Admin"}
+	readerLevel := &model.Level{Level: "Reader", Description: "Reader"}
+	database.Create(adminLevel)
+	database.Create(readerLevel)
+	userForm := &model.Form{Title: "Users", PathAPI: "user|users"}
+	database.Create(userForm)
+	database.Create(&model.LevelPrivileges{LevelID: adminLevel.ID, FormID: userForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true})
+	database.Create(&model.LevelPrivileges{LevelID: readerLevel.ID, FormID: userForm.ID, Read: true})
+
+	admin := &model.User{Username: "admin", Email: "admin@example.com", FullName: "Admin", Password: "hash", LevelID: adminLevel.ID}
+	reader := &model.User{Username: "reader", Email: "reader@example.com", FullName: "Reader", Password: "hash", LevelID: readerLevel.ID}
+	database.Create(admin)
+	database.Create(reader)
+
+	userRepo := db.NewUserRepository(database)
+	sessionRepo := db.NewSessionRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	privacyUseCase := usecase.NewPrivacyUseCase(userRepo, sessionRepo, db.NewAPIKeyRepository(database), db.NewInvitationRepository(database), db.NewAuditRepository(database), tokenUseCase, adapters.NewLocalStorage(t.TempDir(), "/uploads"))
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil)
+	s := r.Group("")
+	routePermissions := security.NewRoutePermissions()
+	r.Use(middleware.NewAuthorizationMiddleware(db.NewLevelRepository(database), db.NewFormRepository(database), db.NewLevelPrivilegesRepository(database), routePermissions, prefix))
+	handler := api.NewPrivacyHandler(e, privacyUseCase)
+	handler.SessionRoutes(s)
+	handler.RegisterRoutes(api.NewProtectedGroup(r, routePermissions))
+
+	do := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/"+prefix+path, bytes.NewBuffer(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	adminToken, _ := helpers.GenerateJWT(admin, time.Hour)
+	readerToken, _ := helpers.GenerateJWT(reader, time.Hour)
+
+	// Cada usuario puede descargar sus propios datos
+	rec := do(http.MethodGet, "/me/export", readerToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), fmt.Sprintf("user-%d-export.json", reader.ID))
+	assert.Contains(t, rec.Body.String(), "reader@example.com")
+	assert.NotContains(t, rec.Body.String(), `"password"`)
+
+	// Leer usuarios no permite exportar los datos de otro usuario
+	rec = do(http.MethodGet, fmt.Sprintf("/user/%d/export", admin.ID), readerToken, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do(http.MethodGet, fmt.Sprintf("/user/%d/export?format=zip", reader.ID), adminToken, nil)
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		t.FailNow()
+	}
+	assert.Equal(t, "application/zip", rec.Header().Get(echo.HeaderContentType))
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"profile.json", "sessions.json", "api_keys.json", "invitations.json", "audit_entries.json"}, names)
+
+	rec = do(http.MethodGet, fmt.Sprintf("/user/%d/export?format=xml", reader.ID), adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Suprimir requiere escribir sobre usuarios
+	rec = do(http.MethodPost, "/user/erase", readerToken, model.UserErasureRequest{ID: admin.ID})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do(http.MethodPost, "/user/erase", adminToken, model.UserErasureRequest{ID: admin.ID})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, "/user/erase", adminToken, model.UserErasureRequest{ID: reader.ID, Reason: "Solicitud 42"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// El usuario suprimido sigue existiendo, pero ya sin datos personales
+	rec = do(http.MethodGet, fmt.Sprintf("/user/%d/export", reader.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "reader@example.com")
+
+	rec = do(http.MethodGet, "/user/9999/export", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
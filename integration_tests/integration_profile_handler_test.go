@@ -0,0 +1,70 @@
+package integration_tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileHandler_ChangePasswordReturnsUsableTokens_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	hasher := utils.NewTestPasswordHasher()
+	password, _ := hasher.Hash("password")
+	user := &model.User{Username: "testuser", Email: "test@example.com", FullName: "Test User", Password: password}
+	assert.NoError(t, database.Create(user).Error)
+
+	userRepo := db.NewUserRepository(database)
+	sessionRepo := db.NewSessionRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, userRepo, tokenUseCase, 24*time.Hour)
+	passwordPolicy := utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher)
+	profileUseCase := usecase.NewProfileUseCase(userRepo, hasher, passwordPolicy, tokenUseCase, adapters.NewLocalStorage(t.TempDir(), "/uploads"), 1024*1024)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.NotRevoked(tokenUseCase), router.SessionActive(sessionUseCase))
+	api.NewProfileHandler(e, profileUseCase).SessionRoutes(r)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/"+prefix+path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tokens, err := tokenUseCase.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	rec := do(http.MethodPost, "/me/password", tokens.AccessToken, `{"currentPassword": "password", "password": "n3w-s3cret", "confirmPassword": "n3w-s3cret"}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	var response struct {
+		Data model.TokenPair `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	// El par devuelto sirve de inmediato, aunque se emita en el mismo segundo
+	// que la revocación, y el token anterior deja de aceptarse
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", response.Data.AccessToken, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", tokens.AccessToken, "").Code)
+}
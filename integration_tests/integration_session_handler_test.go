@@ -0,0 +1,86 @@
+package integration_tests_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/infrastructure/router"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionHandler_ListAndRevoke_Integration(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+
+	userRepo := db.NewUserRepository(database)
+	sessionRepo := db.NewSessionRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, userRepo, tokenUseCase, 24*time.Hour)
+
+	e, r, _, prefix := router.NewEchoRouter(utils.NewTestTokenKeys("test_secret"), nil, router.SessionActive(sessionUseCase))
+	handler := api.NewSessionHandler(e, sessionUseCase)
+	handler.SessionRoutes(r)
+	handler.RegisterRoutes(api.NewProtectedGroup(r, security.NewRoutePermissions()))
+	// Las rutas de sesiones conviven con la paginación de usuarios
+	r.GET("/users/:page", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Param("page"))
+	})
+
+	user := &model.User{Username: "testuser", Email: "test@example.com", FullName: "Test User", Password: "password"}
+	database.Create(user)
+
+	laptop, err := tokenUseCase.IssueTokens(user, model.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	phone, err := tokenUseCase.IssueTokens(user, model.ClientInfo{UserAgent: "Safari", IP: "10.0.0.2"})
+	assert.NoError(t, err)
+	phoneClaims, _ := helpers.ParseJWT(phone.AccessToken)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/"+prefix+path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/me/sessions", laptop.AccessToken)
+	var listResponse struct {
+		Data []model.Session `json:"data"`
+	}
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listResponse))
+		assert.Len(t, listResponse.Data, 2)
+	}
+
+	rec = do(http.MethodGet, "/users/2", laptop.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Body.String())
+
+	rec = do(http.MethodGet, fmt.Sprintf("/users/%d/sessions", user.ID), laptop.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(http.MethodDelete, fmt.Sprintf("/me/sessions/%d", phoneClaims.SessionID), laptop.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// El token del dispositivo cerrado deja de aceptarse
+	rec = do(http.MethodGet, "/me/sessions", phone.AccessToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(http.MethodDelete, fmt.Sprintf("/users/%d/sessions/%d", user.ID, phoneClaims.SessionID), laptop.AccessToken)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/service"
 	"github.com/drossan/core-api/utils"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -18,15 +19,23 @@ import (
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
+// newUserHandler construye el handler de usuarios con repositorios reales sobre la base de datos de test
+func newUserHandler(e *echo.Echo, database *gorm.DB) *api.UserHandler {
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(database), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	userUseCase := usecase.NewUserUseCase(userRepo, utils.NewTestPasswordHasher(), utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), utils.NewTestPasswordHasher()), tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), service.NewPermissionResolver(db.NewLevelRepository(database)))
+	return api.NewUserHandler(e, userUseCase, tokenUseCase)
+}
+
 func TestUserHandler_CreateOrUpdateUser_Integration(t *testing.T) {
 	e := echo.New()
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
-	UserRepo := db.NewUserRepository(database)
-	UserUseCase := usecase.NewUserUseCase(UserRepo)
-	handler := api.NewUserHandler(e, UserUseCase)
+	handler := newUserHandler(e, database)
 
 	mockUser := &model.User{
 		Username: "testuser",
@@ -51,8 +60,9 @@ func TestUserHandler_CreateOrUpdateUser_Integration(t *testing.T) {
 		assert.Equal(t, mockUser.FullName, actualResponse.FullName)
 
 		// Verificar la contraseña hasheada
-		err = bcrypt.CompareHashAndPassword([]byte(actualResponse.Password), []byte(mockUser.Password))
-		assert.NoError(t, err, "The password should be hashed correctly and match the original password")
+		ok, err := utils.NewTestPasswordHasher().Verify(actualResponse.Password, mockUser.Password)
+		assert.NoError(t, err)
+		assert.True(t, ok, "The password should be hashed correctly and match the original password")
 	}
 }
 
@@ -62,9 +72,7 @@ func TestUserHandler_PaginateUsers_Integration(t *testing.T) {
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
 
-	UserRepo := db.NewUserRepository(database)
-	UserUseCase := usecase.NewUserUseCase(UserRepo)
-	UserHandler := api.NewUserHandler(e, UserUseCase)
+	UserHandler := newUserHandler(e, database)
 
 	// Crear datos iniciales
 	Users := []model.User{
@@ -114,9 +122,7 @@ func TestUserHandler_GetUserData_Integration(t *testing.T) {
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
 
-	UserRepo := db.NewUserRepository(database)
-	UserUseCase := usecase.NewUserUseCase(UserRepo)
-	UserHandler := api.NewUserHandler(e, UserUseCase)
+	UserHandler := newUserHandler(e, database)
 
 	// Crear un item inicial
 	mockUser := &model.User{
@@ -168,9 +174,7 @@ func TestUserHandler_DeleteUser_Integration(t *testing.T) {
 	database := utils.SetupTestDB(t)
 	utils.ResetTestDB(database, t)
 
-	UserRepo := db.NewUserRepository(database)
-	UserUseCase := usecase.NewUserUseCase(UserRepo)
-	UserHandler := api.NewUserHandler(e, UserUseCase)
+	UserHandler := newUserHandler(e, database)
 
 	// Crear un item inicial
 	mockUser := &model.User{
@@ -200,3 +204,57 @@ func TestUserHandler_DeleteUser_Integration(t *testing.T) {
 		assert.Equal(t, int64(0), count)
 	}
 }
+
+func TestUserHandler_LoginAndRefresh_Integration(t *testing.T) {
+	e := echo.New()
+
+	_ = os.Setenv("JWT_SECRET", "test_secret")
+	defer func() {
+		_ = os.Unsetenv("JWT_SECRET")
+	}()
+
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+
+	UserHandler := newUserHandler(e, database)
+
+	password, _ := utils.NewTestPasswordHasher().Hash("password")
+	database.Create(&model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		FullName: "Test User",
+		Password: password,
+	})
+
+	credentialsJSON, _ := json.Marshal(map[string]string{"email": "test@example.com", "password": "password"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(credentialsJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var loginResponse struct {
+		Data model.TokenPair `json:"data"`
+	}
+	if assert.NoError(t, UserHandler.Login(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &loginResponse))
+		assert.NotEmpty(t, loginResponse.Data.AccessToken)
+		assert.NotEmpty(t, loginResponse.Data.RefreshToken)
+	}
+
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, UserHandler.Refresh(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec = refresh(loginResponse.Data.RefreshToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// El token original ya se ha rotado y no puede volver a usarse
+	rec = refresh(loginResponse.Data.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHandler manages the API keys used by scripts and internal services
+type APIKeyHandler struct {
+	apiKeyUseCase *usecase.APIKeyUseCase
+}
+
+// NewAPIKeyHandler initializes a new APIKeyHandler
+func NewAPIKeyHandler(e *echo.Echo, uc *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyUseCase: uc}
+}
+
+// SessionRoutes registra la gestión de las claves del propio usuario
+func (h *APIKeyHandler) SessionRoutes(g *echo.Group) {
+	g.GET("/me/api-keys", h.ListAPIKeys)
+	g.POST("/me/api-keys", h.CreateAPIKey, middleware.DenyImpersonation())
+	g.POST("/me/api-keys/revoke", h.RevokeAPIKey)
+}
+
+// RegisterRoutes registra las rutas que requieren privilegios
+func (h *APIKeyHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.POST("/user/api-keys/revoke", h.RevokeUserAPIKeys, security.Update("user").WithoutImpersonation())
+}
+
+// ListAPIKeys godoc
+// @Summary List own API keys
+// @Description List the API keys of the current user. The keys themselves are never returned again.
+// @Tags me
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	keys, err := h.apiKeyUseCase.List(helpers.GetCurrentUser(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   keys,
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a named, scoped and expiring API key for the current user. Scopes look like "user:read" or "level:write" and cannot exceed the privileges of the user's level. The key is only shown in this response.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param key body model.APIKeyRequest true "API key"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	request := new(model.APIKeyRequest)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	created, err := h.apiKeyUseCase.Create(helpers.GetCurrentUser(c), request)
+	switch {
+	case errors.Is(err, usecase.ErrAPIKeyScopeNotAllowed):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrAPIKeyNameRequired),
+		errors.Is(err, usecase.ErrAPIKeyScopesRequired),
+		errors.Is(err, usecase.ErrInvalidAPIKeyScope),
+		errors.Is(err, usecase.ErrInvalidAPIKeyExpiry):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"status": 201,
+		"data":   created,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an own API key
+// @Description Revoke one of the API keys of the current user
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param key body model.APIKey true "API key"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/api-keys/revoke [post]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	key := new(model.APIKey)
+	if err := c.Bind(key); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	err := h.apiKeyUseCase.Revoke(helpers.GetCurrentUser(c), key.ID)
+	if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// RevokeUserAPIKeys godoc
+// @Summary Revoke the API keys of a user
+// @Description Revoke every API key of a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/api-keys/revoke [post]
+func (h *APIKeyHandler) RevokeUserAPIKeys(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.apiKeyUseCase.RevokeAllForUser(user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// AuthorizationHandler exposes the state of the authorization layer
+type AuthorizationHandler struct {
+	authorizationUseCase *usecase.AuthorizationUseCase
+}
+
+// NewAuthorizationHandler initializes a new AuthorizationHandler
+func NewAuthorizationHandler(e *echo.Echo, uc *usecase.AuthorizationUseCase) *AuthorizationHandler {
+	return &AuthorizationHandler{authorizationUseCase: uc}
+}
+
+// SessionRoutes registra los privilegios del propio usuario
+func (h *AuthorizationHandler) SessionRoutes(g *echo.Group) {
+	g.GET("/me/permissions", h.GetMyPermissions)
+	g.POST("/authz/check", h.Check)
+}
+
+// RegisterRoutes registers authorization routes
+func (h *AuthorizationHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/authz/cache", h.GetCacheStats, security.Read("authz"))
+}
+
+// GetCacheStats godoc
+// @Summary Get authorization cache metrics
+// @Description Hits, misses, evictions and size of the in-process cache of level privileges. enabled is false when the cache is disabled
+// @Tags authorization
+// @Produce json
+// @Success 200 {object} model.AuthorizationCacheStats
+// @Router /authz/cache [get]
+func (h *AuthorizationHandler) GetCacheStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{"status": http.StatusOK, "data": h.authorizationUseCase.CacheStats()})
+}
+
+// GetMyPermissions godoc
+// @Summary Get own effective permissions
+// @Description Actions that the levels of the current user, and the levels they inherit from, allow on each form. condition is the row access condition of the form
+// @Tags me
+// @Produce json
+// @Success 200 {array} model.EffectivePermission
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/permissions [get]
+func (h *AuthorizationHandler) GetMyPermissions(c echo.Context) error {
+	permissions, err := h.authorizationUseCase.EffectivePermissions(helpers.GetCurrentClaims(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, echo.Map{"status": http.StatusOK, "data": permissions})
+}
+
+// Check godoc
+// @Summary Check several permissions at once
+// @Description Answer each (resource, action) pair with the same decision the API makes for its routes. resource is the permission key declared by the routes ("user", "level"...) and action one of read, create, update, delete, export or approve. reason is "privilege" or "scope" when not allowed. At most 100 checks per request
+// @Tags authorization
+// @Accept json
+// @Produce json
+// @Param checks body model.AuthorizationCheckRequest true "Checks"
+// @Success 200 {array} model.AuthorizationCheckResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /authz/check [post]
+func (h *AuthorizationHandler) Check(c echo.Context) error {
+	var request model.AuthorizationCheckRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request body"})
+	}
+
+	results, stale, err := h.authorizationUseCase.Check(helpers.GetCurrentClaims(c), request.Checks)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAuthorizationCheck) || errors.Is(err, usecase.ErrTooManyAuthorizationChecks) {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+	if stale {
+		c.Response().Header().Set(middleware.PermissionsStaleHeader, "true")
+	}
+	return c.JSON(http.StatusOK, echo.Map{"status": http.StatusOK, "data": results})
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 )
@@ -17,31 +20,48 @@ func NewFormHandler(e *echo.Echo, uc *usecase.FormUseCase) *FormHandler {
 	return &FormHandler{formUseCase: uc}
 }
 
-func (h *FormHandler) RegisterRoutes(g *echo.Group) {
-	g.POST("/form", h.CreateOrUpdateForm)
-	g.GET("/forms", h.GetAllForms)
-	g.GET("/forms/:page", h.PaginateForms)
-	g.POST("/form/delete", h.DeleteForm)
+func (h *FormHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.POST("/form", h.CreateOrUpdateForm, security.Create("form").WithoutImpersonation())
+	g.PUT("/form", h.CreateOrUpdateForm, security.Update("form").WithoutImpersonation())
+	g.GET("/forms", h.GetAllForms, security.Read("form"))
+	g.GET("/forms/:page", h.PaginateForms, security.Read("form"))
+	g.POST("/form/delete", h.DeleteForm, security.Delete("form").WithoutImpersonation())
 }
 
 // CreateOrUpdateForm godoc
 // @Summary Create or update a form
-// @Description Create or update a form with the input payload
+// @Description Create a form with POST, without an ID, or update the form with the given ID with PUT. Each method requires its own privilege (create or update). condition restricts the records the levels with privileges on the form can access, e.g. "owner_id == user.id && status != 'deleted'": columns are compared with == or != against user.id, user.email, user.level_id or literals, combined with &&, || and parentheses. The columns must exist in the tables of the resources in path_api. An empty condition does not restrict access. Saving a record that would not meet the condition is rejected with 403.
 // @Tags forms
 // @Accept json
 // @Produce json
 // @Param form body model.Form true "Form"
 // @Success 201 {object} model.Form
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /form [post]
+// @Router /form [put]
 func (h *FormHandler) CreateOrUpdateForm(c echo.Context) error {
 	form := new(model.Form)
 	if err := c.Bind(form); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
+	if err := checkSaveMethod(c, form.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
 
-	err := h.formUseCase.CreateOrUpdateForm(form)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.formUseCase.CreateOrUpdateForm(form, filter)
+	if errors.Is(err, usecase.ErrInvalidFormCondition) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -59,7 +79,12 @@ func (h *FormHandler) CreateOrUpdateForm(c echo.Context) error {
 // @Failure 500 {object} map[string]interface{}
 // @Router /forms [get]
 func (h *FormHandler) GetAllForms(c echo.Context) error {
-	forms, err := h.formUseCase.GetAllForms()
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	forms, err := h.formUseCase.GetAllForms(filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -90,7 +115,12 @@ func (h *FormHandler) PaginateForms(c echo.Context) error {
 		rows = 50
 	}
 
-	forms, total, err := h.formUseCase.PaginateForms(page, rows)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	forms, total, err := h.formUseCase.PaginateForms(page, rows, filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -110,6 +140,7 @@ func (h *FormHandler) PaginateForms(c echo.Context) error {
 // @Param form body model.Form true "Form"
 // @Success 200 {object} model.Form
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /form/delete [post]
 func (h *FormHandler) DeleteForm(c echo.Context) error {
@@ -118,7 +149,15 @@ func (h *FormHandler) DeleteForm(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	err := h.formUseCase.DeleteForm(form)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.formUseCase.DeleteForm(form, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
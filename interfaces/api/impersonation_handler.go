@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonationHandler lets support staff act as another user
+type ImpersonationHandler struct {
+	impersonationUseCase *usecase.ImpersonationUseCase
+}
+
+// NewImpersonationHandler initializes a new ImpersonationHandler
+func NewImpersonationHandler(e *echo.Echo, uc *usecase.ImpersonationUseCase) *ImpersonationHandler {
+	return &ImpersonationHandler{impersonationUseCase: uc}
+}
+
+// RegisterRoutes registra las rutas que requieren el privilegio de suplantación
+func (h *ImpersonationHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.POST("/"+usecase.ImpersonationPath, h.Impersonate, security.Create("impersonate").WithoutImpersonation())
+}
+
+// Impersonate godoc
+// @Summary Log in as another user
+// @Description Issue a short-lived access token for the user, carrying the caller in the act claim. There is no refresh token; call /logout to end the impersonation. Passwords, 2FA, API keys, levels and privileges cannot be changed with this token. Users who can impersonate, or who have privileges the caller does not have, cannot be impersonated. The row conditions of the impersonate form apply to the target user.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User (only id is used)"
+// @Success 200 {object} model.ImpersonationToken
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	token, err := h.impersonationUseCase.Start(helpers.GetCurrentClaims(c), user.ID, filter)
+	switch {
+	case errors.Is(err, usecase.ErrImpersonationNotAllowed),
+		errors.Is(err, usecase.ErrRowAccessDenied),
+		errors.Is(err, usecase.ErrCannotImpersonateSelf),
+		errors.Is(err, usecase.ErrCannotImpersonateStaff),
+		errors.Is(err, usecase.ErrCannotImpersonateHigher):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrImpersonationTarget):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   token,
+	})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// InvitationHandler invites new users by email instead of choosing their password
+type InvitationHandler struct {
+	invitationUseCase *usecase.InvitationUseCase
+}
+
+// NewInvitationHandler initializes a new InvitationHandler
+func NewInvitationHandler(e *echo.Echo, uc *usecase.InvitationUseCase) *InvitationHandler {
+	return &InvitationHandler{invitationUseCase: uc}
+}
+
+// AuthRoutes registra la aceptación de la invitación, que es pública
+func (h *InvitationHandler) AuthRoutes(g *echo.Group) {
+	g.POST("/invitations/accept", h.AcceptInvitation)
+}
+
+// RegisterRoutes registra las rutas que requieren privilegios sobre usuarios;
+// sus condiciones de acceso a filas también se aplican a las invitaciones
+func (h *InvitationHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/user/invitations", h.ListInvitations, security.Read("user"))
+	g.POST("/user/invitations", h.CreateInvitation, security.Create("user").WithoutImpersonation())
+	g.POST("/user/invitations/:id/resend", h.ResendInvitation, security.Update("user").WithoutImpersonation())
+	g.DELETE("/user/invitations/:id", h.RevokeInvitation, security.Delete("user").WithoutImpersonation())
+}
+
+// CreateInvitation godoc
+// @Summary Invite a user
+// @Description Create a pending user with a level and email them a signed, expiring link to choose their password. The level cannot have privileges the inviter does not have, and the invitation must meet the row access conditions on users.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param invitation body model.InvitationRequest true "Invitation"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c echo.Context) error {
+	request := new(model.InvitationRequest)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	invitation, err := h.invitationUseCase.Invite(helpers.GetCurrentUser(c), request, filter)
+	switch {
+	case errors.Is(err, usecase.ErrInvitationEmailRequired),
+		errors.Is(err, usecase.ErrInvitationFullNameRequired),
+		errors.Is(err, usecase.ErrInvitationLevelNotFound):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrLevelNotGrantable), errors.Is(err, usecase.ErrRowAccessDenied):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvitationEmailInUse),
+		errors.Is(err, usecase.ErrInvitationAlreadyPending):
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"status": 201,
+		"data":   invitation,
+	})
+}
+
+// ListInvitations godoc
+// @Summary List pending invitations
+// @Description List the invitations that have not been accepted or revoked, including the expired ones, that meet the row access conditions on users
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/invitations [get]
+func (h *InvitationHandler) ListInvitations(c echo.Context) error {
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	invitations, err := h.invitationUseCase.List(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   invitations,
+	})
+}
+
+// ResendInvitation godoc
+// @Summary Resend an invitation
+// @Description Email a new link for a pending invitation with a renewed expiry. Previous links stop working.
+// @Tags users
+// @Produce json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/invitations/{id}/resend [post]
+func (h *InvitationHandler) ResendInvitation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid invitation ID"})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	invitation, err := h.invitationUseCase.Resend(uint(id), filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrInvitationNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   invitation,
+	})
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Cancel a pending invitation so its link can no longer be used
+// @Tags users
+// @Produce json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/invitations/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid invitation ID"})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.invitationUseCase.Revoke(uint(id), filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrInvitationNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Create the invited account with the token of the invitation link and a password that meets the password policy. The user can then log in.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param invitation body model.InvitationAcceptance true "Token and password"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /invitations/accept [post]
+func (h *InvitationHandler) AcceptInvitation(c echo.Context) error {
+	request := new(model.InvitationAcceptance)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	user, err := h.invitationUseCase.Accept(request)
+	var policyErr *usecase.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return passwordRejected(c, policyErr)
+	case errors.Is(err, usecase.ErrInvalidInvitation),
+		errors.Is(err, usecase.ErrPasswordMismatch):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"status": 201,
+		"data":   withoutPassword(user),
+	})
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/drossan/core-api/service"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKSHandler publishes the public keys used to sign the API tokens
+type JWKSHandler struct {
+	keyManager *service.KeyManager
+}
+
+// NewJWKSHandler initializes a new JWKSHandler
+func NewJWKSHandler(e *echo.Echo, keyManager *service.KeyManager) *JWKSHandler {
+	return &JWKSHandler{keyManager: keyManager}
+}
+
+// WellKnownRoutes registra el JWKS en la raíz, fuera del prefijo del API, que
+// es donde lo buscan las librerías de otros servicios
+func (h *JWKSHandler) WellKnownRoutes(e *echo.Echo) {
+	e.GET("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys (RS256/EdDSA) that verify the access tokens issued by the API, selected by the kid header of each token. Keys being rotated out are listed after the active one.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} model.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.keyManager.JWKS())
+}
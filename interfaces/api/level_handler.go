@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 )
@@ -20,11 +23,12 @@ func NewLevelHandler(e *echo.Echo, uc *usecase.LevelUseCase) *LevelHandler {
 }
 
 // RegisterRoutes registers level routes
-func (h *LevelHandler) RegisterRoutes(g *echo.Group) {
-	g.GET("/levels", h.GetAllLevels)
-	g.GET("/levels/:page", h.PaginateLevels)
-	g.POST("/level", h.CreateOrUpdateLevel)
-	g.POST("/level/delete", h.DeleteLevel)
+func (h *LevelHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/levels", h.GetAllLevels, security.Read("level"))
+	g.GET("/levels/:page", h.PaginateLevels, security.Read("level"))
+	g.POST("/level", h.CreateOrUpdateLevel, security.Create("level").WithoutImpersonation())
+	g.PUT("/level", h.CreateOrUpdateLevel, security.Update("level").WithoutImpersonation())
+	g.POST("/level/delete", h.DeleteLevel, security.Delete("level").WithoutImpersonation())
 }
 
 // GetAllLevels godoc
@@ -37,7 +41,12 @@ func (h *LevelHandler) RegisterRoutes(g *echo.Group) {
 // @Failure 500 {object} map[string]interface{}
 // @Router /levels [get]
 func (h *LevelHandler) GetAllLevels(c echo.Context) error {
-	levels, err := h.levelUseCase.GetAllLevels()
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	levels, err := h.levelUseCase.GetAllLevels(filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -67,7 +76,12 @@ func (h *LevelHandler) PaginateLevels(c echo.Context) error {
 		rows = 50
 	}
 
-	levels, total, err := h.levelUseCase.PaginateLevels(page, rows)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	levels, total, err := h.levelUseCase.PaginateLevels(page, rows, filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -79,23 +93,39 @@ func (h *LevelHandler) PaginateLevels(c echo.Context) error {
 }
 
 // CreateOrUpdateLevel godoc
-// @Summary Add a new level
-// @Description Add a new level with the input payload
+// @Summary Create or update a level
+// @Description Create a level with POST, without an ID, or update the level with the given ID with PUT. Each method requires its own privilege (create or update). parent_id makes the level inherit the privileges of another level; cycles are rejected
 // @Tags levels
 // @Accept json
 // @Produce json
 // @Param level body model.Level true "Level"
 // @Success 201 {object} model.Level
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /level [post]
+// @Router /level [put]
 func (h *LevelHandler) CreateOrUpdateLevel(c echo.Context) error {
 	level := new(model.Level)
 	if err := c.Bind(level); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
+	if err := checkSaveMethod(c, level.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
 
-	err := h.levelUseCase.CreateOrUpdateLevel(level)
+	err = h.levelUseCase.CreateOrUpdateLevel(level, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrLevelParentNotFound) || errors.Is(err, usecase.ErrLevelCycle) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -112,6 +142,7 @@ func (h *LevelHandler) CreateOrUpdateLevel(c echo.Context) error {
 // @Param level body model.Level true "Level"
 // @Success 200 {object} model.Level
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /level/delete [post]
 func (h *LevelHandler) DeleteLevel(c echo.Context) error {
@@ -120,7 +151,15 @@ func (h *LevelHandler) DeleteLevel(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	err := h.levelUseCase.DeleteLevel(level)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.levelUseCase.DeleteLevel(level, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
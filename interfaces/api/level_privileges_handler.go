@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -18,10 +21,11 @@ func NewLevelPrivilegesHandler(e *echo.Echo, uc *usecase.LevelPrivilegesUseCase)
 }
 
 // RegisterRoutes registers level privileges routes
-func (h *LevelPrivilegesHandler) RegisterRoutes(g *echo.Group) {
-	g.GET("/level-privileges", h.GetAllLevelPrivileges)
-	g.POST("/level-privilege", h.CreateLevelPrivilege)
-	g.POST("/level-privilege/delete", h.DeleteLevelPrivilege)
+func (h *LevelPrivilegesHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/level-privileges", h.GetAllLevelPrivileges, security.Read("level-privilege"))
+	g.POST("/level-privilege", h.CreateLevelPrivilege, security.Create("level-privilege").WithoutImpersonation())
+	g.PUT("/level-privilege", h.CreateLevelPrivilege, security.Update("level-privilege").WithoutImpersonation())
+	g.POST("/level-privilege/delete", h.DeleteLevelPrivilege, security.Delete("level-privilege").WithoutImpersonation())
 }
 
 // GetAllLevelPrivileges godoc
@@ -34,7 +38,12 @@ func (h *LevelPrivilegesHandler) RegisterRoutes(g *echo.Group) {
 // @Failure 500 {object} map[string]interface{}
 // @Router /level-privileges [get]
 func (h *LevelPrivilegesHandler) GetAllLevelPrivileges(c echo.Context) error {
-	levelPrivileges, err := h.levelPrivilegesUseCase.GetAllLevelPrivilege()
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	levelPrivileges, err := h.levelPrivilegesUseCase.GetAllLevelPrivilege(filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -42,23 +51,36 @@ func (h *LevelPrivilegesHandler) GetAllLevelPrivileges(c echo.Context) error {
 }
 
 // CreateLevelPrivilege godoc
-// @Summary Create a new level privilege
-// @Description Create a new level privilege with the input payload
+// @Summary Create or update a level privilege
+// @Description Create a level privilege with POST, without an ID, or update the level privilege with the given ID with PUT. Each method requires its own privilege (create or update). Actions are granted separately (read, create, update, delete, export, approve); a legacy write without actions grants create, update and delete
 // @Tags level-privileges
 // @Accept json
 // @Produce json
 // @Param levelPrivilege body model.LevelPrivileges true "Level Privilege"
 // @Success 201 {object} model.LevelPrivileges
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /level-privilege [post]
+// @Router /level-privilege [put]
 func (h *LevelPrivilegesHandler) CreateLevelPrivilege(c echo.Context) error {
 	levelPrivilege := new(model.LevelPrivileges)
 	if err := c.Bind(levelPrivilege); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
+	if err := checkSaveMethod(c, levelPrivilege.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
 
-	err := h.levelPrivilegesUseCase.CreateOrUpdateLevelPrivilege(levelPrivilege)
+	err = h.levelPrivilegesUseCase.CreateOrUpdateLevelPrivilege(levelPrivilege, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -75,6 +97,7 @@ func (h *LevelPrivilegesHandler) CreateLevelPrivilege(c echo.Context) error {
 // @Param levelPrivilege body model.LevelPrivileges true "Level Privilege"
 // @Success 200 {object} model.LevelPrivileges
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /level-privilege/delete [post]
 func (h *LevelPrivilegesHandler) DeleteLevelPrivilege(c echo.Context) error {
@@ -83,7 +106,15 @@ func (h *LevelPrivilegesHandler) DeleteLevelPrivilege(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	err := h.levelPrivilegesUseCase.DeleteLevelPrivilege(levelPrivilege)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.levelPrivilegesUseCase.DeleteLevelPrivilege(levelPrivilege, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
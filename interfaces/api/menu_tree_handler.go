@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 )
@@ -17,11 +20,12 @@ func NewMenuTreeHandler(e *echo.Echo, uc *usecase.MenuTreeUseCase) *MenuTreeHand
 	return &MenuTreeHandler{menuTreeUseCase: uc}
 }
 
-func (h *MenuTreeHandler) RegisterRoutes(g *echo.Group) {
-	g.GET("/expanses-menus", h.GetAllExpanseMenus)
-	g.GET("/expanses-menus/:page", h.PaginateExpanseMenus)
-	g.POST("/expanses-menus", h.CreateOrUpdateExpanseMenu)
-	g.POST("/expanses-menus/delete", h.DeleteExpanseMenu)
+func (h *MenuTreeHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/expanses-menus", h.GetAllExpanseMenus, security.Read("expanses-menu"))
+	g.GET("/expanses-menus/:page", h.PaginateExpanseMenus, security.Read("expanses-menu"))
+	g.POST("/expanses-menus", h.CreateOrUpdateExpanseMenu, security.Create("expanses-menu"))
+	g.PUT("/expanses-menus", h.CreateOrUpdateExpanseMenu, security.Update("expanses-menu"))
+	g.POST("/expanses-menus/delete", h.DeleteExpanseMenu, security.Delete("expanses-menu"))
 }
 
 // GetAllExpanseMenus godoc
@@ -34,7 +38,12 @@ func (h *MenuTreeHandler) RegisterRoutes(g *echo.Group) {
 // @Failure 500 {object} map[string]interface{}
 // @Router /expanses-menus [get]
 func (h *MenuTreeHandler) GetAllExpanseMenus(c echo.Context) error {
-	menus, err := h.menuTreeUseCase.GetAllMenuTrees()
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	menus, err := h.menuTreeUseCase.GetAllMenuTrees(filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -64,7 +73,12 @@ func (h *MenuTreeHandler) PaginateExpanseMenus(c echo.Context) error {
 		rows = 50
 	}
 
-	menus, total, err := h.menuTreeUseCase.PaginateMenuTrees(page, rows)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	menus, total, err := h.menuTreeUseCase.PaginateMenuTrees(page, rows, filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -76,23 +90,36 @@ func (h *MenuTreeHandler) PaginateExpanseMenus(c echo.Context) error {
 }
 
 // CreateOrUpdateExpanseMenu godoc
-// @Summary Add a new expanse menu
-// @Description Add a new expanse menu
+// @Summary Create or update an expanse menu
+// @Description Create an expanse menu with POST, without an ID, or update the expanse menu with the given ID with PUT. Each method requires its own privilege (create or update).
 // @Tags expanse menus
 // @Accept json
 // @Produce json
 // @Param menu body model.MenuTree true "Expanse Menu"
 // @Success 201 {object} model.MenuTree
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /expanses-menus [post]
+// @Router /expanses-menus [put]
 func (h *MenuTreeHandler) CreateOrUpdateExpanseMenu(c echo.Context) error {
 	menu := new(model.MenuTree)
 	if err := c.Bind(menu); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
+	if err := checkSaveMethod(c, menu.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
 
-	err := h.menuTreeUseCase.CreateOrUpdateMenuTree(menu)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.menuTreeUseCase.CreateOrUpdateMenuTree(menu, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -109,6 +136,7 @@ func (h *MenuTreeHandler) CreateOrUpdateExpanseMenu(c echo.Context) error {
 // @Param id query int true "Menu ID"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /expanses-menus/delete [post]
 func (h *MenuTreeHandler) DeleteExpanseMenu(c echo.Context) error {
@@ -118,7 +146,15 @@ func (h *MenuTreeHandler) DeleteExpanseMenu(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	err := h.menuTreeUseCase.DeleteMenuTree(menu)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.menuTreeUseCase.DeleteMenuTree(menu, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
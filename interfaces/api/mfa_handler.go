@@ -0,0 +1,249 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// MFAHandler manages two-factor authentication
+type MFAHandler struct {
+	mfaUseCase *usecase.MFAUseCase
+}
+
+// NewMFAHandler initializes a new MFAHandler
+func NewMFAHandler(e *echo.Echo, uc *usecase.MFAUseCase) *MFAHandler {
+	return &MFAHandler{mfaUseCase: uc}
+}
+
+// AuthRoutes registers the routes that complete the login with the mfa token
+func (h *MFAHandler) AuthRoutes(g *echo.Group) {
+	g.POST("/login/mfa", h.VerifyLogin)
+	g.POST("/login/mfa/enroll", h.EnrollPending)
+}
+
+// SessionRoutes registra la gestión del 2FA del propio usuario, que no se
+// puede cambiar mientras se suplanta a otro usuario
+func (h *MFAHandler) SessionRoutes(g *echo.Group) {
+	g.POST("/mfa/enroll", h.Enroll, middleware.DenyImpersonation())
+	g.POST("/mfa/activate", h.Activate, middleware.DenyImpersonation())
+	g.POST("/mfa/disable", h.Disable, middleware.DenyImpersonation())
+	g.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes, middleware.DenyImpersonation())
+}
+
+// RegisterRoutes registers the admin routes
+func (h *MFAHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.POST("/user/mfa/reset", h.ResetUserMFA, security.Update("user").WithoutImpersonation())
+}
+
+// VerifyLogin godoc
+// @Summary Complete the login with a second factor
+// @Description Exchange the mfa_token returned by /login and a TOTP or recovery code for the session tokens. If the user was setting up 2FA it gets activated and the recovery codes are returned.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param verification body model.MFAVerification true "MFA token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /login/mfa [post]
+func (h *MFAHandler) VerifyLogin(c echo.Context) error {
+	verification := new(model.MFAVerification)
+	if err := c.Bind(verification); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	response, err := h.mfaUseCase.VerifyLogin(verification, helpers.GetClientInfo(c))
+	var lockedErr *usecase.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		return accountLocked(c, lockedErr)
+	}
+	if err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   response,
+	})
+}
+
+// EnrollPending godoc
+// @Summary Set up 2FA during login
+// @Description Generate the TOTP secret for a user whose levels require 2FA and has not set it up yet. The login is completed on /login/mfa with the first code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param verification body model.MFAVerification true "MFA token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /login/mfa/enroll [post]
+func (h *MFAHandler) EnrollPending(c echo.Context) error {
+	verification := new(model.MFAVerification)
+	if err := c.Bind(verification); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	enrollment, err := h.mfaUseCase.EnrollPending(verification.MFAToken)
+	if err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   enrollment,
+	})
+}
+
+// Enroll godoc
+// @Summary Start the 2FA setup
+// @Description Generate a new TOTP secret and its provisioning URI for the QR code. 2FA is not enabled until it is confirmed on /mfa/activate.
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /mfa/enroll [post]
+func (h *MFAHandler) Enroll(c echo.Context) error {
+	enrollment, err := h.mfaUseCase.Enroll(helpers.GetCurrentUser(c))
+	if err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   enrollment,
+	})
+}
+
+// Activate godoc
+// @Summary Enable 2FA
+// @Description Confirm the TOTP secret with a code and return the one-time recovery codes
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param code body map[string]string true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /mfa/activate [post]
+func (h *MFAHandler) Activate(c echo.Context) error {
+	verification := new(model.MFAVerification)
+	if err := c.Bind(verification); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	codes, err := h.mfaUseCase.Activate(helpers.GetCurrentUser(c), verification.Code)
+	if err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   echo.Map{"recovery_codes": codes},
+	})
+}
+
+// Disable godoc
+// @Summary Disable 2FA
+// @Description Disable 2FA with a TOTP or recovery code. Not allowed when any level of the user, or a level it inherits from, requires 2FA.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param code body map[string]string true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /mfa/disable [post]
+func (h *MFAHandler) Disable(c echo.Context) error {
+	verification := new(model.MFAVerification)
+	if err := c.Bind(verification); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	if err := h.mfaUseCase.Disable(helpers.GetCurrentUser(c), verification.Code); err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate the recovery codes
+// @Description Invalidate the current recovery codes and return new ones
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param code body map[string]string true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	verification := new(model.MFAVerification)
+	if err := c.Bind(verification); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	codes, err := h.mfaUseCase.RegenerateRecoveryCodes(helpers.GetCurrentUser(c), verification.Code)
+	if err != nil {
+		return c.JSON(mfaErrorStatus(err), map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   echo.Map{"recovery_codes": codes},
+	})
+}
+
+// ResetUserMFA godoc
+// @Summary Reset the 2FA of a user
+// @Description Remove the TOTP secret and recovery codes of a user who lost the device
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/mfa/reset [post]
+func (h *MFAHandler) ResetUserMFA(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.mfaUseCase.ResetForUser(user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidMFAToken), errors.Is(err, usecase.ErrInvalidMFACode):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrMFARequiredByLevel):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrMFAAlreadyEnabled), errors.Is(err, usecase.ErrMFANotEnrolled):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// OIDCHandler logs users in through the company identity provider
+type OIDCHandler struct {
+	oidcUseCase *usecase.OIDCUseCase
+}
+
+// NewOIDCHandler initializes a new OIDCHandler
+func NewOIDCHandler(e *echo.Echo, uc *usecase.OIDCUseCase) *OIDCHandler {
+	return &OIDCHandler{oidcUseCase: uc}
+}
+
+// AuthRoutes registra las rutas públicas del login con el proveedor de identidad
+func (h *OIDCHandler) AuthRoutes(g *echo.Group) {
+	g.GET("/oidc/login", h.Login)
+	g.GET("/oidc/callback", h.Callback)
+}
+
+// Login godoc
+// @Summary Start an OpenID Connect login
+// @Description Redirect the browser to the identity provider using the authorization code flow with PKCE
+// @Tags auth
+// @Success 302
+// @Failure 500 {object} map[string]interface{}
+// @Router /oidc/login [get]
+func (h *OIDCHandler) Login(c echo.Context) error {
+	authURL, err := h.oidcUseCase.Begin()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Complete an OpenID Connect login
+// @Description Exchange the authorization code returned by the identity provider, provision the user and return the API token pair, or an mfa_token if the user needs a second factor
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the identity provider"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /oidc/callback [get]
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	if idpError := c.QueryParam("error"); idpError != "" {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": idpError})
+	}
+
+	tokens, err := h.oidcUseCase.Complete(c.Request().Context(), c.QueryParam("state"), c.QueryParam("code"), helpers.GetClientInfo(c))
+	var mfaErr *usecase.MFARequiredError
+	switch {
+	case errors.As(err, &mfaErr):
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": 200,
+			"data":   mfaErr.Challenge,
+		})
+	case errors.Is(err, usecase.ErrInvalidOIDCState):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOIDCAuthentication), errors.Is(err, usecase.ErrOIDCEmailRequired):
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOIDCNoLevel), errors.Is(err, usecase.ErrAccountSuspended):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   tokens,
+	})
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// PasswordResetHandler manages the password recovery flow
+type PasswordResetHandler struct {
+	passwordResetUseCase *usecase.PasswordResetUseCase
+}
+
+// NewPasswordResetHandler initializes a new PasswordResetHandler
+func NewPasswordResetHandler(e *echo.Echo, uc *usecase.PasswordResetUseCase) *PasswordResetHandler {
+	return &PasswordResetHandler{passwordResetUseCase: uc}
+}
+
+// AuthRoutes registers the public password recovery routes
+func (h *PasswordResetHandler) AuthRoutes(g *echo.Group) {
+	g.POST("/forgot-password", h.ForgotPassword)
+	g.POST("/reset-password", h.ResetPassword)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Send a password reset link to the email if it belongs to a user. The link is sent in the background, so the response is the same, and takes the same time, whether the email exists or not.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body map[string]string true "Email"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c echo.Context) error {
+	request := new(struct {
+		Email string `json:"email"`
+	})
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	// El enlace se envía en segundo plano: la respuesta no puede depender de si el email existe
+	h.passwordResetUseCase.RequestPasswordReset(request.Email)
+
+	return c.JSON(http.StatusAccepted, echo.Map{
+		"status":  http.StatusAccepted,
+		"message": "If the email belongs to an account, a reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset the password
+// @Description Set a new password using the token received by email. The new password must meet the password policy; otherwise the response lists the failed rules in fields. All existing sessions are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param recover body model.RecoverPass true "Token and new password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c echo.Context) error {
+	request := new(model.RecoverPass)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	err := h.passwordResetUseCase.ResetPassword(request)
+	var policyErr *usecase.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return passwordRejected(c, policyErr)
+	}
+	if errors.Is(err, usecase.ErrInvalidResetToken) || errors.Is(err, usecase.ErrPasswordRequired) || errors.Is(err, usecase.ErrPasswordMismatch) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
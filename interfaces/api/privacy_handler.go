@@ -0,0 +1,181 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// PrivacyHandler answers data subject access and erasure requests
+type PrivacyHandler struct {
+	privacyUseCase *usecase.PrivacyUseCase
+}
+
+// NewPrivacyHandler initializes a new PrivacyHandler
+func NewPrivacyHandler(e *echo.Echo, uc *usecase.PrivacyUseCase) *PrivacyHandler {
+	return &PrivacyHandler{privacyUseCase: uc}
+}
+
+// SessionRoutes registra la exportación de los datos propios
+func (h *PrivacyHandler) SessionRoutes(g *echo.Group) {
+	g.GET("/me/export", h.ExportOwnData)
+}
+
+// RegisterRoutes registra las rutas que requieren privilegios sobre usuarios
+func (h *PrivacyHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/user/:id/export", h.ExportUserData, security.Export("user"))
+	g.POST("/user/erase", h.EraseUser, security.Delete("user").WithoutImpersonation())
+}
+
+// ExportOwnData godoc
+// @Summary Export own data
+// @Description Download everything stored about the current user as a JSON document, or as a ZIP archive with one JSON file per section with format=zip
+// @Tags me
+// @Produce json
+// @Produce application/zip
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} model.UserDataExport
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/export [get]
+func (h *PrivacyHandler) ExportOwnData(c echo.Context) error {
+	// Durante una suplantación la auditoría registra a quien suplanta
+	claims := helpers.GetCurrentClaims(c)
+	actorID := claims.UserID
+	if claims.Actor != nil {
+		actorID = claims.Actor.UserID
+	}
+	return h.export(c, actorID, claims.UserID)
+}
+
+// ExportUserData godoc
+// @Summary Export the data of a user
+// @Description Download everything stored about a user (profile, level, sessions, API keys, invitations and audit entries) to answer a subject access request, also of deleted users that have not been purged yet. The export is recorded in the audit log.
+// @Tags users
+// @Produce json
+// @Produce application/zip
+// @Param id path int true "User ID"
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} model.UserDataExport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/{id}/export [get]
+func (h *PrivacyHandler) ExportUserData(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid user ID"})
+	}
+	return h.export(c, helpers.GetCurrentUser(c), uint(id))
+}
+
+func (h *PrivacyHandler) export(c echo.Context, actorID uint, userID uint) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "zip" {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "format must be json or zip"})
+	}
+
+	// Las rutas propias no tienen condiciones de acceso a filas
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	export, err := h.privacyUseCase.Export(actorID, userID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	name := fmt.Sprintf("user-%d-export", userID)
+	if format != "zip" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".json"))
+		return c.JSON(http.StatusOK, export)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".zip"))
+	c.Response().WriteHeader(http.StatusOK)
+	return writeExportArchive(c.Response(), export)
+}
+
+// writeExportArchive escribe un fichero JSON por cada sección de la exportación
+func writeExportArchive(w http.ResponseWriter, export *model.UserDataExport) error {
+	archive := zip.NewWriter(w)
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"api_keys.json", export.APIKeys},
+		{"invitations.json", export.Invitations},
+		{"audit_entries.json", export.AuditEntries},
+	}
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: section.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// EraseUser godoc
+// @Summary Erase the personal data of a user
+// @Description Delete the uploaded avatar and anonymize the username, email, full name and picture of a user, close their sessions and delete their credentials, keeping the account row so that references stay valid. It cannot be undone and is recorded in the audit log.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param erasure body model.UserErasureRequest true "User ID and reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/erase [post]
+func (h *PrivacyHandler) EraseUser(c echo.Context) error {
+	request := new(model.UserErasureRequest)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.privacyUseCase.Erase(helpers.GetCurrentUser(c), request, filter)
+	switch {
+	case errors.Is(err, usecase.ErrRowAccessDenied):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCannotEraseSelf):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
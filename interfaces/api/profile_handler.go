@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// ProfileHandler lets users manage their own account
+type ProfileHandler struct {
+	profileUseCase *usecase.ProfileUseCase
+}
+
+// NewProfileHandler initializes a new ProfileHandler
+func NewProfileHandler(e *echo.Echo, uc *usecase.ProfileUseCase) *ProfileHandler {
+	return &ProfileHandler{profileUseCase: uc}
+}
+
+// SessionRoutes registra las rutas del propio usuario, que solo requieren un token válido
+func (h *ProfileHandler) SessionRoutes(g *echo.Group) {
+	g.GET("/me", h.GetProfile)
+	g.PUT("/me", h.UpdateProfile)
+	g.POST("/me/password", h.ChangePassword, middleware.DenyImpersonation())
+	// El límite incluye margen para el resto del cuerpo multipart
+	bodyLimit := fmt.Sprintf("%dK", h.profileUseCase.MaxPictureSize()/1024+64)
+	g.POST("/me/picture", h.UploadPicture, echomiddleware.BodyLimit(bodyLimit))
+}
+
+// GetProfile godoc
+// @Summary Get own profile
+// @Description Get the profile of the current user
+// @Tags me
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me [get]
+func (h *ProfileHandler) GetProfile(c echo.Context) error {
+	user, err := h.profileUseCase.GetProfile(helpers.GetCurrentUser(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   withoutPassword(user),
+	})
+}
+
+// UpdateProfile godoc
+// @Summary Update own profile
+// @Description Update the full name, picture and language of the current user. Other fields are ignored.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param profile body model.Profile true "Profile"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me [put]
+func (h *ProfileHandler) UpdateProfile(c echo.Context) error {
+	profile := new(model.Profile)
+	if err := c.Bind(profile); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	user, err := h.profileUseCase.UpdateProfile(helpers.GetCurrentUser(c), profile)
+	if errors.Is(err, usecase.ErrFullNameRequired) || errors.Is(err, usecase.ErrInvalidLanguage) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   withoutPassword(user),
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change own password
+// @Description Change the password of the current user after checking the current one. The new password must meet the password policy; otherwise the response lists the failed rules in fields. All sessions are closed and a new token pair is returned.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param password body model.PasswordChange true "Current and new password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/password [post]
+func (h *ProfileHandler) ChangePassword(c echo.Context) error {
+	change := new(model.PasswordChange)
+	if err := c.Bind(change); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	tokens, err := h.profileUseCase.ChangePassword(helpers.GetCurrentUser(c), change, helpers.GetClientInfo(c))
+	var policyErr *usecase.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return passwordRejected(c, policyErr)
+	case errors.Is(err, usecase.ErrPasswordRequired),
+		errors.Is(err, usecase.ErrPasswordMismatch),
+		errors.Is(err, usecase.ErrInvalidCurrentPassword):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   tokens,
+	})
+}
+
+// UploadPicture godoc
+// @Summary Upload own picture
+// @Description Upload a JPEG, PNG, GIF or WebP image as the picture of the current user. It is cropped to a square and stored with its thumbnails.
+// @Tags me
+// @Accept multipart/form-data
+// @Produce json
+// @Param picture formData file true "Picture"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/picture [post]
+func (h *ProfileHandler) UploadPicture(c echo.Context) error {
+	fileHeader, err := c.FormFile("picture")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if fileHeader.Size > h.profileUseCase.MaxPictureSize() {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": usecase.ErrPictureTooLarge.Error()})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	defer file.Close()
+
+	picture, err := h.profileUseCase.UpdatePicture(helpers.GetCurrentUser(c), file)
+	switch {
+	case errors.Is(err, usecase.ErrPictureTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedPictureType):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidPicture):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   picture,
+	})
+}
+
+// withoutPassword evita devolver el hash de la contraseña al cliente
+func withoutPassword(user *model.User) *model.User {
+	user.Password = ""
+	return user
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/labstack/echo/v4"
+)
+
+// ProtectedGroup wraps the group with privilege checks. Every route declares
+// the permission it requires in the central registry that the authorization
+// middleware checks, so there is no way to add a route without one.
+type ProtectedGroup struct {
+	group       *echo.Group
+	permissions *security.RoutePermissions
+}
+
+// NewProtectedGroup initializes a new ProtectedGroup
+func NewProtectedGroup(g *echo.Group, permissions *security.RoutePermissions) *ProtectedGroup {
+	return &ProtectedGroup{group: g, permissions: permissions}
+}
+
+// GET registers a GET route that requires permission
+func (p *ProtectedGroup) GET(path string, h echo.HandlerFunc, permission security.RoutePermission, m ...echo.MiddlewareFunc) *echo.Route {
+	return p.add(http.MethodGet, path, h, permission, m)
+}
+
+// POST registers a POST route that requires permission
+func (p *ProtectedGroup) POST(path string, h echo.HandlerFunc, permission security.RoutePermission, m ...echo.MiddlewareFunc) *echo.Route {
+	return p.add(http.MethodPost, path, h, permission, m)
+}
+
+// PUT registers a PUT route that requires permission
+func (p *ProtectedGroup) PUT(path string, h echo.HandlerFunc, permission security.RoutePermission, m ...echo.MiddlewareFunc) *echo.Route {
+	return p.add(http.MethodPut, path, h, permission, m)
+}
+
+// DELETE registers a DELETE route that requires permission
+func (p *ProtectedGroup) DELETE(path string, h echo.HandlerFunc, permission security.RoutePermission, m ...echo.MiddlewareFunc) *echo.Route {
+	return p.add(http.MethodDelete, path, h, permission, m)
+}
+
+// add registra la ruta y su permiso. Una declaración incorrecta es un error de
+// programación y detiene el arranque.
+func (p *ProtectedGroup) add(method string, path string, h echo.HandlerFunc, permission security.RoutePermission, m []echo.MiddlewareFunc) *echo.Route {
+	route := p.group.Add(method, path, h, m...)
+	if err := p.permissions.Register(route.Method, route.Path, permission); err != nil {
+		panic(err)
+	}
+	return route
+}
+
+var (
+	errCreateWithID    = errors.New("new records are created without an ID; use PUT to update an existing record")
+	errUpdateWithoutID = errors.New("the ID of the record to update is required")
+)
+
+// checkSaveMethod comprueba que el registro recibido corresponde a la acción
+// que autoriza la ruta de guardado: POST crea registros nuevos, sin ID, y PUT
+// modifica registros existentes
+func checkSaveMethod(c echo.Context, id uint) error {
+	if c.Request().Method == http.MethodPut {
+		if id == 0 {
+			return errUpdateWithoutID
+		}
+		return nil
+	}
+	if id != 0 {
+		return errCreateWithID
+	}
+	return nil
+}
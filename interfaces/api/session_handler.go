@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// SessionHandler lists and closes the sessions open on each device
+type SessionHandler struct {
+	sessionUseCase *usecase.SessionUseCase
+}
+
+// NewSessionHandler initializes a new SessionHandler
+func NewSessionHandler(e *echo.Echo, uc *usecase.SessionUseCase) *SessionHandler {
+	return &SessionHandler{sessionUseCase: uc}
+}
+
+// SessionRoutes registra las sesiones del propio usuario
+func (h *SessionHandler) SessionRoutes(g *echo.Group) {
+	g.GET("/me/sessions", h.ListSessions)
+	g.DELETE("/me/sessions/:id", h.RevokeSession)
+}
+
+// RegisterRoutes registra las rutas que requieren privilegios
+func (h *SessionHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/users/:id/sessions", h.ListUserSessions, security.Read("user"))
+	g.DELETE("/users/:id/sessions/:session", h.RevokeUserSession, security.Delete("user").WithoutImpersonation())
+}
+
+// ListSessions godoc
+// @Summary List own sessions
+// @Description List the devices where the current user is logged in. The session of the request is marked as current.
+// @Tags me
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/sessions [get]
+func (h *SessionHandler) ListSessions(c echo.Context) error {
+	claims := helpers.GetCurrentClaims(c)
+	sessions, err := h.sessionUseCase.List(claims.UserID, claims.SessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary Close an own session
+// @Description Log out the device of one of the sessions of the current user
+// @Tags me
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid session ID"})
+	}
+
+	return h.revoke(c, helpers.GetCurrentUser(c), uint(sessionID))
+}
+
+// ListUserSessions godoc
+// @Summary List the sessions of a user
+// @Description List the devices where a user is logged in
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid user ID"})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	sessions, err := h.sessionUseCase.ListForUser(uint(userID), filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   sessions,
+	})
+}
+
+// RevokeUserSession godoc
+// @Summary Close a session of a user
+// @Description Log out the device of one of the sessions of a user
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param session path int true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /users/{id}/sessions/{session} [delete]
+func (h *SessionHandler) RevokeUserSession(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid user ID"})
+	}
+	sessionID, err := strconv.ParseUint(c.Param("session"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid session ID"})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.sessionUseCase.RevokeForUser(uint(userID), uint(sessionID), filter)
+	return h.revokeResult(c, err)
+}
+
+func (h *SessionHandler) revoke(c echo.Context, userID uint, sessionID uint) error {
+	return h.revokeResult(c, h.sessionUseCase.Revoke(userID, sessionID))
+}
+
+func (h *SessionHandler) revokeResult(c echo.Context, err error) error {
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrSessionNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
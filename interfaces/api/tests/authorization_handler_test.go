@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationHandler_GetCacheStats(t *testing.T) {
+	e := echo.New()
+
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(&model.Level{}, nil)
+	cache := memory.NewAuthorizationCache(levelRepo, time.Minute, 100)
+	_, _ = cache.GetByID(1)
+	_, _ = cache.GetByID(1)
+
+	for _, tc := range []struct {
+		name    string
+		useCase *usecase.AuthorizationUseCase
+		enabled bool
+		hits    uint64
+	}{
+		{"enabled", usecase.NewAuthorizationUseCase(cache, nil), true, 1},
+		{"disabled", usecase.NewAuthorizationUseCase(nil, nil), false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := api.NewAuthorizationHandler(e, tc.useCase)
+
+			req := httptest.NewRequest(http.MethodGet, "/authz/cache", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.GetCacheStats(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				var response struct {
+					Data model.AuthorizationCacheStats `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, tc.enabled, response.Data.Enabled)
+				assert.Equal(t, tc.hits, response.Data.Hits)
+			}
+		})
+	}
+}
+
+func TestAuthorizationHandler_CheckRejectsInvalidChecks(t *testing.T) {
+	e := echo.New()
+	levelRepo := new(mocks.MockLevelRepository)
+	handler := api.NewAuthorizationHandler(e, usecase.NewAuthorizationUseCase(nil, service.NewPermissionResolver(levelRepo)))
+
+	for _, body := range []string{
+		`{"checks": [{"resource": "user", "action": "write"}]}`,
+		`{"checks": [{"action": "read"}]}`,
+		`{"checks": "user"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/authz/check", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 1, LevelID: 1}})
+
+		if assert.NoError(t, handler.Check(c), body) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+	}
+	levelRepo.AssertNotCalled(t, "GetByID", uint(1))
+}
@@ -20,7 +20,9 @@ func TestFormHandler_CreateOrUpdateForm(t *testing.T) {
 	e := echo.New()
 	mockRepo := new(mocks.MockFormRepository)
 
-	FormUseCase := usecase.NewFormUseCase(mockRepo)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersionByForm", mock.Anything).Return(nil)
+	FormUseCase := usecase.NewFormUseCase(mockRepo, mockLevelRepo)
 	handler := api.NewFormHandler(e, FormUseCase)
 
 	mockForm := &model.Form{
@@ -38,7 +40,7 @@ func TestFormHandler_CreateOrUpdateForm(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	// Definir la expectativa de la llamada al método CreateOrUpdate
-	mockRepo.On("CreateOrUpdate", mock.Anything).Return(nil)
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
 
 	if assert.NoError(t, handler.CreateOrUpdateForm(c)) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
@@ -58,6 +60,24 @@ func TestFormHandler_CreateOrUpdateForm(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestFormHandler_CreateOrUpdateFormRejectsInvalidCondition(t *testing.T) {
+	e := echo.New()
+	mockRepo := new(mocks.MockFormRepository)
+	handler := api.NewFormHandler(e, usecase.NewFormUseCase(mockRepo, new(mocks.MockLevelRepository)))
+
+	FormJSON, _ := json.Marshal(&model.Form{Title: "Usuarios", PathAPI: "user|users", Condition: "owner_id = user.id"})
+	req := httptest.NewRequest(http.MethodPost, "/form", bytes.NewBuffer(FormJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, handler.CreateOrUpdateForm(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "invalid form condition")
+	}
+	mockRepo.AssertNotCalled(t, "CreateOrUpdate", mock.Anything)
+}
+
 func TestFormHandler_PaginateForms(t *testing.T) {
 	e := echo.New()
 	mockRepo := new(mocks.MockFormRepository)
@@ -81,9 +101,9 @@ func TestFormHandler_PaginateForms(t *testing.T) {
 			Order:   2,
 		},
 	}
-	mockRepo.On("Paginate", 1, 2).Return(mockForms, 2, nil)
+	mockRepo.On("Paginate", 1, 2, mock.Anything).Return(mockForms, 2, nil)
 
-	FormUseCase := usecase.NewFormUseCase(mockRepo)
+	FormUseCase := usecase.NewFormUseCase(mockRepo, new(mocks.MockLevelRepository))
 	handler := api.NewFormHandler(e, FormUseCase)
 
 	req := httptest.NewRequest(http.MethodGet, "/forms/1?rows=2", nil)
@@ -108,7 +128,9 @@ func TestFormHandler_DeleteForm(t *testing.T) {
 
 	mockRepo := new(mocks.MockFormRepository)
 
-	FormUseCase := usecase.NewFormUseCase(mockRepo)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersionByForm", mock.Anything).Return(nil)
+	FormUseCase := usecase.NewFormUseCase(mockRepo, mockLevelRepo)
 	handler := api.NewFormHandler(e, FormUseCase)
 
 	mockForm := &model.Form{
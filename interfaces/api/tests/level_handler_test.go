@@ -30,7 +30,7 @@ func TestLevelHandler_CreateOrUpdateLevel(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mockRepo.On("CreateOrUpdate", mock.Anything).Return(nil)
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
 
 	if assert.NoError(t, handler.CreateOrUpdateLevel(c)) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
@@ -48,7 +48,7 @@ func TestLevelHandler_PaginateLevels(t *testing.T) {
 		{Level: "Level1"},
 		{Level: "Level2"},
 	}
-	mockRepo.On("Paginate", 1, 2).Return(mockLevels, 2, nil)
+	mockRepo.On("Paginate", 1, 2, mock.Anything).Return(mockLevels, 2, nil)
 
 	LevelUseCase := usecase.NewLevelUseCase(mockRepo)
 	handler := api.NewLevelHandler(e, LevelUseCase)
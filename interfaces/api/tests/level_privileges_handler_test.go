@@ -20,7 +20,9 @@ func TestLevelPrivilegesHandler_CreateOrUpdateLevel(t *testing.T) {
 
 	mockRepo := new(mocks.MockLevelPrivilegesRepository)
 
-	LevelUseCase := usecase.NewLevelPrivilegesUseCase(mockRepo)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", mock.Anything).Return(nil)
+	LevelUseCase := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
 	handler := api.NewLevelPrivilegesHandler(e, LevelUseCase)
 
 	mockLevel := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
@@ -30,7 +32,7 @@ func TestLevelPrivilegesHandler_CreateOrUpdateLevel(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mockRepo.On("CreateOrUpdate", mock.Anything).Return(nil)
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
 
 	if assert.NoError(t, handler.CreateLevelPrivilege(c)) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
@@ -46,7 +48,9 @@ func TestLevelPrivilegesHandler_DeleteLevel(t *testing.T) {
 
 	mockRepo := new(mocks.MockLevelPrivilegesRepository)
 
-	LevelUseCase := usecase.NewLevelPrivilegesUseCase(mockRepo)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", mock.Anything).Return(nil)
+	LevelUseCase := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
 	handler := api.NewLevelPrivilegesHandler(e, LevelUseCase)
 
 	mockLevel := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
@@ -34,7 +34,7 @@ func TestMenuTreeHandler_CreateOrUpdateMenuTree(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mockRepo.On("CreateOrUpdate", mock.Anything).Return(nil)
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
 
 	if assert.NoError(t, handler.CreateOrUpdateExpanseMenu(c)) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
@@ -65,7 +65,7 @@ func TestMenuTreeHandler_PaginateMenuTrees(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("Paginate", 1, 2).Return(mockMenuTree, 2, nil)
+	mockRepo.On("Paginate", 1, 2, mock.Anything).Return(mockMenuTree, 2, nil)
 
 	MenuTreeUseCase := usecase.NewMenuTreeUseCase(mockRepo)
 	handler := api.NewMenuTreeHandler(e, MenuTreeUseCase)
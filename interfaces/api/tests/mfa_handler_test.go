@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/interfaces/api/tests/mocks"
+	testifyMocks "github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newMFAHandlers(e *echo.Echo, userRepo *mocks.MockUserRepository) (*api.UserHandler, *api.MFAHandler) {
+	refreshTokenRepo := &mocks.MockRefreshTokenRepository{
+		CreateFunc: func(token *model.RefreshToken) error {
+			token.ID = 1
+			return nil
+		},
+	}
+	sessionRepo := &mocks.MockSessionRepository{
+		CreateFunc: func(session *model.Session) error {
+			session.ID = 1
+			return nil
+		},
+	}
+	recoveryCodeRepo := new(testifyMocks.MockRecoveryCodeRepository)
+	recoveryCodeRepo.On("Consume", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	tokenUseCase := usecase.NewTokenUseCase(refreshTokenRepo, sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, recoveryCodeRepo, tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, newTestPermissionResolver())
+	userUseCase := usecase.NewUserUseCase(userRepo, utils.NewTestPasswordHasher(), newTestPasswordPolicy(), tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), newTestPermissionResolver())
+	return api.NewUserHandler(e, userUseCase, tokenUseCase), api.NewMFAHandler(e, mfaUseCase)
+}
+
+func postJSON(e *echo.Echo, path string, body interface{}) (echo.Context, *httptest.ResponseRecorder) {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func TestMFAHandler_TwoStepLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	e := echo.New()
+
+	hasher := utils.NewTestPasswordHasher()
+	password, _ := hasher.Hash("password")
+	secret, _ := helpers.GenerateTOTPSecret()
+	user := &model.User{
+		Model:      gorm.Model{ID: 1},
+		Email:      "test@example.com",
+		Password:   password,
+		MFAEnabled: true,
+		MFASecret:  secret,
+	}
+
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByEmailFunc: func(email string) (*model.User, error) {
+			return user, nil
+		},
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return user, nil
+		},
+		MarkMFAStepUsedFunc: func(id uint, step int64) (bool, error) {
+			return true, nil
+		},
+		IncrementFailureFunc: func(id uint) (int, error) {
+			return 1, nil
+		},
+	}
+
+	userHandler, mfaHandler := newMFAHandlers(e, mockUserRepo)
+
+	// La contraseña correcta solo devuelve el token para el segundo factor
+	c, rec := postJSON(e, "/api/v1/login", map[string]string{"email": "test@example.com", "password": "password"})
+	if !assert.NoError(t, userHandler.Login(c)) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var loginResponse struct {
+		Data model.MFAChallenge `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &loginResponse))
+	assert.True(t, loginResponse.Data.MFARequired)
+	assert.NotEmpty(t, loginResponse.Data.MFAToken)
+
+	c, rec = postJSON(e, "/api/v1/login/mfa", model.MFAVerification{MFAToken: loginResponse.Data.MFAToken, Code: "000000"})
+	if assert.NoError(t, mfaHandler.VerifyLogin(c)) {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	code, _ := helpers.TOTPCode(secret, time.Now())
+	c, rec = postJSON(e, "/api/v1/login/mfa", model.MFAVerification{MFAToken: loginResponse.Data.MFAToken, Code: code})
+	if assert.NoError(t, mfaHandler.VerifyLogin(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var verifyResponse struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &verifyResponse))
+		assert.NotEmpty(t, verifyResponse.Data["token"])
+		assert.NotEmpty(t, verifyResponse.Data["refresh_token"])
+	}
+}
+
+func TestMFAHandler_VerifyLoginRejectsSessionTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	e := echo.New()
+
+	_, mfaHandler := newMFAHandlers(e, &mocks.MockUserRepository{})
+
+	// Un access token normal no sirve como token de mfa pendiente
+	accessToken, _ := helpers.GenerateJWT(&model.User{Model: gorm.Model{ID: 1}}, time.Minute)
+	c, rec := postJSON(e, "/api/v1/login/mfa", model.MFAVerification{MFAToken: accessToken, Code: "123456"})
+
+	if assert.NoError(t, mfaHandler.VerifyLogin(c)) {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+)
+
+type MockPasswordHistoryRepository struct {
+	CreateFunc    func(entry *model.PasswordHistory) error
+	GetRecentFunc func(userID uint, limit int) ([]*model.PasswordHistory, error)
+	PruneFunc     func(userID uint, keep int) error
+}
+
+var _ repository.PasswordHistoryRepository = &MockPasswordHistoryRepository{}
+
+func (m *MockPasswordHistoryRepository) Create(entry *model.PasswordHistory) error {
+	return m.CreateFunc(entry)
+}
+
+func (m *MockPasswordHistoryRepository) GetRecent(userID uint, limit int) ([]*model.PasswordHistory, error) {
+	return m.GetRecentFunc(userID, limit)
+}
+
+func (m *MockPasswordHistoryRepository) Prune(userID uint, keep int) error {
+	return m.PruneFunc(userID, keep)
+}
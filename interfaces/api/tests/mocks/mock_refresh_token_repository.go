@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+)
+
+type MockRefreshTokenRepository struct {
+	CreateFunc       func(token *model.RefreshToken) error
+	GetByHashFunc    func(tokenHash string) (*model.RefreshToken, error)
+	RevokeFunc       func(id uint, revokedAt time.Time, replacedBy *uint) (bool, error)
+	RevokeFamilyFunc func(familyID string, revokedAt time.Time) error
+	RevokeByUserFunc func(userID uint, revokedAt time.Time) error
+}
+
+var _ repository.RefreshTokenRepository = &MockRefreshTokenRepository{}
+
+func (m *MockRefreshTokenRepository) Create(token *model.RefreshToken) error {
+	return m.CreateFunc(token)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(tokenHash string) (*model.RefreshToken, error) {
+	return m.GetByHashFunc(tokenHash)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(id uint, revokedAt time.Time, replacedBy *uint) (bool, error) {
+	return m.RevokeFunc(id, revokedAt, replacedBy)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return m.RevokeFamilyFunc(familyID, revokedAt)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	return m.RevokeByUserFunc(userID, revokedAt)
+}
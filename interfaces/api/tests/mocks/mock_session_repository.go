@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+)
+
+type MockSessionRepository struct {
+	CreateFunc          func(session *model.Session) error
+	GetByIDFunc         func(id uint) (*model.Session, error)
+	GetByFamilyFunc     func(familyID string) (*model.Session, error)
+	GetByUserFunc       func(userID uint) ([]*model.Session, error)
+	GetActiveByUserFunc func(userID uint, seenAfter time.Time) ([]*model.Session, error)
+	TouchFunc           func(id uint, seenAt time.Time) error
+	RevokeFunc          func(id uint, userID uint, revokedAt time.Time) (bool, error)
+	RevokeByUserFunc    func(userID uint, revokedAt time.Time) error
+}
+
+var _ repository.SessionRepository = &MockSessionRepository{}
+
+func (m *MockSessionRepository) Create(session *model.Session) error {
+	return m.CreateFunc(session)
+}
+
+func (m *MockSessionRepository) GetByID(id uint) (*model.Session, error) {
+	return m.GetByIDFunc(id)
+}
+
+func (m *MockSessionRepository) GetByFamily(familyID string) (*model.Session, error) {
+	return m.GetByFamilyFunc(familyID)
+}
+
+func (m *MockSessionRepository) GetByUser(userID uint) ([]*model.Session, error) {
+	return m.GetByUserFunc(userID)
+}
+
+func (m *MockSessionRepository) GetActiveByUser(userID uint, seenAfter time.Time) ([]*model.Session, error) {
+	return m.GetActiveByUserFunc(userID, seenAfter)
+}
+
+func (m *MockSessionRepository) Touch(id uint, seenAt time.Time) error {
+	return m.TouchFunc(id, seenAt)
+}
+
+func (m *MockSessionRepository) Revoke(id uint, userID uint, revokedAt time.Time) (bool, error) {
+	return m.RevokeFunc(id, userID, revokedAt)
+}
+
+func (m *MockSessionRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	return m.RevokeByUserFunc(userID, revokedAt)
+}
@@ -1,64 +1,149 @@
 package mocks
 
 import (
-	"errors"
+	"time"
+
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
-	"github.com/golang-jwt/jwt/v5"
-	"time"
+	"github.com/drossan/core-api/domain/security"
 )
 
 type MockUserRepository struct {
-	CreateFunc     func(user *model.User) error
-	UpdateFunc     func(user *model.User) error
-	GetByIDFunc    func(id uint) (*model.User, error)
-	GetByEmailFunc func(email string) (*model.User, error)
-	GetAllFunc     func() ([]*model.User, error)
-	PaginateFunc   func(page int, pageSize int) ([]*model.User, int, error)
-	DeleteFunc     func(user *model.User) error
-	LoginFunc      func(email, password string) (string, error)
+	CreateFunc              func(user *model.User, filter *security.RowFilter) error
+	UpdateFunc              func(user *model.User, filter *security.RowFilter) error
+	GetByIDFunc             func(id uint) (*model.User, error)
+	GetByIDUnscopedFunc     func(id uint) (*model.User, error)
+	GetByEmailFunc          func(email string) (*model.User, error)
+	GetByTokenFunc          func(token string) (*model.User, error)
+	GetAllFunc              func(filter *security.RowFilter) ([]*model.User, error)
+	PaginateFunc            func(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error)
+	MatchesFilterFunc       func(id uint, filter *security.RowFilter) (bool, error)
+	DeleteFunc              func(user *model.User) error
+	GetStatusFunc           func(id uint) (string, error)
+	SetStatusFunc           func(id uint, status string, reason string, changedAt time.Time) error
+	SetLevelsFunc           func(id uint, levelIDs []uint) error
+	PaginateDeletedFunc     func(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error)
+	RestoreFunc             func(id uint, restoredAt time.Time) (bool, error)
+	PurgeFunc               func(id uint) (bool, error)
+	CreateBatchFunc         func(users []*model.User, filter *security.RowFilter) error
+	EraseFunc               func(id uint, erasedAt time.Time, audit *model.AuditEntry) error
+	GetTakenIdentifiersFunc func(usernames []string, emails []string) ([]string, []string, error)
+	IncrementFailureFunc    func(id uint) (int, error)
+	LockUntilFunc           func(id uint, until time.Time) error
+	ResetFailuresFunc       func(id uint) error
+	UpdateProfileFunc       func(id uint, profile *model.Profile) error
+	UpdatePasswordFunc      func(id uint, hashedPassword string) error
+	ResetPasswordFunc       func(id uint, token string, hashedPassword string, changedAt time.Time) (bool, error)
+	UpdateMFAFunc           func(id uint, secret string, enabled bool) error
+	MarkMFAStepUsedFunc     func(id uint, step int64) (bool, error)
 }
 
 var _ repository.UserRepository = &MockUserRepository{}
 
-func (m *MockUserRepository) Create(user *model.User) error {
-	return m.CreateFunc(user)
+func (m *MockUserRepository) Create(user *model.User, filter *security.RowFilter) error {
+	return m.CreateFunc(user, filter)
 }
 
-func (m *MockUserRepository) Update(user *model.User) error {
-	return m.UpdateFunc(user)
+func (m *MockUserRepository) Update(user *model.User, filter *security.RowFilter) error {
+	return m.UpdateFunc(user, filter)
 }
 
 func (m *MockUserRepository) GetByID(id uint) (*model.User, error) {
 	return m.GetByIDFunc(id)
 }
 
+func (m *MockUserRepository) GetByIDUnscoped(id uint) (*model.User, error) {
+	return m.GetByIDUnscopedFunc(id)
+}
+
 func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
 	return m.GetByEmailFunc(email)
 }
 
-func (m *MockUserRepository) GetAll() ([]*model.User, error) {
-	return m.GetAllFunc()
+func (m *MockUserRepository) GetByToken(token string) (*model.User, error) {
+	return m.GetByTokenFunc(token)
 }
 
-func (m *MockUserRepository) Paginate(page int, pageSize int) ([]*model.User, int, error) {
-	return m.PaginateFunc(page, pageSize)
+func (m *MockUserRepository) GetAll(filter *security.RowFilter) ([]*model.User, error) {
+	return m.GetAllFunc(filter)
+}
+
+func (m *MockUserRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	return m.PaginateFunc(page, pageSize, filter)
+}
+
+func (m *MockUserRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	return m.MatchesFilterFunc(id, filter)
 }
 
 func (m *MockUserRepository) Delete(user *model.User) error {
 	return m.DeleteFunc(user)
 }
 
-func (m *MockUserRepository) Login(email, password string) (string, error) {
-	if email == "test@example.com" && password == "password" {
-		claims := &jwt.MapClaims{
-			"user_id": 1,
-			"email":   "test@example.com",
-			"iss":     "Intranet API - generate by IslaIT",
-			"exp":     time.Now().Add(time.Hour * 72).Unix(),
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		return token.SignedString([]byte("test_secret"))
-	}
-	return "", errors.New("invalid email or password")
+func (m *MockUserRepository) IncrementFailure(id uint) (int, error) {
+	return m.IncrementFailureFunc(id)
+}
+
+func (m *MockUserRepository) LockUntil(id uint, until time.Time) error {
+	return m.LockUntilFunc(id, until)
+}
+
+func (m *MockUserRepository) ResetFailures(id uint) error {
+	return m.ResetFailuresFunc(id)
+}
+
+func (m *MockUserRepository) UpdateMFA(id uint, secret string, enabled bool) error {
+	return m.UpdateMFAFunc(id, secret, enabled)
+}
+
+func (m *MockUserRepository) MarkMFAStepUsed(id uint, step int64) (bool, error) {
+	return m.MarkMFAStepUsedFunc(id, step)
+}
+
+func (m *MockUserRepository) UpdateProfile(id uint, profile *model.Profile) error {
+	return m.UpdateProfileFunc(id, profile)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uint, hashedPassword string) error {
+	return m.UpdatePasswordFunc(id, hashedPassword)
+}
+
+func (m *MockUserRepository) ResetPassword(id uint, token string, hashedPassword string, changedAt time.Time) (bool, error) {
+	return m.ResetPasswordFunc(id, token, hashedPassword, changedAt)
+}
+
+func (m *MockUserRepository) GetStatus(id uint) (string, error) {
+	return m.GetStatusFunc(id)
+}
+
+func (m *MockUserRepository) SetStatus(id uint, status string, reason string, changedAt time.Time) error {
+	return m.SetStatusFunc(id, status, reason, changedAt)
+}
+
+func (m *MockUserRepository) SetLevels(id uint, levelIDs []uint) error {
+	return m.SetLevelsFunc(id, levelIDs)
+}
+
+func (m *MockUserRepository) PaginateDeleted(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	return m.PaginateDeletedFunc(page, pageSize, filter)
+}
+
+func (m *MockUserRepository) Restore(id uint, restoredAt time.Time) (bool, error) {
+	return m.RestoreFunc(id, restoredAt)
+}
+
+func (m *MockUserRepository) Purge(id uint) (bool, error) {
+	return m.PurgeFunc(id)
+}
+
+func (m *MockUserRepository) CreateBatch(users []*model.User, filter *security.RowFilter) error {
+	return m.CreateBatchFunc(users, filter)
+}
+
+func (m *MockUserRepository) Erase(id uint, erasedAt time.Time, audit *model.AuditEntry) error {
+	return m.EraseFunc(id, erasedAt, audit)
+}
+
+func (m *MockUserRepository) GetTakenIdentifiers(usernames []string, emails []string) ([]string, []string, error) {
+	return m.GetTakenIdentifiersFunc(usernames, emails)
 }
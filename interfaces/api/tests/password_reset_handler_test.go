@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/interfaces/api/tests/mocks"
+	testifyMocks "github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newPasswordResetHandler(userRepo *mocks.MockUserRepository, mailer *testifyMocks.MockMailer) (*api.PasswordResetHandler, *usecase.PasswordResetUseCase) {
+	refreshTokenRepo := &mocks.MockRefreshTokenRepository{
+		RevokeByUserFunc: func(userID uint, revokedAt time.Time) error {
+			return nil
+		},
+	}
+	sessionRepo := &mocks.MockSessionRepository{
+		RevokeByUserFunc: func(userID uint, revokedAt time.Time) error {
+			return nil
+		},
+	}
+	tokenUseCase := usecase.NewTokenUseCase(refreshTokenRepo, sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	uc := usecase.NewPasswordResetUseCase(userRepo, newTestPasswordPolicy(), tokenUseCase, mailer, "https://intranet.test/reset-password", time.Hour)
+	return api.NewPasswordResetHandler(echo.New(), uc), uc
+}
+
+func TestPasswordResetHandler_ForgotPasswordDoesNotRevealEmails(t *testing.T) {
+	e := echo.New()
+
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByEmailFunc: func(email string) (*model.User, error) {
+			if email == "test@example.com" {
+				return &model.User{Model: gorm.Model{ID: 1}, Email: email}, nil
+			}
+			return nil, errors.New("record not found")
+		},
+		UpdateFunc: func(user *model.User, filter *security.RowFilter) error {
+			return nil
+		},
+	}
+	mailer := new(testifyMocks.MockMailer)
+	mailer.On("SendTemplateTo", "test@example.com", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	handler, uc := newPasswordResetHandler(mockUserRepo, mailer)
+
+	responses := make([]string, 0, 2)
+	for _, email := range []string{"test@example.com", "unknown@example.com"} {
+		body, _ := json.Marshal(map[string]string{"email": email})
+		req := httptest.NewRequest(http.MethodPost, "/forgot-password", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if assert.NoError(t, handler.ForgotPassword(e.NewContext(req, rec))) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			responses = append(responses, rec.Body.String())
+		}
+	}
+
+	assert.Equal(t, responses[0], responses[1])
+	uc.Wait()
+	mailer.AssertNumberOfCalls(t, "SendTemplateTo", 1)
+}
+
+func TestPasswordResetHandler_ResetPasswordInvalidToken(t *testing.T) {
+	e := echo.New()
+
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByTokenFunc: func(token string) (*model.User, error) {
+			return nil, errors.New("record not found")
+		},
+	}
+	handler, _ := newPasswordResetHandler(mockUserRepo, new(testifyMocks.MockMailer))
+
+	body, _ := json.Marshal(map[string]string{"token": "invalid", "password": "new-password", "confirmPassword": "new-password"})
+	req := httptest.NewRequest(http.MethodPost, "/reset-password", bytes.NewBuffer(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if assert.NoError(t, handler.ResetPassword(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var actualResponse map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &actualResponse))
+		assert.Equal(t, "invalid or expired token", actualResponse["error"])
+	}
+}
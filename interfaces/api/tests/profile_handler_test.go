@@ -0,0 +1,192 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/interfaces/api"
+	"github.com/drossan/core-api/interfaces/api/tests/mocks"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newProfileHandler(t *testing.T, e *echo.Echo, userRepo *mocks.MockUserRepository) *api.ProfileHandler {
+	tokenUseCase := usecase.NewTokenUseCase(&mocks.MockRefreshTokenRepository{}, &mocks.MockSessionRepository{}, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	fileStorage := adapters.NewLocalStorage(t.TempDir(), "/uploads")
+	return api.NewProfileHandler(e, usecase.NewProfileUseCase(userRepo, utils.NewTestPasswordHasher(), newTestPasswordPolicy(), tokenUseCase, fileStorage, 1024*1024))
+}
+
+func TestProfileHandler_UpdateProfileOnlyTouchesCurrentUser(t *testing.T) {
+	e := echo.New()
+
+	var updatedID uint
+	var updatedProfile *model.Profile
+	mockUserRepo := &mocks.MockUserRepository{
+		UpdateProfileFunc: func(id uint, profile *model.Profile) error {
+			updatedID = id
+			updatedProfile = profile
+			return nil
+		},
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return &model.User{Model: gorm.Model{ID: id}, FullName: updatedProfile.FullName, Password: "hash", LevelID: 3}, nil
+		},
+	}
+
+	handler := newProfileHandler(t, e, mockUserRepo)
+
+	// El ID y el nivel del cuerpo se ignoran
+	body := `{"id": 2, "LevelID": 1, "fullname": "New Name", "language": "en"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/me", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 5}})
+
+	if assert.NoError(t, handler.UpdateProfile(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, uint(5), updatedID)
+		assert.Equal(t, &model.Profile{FullName: "New Name", Language: "en"}, updatedProfile)
+
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.NotContains(t, response.Data, "password")
+	}
+}
+
+func TestProfileHandler_ChangePasswordRejectsWrongCurrentPassword(t *testing.T) {
+	e := echo.New()
+
+	password, _ := utils.NewTestPasswordHasher().Hash("password")
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return &model.User{Model: gorm.Model{ID: id}, Password: password}, nil
+		},
+	}
+
+	handler := newProfileHandler(t, e, mockUserRepo)
+
+	body, _ := json.Marshal(map[string]string{
+		"currentPassword": "wrong",
+		"password":        "newpassword",
+		"confirmPassword": "newpassword",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/me/password", bytes.NewBuffer(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 5}})
+
+	if assert.NoError(t, handler.ChangePassword(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestProfileHandler_ChangePasswordReturnsPolicyFields(t *testing.T) {
+	e := echo.New()
+
+	password, _ := utils.NewTestPasswordHasher().Hash("password")
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return &model.User{Model: gorm.Model{ID: id}, Password: password}, nil
+		},
+	}
+
+	handler := newProfileHandler(t, e, mockUserRepo)
+
+	body, _ := json.Marshal(map[string]string{
+		"currentPassword": "password",
+		"password":        "qwerty",
+		"confirmPassword": "qwerty",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/me/password", bytes.NewBuffer(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 5}})
+
+	if assert.NoError(t, handler.ChangePassword(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var response struct {
+			Error  string             `json:"error"`
+			Fields []model.FieldError `json:"fields"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		if assert.Len(t, response.Fields, 1) {
+			assert.Equal(t, "password", response.Fields[0].Field)
+			assert.Equal(t, "common", response.Fields[0].Code)
+		}
+	}
+}
+
+func multipartPicture(t *testing.T, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("picture", filename)
+	assert.NoError(t, err)
+	_, _ = part.Write(content)
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/me/picture", &body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
+func TestProfileHandler_UploadPicture(t *testing.T) {
+	e := echo.New()
+
+	var savedPicture string
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return &model.User{Model: gorm.Model{ID: id}, FullName: "Test User"}, nil
+		},
+		UpdateProfileFunc: func(id uint, profile *model.Profile) error {
+			savedPicture = profile.Picture
+			return nil
+		},
+	}
+
+	handler := newProfileHandler(t, e, mockUserRepo)
+
+	var picture bytes.Buffer
+	assert.NoError(t, png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 300, 200))))
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(multipartPicture(t, "avatar.png", picture.Bytes()), rec)
+	c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 5}})
+
+	if assert.NoError(t, handler.UploadPicture(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, savedPicture, "/uploads/avatars/5/")
+	}
+}
+
+func TestProfileHandler_UploadPictureRejectsOtherFiles(t *testing.T) {
+	e := echo.New()
+
+	handler := newProfileHandler(t, e, &mocks.MockUserRepository{})
+
+	// La extensión no importa: se comprueba el contenido
+	rec := httptest.NewRecorder()
+	c := e.NewContext(multipartPicture(t, "avatar.png", []byte("%PDF-1.4 not an image")), rec)
+	c.Set("user", &jwt.Token{Claims: &model.Claim{UserID: 5}})
+
+	if assert.NoError(t, handler.UploadPicture(c)) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	}
+}
@@ -7,15 +7,22 @@ import (
 	"errors"
 	"fmt"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/memory"
 	"github.com/drossan/core-api/interfaces/api"
 	"github.com/drossan/core-api/interfaces/api/tests/mocks"
+	testifyMocks "github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
 	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 )
@@ -37,20 +44,62 @@ func generateToken(userID uint, jwtSecret string) (string, error) {
 	return token.SignedString([]byte(jwtSecret))
 }
 
+// newTestPasswordPolicy política de los tests; los niveles simulados no la
+// cambian y no guarda historial
+func newTestPasswordPolicy() *usecase.PasswordPolicyUseCase {
+	levelRepo := new(testifyMocks.MockLevelRepository)
+	levelRepo.On("GetByID", mock.Anything).Return(&model.Level{}, nil).Maybe()
+	return utils.NewTestPasswordPolicy(&mocks.MockPasswordHistoryRepository{}, levelRepo, utils.NewTestPasswordHasher())
+}
+
+// newTestPermissionResolver resuelve los privilegios de los tests sobre
+// niveles simulados sin privilegios
+func newTestPermissionResolver() *service.PermissionResolver {
+	levelRepo := new(testifyMocks.MockLevelRepository)
+	levelRepo.On("GetByID", mock.Anything).Return(&model.Level{}, nil).Maybe()
+	return service.NewPermissionResolver(levelRepo)
+}
+
+// newUserHandler construye el handler de usuarios sobre el repositorio simulado
+func newUserHandler(e *echo.Echo, userRepo *mocks.MockUserRepository) *api.UserHandler {
+	refreshTokenRepo := &mocks.MockRefreshTokenRepository{
+		CreateFunc: func(token *model.RefreshToken) error {
+			token.ID = 1
+			return nil
+		},
+		RevokeByUserFunc: func(userID uint, revokedAt time.Time) error {
+			return nil
+		},
+	}
+	sessionRepo := &mocks.MockSessionRepository{
+		CreateFunc: func(session *model.Session) error {
+			session.ID = 1
+			return nil
+		},
+		RevokeByUserFunc: func(userID uint, revokedAt time.Time) error {
+			return nil
+		},
+	}
+	tokenUseCase := usecase.NewTokenUseCase(refreshTokenRepo, sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	// Los usuarios de estos tests no tienen 2FA, así que no se usan códigos de recuperación
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, nil, tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, newTestPermissionResolver())
+	userUseCase := usecase.NewUserUseCase(userRepo, utils.NewTestPasswordHasher(), newTestPasswordPolicy(), tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), newTestPermissionResolver())
+	return api.NewUserHandler(e, userUseCase, tokenUseCase)
+}
+
 func TestUserHandler_CreateOrUpdateUser(t *testing.T) {
 	e := echo.New()
 
 	mockUserRepo := &mocks.MockUserRepository{
-		CreateFunc: func(user *model.User) error {
+		CreateFunc: func(user *model.User, filter *security.RowFilter) error {
 			user.ID = 1
 			return nil
 		},
 	}
 
-	userUseCase := usecase.NewUserUseCase(mockUserRepo)
-	handler := api.NewUserHandler(e, userUseCase)
+	handler := newUserHandler(e, mockUserRepo)
 
-	mockUser := &model.User{Username: "testuser", Email: "test@example.com"}
+	mockUser := &model.User{Username: "testuser", Email: "test@example.com", Password: "password"}
 	userJSON, _ := json.Marshal(mockUser)
 	req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewBuffer(userJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -80,8 +129,7 @@ func TestUserHandler_GetUserData(t *testing.T) {
 		},
 	}
 
-	userUseCase := usecase.NewUserUseCase(mockUserRepo)
-	handler := api.NewUserHandler(e, userUseCase)
+	handler := newUserHandler(e, mockUserRepo)
 
 	token, err := generateToken(1, "testsecret")
 	assert.NoError(t, err)
@@ -114,7 +162,7 @@ func TestUserHandler_PaginateUsers(t *testing.T) {
 	e := echo.New()
 
 	mockUserRepo := &mocks.MockUserRepository{
-		PaginateFunc: func(page int, pageSize int) ([]*model.User, int, error) {
+		PaginateFunc: func(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
 			users := []*model.User{
 				{
 					Model:    gorm.Model{ID: 1},
@@ -131,8 +179,7 @@ func TestUserHandler_PaginateUsers(t *testing.T) {
 		},
 	}
 
-	userUseCase := usecase.NewUserUseCase(mockUserRepo)
-	handler := api.NewUserHandler(e, userUseCase)
+	handler := newUserHandler(e, mockUserRepo)
 
 	req := httptest.NewRequest(http.MethodGet, "/users/1?rows=2", nil)
 	rec := httptest.NewRecorder()
@@ -160,8 +207,7 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 		},
 	}
 
-	userUseCase := usecase.NewUserUseCase(mockUserRepo)
-	handler := api.NewUserHandler(e, userUseCase)
+	handler := newUserHandler(e, mockUserRepo)
 
 	mockUser := &model.User{
 		Model: gorm.Model{ID: 1},
@@ -184,6 +230,11 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 func TestUserHandler_Login(t *testing.T) {
 	e := echo.New()
 
+	_ = os.Setenv("JWT_SECRET", "test_secret")
+	defer func() {
+		_ = os.Unsetenv("JWT_SECRET")
+	}()
+
 	// Crear los mocks
 	mockUserRepo := &mocks.MockUserRepository{
 		GetByEmailFunc: func(email string) (*model.User, error) {
@@ -196,18 +247,17 @@ func TestUserHandler_Login(t *testing.T) {
 					Password: pwd,
 				}, nil
 			}
-			return nil, errors.New("invalid email or password")
+			return nil, errors.New("record not found")
 		},
-		LoginFunc: func(email, password string) (string, error) {
-			if email == "test@example.com" && password == "password" {
-				return "mockToken", nil
-			}
-			return "", errors.New("invalid email or password")
+		UpdateFunc: func(user *model.User, filter *security.RowFilter) error {
+			return nil
+		},
+		IncrementFailureFunc: func(id uint) (int, error) {
+			return 1, nil
 		},
 	}
 
-	userUseCase := usecase.NewUserUseCase(mockUserRepo)
-	handler := api.NewUserHandler(e, userUseCase)
+	handler := newUserHandler(e, mockUserRepo)
 
 	// Prueba de login exitoso
 	mockCredentials := map[string]string{
@@ -238,7 +288,7 @@ func TestUserHandler_Login(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, float64(1), claims["user_id"])
 		assert.Equal(t, "test@example.com", claims["email"])
-		assert.Equal(t, "Intranet API - generate by IslaIT", claims["iss"])
+		assert.Equal(t, "Intranet API - Generate by IslaIT", claims["iss"])
 	}
 
 	// Prueba de login fallido
@@ -263,3 +313,61 @@ func TestUserHandler_Login(t *testing.T) {
 		assert.Equal(t, expectedResponse, actualResponse)
 	}
 }
+
+func TestUserHandler_LoginLockedAccount(t *testing.T) {
+	e := echo.New()
+
+	lockedUntil := time.Now().Add(90 * time.Second)
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByEmailFunc: func(email string) (*model.User, error) {
+			return &model.User{
+				Model:       gorm.Model{ID: 1},
+				Email:       email,
+				LockedUntil: &lockedUntil,
+			}, nil
+		},
+	}
+
+	handler := newUserHandler(e, mockUserRepo)
+
+	credentialsJSON, _ := json.Marshal(map[string]string{
+		"email":    "test@example.com",
+		"password": "password",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBuffer(credentialsJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, handler.Login(c)) {
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "90", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestUserHandler_UnlockUser(t *testing.T) {
+	e := echo.New()
+
+	var unlockedID uint
+	mockUserRepo := &mocks.MockUserRepository{
+		GetByIDFunc: func(id uint) (*model.User, error) {
+			return &model.User{Model: gorm.Model{ID: id}}, nil
+		},
+		ResetFailuresFunc: func(id uint) error {
+			unlockedID = id
+			return nil
+		},
+	}
+
+	handler := newUserHandler(e, mockUserRepo)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/user/unlock", bytes.NewBufferString(`{"id":5}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, handler.UnlockUser(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, uint(5), unlockedID)
+	}
+}
@@ -1,61 +1,96 @@
 package api
 
 import (
+	"errors"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/helpers"
+	"math"
 	"net/http"
 	"strconv"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/middleware"
 	"github.com/drossan/core-api/usecase"
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	userUseCase *usecase.UserUseCase
+	userUseCase  *usecase.UserUseCase
+	tokenUseCase *usecase.TokenUseCase
 }
 
-func NewUserHandler(e *echo.Echo, uc *usecase.UserUseCase) *UserHandler {
-	return &UserHandler{userUseCase: uc}
+func NewUserHandler(e *echo.Echo, uc *usecase.UserUseCase, tokenUC *usecase.TokenUseCase) *UserHandler {
+	return &UserHandler{userUseCase: uc, tokenUseCase: tokenUC}
 }
 
-func (h *UserHandler) RegisterRoutes(g *echo.Group) {
-	g.GET("/users/:page", h.PaginateUsers)
-	g.GET("/user", h.GetUserData)
-	g.POST("/user", h.CreateOrUpdateUser)
-	g.POST("/user/delete", h.DeleteUser)
+func (h *UserHandler) RegisterRoutes(g *ProtectedGroup) {
+	g.GET("/users/:page", h.PaginateUsers, security.Read("user"))
+	g.GET("/user", h.GetUserData, security.Read("user"))
+	g.POST("/user", h.CreateOrUpdateUser, security.Create("user").WithoutImpersonation())
+	g.PUT("/user", h.CreateOrUpdateUser, security.Update("user").WithoutImpersonation())
+	g.POST("/user/delete", h.DeleteUser, security.Delete("user").WithoutImpersonation())
+	g.POST("/user/revoke-tokens", h.RevokeUserTokens, security.Update("user").WithoutImpersonation())
+	g.POST("/user/unlock", h.UnlockUser, security.Update("user").WithoutImpersonation())
+	g.POST("/user/suspend", h.SuspendUser, security.Update("user").WithoutImpersonation())
+	g.POST("/user/reactivate", h.ReactivateUser, security.Update("user").WithoutImpersonation())
+	g.POST("/user/levels", h.SetUserLevels, security.Update("user").WithoutImpersonation())
+	g.GET("/users/deleted/:page", h.PaginateDeletedUsers, security.Read("user"))
+	g.POST("/user/restore", h.RestoreUser, security.Update("user").WithoutImpersonation())
+	g.POST("/user/purge", h.PurgeUser, security.Delete("user").WithoutImpersonation())
 }
 
 func (h *UserHandler) AuthRoutes(e *echo.Group) {
 	e.POST("/login", h.Login)
+	e.POST("/login/password", h.ChangeExpiredPassword)
+	e.POST("/refresh", h.Refresh)
+}
+
+// SessionRoutes registra las rutas que solo requieren un token válido
+func (h *UserHandler) SessionRoutes(g *echo.Group) {
+	g.POST("/logout", h.Logout)
 }
 
 // CreateOrUpdateUser godoc
 // @Summary Create or update a user
-// @Description Create or update a user with the input payload
+// @Description Create a user with POST, without an ID, or update the user with the given ID with PUT. Each method requires its own privilege (create or update). The password is required when creating and optional when updating; if sent it must meet the password policy, otherwise the response lists the failed rules in fields.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body model.User true "User"
 // @Success 201 {object} model.User
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /user [post]
+// @Router /user [put]
 func (h *UserHandler) CreateOrUpdateUser(c echo.Context) error {
 	user := new(model.User)
 	if err := c.Bind(user); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
+	if err := checkSaveMethod(c, user.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
 
 	if user.ID != 0 {
-		err := h.userUseCase.UpdateUser(user)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
-		}
+		err = h.userUseCase.UpdateUser(user, filter)
 	} else {
-		err := h.userUseCase.CreateUser(user)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
-		}
+		err = h.userUseCase.CreateUser(user, filter)
+	}
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	var policyErr *usecase.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return passwordRejected(c, policyErr)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, user)
@@ -63,7 +98,7 @@ func (h *UserHandler) CreateOrUpdateUser(c echo.Context) error {
 
 // GetUserData obtiene los datos del usuario actual
 // @Summary Get current user data
-// @Description Get data for the current user based on the token
+// @Description Get data for the current user based on the token. privileges holds the effective privileges of all the levels of the user and of the levels they inherit from. While impersonating, impersonated is true and impersonated_by holds the real user.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -72,13 +107,19 @@ func (h *UserHandler) CreateOrUpdateUser(c echo.Context) error {
 // @Failure 500 {object} map[string]interface{}
 // @Router /user [get]
 func (h *UserHandler) GetUserData(c echo.Context) error {
-	userID := helpers.GetCurrentUser(c)
+	claims := helpers.GetCurrentClaims(c)
 
-	user, err := h.userUseCase.GetUserByID(userID)
+	user, err := h.userUseCase.GetUserWithPrivileges(claims.UserID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
 
+	// Aviso para que el frontend muestre que se está suplantando al usuario
+	if claims.Actor != nil {
+		user.Impersonated = true
+		user.ImpersonatedBy = claims.Actor
+	}
+
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -105,7 +146,12 @@ func (h *UserHandler) PaginateUsers(c echo.Context) error {
 		rows = 50
 	}
 
-	users, total, err := h.userUseCase.PaginateUsers(page, rows)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	users, total, err := h.userUseCase.PaginateUsers(page, rows, filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -125,6 +171,7 @@ func (h *UserHandler) PaginateUsers(c echo.Context) error {
 // @Param user body model.User true "User"
 // @Success 200 {object} model.User
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /user/delete [post]
 func (h *UserHandler) DeleteUser(c echo.Context) error {
@@ -133,7 +180,15 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	err := h.userUseCase.DeleteUser(user)
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.DeleteUser(user, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
 	}
@@ -143,14 +198,16 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 
 // Login godoc
 // @Summary Login a user
-// @Description Login a user with the input payload
+// @Description Login a user with the input payload. If the password has expired the response contains a password_token to set a new one on /login/password, and if the user needs a second factor it contains an mfa_token to complete the login on /login/mfa instead of the session tokens.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body map[string]string true "Credentials"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /login [post]
 func (h *UserHandler) Login(c echo.Context) error {
 	user := new(model.User)
@@ -158,15 +215,451 @@ func (h *UserHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	token, err := h.userUseCase.Login(user.Email, user.Password)
+	tokens, err := h.userUseCase.Login(user.Email, user.Password, helpers.GetClientInfo(c))
+	var expiredErr *usecase.PasswordExpiredError
+	if errors.As(err, &expiredErr) {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": 200,
+			"data":   expiredErr.Challenge,
+		})
+	}
+	var mfaErr *usecase.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": 200,
+			"data":   mfaErr.Challenge,
+		})
+	}
+	var lockedErr *usecase.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		return accountLocked(c, lockedErr)
+	}
+	if errors.Is(err, usecase.ErrAccountSuspended) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
 		"status": 200,
-		"data": echo.Map{
-			"token": token,
-		},
+		"data":   tokens,
 	})
 }
+
+// ChangeExpiredPassword godoc
+// @Summary Change an expired password
+// @Description Set a new password with the password_token returned by /login when the password has expired. The new password must meet the password policy. Returns the session tokens, or an mfa_token if the user needs a second factor.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param password body model.ExpiredPasswordChange true "Password token and new password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /login/password [post]
+func (h *UserHandler) ChangeExpiredPassword(c echo.Context) error {
+	request := new(model.ExpiredPasswordChange)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	tokens, err := h.userUseCase.ChangeExpiredPassword(request, helpers.GetClientInfo(c))
+	var policyErr *usecase.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return passwordRejected(c, policyErr)
+	}
+	var mfaErr *usecase.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": 200,
+			"data":   mfaErr.Challenge,
+		})
+	}
+	if errors.Is(err, usecase.ErrPasswordMismatch) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrInvalidPasswordToken) {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   tokens,
+	})
+}
+
+// passwordRejected responde 400 con un error por cada regla de la política
+// que no cumple la contraseña
+func passwordRejected(c echo.Context, policyErr *usecase.PasswordPolicyError) error {
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":  policyErr.Error(),
+		"fields": policyErr.Fields,
+	})
+}
+
+// accountLocked responde 429 indicando cuándo se puede volver a intentar
+func accountLocked(c echo.Context, lockedErr *usecase.AccountLockedError) error {
+	retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return c.JSON(http.StatusTooManyRequests, map[string]interface{}{"error": lockedErr.Error()})
+}
+
+// Refresh godoc
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access and refresh token pair. The refresh token is rotated on every use.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body map[string]string true "Refresh token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /refresh [post]
+func (h *UserHandler) Refresh(c echo.Context) error {
+	request := new(struct {
+		RefreshToken string `json:"refresh_token"`
+	})
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	tokens, err := h.tokenUseCase.Refresh(request.RefreshToken)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"status": 200,
+		"data":   tokens,
+	})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and, if sent, the refresh token family
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body map[string]string false "Refresh token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /logout [post]
+func (h *UserHandler) Logout(c echo.Context) error {
+	request := new(struct {
+		RefreshToken string `json:"refresh_token"`
+	})
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	if err := h.tokenUseCase.Logout(helpers.GetCurrentClaims(c), request.RefreshToken); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// RevokeUserTokens godoc
+// @Summary Revoke all tokens of a user
+// @Description Invalidate every access and refresh token issued to the user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/revoke-tokens [post]
+func (h *UserHandler) RevokeUserTokens(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.RevokeUserTokens(user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Reset the failed login counter and remove the lockout of a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/unlock [post]
+func (h *UserHandler) UnlockUser(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.UnlockUser(user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Block the account without deleting it. The user cannot log in and every token and API key stops working until the account is reactivated.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param status body model.UserStatusChange true "User ID and reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/suspend [post]
+func (h *UserHandler) SuspendUser(c echo.Context) error {
+	change := new(model.UserStatusChange)
+	if err := c.Bind(change); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.SuspendUser(helpers.GetCurrentUser(c), change, filter)
+	switch {
+	case errors.Is(err, usecase.ErrRowAccessDenied):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCannotSuspendSelf):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate a user
+// @Description Return a suspended account to the active status
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/reactivate [post]
+func (h *UserHandler) ReactivateUser(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.ReactivateUser(helpers.GetCurrentUser(c), user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// SetUserLevels godoc
+// @Summary Set the additional levels of a user
+// @Description Replace the additional levels of a user. Their privileges are added to those of the main level (LevelID). Users cannot change their own levels nor grant levels with privileges they do not have. Tokens already issued keep the previous levels until they are refreshed.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param levels body model.UserLevelsChange true "User ID and level IDs"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/levels [post]
+func (h *UserHandler) SetUserLevels(c echo.Context) error {
+	change := new(model.UserLevelsChange)
+	if err := c.Bind(change); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.SetUserLevels(helpers.GetCurrentUser(c), change, filter)
+	switch {
+	case errors.Is(err, usecase.ErrRowAccessDenied), errors.Is(err, usecase.ErrCannotChangeOwnLevels), errors.Is(err, usecase.ErrLevelNotGrantable):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUserLevelNotFound):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// PaginateDeletedUsers godoc
+// @Summary Paginate deleted users
+// @Description Get a paginated list of deleted users that can still be restored or purged
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param page path int true "Page number"
+// @Param rows query int false "Rows per page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /users/deleted/{page} [get]
+func (h *UserHandler) PaginateDeletedUsers(c echo.Context) error {
+	page, err := strconv.Atoi(c.Param("page"))
+	if err != nil || page < 1 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid page number"})
+	}
+
+	rows, err := strconv.Atoi(c.QueryParam("rows"))
+	if err != nil || rows < 1 {
+		rows = 50
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	users, total, err := h.userUseCase.PaginateDeletedUsers(page, rows, filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"items": users,
+		"total": total,
+	})
+}
+
+// RestoreUser godoc
+// @Summary Restore a deleted user
+// @Description Undo the deletion of a user, who becomes active again
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/restore [post]
+func (h *UserHandler) RestoreUser(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.RestoreUser(helpers.GetCurrentUser(c), user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrUserNotDeleted) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
+
+// PurgeUser godoc
+// @Summary Purge a deleted user
+// @Description Permanently remove a deleted user and its credentials. Only users that were deleted first can be purged and it cannot be undone.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "User"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /user/purge [post]
+func (h *UserHandler) PurgeUser(c echo.Context) error {
+	user := new(model.User)
+	if err := c.Bind(user); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	err = h.userUseCase.PurgeUser(helpers.GetCurrentUser(c), user.ID, filter)
+	if errors.Is(err, usecase.ErrRowAccessDenied) {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+	if errors.Is(err, usecase.ErrUserNotDeleted) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"status": 200})
+}
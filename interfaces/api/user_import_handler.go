@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/drossan/core-api/domain/importer"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/middleware"
+	"github.com/drossan/core-api/usecase"
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// UserImportHandler bulk creates users from a CSV or XLSX file
+type UserImportHandler struct {
+	userImportUseCase *usecase.UserImportUseCase
+}
+
+// NewUserImportHandler initializes a new UserImportHandler
+func NewUserImportHandler(e *echo.Echo, uc *usecase.UserImportUseCase) *UserImportHandler {
+	return &UserImportHandler{userImportUseCase: uc}
+}
+
+// RegisterRoutes registra la importación, que requiere privilegios sobre usuarios
+func (h *UserImportHandler) RegisterRoutes(g *ProtectedGroup) {
+	// El límite incluye margen para el resto del cuerpo multipart
+	bodyLimit := fmt.Sprintf("%dK", h.userImportUseCase.MaxFileSize()/1024+64)
+	g.POST("/users/import", h.ImportUsers, security.Create("user").WithoutImpersonation(), echomiddleware.BodyLimit(bodyLimit))
+}
+
+// ImportUsers godoc
+// @Summary Import users
+// @Description Create users in bulk from a CSV or XLSX file with the columns username, email, fullname and level (the level name), which must not have privileges the importing user lacks. Every row is validated first and, if any fails, nothing is imported. With dry_run the file is only validated; with invite the users receive an invitation to choose their password instead of being created directly.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run formData bool false "Only validate the file"
+// @Param invite formData bool false "Send invitations instead of creating the accounts"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /users/import [post]
+func (h *UserImportHandler) ImportUsers(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if fileHeader.Size > h.userImportUseCase.MaxFileSize() {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": usecase.ErrImportTooLarge.Error()})
+	}
+
+	options := model.UserImportOptions{}
+	options.DryRun, _ = strconv.ParseBool(c.FormValue("dry_run"))
+	options.Invite, _ = strconv.ParseBool(c.FormValue("invite"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	defer file.Close()
+
+	filter, err := middleware.RowFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	result, err := h.userImportUseCase.Import(helpers.GetCurrentUser(c), filepath.Ext(fileHeader.Filename), file, options, filter)
+	switch {
+	case errors.Is(err, usecase.ErrRowAccessDenied), errors.Is(err, usecase.ErrLevelNotGrantable):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrImportInvalidRows):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+			"rows":  result.Errors,
+		})
+	case errors.Is(err, usecase.ErrUnsupportedImportFormat):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, usecase.ErrImportTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, importer.ErrInvalidFile),
+		errors.Is(err, usecase.ErrImportMissingColumn),
+		errors.Is(err, usecase.ErrImportEmpty),
+		errors.Is(err, usecase.ErrImportTooManyRows):
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	if options.DryRun {
+		return c.JSON(http.StatusOK, echo.Map{
+			"status": 200,
+			"data":   result,
+		})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"status": 201,
+		"data":   result,
+	})
+}
@@ -1,74 +1,105 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/service"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
-// AuthorizationMiddlewareConfig guarda las dependencias necesarias para el middleware
+// PermissionsStaleHeader cabecera de respuesta que indica que los privilegios
+// del access token están obsoletos y conviene refrescarlo
+const PermissionsStaleHeader = "X-Permissions-Stale"
+
+// rowFilterKey clave del contexto con la función que calcula las condiciones
+// de acceso a filas de la petición
+const rowFilterKey = "row_filter"
+
+// AuthorizationMiddlewareConfig guarda las dependencias necesarias para el middleware.
+// RoutePermissions es el registro con el permiso que declara cada ruta.
 type AuthorizationMiddlewareConfig struct {
 	LevelRepo           repository.LevelRepository
 	FormRepo            repository.FormRepository
 	LevelPrivilegesRepo repository.LevelPrivilegesRepository
+	RoutePermissions    *security.RoutePermissions
 }
 
-// AuthorizationMiddleware verifica los permisos del usuario
+// AuthorizationMiddleware verifica los permisos del usuario sobre el recurso
+// que declara la ruta: la unión de los privilegios de todos sus niveles y de
+// los niveles de los que heredan. Las rutas sin permiso declarado se deniegan.
 func AuthorizationMiddleware(config AuthorizationMiddlewareConfig, prefix string) echo.MiddlewareFunc {
+	permissions := service.NewPermissionResolver(config.LevelRepo)
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			userToken := c.Get("user").(*jwt.Token)
 			claims := userToken.Claims.(*model.Claim)
-			levelID := claims.LevelID
+			method := c.Request().Method
 
-			// Obtener el path después de /api/v1/
-			path := strings.TrimPrefix(c.Path(), "/"+prefix+"/")
-			segments := strings.Split(path, "/")
-			path = segments[0]
+			permission, ok := config.RoutePermissions.Lookup(method, c.Path())
+			if !ok {
+				log.Printf("Route %s %s has no permission mapping", method, c.Path())
+				return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "04 - Access denied"})
+			}
 
-			// Obtener el nivel del usuario
-			level, err := config.LevelRepo.GetByID(levelID)
+			// Las API keys también aceptan scopes que nombran el primer
+			// segmento de la ruta en lugar del recurso
+			segment := strings.Split(strings.TrimPrefix(c.Path(), "/"+prefix+"/"), "/")[0]
+			decision, err := permissions.Authorize(claims, permission.Resource, permission.Action, segment)
+			// Se avisa al cliente de que refresque el token cuando sus
+			// privilegios han cambiado
+			if decision.Stale {
+				c.Response().Header().Set(PermissionsStaleHeader, "true")
+			}
 			if err != nil {
 				return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "01 - Access denied"})
 			}
-
-			// Verificar los privilegios del nivel del usuario
-			hasAccess := false
-			method := c.Request().Method
-
-			for _, privilege := range level.LevelPrivileges {
-				pathAPI := strings.Split(privilege.Form.PathAPI, "|")
-
-				if len(pathAPI) > 1 && (pathAPI[0] == path || pathAPI[1] == path) {
-					if method == http.MethodGet && privilege.Read {
-						hasAccess = true
-						break
-					} else if (method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete) && privilege.Write {
-						hasAccess = true
-						break
-					}
-				}
+			if decision.Denial == service.DenialScope {
+				return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "03 - Access denied"})
 			}
-
-			if !hasAccess {
+			if !decision.Allowed {
 				return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "02 - Access denied"})
 			}
 
+			// Las condiciones de acceso a filas solo se calculan si el handler
+			// las pide, para no consultar los niveles en cada petición
+			c.Set(rowFilterKey, func() (*security.RowFilter, error) {
+				subject := security.RowSubject{
+					security.RowSubjectID:      claims.UserID,
+					security.RowSubjectEmail:   claims.Email,
+					security.RowSubjectLevelID: claims.LevelID,
+				}
+				return permissions.RowFilter(permission.Resource, permission.Action, subject, claims.AllLevelIDs()...)
+			})
+
 			return next(c)
 		}
 	}
 }
 
+// RowFilter devuelve las condiciones de acceso a filas que los niveles del
+// usuario imponen sobre el recurso y la acción de la ruta. Sin
+// AuthorizationMiddleware la ruta no tiene condiciones y devuelve nil.
+func RowFilter(c echo.Context) (*security.RowFilter, error) {
+	resolve, ok := c.Get(rowFilterKey).(func() (*security.RowFilter, error))
+	if !ok {
+		return nil, nil
+	}
+	return resolve()
+}
+
 // NewAuthorizationMiddleware crea una nueva instancia de AuthorizationMiddlewareConfig y la devuelve como un middleware
-func NewAuthorizationMiddleware(levelRepo repository.LevelRepository, formRepo repository.FormRepository, levelPrivilegesRepo repository.LevelPrivilegesRepository, prefix string) echo.MiddlewareFunc {
+func NewAuthorizationMiddleware(levelRepo repository.LevelRepository, formRepo repository.FormRepository, levelPrivilegesRepo repository.LevelPrivilegesRepository, routePermissions *security.RoutePermissions, prefix string) echo.MiddlewareFunc {
 	config := AuthorizationMiddlewareConfig{
 		LevelRepo:           levelRepo,
 		FormRepo:            formRepo,
 		LevelPrivilegesRepo: levelPrivilegesRepo,
+		RoutePermissions:    routePermissions,
 	}
 
 	return AuthorizationMiddleware(config, prefix)
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonationAudit deja constancia de cada petición hecha con un token de
+// suplantación, con el usuario suplantado y el administrador que actúa
+func ImpersonationAudit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := c.Get("user").(*jwt.Token).Claims.(*model.Claim)
+			if claims.Actor == nil {
+				return next(c)
+			}
+
+			err := next(c)
+			status := c.Response().Status
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				status = httpErr.Code
+			}
+			log.Printf("Impersonation: user %d <%s> acting as user %d <%s>: %s %s -> %d",
+				claims.Actor.UserID, claims.Actor.Email, claims.UserID, claims.Email,
+				c.Request().Method, c.Request().URL.Path, status)
+			return err
+		}
+	}
+}
+
+// ImpersonationGuard impide usar mientras se suplanta a otro usuario las rutas
+// protegidas que lo declaran en el registro de permisos (contraseñas, 2FA,
+// credenciales, niveles y privilegios...), como el middleware de autorización
+// lee su permiso, de forma que una ruta nueva no se puede quedar fuera de una
+// lista aparte. Las lecturas (GET) siempre se permiten.
+func ImpersonationGuard(permissions *security.RoutePermissions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if impersonating(c) && permissions.BlocksImpersonation(c.Request().Method, c.Path()) {
+				return impersonationForbidden(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// DenyImpersonation impide usar mientras se suplanta a otro usuario las rutas
+// de sesión que lo añaden, que no pasan por el registro de permisos. Las
+// lecturas (GET) siempre se permiten.
+func DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if impersonating(c) {
+				return impersonationForbidden(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// impersonating indica si la petición modifica algo con un token de suplantación
+func impersonating(c echo.Context) bool {
+	claims := c.Get("user").(*jwt.Token).Claims.(*model.Claim)
+	return claims.Actor != nil && c.Request().Method != http.MethodGet
+}
+
+func impersonationForbidden(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "This action is not allowed while impersonating a user"})
+}
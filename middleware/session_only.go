@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// SessionOnly rechaza las peticiones autenticadas con API key. Se usa en las
+// rutas de la propia cuenta (perfil, contraseña, 2FA, claves...), que no pasan
+// por la comprobación de privilegios y no deben quedar al alcance de una clave.
+func SessionOnly() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userToken := c.Get("user").(*jwt.Token)
+			claims := userToken.Claims.(*model.Claim)
+			if claims.APIKeyID != 0 {
+				return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "API keys cannot access this resource"})
+			}
+
+			return next(c)
+		}
+	}
+}
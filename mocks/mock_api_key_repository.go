@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(keyHash string) (*model.APIKey, error) {
+	args := m.Called(keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByUser(userID uint) ([]*model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uint, userID uint, revokedAt time.Time) (bool, error) {
+	args := m.Called(id, userID, revokedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	args := m.Called(userID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,8 +10,8 @@ type MockFormRepository struct {
 	mock.Mock
 }
 
-func (m *MockFormRepository) CreateOrUpdate(form *model.Form) error {
-	args := m.Called(form)
+func (m *MockFormRepository) CreateOrUpdate(form *model.Form, filter *security.RowFilter) error {
+	args := m.Called(form, filter)
 	return args.Error(0)
 }
 
@@ -19,13 +20,18 @@ func (m *MockFormRepository) GetByID(id uint) (*model.Form, error) {
 	return args.Get(0).(*model.Form), args.Error(1)
 }
 
-func (m *MockFormRepository) GetAll() ([]*model.Form, error) {
-	args := m.Called()
+func (m *MockFormRepository) GetAll(filter *security.RowFilter) ([]*model.Form, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*model.Form), args.Error(1)
 }
 
-func (m *MockFormRepository) Paginate(page, pageSize int) ([]*model.Form, int, error) {
-	args := m.Called(page, pageSize)
+func (m *MockFormRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	args := m.Called(id, filter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFormRepository) Paginate(page, pageSize int, filter *security.RowFilter) ([]*model.Form, int, error) {
+	args := m.Called(page, pageSize, filter)
 	return args.Get(0).([]*model.Form), args.Int(1), args.Error(2)
 }
 
@@ -33,3 +39,8 @@ func (m *MockFormRepository) Delete(form *model.Form) error {
 	args := m.Called(form)
 	return args.Error(0)
 }
+
+func (m *MockFormRepository) UnknownColumns(pathAPI string, columns []string) ([]string, error) {
+	args := m.Called(pathAPI, columns)
+	return args.Get(0).([]string), args.Error(1)
+}
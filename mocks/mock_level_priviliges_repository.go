@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,16 +10,29 @@ type MockLevelPrivilegesRepository struct {
 	mock.Mock
 }
 
-func (m *MockLevelPrivilegesRepository) CreateOrUpdate(levelPrivilege *model.LevelPrivileges) error {
-	args := m.Called(levelPrivilege)
+func (m *MockLevelPrivilegesRepository) CreateOrUpdate(levelPrivilege *model.LevelPrivileges, filter *security.RowFilter) error {
+	args := m.Called(levelPrivilege, filter)
 	return args.Error(0)
 }
 
-func (m *MockLevelPrivilegesRepository) GetAll() ([]*model.LevelPrivileges, error) {
-	args := m.Called()
+func (m *MockLevelPrivilegesRepository) GetByID(id uint) (*model.LevelPrivileges, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LevelPrivileges), args.Error(1)
+}
+
+func (m *MockLevelPrivilegesRepository) GetAll(filter *security.RowFilter) ([]*model.LevelPrivileges, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*model.LevelPrivileges), args.Error(1)
 }
 
+func (m *MockLevelPrivilegesRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	args := m.Called(id, filter)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockLevelPrivilegesRepository) Delete(levelPrivilege *model.LevelPrivileges) error {
 	args := m.Called(levelPrivilege)
 	return args.Error(0)
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,8 +10,8 @@ type MockLevelRepository struct {
 	mock.Mock
 }
 
-func (m *MockLevelRepository) CreateOrUpdate(level *model.Level) error {
-	args := m.Called(level)
+func (m *MockLevelRepository) CreateOrUpdate(level *model.Level, filter *security.RowFilter) error {
+	args := m.Called(level, filter)
 	return args.Error(0)
 }
 
@@ -19,13 +20,18 @@ func (m *MockLevelRepository) GetByID(id uint) (*model.Level, error) {
 	return args.Get(0).(*model.Level), args.Error(1)
 }
 
-func (m *MockLevelRepository) GetAll() ([]*model.Level, error) {
-	args := m.Called()
+func (m *MockLevelRepository) GetAll(filter *security.RowFilter) ([]*model.Level, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*model.Level), args.Error(1)
 }
 
-func (m *MockLevelRepository) Paginate(page, pageSize int) ([]*model.Level, int, error) {
-	args := m.Called(page, pageSize)
+func (m *MockLevelRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	args := m.Called(id, filter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLevelRepository) Paginate(page, pageSize int, filter *security.RowFilter) ([]*model.Level, int, error) {
+	args := m.Called(page, pageSize, filter)
 	return args.Get(0).([]*model.Level), args.Int(1), args.Error(2)
 }
 
@@ -33,3 +39,18 @@ func (m *MockLevelRepository) Delete(level *model.Level) error {
 	args := m.Called(level)
 	return args.Error(0)
 }
+
+func (m *MockLevelRepository) GetPermissionVersion(id uint) (uint, error) {
+	args := m.Called(id)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockLevelRepository) BumpPermissionVersion(ids ...uint) error {
+	args := m.Called(ids)
+	return args.Error(0)
+}
+
+func (m *MockLevelRepository) BumpPermissionVersionByForm(formID uint) error {
+	args := m.Called(formID)
+	return args.Error(0)
+}
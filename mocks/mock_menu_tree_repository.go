@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,8 +10,8 @@ type MockMenuTreeRepository struct {
 	mock.Mock
 }
 
-func (m *MockMenuTreeRepository) CreateOrUpdate(MenuTree *model.MenuTree) error {
-	args := m.Called(MenuTree)
+func (m *MockMenuTreeRepository) CreateOrUpdate(MenuTree *model.MenuTree, filter *security.RowFilter) error {
+	args := m.Called(MenuTree, filter)
 	return args.Error(0)
 }
 
@@ -19,13 +20,18 @@ func (m *MockMenuTreeRepository) GetByID(id uint) (*model.MenuTree, error) {
 	return args.Get(0).(*model.MenuTree), args.Error(1)
 }
 
-func (m *MockMenuTreeRepository) GetAll() ([]*model.MenuTree, error) {
-	args := m.Called()
+func (m *MockMenuTreeRepository) GetAll(filter *security.RowFilter) ([]*model.MenuTree, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*model.MenuTree), args.Error(1)
 }
 
-func (m *MockMenuTreeRepository) Paginate(page, pageSize int) ([]*model.MenuTree, int, error) {
-	args := m.Called(page, pageSize)
+func (m *MockMenuTreeRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	args := m.Called(id, filter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMenuTreeRepository) Paginate(page, pageSize int, filter *security.RowFilter) ([]*model.MenuTree, int, error) {
+	args := m.Called(page, pageSize, filter)
 	return args.Get(0).([]*model.MenuTree), args.Int(1), args.Error(2)
 }
 
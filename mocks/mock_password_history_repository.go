@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHistoryRepository) Create(entry *model.PasswordHistory) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) GetRecent(userID uint, limit int) ([]*model.PasswordHistory, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]*model.PasswordHistory), args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) Prune(userID uint, keep int) error {
+	args := m.Called(userID, keep)
+	return args.Error(0)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(token *model.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(id uint, revokedAt time.Time, replacedBy *uint) (bool, error) {
+	args := m.Called(id, revokedAt, replacedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	args := m.Called(familyID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	args := m.Called(userID, revokedAt)
+	return args.Error(0)
+}
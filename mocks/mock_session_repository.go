@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session *model.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(id uint) (*model.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetByFamily(familyID string) (*model.Session, error) {
+	args := m.Called(familyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetByUser(userID uint) ([]*model.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetActiveByUser(userID uint, seenAfter time.Time) ([]*model.Session, error) {
+	args := m.Called(userID, seenAfter)
+	return args.Get(0).([]*model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(id uint, seenAt time.Time) error {
+	args := m.Called(id, seenAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(id uint, userID uint, revokedAt time.Time) (bool, error) {
+	args := m.Called(id, userID, revokedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeByUser(userID uint, revokedAt time.Time) error {
+	args := m.Called(userID, revokedAt)
+	return args.Error(0)
+}
@@ -1,26 +1,24 @@
 package mocks
 
 import (
-	"crypto/sha256"
-	"errors"
-	"fmt"
+	"time"
+
 	"github.com/drossan/core-api/domain/model"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/stretchr/testify/mock"
-	"time"
 )
 
 type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *model.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Create(user *model.User, filter *security.RowFilter) error {
+	args := m.Called(user, filter)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Update(user *model.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Update(user *model.User, filter *security.RowFilter) error {
+	args := m.Called(user, filter)
 	return args.Error(0)
 }
 
@@ -29,18 +27,33 @@ func (m *MockUserRepository) GetByID(id uint) (*model.User, error) {
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDUnscoped(id uint) (*model.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetAll() ([]*model.User, error) {
-	args := m.Called()
+func (m *MockUserRepository) GetByToken(token string) (*model.User, error) {
+	args := m.Called(token)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAll(filter *security.RowFilter) ([]*model.User, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Paginate(page int, pageSize int) ([]*model.User, int, error) {
-	args := m.Called(page, pageSize)
+func (m *MockUserRepository) MatchesFilter(id uint, filter *security.RowFilter) (bool, error) {
+	args := m.Called(id, filter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Paginate(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	args := m.Called(page, pageSize, filter)
 	return args.Get(0).([]*model.User), args.Int(1), args.Error(2)
 }
 
@@ -49,25 +62,87 @@ func (m *MockUserRepository) Delete(user *model.User) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Login(email, password string) (string, error) {
-	user, err := m.GetByEmail(email)
-	if err != nil {
-		return "", errors.New("invalid email or password")
-	}
+func (m *MockUserRepository) IncrementFailure(id uint) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) LockUntil(id uint, until time.Time) error {
+	args := m.Called(id, until)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetFailures(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
 
-	ps := sha256.Sum256([]byte(password))
-	pwd := fmt.Sprintf("%x", ps)
+func (m *MockUserRepository) UpdateMFA(id uint, secret string, enabled bool) error {
+	args := m.Called(id, secret, enabled)
+	return args.Error(0)
+}
 
-	if pwd == user.Password {
-		claims := &jwt.MapClaims{
-			"user_id": user.ID,
-			"email":   user.Email,
-			"iss":     "Intranet API - generate by IslaIT",
-			"exp":     time.Now().Add(time.Hour * 72).Unix(),
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		return token.SignedString([]byte("test_secret"))
-	}
+func (m *MockUserRepository) MarkMFAStepUsed(id uint, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(id uint, profile *model.Profile) error {
+	args := m.Called(id, profile)
+	return args.Error(0)
+}
 
-	return "", errors.New("invalid email or password")
+func (m *MockUserRepository) UpdatePassword(id uint, hashedPassword string) error {
+	args := m.Called(id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetPassword(id uint, token string, hashedPassword string, changedAt time.Time) (bool, error) {
+	args := m.Called(id, token, hashedPassword, changedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateBatch(users []*model.User, filter *security.RowFilter) error {
+	args := m.Called(users, filter)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetTakenIdentifiers(usernames []string, emails []string) ([]string, []string, error) {
+	args := m.Called(usernames, emails)
+	return args.Get(0).([]string), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockUserRepository) Erase(id uint, erasedAt time.Time, audit *model.AuditEntry) error {
+	args := m.Called(id, erasedAt, audit)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetStatus(id uint) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SetStatus(id uint, status string, reason string, changedAt time.Time) error {
+	args := m.Called(id, status, reason, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetLevels(id uint, levelIDs []uint) error {
+	args := m.Called(id, levelIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PaginateDeleted(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	args := m.Called(page, pageSize, filter)
+	return args.Get(0).([]*model.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) Restore(id uint, restoredAt time.Time) (bool, error) {
+	args := m.Called(id, restoredAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Purge(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
 }
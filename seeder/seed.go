@@ -1,17 +1,16 @@
 package seeder
 
 import (
-	"crypto/sha256"
-	"fmt"
 	"log"
 	"time"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/infrastructure/db"
 	"gorm.io/gorm"
 )
 
-func Seed() {
+func Seed(passwordHasher security.PasswordHasher) {
 	dbConn := db.GetConnection()
 
 	// Seed forms
@@ -31,7 +30,7 @@ func Seed() {
 
 	// Seed users
 	if isTableEmpty(dbConn, &model.User{}) {
-		seedUsers(dbConn)
+		seedUsers(dbConn, passwordHasher)
 	}
 }
 
@@ -56,7 +55,7 @@ func seedForms(dbConn *gorm.DB) {
 			Icon:    "mdi-account-check-outline",
 			Link:    "roles",
 			Setting: true,
-			PathAPI: "level|levels",
+			PathAPI: "level|levels|level-privilege|level-privileges",
 			Order:   2,
 		},
 		{
@@ -112,6 +111,22 @@ func seedForms(dbConn *gorm.DB) {
 			Count:   "automatic_notification_pushes",
 			Order:   8,
 		},
+		{
+			Title:   "Suplantar usuarios",
+			Icon:    "mdi-account-switch-outline",
+			Link:    "suplantar",
+			Setting: true,
+			PathAPI: "impersonate|impersonations",
+			Order:   9,
+		},
+		{
+			Title:   "Autorización",
+			Icon:    "mdi-shield-key-outline",
+			Link:    "autorizacion",
+			Setting: true,
+			PathAPI: "authz",
+			Order:   10,
+		},
 	}
 
 	for _, form := range forms {
@@ -121,7 +136,10 @@ func seedForms(dbConn *gorm.DB) {
 	}
 }
 
+// seedLevels crea los niveles iniciales. Administrador hereda de Invitado la
+// lectura de usuarios e identidades y solo añade el resto de acciones.
 func seedLevels(dbConn *gorm.DB) {
+	guestLevelID := uint(3)
 	levels := []model.Level{
 		{
 			Model: gorm.Model{
@@ -135,6 +153,7 @@ func seedLevels(dbConn *gorm.DB) {
 			Model:       gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()},
 			Level:       "Administrador",
 			Description: "Lo puede hacer todo y más",
+			ParentID:    &guestLevelID,
 		},
 		{
 			Model:       gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()},
@@ -152,16 +171,18 @@ func seedLevels(dbConn *gorm.DB) {
 
 func seedLevelPrivileges(dbConn *gorm.DB) {
 	levelPrivileges := []model.LevelPrivileges{
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 1, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 2, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 3, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 4, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 5, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 6, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 7, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 8, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 2, FormID: 1, Read: true, Write: true},
-		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 2, FormID: 2, Read: true, Write: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 1, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 2, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 3, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 4, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 5, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 6, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 7, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 8, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 9, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 1, FormID: 10, Read: true, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 2, FormID: 1, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
+		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 2, FormID: 2, Write: true, Create: true, Update: true, Delete: true, Export: true, Approve: true},
 		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 3, FormID: 1, Read: true, Write: false},
 		{Model: gorm.Model{CreatedAt: time.Now(), UpdatedAt: time.Now()}, LevelID: 3, FormID: 2, Read: true, Write: false},
 	}
@@ -173,10 +194,12 @@ func seedLevelPrivileges(dbConn *gorm.DB) {
 	}
 }
 
-func seedUsers(dbConn *gorm.DB) {
-
-	pw := sha256.Sum256([]byte("awesomepassword"))
-	pwd := fmt.Sprintf("%x", pw)
+func seedUsers(dbConn *gorm.DB, passwordHasher security.PasswordHasher) {
+	pwd, err := passwordHasher.Hash("awesomepassword")
+	if err != nil {
+		log.Printf("Failed to hash seed password: %v", err)
+		return
+	}
 
 	users := []model.User{
 		{
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrSigningKeyRequired = errors.New("a private signing key is required")
+	ErrDuplicateKeyID     = errors.New("duplicate signing key id")
+	ErrUnknownKeyID       = errors.New("unknown signing key id")
+)
+
+// KeyManager firma los JWT con la clave activa y mantiene las claves
+// anteriores para verificar los tokens emitidos antes de una rotación. Para
+// rotar se añade la nueva clave como activa y la anterior como clave de
+// verificación hasta que caduquen sus tokens.
+type KeyManager struct {
+	signing *security.SigningKey
+	keys    map[string]*security.SigningKey
+	methods []string
+}
+
+func NewKeyManager(signing *security.SigningKey, verification ...*security.SigningKey) (*KeyManager, error) {
+	if signing == nil || signing.Private == nil {
+		return nil, ErrSigningKeyRequired
+	}
+
+	m := &KeyManager{signing: signing, keys: make(map[string]*security.SigningKey)}
+	for _, key := range append([]*security.SigningKey{signing}, verification...) {
+		if _, ok := m.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKeyID, key.ID)
+		}
+		m.keys[key.ID] = key
+		if !containsMethod(m.methods, key.Method.Alg()) {
+			m.methods = append(m.methods, key.Method.Alg())
+		}
+	}
+	return m, nil
+}
+
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(m.signing.Method, claims)
+	if m.signing.ID != "" {
+		token.Header["kid"] = m.signing.ID
+	}
+	return token.SignedString(m.signing.Private)
+}
+
+// Keyfunc devuelve la clave del kid del token comprobando que el algoritmo
+// sea el de esa clave, para que no se pueda verificar una firma HMAC usando
+// una clave pública como secreto. Los tokens sin kid solo se aceptan con la
+// clave HMAC heredada.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method=%v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+func (m *KeyManager) Methods() []string {
+	return m.methods
+}
+
+// JWKS claves públicas en rotación; los secretos HMAC nunca se publican
+func (m *KeyManager) JWKS() model.JWKSet {
+	set := model.JWKSet{Keys: []model.JWK{}}
+	for _, id := range m.orderedIDs() {
+		key := m.keys[id]
+		if key.Symmetric() {
+			continue
+		}
+
+		jwk := model.JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// orderedIDs la clave activa primero y después el resto en orden estable
+func (m *KeyManager) orderedIDs() []string {
+	ids := []string{m.signing.ID}
+	for id := range m.keys {
+		if id != m.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids[1:])
+	return ids
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
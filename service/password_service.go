@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/drossan/core-api/domain/security"
+)
+
+var ErrUnknownPasswordAlgorithm = errors.New("unknown password hash algorithm")
+
+var legacySHA256 = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// PasswordService elige el hasher adecuado a partir del prefijo de cada hash.
+// Los hashes nuevos se generan siempre con el algoritmo actual, mientras que los
+// algoritmos registrados como heredados solo se usan para verificar.
+type PasswordService struct {
+	current security.PasswordHasher
+	hashers map[string]security.PasswordHasher
+}
+
+func NewPasswordService(current security.PasswordHasher, legacy ...security.PasswordHasher) *PasswordService {
+	s := &PasswordService{
+		current: current,
+		hashers: make(map[string]security.PasswordHasher),
+	}
+	s.RegisterHasher(current)
+	for _, hasher := range legacy {
+		s.RegisterHasher(hasher)
+	}
+	return s
+}
+
+func (s *PasswordService) RegisterHasher(hasher security.PasswordHasher) {
+	s.hashers[hasher.Algorithm()] = hasher
+}
+
+func (s *PasswordService) Algorithm() string {
+	return s.current.Algorithm()
+}
+
+func (s *PasswordService) Hash(password string) (string, error) {
+	return s.current.Hash(password)
+}
+
+func (s *PasswordService) Verify(encoded, password string) (bool, error) {
+	hasher, err := s.hasherFor(encoded)
+	if err != nil {
+		return false, err
+	}
+	return hasher.Verify(encoded, password)
+}
+
+// NeedsRehash devuelve true si el hash no se generó con el algoritmo actual o
+// si sus parámetros (coste, memoria...) están desactualizados.
+func (s *PasswordService) NeedsRehash(encoded string) bool {
+	if algorithmOf(encoded) != s.current.Algorithm() {
+		return true
+	}
+	return s.current.NeedsRehash(encoded)
+}
+
+func (s *PasswordService) hasherFor(encoded string) (security.PasswordHasher, error) {
+	if hasher, ok := s.hashers[algorithmOf(encoded)]; ok {
+		return hasher, nil
+	}
+	return nil, ErrUnknownPasswordAlgorithm
+}
+
+// algorithmOf extrae el prefijo del hash. Los hashes guardados antes de usar
+// prefijos se reconocen por su formato: "$2a$..." para bcrypt y 64 caracteres
+// hexadecimales para SHA-256.
+func algorithmOf(encoded string) string {
+	if idx := strings.Index(encoded, "$"); idx > 0 {
+		return encoded[:idx]
+	}
+	if strings.HasPrefix(encoded, "$2") {
+		return "bcrypt"
+	}
+	if legacySHA256.MatchString(encoded) {
+		return "sha256"
+	}
+	return ""
+}
+
+// Asegúrate de que PasswordService implemente PasswordHasher
+var _ security.PasswordHasher = &PasswordService{}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+)
+
+// PermissionResolver calcula los privilegios efectivos de un usuario: la unión
+// de los privilegios de todos sus niveles y de los niveles de los que heredan.
+type PermissionResolver struct {
+	levelRepository repository.LevelRepository
+}
+
+func NewPermissionResolver(levelRepo repository.LevelRepository) *PermissionResolver {
+	return &PermissionResolver{levelRepository: levelRepo}
+}
+
+// Levels devuelve los niveles indicados y sus ascendientes sin repetir. Si la
+// herencia tiene un ciclo se deja de subir al volver a un nivel ya visitado.
+// El nivel 0 es el de los usuarios sin nivel y no tiene privilegios.
+func (r *PermissionResolver) Levels(levelIDs ...uint) ([]*model.Level, error) {
+	visited := map[uint]bool{0: true}
+	var levels []*model.Level
+	for _, levelID := range levelIDs {
+		for current := &levelID; current != nil && !visited[*current]; {
+			visited[*current] = true
+			level, err := r.levelRepository.GetByID(*current)
+			if err != nil {
+				return nil, err
+			}
+			levels = append(levels, level)
+			current = level.ParentID
+		}
+	}
+	return levels, nil
+}
+
+// Privileges devuelve un privilegio por formulario con todas las acciones que
+// le conceden los niveles indicados o sus ascendientes
+func (r *PermissionResolver) Privileges(levelIDs ...uint) ([]model.LevelPrivileges, error) {
+	levels, err := r.Levels(levelIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	type formKey struct {
+		id      uint
+		pathAPI string
+	}
+	positions := make(map[formKey]int)
+	privileges := make([]model.LevelPrivileges, 0)
+	for _, level := range levels {
+		for _, privilege := range level.LevelPrivileges {
+			key := formKey{privilege.FormID, privilege.Form.PathAPI}
+			position, ok := positions[key]
+			if !ok {
+				position = len(privileges)
+				positions[key] = position
+				privileges = append(privileges, model.LevelPrivileges{FormID: privilege.FormID, Form: privilege.Form})
+			}
+			privileges[position].Merge(privilege)
+		}
+	}
+	return privileges, nil
+}
+
+// Allows indica si los niveles indicados permiten la acción sobre el recurso
+func (r *PermissionResolver) Allows(resource string, action string, levelIDs ...uint) (bool, error) {
+	privileges, err := r.Privileges(levelIDs...)
+	if err != nil {
+		return false, err
+	}
+	return PrivilegesAllow(privileges, resource, action), nil
+}
+
+// RowFilter devuelve las condiciones de acceso a filas que imponen los
+// formularios con los que los niveles permiten la acción sobre el recurso. Si
+// alguno no tiene condición el acceso no se restringe y devuelve nil. Una
+// condición guardada que no es válida deniega el acceso en lugar de ignorarse,
+// salvo que otro formulario lo conceda sin condición.
+func (r *PermissionResolver) RowFilter(resource string, action string, subject security.RowSubject, levelIDs ...uint) (*security.RowFilter, error) {
+	privileges, err := r.Privileges(levelIDs...)
+	if err != nil {
+		return nil, err
+	}
+	var policies []*security.RowPolicy
+	var invalid error
+	for _, privilege := range privileges {
+		if !security.PathAPIGrants(privilege.Form.PathAPI, resource) || !privilege.Allows(action) {
+			continue
+		}
+		policy, err := security.ParseRowPolicy(privilege.Form.Condition)
+		if err != nil {
+			invalid = err
+			continue
+		}
+		if policy == nil {
+			return nil, nil
+		}
+		policies = append(policies, policy)
+	}
+	if invalid != nil {
+		return nil, invalid
+	}
+	return security.NewRowFilter(subject, policies...), nil
+}
+
+// Version suma las versiones de los privilegios de los niveles indicados. La
+// versión de un nivel también cambia con la de sus ascendientes, así que no
+// hace falta recorrer la herencia, y como las versiones solo crecen la suma
+// cambia siempre que cambie alguna.
+func (r *PermissionResolver) Version(levelIDs ...uint) (uint, error) {
+	counted := map[uint]bool{0: true}
+	var version uint
+	for _, levelID := range levelIDs {
+		if counted[levelID] {
+			continue
+		}
+		counted[levelID] = true
+		levelVersion, err := r.levelRepository.GetPermissionVersion(levelID)
+		if err != nil {
+			return 0, err
+		}
+		version += levelVersion
+	}
+	return version, nil
+}
+
+// Motivos por los que Authorize deniega una acción
+const (
+	DenialPrivilege = "privilege"
+	DenialScope     = "scope"
+)
+
+// AuthorizationDecision resultado de autorizar una acción con un token.
+// Denial es el motivo si no se permite y Stale indica que la copia de los
+// privilegios del token está obsoleta y conviene refrescarlo.
+type AuthorizationDecision struct {
+	Allowed bool
+	Denial  string
+	Stale   bool
+}
+
+// Authorize decide si el token permite la acción sobre el recurso. Es la
+// única decisión de AuthorizationMiddleware y de POST /authz/check, para que
+// la API y el frontend no puedan discrepar. Usa la copia de los privilegios
+// del token si sigue vigente y si no los de sus niveles; a las API keys además
+// les exige un scope que cubra el recurso o alguno de scopePaths, los nombres
+// anteriores del recurso en los scopes (el primer segmento de la ruta).
+func (r *PermissionResolver) Authorize(claims *model.Claim, resource string, action string, scopePaths ...string) (AuthorizationDecision, error) {
+	var decision AuthorizationDecision
+	if claims.PermissionVersion != 0 && r.snapshotCurrent(claims) {
+		// El token lleva los privilegios vigentes de los niveles
+		decision.Allowed = security.PermissionsAllow(claims.Permissions, resource, action)
+	} else {
+		// Los privilegios del token han cambiado: se autoriza contra los
+		// niveles del usuario
+		decision.Stale = claims.PermissionVersion != 0
+		allowed, err := r.Allows(resource, action, claims.AllLevelIDs()...)
+		if err != nil {
+			return decision, err
+		}
+		decision.Allowed = allowed
+	}
+	if !decision.Allowed {
+		decision.Denial = DenialPrivilege
+		return decision, nil
+	}
+
+	// Una API key solo puede usar los privilegios del nivel que además estén
+	// incluidos en sus scopes
+	if claims.APIKeyID != 0 && !scopesAllow(claims.Scopes, action, append([]string{resource}, scopePaths...)) {
+		decision.Allowed = false
+		decision.Denial = DenialScope
+	}
+	return decision, nil
+}
+
+// snapshotCurrent indica si la copia de los privilegios del token sigue
+// siendo la versión vigente de sus niveles
+func (r *PermissionResolver) snapshotCurrent(claims *model.Claim) bool {
+	version, err := r.Version(claims.AllLevelIDs()...)
+	return err == nil && version == claims.PermissionVersion
+}
+
+func scopesAllow(scopes []string, action string, paths []string) bool {
+	for _, path := range paths {
+		if security.ScopesAllow(scopes, path, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrivilegesAllow indica si alguno de los privilegios permite la acción sobre
+// el recurso
+func PrivilegesAllow(privileges []model.LevelPrivileges, resource string, action string) bool {
+	for _, privilege := range privileges {
+		if security.PathAPIGrants(privilege.Form.PathAPI, resource) && privilege.Allows(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrivilegesCover indica si privileges concede sobre cada formulario todas
+// las acciones que concede other
+func PrivilegesCover(privileges []model.LevelPrivileges, other []model.LevelPrivileges) bool {
+	granted := make(map[uint]*model.LevelPrivileges, len(privileges))
+	for _, privilege := range privileges {
+		merged, ok := granted[privilege.FormID]
+		if !ok {
+			merged = &model.LevelPrivileges{}
+			granted[privilege.FormID] = merged
+		}
+		merged.Merge(privilege)
+	}
+	for _, privilege := range other {
+		merged := granted[privilege.FormID]
+		for _, action := range privilege.Actions() {
+			if merged == nil || !merged.Allows(string(action)) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package service_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRSAKey(t *testing.T, id string) *security.SigningKey {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return &security.SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey}
+}
+
+func newEd25519Key(t *testing.T, id string) *security.SigningKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return &security.SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: public}
+}
+
+func parse(keys security.TokenKeys, signed string) (*model.Claim, error) {
+	token, err := jwt.ParseWithClaims(signed, new(model.Claim), keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	if err != nil {
+		return nil, err
+	}
+	return token.Claims.(*model.Claim), nil
+}
+
+func newClaims(userID uint) model.Claim {
+	return model.Claim{
+		UserID:           userID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}
+}
+
+func TestKeyManager_SignsWithKid(t *testing.T) {
+	for _, key := range []*security.SigningKey{newRSAKey(t, "rsa-1"), newEd25519Key(t, "ed-1")} {
+		keys, err := service.NewKeyManager(key)
+		assert.NoError(t, err)
+
+		signed, err := keys.Sign(newClaims(7))
+		assert.NoError(t, err)
+
+		token, _, err := jwt.NewParser().ParseUnverified(signed, new(model.Claim))
+		assert.NoError(t, err)
+		assert.Equal(t, key.ID, token.Header["kid"])
+		assert.Equal(t, key.Method.Alg(), token.Header["alg"])
+
+		claims, err := parse(keys, signed)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), claims.UserID)
+	}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2026-01")
+	newKey := newEd25519Key(t, "2026-02")
+
+	before, _ := service.NewKeyManager(oldKey)
+	oldToken, err := before.Sign(newClaims(1))
+	assert.NoError(t, err)
+
+	// Tras rotar la clave anterior solo verifica
+	retired := *oldKey
+	retired.Private = nil
+	after, err := service.NewKeyManager(newKey, &retired)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"EdDSA", "RS256"}, after.Methods())
+
+	_, err = parse(after, oldToken)
+	assert.NoError(t, err)
+
+	newToken, err := after.Sign(newClaims(2))
+	assert.NoError(t, err)
+	_, err = parse(after, newToken)
+	assert.NoError(t, err)
+
+	// Una vez retirada del todo, los tokens antiguos dejan de valer
+	final, _ := service.NewKeyManager(newKey, newEd25519Key(t, "2026-03"))
+	_, err = parse(final, oldToken)
+	assert.Error(t, err)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newClaims(3))
+	unknown.Header["kid"] = "2025-12"
+	signed, _ := unknown.SignedString(newEd25519Key(t, "2025-12").Private)
+	_, err = parse(final, signed)
+	assert.ErrorIs(t, err, service.ErrUnknownKeyID)
+
+	jwks := after.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "2026-02", jwks.Keys[0].Kid)
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+		assert.NotEmpty(t, jwks.Keys[0].X)
+		assert.Equal(t, "2026-01", jwks.Keys[1].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	}
+}
+
+func TestKeyManager_LegacySecret(t *testing.T) {
+	secret := adapters.NewHMACSigningKey("test_secret")
+	legacy, _ := service.NewKeyManager(secret)
+	legacyToken, err := legacy.Sign(newClaims(1))
+	assert.NoError(t, err)
+
+	keys, err := service.NewKeyManager(newRSAKey(t, "rsa-1"), &security.SigningKey{Method: secret.Method, Public: secret.Public})
+	assert.NoError(t, err)
+
+	// Los tokens HS256 sin kid siguen verificándose con el secreto
+	_, err = parse(keys, legacyToken)
+	assert.NoError(t, err)
+
+	// El secreto nunca se publica
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "rsa-1", jwks.Keys[0].Kid)
+}
+
+func TestKeyManager_RejectsAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	keys, _ := service.NewKeyManager(key)
+
+	// Token HS256 firmado con la clave pública (conocida por todos) como secreto
+	der, _ := x509.MarshalPKIXPublicKey(key.Public)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(1))
+	forged.Header["kid"] = "rsa-1"
+	signed, err := forged.SignedString(publicPEM)
+	assert.NoError(t, err)
+
+	_, err = parse(keys, signed)
+	assert.Error(t, err)
+}
+
+func TestNewKeyManager_Validation(t *testing.T) {
+	verifyOnly := newRSAKey(t, "rsa-1")
+	verifyOnly.Private = nil
+
+	_, err := service.NewKeyManager(verifyOnly)
+	assert.ErrorIs(t, err, service.ErrSigningKeyRequired)
+
+	_, err = service.NewKeyManager(newRSAKey(t, "same"), newEd25519Key(t, "same"))
+	assert.ErrorIs(t, err, service.ErrDuplicateKeyID)
+}
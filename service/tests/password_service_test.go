@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/service"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newPasswordService() *service.PasswordService {
+	return service.NewPasswordService(
+		adapters.NewArgon2Hasher(),
+		adapters.NewBcryptHasher(bcrypt.MinCost),
+		adapters.NewSHA256Hasher(),
+	)
+}
+
+func TestPasswordService_HashUsesCurrentAlgorithm(t *testing.T) {
+	passwords := newPasswordService()
+
+	hash, err := passwords.Hash("password")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "argon2id$"))
+
+	ok, err := passwords.Verify(hash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = passwords.Verify(hash, "wrongpassword")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, passwords.NeedsRehash(hash))
+}
+
+func TestPasswordService_VerifiesLegacyHashes(t *testing.T) {
+	passwords := newPasswordService()
+
+	sum := sha256.Sum256([]byte("password"))
+	legacySHA256 := fmt.Sprintf("%x", sum)
+
+	rawBcrypt, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	prefixedBcrypt, err := adapters.NewBcryptHasher(bcrypt.MinCost).Hash("password")
+	assert.NoError(t, err)
+
+	for _, hash := range []string{legacySHA256, string(rawBcrypt), prefixedBcrypt} {
+		ok, err := passwords.Verify(hash, "password")
+		assert.NoError(t, err)
+		assert.True(t, ok, hash)
+
+		ok, err = passwords.Verify(hash, "wrongpassword")
+		assert.NoError(t, err)
+		assert.False(t, ok, hash)
+
+		assert.True(t, passwords.NeedsRehash(hash), hash)
+	}
+}
+
+func TestPasswordService_UnknownAlgorithm(t *testing.T) {
+	passwords := newPasswordService()
+
+	ok, err := passwords.Verify("md5$5f4dcc3b5aa765d61d8327deb882cf99", "password")
+	assert.ErrorIs(t, err, service.ErrUnknownPasswordAlgorithm)
+	assert.False(t, ok)
+}
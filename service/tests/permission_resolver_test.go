@@ -0,0 +1,150 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func inheritingLevel(id uint, parentID *uint, privileges ...model.LevelPrivileges) *model.Level {
+	return &model.Level{Model: gorm.Model{ID: id}, ParentID: parentID, LevelPrivileges: privileges}
+}
+
+func TestPermissionResolver_UnionWithInheritance(t *testing.T) {
+	guestID := uint(3)
+	users := model.Form{Model: gorm.Model{ID: 1}, PathAPI: "user|users"}
+	levels := model.Form{Model: gorm.Model{ID: 2}, PathAPI: "level|levels"}
+
+	levelRepo := new(mocks.MockLevelRepository)
+	// Soporte hereda la lectura de Invitado y añade modificar usuarios
+	levelRepo.On("GetByID", uint(2)).Return(inheritingLevel(2, &guestID, model.LevelPrivileges{FormID: 1, Form: users, Update: true}), nil)
+	levelRepo.On("GetByID", uint(3)).Return(inheritingLevel(3, nil, model.LevelPrivileges{FormID: 1, Form: users, Read: true}), nil)
+	levelRepo.On("GetByID", uint(4)).Return(inheritingLevel(4, nil, model.LevelPrivileges{FormID: 2, Form: levels, Export: true}), nil)
+	resolver := service.NewPermissionResolver(levelRepo)
+
+	privileges, err := resolver.Privileges(2, 4)
+	assert.NoError(t, err)
+	if !assert.Len(t, privileges, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "ru", privileges[0].Actions())
+	assert.Equal(t, "e", privileges[1].Actions())
+
+	allowed, err := resolver.Allows("users", security.PermissionRead, 2)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _ = resolver.Allows("users", security.PermissionDelete, 2, 4)
+	assert.False(t, allowed)
+	allowed, _ = resolver.Allows("level", security.PermissionExport, 2, 4)
+	assert.True(t, allowed)
+}
+
+func TestPermissionResolver_StopsAtCycles(t *testing.T) {
+	first, second := uint(1), uint(2)
+	form := model.Form{PathAPI: "user|users"}
+
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(inheritingLevel(1, &second, model.LevelPrivileges{Form: form, Read: true}), nil)
+	levelRepo.On("GetByID", uint(2)).Return(inheritingLevel(2, &first, model.LevelPrivileges{Form: form, Delete: true}), nil)
+	resolver := service.NewPermissionResolver(levelRepo)
+
+	levels, err := resolver.Levels(1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, levels, 2)
+	levelRepo.AssertNumberOfCalls(t, "GetByID", 2)
+
+	privileges, err := resolver.Privileges(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "rd", privileges[0].Actions())
+}
+
+func TestPermissionResolver_Version(t *testing.T) {
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetPermissionVersion", uint(1)).Return(uint(3), nil)
+	levelRepo.On("GetPermissionVersion", uint(2)).Return(uint(5), nil)
+	resolver := service.NewPermissionResolver(levelRepo)
+
+	// Los niveles repetidos y el nivel 0 no cuentan
+	version, err := resolver.Version(1, 2, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(8), version)
+
+	privileges, err := resolver.Privileges(0)
+	assert.NoError(t, err)
+	assert.Empty(t, privileges)
+}
+
+func TestPermissionResolver_RowFilter(t *testing.T) {
+	own := model.Form{Model: gorm.Model{ID: 1}, PathAPI: "user|users", Condition: "id == user.id"}
+	sameLevel := model.Form{Model: gorm.Model{ID: 2}, PathAPI: "user|users", Condition: "level_id == user.level_id"}
+	open := model.Form{Model: gorm.Model{ID: 3}, PathAPI: "user"}
+	broken := model.Form{Model: gorm.Model{ID: 4}, PathAPI: "user", Condition: "id = 1"}
+
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(inheritingLevel(1, nil,
+		model.LevelPrivileges{FormID: 1, Form: own, Read: true, Update: true},
+		model.LevelPrivileges{FormID: 2, Form: sameLevel, Read: true},
+	), nil)
+	levelRepo.On("GetByID", uint(2)).Return(inheritingLevel(2, nil, model.LevelPrivileges{FormID: 3, Form: open, Read: true}), nil)
+	levelRepo.On("GetByID", uint(3)).Return(inheritingLevel(3, nil, model.LevelPrivileges{FormID: 4, Form: broken, Read: true}), nil)
+	resolver := service.NewPermissionResolver(levelRepo)
+	subject := security.RowSubject{security.RowSubjectID: uint(9), security.RowSubjectLevelID: uint(1)}
+	quote := func(column string) string { return column }
+
+	// Se cumple cualquiera de las condiciones de los formularios que conceden la acción
+	filter, err := resolver.RowFilter("user", security.PermissionRead, subject, 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, filter) {
+		where, args := filter.Where(quote)
+		assert.Equal(t, "(id = ?) OR (level_id = ?)", where)
+		assert.Equal(t, []interface{}{uint(9), uint(1)}, args)
+	}
+	filter, _ = resolver.RowFilter("user", security.PermissionUpdate, subject, 1)
+	where, _ := filter.Where(quote)
+	assert.Equal(t, "(id = ?)", where)
+
+	// Un formulario sin condición no restringe
+	filter, err = resolver.RowFilter("user", security.PermissionRead, subject, 1, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+
+	// Una condición guardada que no es válida deniega en lugar de ignorarse
+	_, err = resolver.RowFilter("user", security.PermissionRead, subject, 3)
+	assert.ErrorIs(t, err, security.ErrInvalidRowPolicy)
+	filter, err = resolver.RowFilter("user", security.PermissionRead, subject, 2, 3)
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+}
+
+func TestPermissionResolver_Authorize(t *testing.T) {
+	users := model.Form{Model: gorm.Model{ID: 1}, PathAPI: "user|users"}
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(inheritingLevel(1, nil, model.LevelPrivileges{FormID: 1, Form: users, Read: true, Update: true}), nil)
+	levelRepo.On("GetPermissionVersion", uint(1)).Return(uint(2), nil)
+	resolver := service.NewPermissionResolver(levelRepo)
+
+	// Con la copia vigente decide el token aunque los niveles digan otra cosa
+	claims := &model.Claim{LevelID: 1, PermissionVersion: 2, Permissions: []string{security.FormatPermission("user", "r")}}
+	decision, err := resolver.Authorize(claims, "user", security.PermissionRead)
+	assert.NoError(t, err)
+	assert.Equal(t, service.AuthorizationDecision{Allowed: true}, decision)
+	decision, _ = resolver.Authorize(claims, "user", security.PermissionUpdate)
+	assert.Equal(t, service.AuthorizationDecision{Denial: service.DenialPrivilege}, decision)
+
+	// Con la copia obsoleta deciden los niveles y se avisa de refrescar el token
+	claims.PermissionVersion = 1
+	decision, _ = resolver.Authorize(claims, "user", security.PermissionUpdate)
+	assert.Equal(t, service.AuthorizationDecision{Allowed: true, Stale: true}, decision)
+
+	// Las API keys además necesitan el scope
+	apiKey := &model.Claim{LevelID: 1, APIKeyID: 5, Scopes: []string{"users:read"}}
+	decision, _ = resolver.Authorize(apiKey, "user", security.PermissionRead, "users")
+	assert.Equal(t, service.AuthorizationDecision{Allowed: true}, decision)
+	decision, _ = resolver.Authorize(apiKey, "user", security.PermissionUpdate, "users")
+	assert.Equal(t, service.AuthorizationDecision{Denial: service.DenialScope}, decision)
+}
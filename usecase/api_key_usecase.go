@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrAPIKeyNameRequired    = errors.New("api key name is required")
+	ErrAPIKeyScopesRequired  = errors.New("api key needs at least one scope")
+	ErrInvalidAPIKeyScope    = errors.New("api key scopes must look like path:action with action read, write, create, update, delete, export or approve")
+	ErrAPIKeyScopeNotAllowed = errors.New("api key scope exceeds the privileges of your level")
+	ErrInvalidAPIKeyExpiry   = errors.New("api key expiry must be in the future and within the maximum lifetime")
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrInvalidAPIKey         = errors.New("invalid or expired api key")
+)
+
+const (
+	// apiKeyPrefix identifica las claves del API frente a los JWT
+	apiKeyPrefix     = "ak_"
+	apiKeyIDSize     = 6
+	apiKeySecretSize = 32
+	// apiKeyTouchInterval evita escribir en base de datos en cada petición
+	apiKeyTouchInterval = time.Minute
+)
+
+type APIKeyUseCase struct {
+	apiKeyRepository repository.APIKeyRepository
+	userRepository   repository.UserRepository
+	permissions      *service.PermissionResolver
+	maxTTL           time.Duration
+}
+
+func NewAPIKeyUseCase(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, permissions *service.PermissionResolver, maxTTL time.Duration) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		apiKeyRepository: apiKeyRepo,
+		userRepository:   userRepo,
+		permissions:      permissions,
+		maxTTL:           maxTTL,
+	}
+}
+
+// Create genera una clave nueva para el usuario. La clave en claro solo se
+// devuelve aquí; en base de datos se guarda su hash.
+func (uc *APIKeyUseCase) Create(userID uint, request *model.APIKeyRequest) (*model.APIKeyCreated, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, ErrAPIKeyNameRequired
+	}
+
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	privileges, err := uc.permissions.Privileges(user.LevelIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, err := allowedScopes(privileges, request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uc.maxTTL)
+	if request.ExpiresAt != nil {
+		if !request.ExpiresAt.After(now) || request.ExpiresAt.After(expiresAt) {
+			return nil, ErrInvalidAPIKeyExpiry
+		}
+		expiresAt = *request.ExpiresAt
+	}
+
+	keyID, err := helpers.GetToken(apiKeyIDSize)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := helpers.GenerateSecureToken(apiKeySecretSize)
+	if err != nil {
+		return nil, err
+	}
+	prefix := apiKeyPrefix + keyID
+	rawKey := prefix + "_" + secret
+
+	apiKey := &model.APIKey{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   helpers.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := uc.apiKeyRepository.Create(apiKey); err != nil {
+		return nil, err
+	}
+
+	return &model.APIKeyCreated{APIKey: apiKey, Key: rawKey}, nil
+}
+
+func (uc *APIKeyUseCase) List(userID uint) ([]*model.APIKey, error) {
+	return uc.apiKeyRepository.GetByUser(userID)
+}
+
+// Revoke revoca una clave del propio usuario
+func (uc *APIKeyUseCase) Revoke(userID uint, keyID uint) error {
+	revoked, err := uc.apiKeyRepository.Revoke(keyID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RevokeAllForUser revoca todas las claves de un usuario (uso administrativo)
+func (uc *APIKeyUseCase) RevokeAllForUser(userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	return uc.apiKeyRepository.RevokeByUser(userID, time.Now())
+}
+
+// IsAPIKey indica si la credencial tiene el formato de una API key
+func (uc *APIKeyUseCase) IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// Authenticate valida la clave y devuelve unos claims equivalentes a los del
+// JWT de su propietario con los niveles que tenga en este momento, de forma
+// que la autorización por nivel se aplica igual que con un token.
+func (uc *APIKeyUseCase) Authenticate(rawKey string) (*model.Claim, error) {
+	if !uc.IsAPIKey(rawKey) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := uc.apiKeyRepository.GetByHash(helpers.HashToken(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || !now.Before(apiKey.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := uc.userRepository.GetByID(apiKey.UserID)
+	if err != nil || !user.IsActive() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := uc.apiKeyRepository.TouchLastUsed(apiKey.ID, now); err != nil {
+			log.Printf("Failed to update api key last use: %v", err)
+		}
+	}
+
+	return &model.Claim{
+		UserID:   user.ID,
+		Email:    user.Email,
+		LevelID:  user.LevelID,
+		LevelIDs: user.LevelIDs()[1:],
+		Admin:    user.LevelID,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// allowedScopes valida los scopes pedidos y comprueba que los privilegios
+// efectivos del usuario los permiten, para que la clave nunca tenga más
+// derechos que él.
+func allowedScopes(privileges []model.LevelPrivileges, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, ErrAPIKeyScopesRequired
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		path, action, ok := security.ParseScope(scope)
+		if !ok {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if path != security.ScopeAnyPath && !scopeAllowed(privileges, path, action) {
+			return nil, ErrAPIKeyScopeNotAllowed
+		}
+		scopes = append(scopes, path+":"+action)
+	}
+	return scopes, nil
+}
+
+// scopeAllowed indica si los privilegios permiten alguna de las acciones que
+// cubre la acción del scope sobre el path
+func scopeAllowed(privileges []model.LevelPrivileges, path string, action string) bool {
+	for _, permissionAction := range security.ScopeActions(action) {
+		if service.PrivilegesAllow(privileges, path, string(permissionAction)) {
+			return true
+		}
+	}
+	return false
+}
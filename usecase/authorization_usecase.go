@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/service"
+)
+
+// MaxAuthorizationChecks preguntas que admite como máximo POST /authz/check
+const MaxAuthorizationChecks = 100
+
+var (
+	ErrInvalidAuthorizationCheck  = errors.New("each check needs a resource and one of the actions read, create, update, delete, export or approve")
+	ErrTooManyAuthorizationChecks = fmt.Errorf("at most %d checks per request", MaxAuthorizationChecks)
+)
+
+type AuthorizationUseCase struct {
+	// authorizationCache es nil si la caché de privilegios está desactivada
+	authorizationCache repository.AuthorizationCache
+	permissions        *service.PermissionResolver
+}
+
+func NewAuthorizationUseCase(authorizationCache repository.AuthorizationCache, permissions *service.PermissionResolver) *AuthorizationUseCase {
+	return &AuthorizationUseCase{authorizationCache: authorizationCache, permissions: permissions}
+}
+
+// CacheStats devuelve las métricas de la caché de privilegios
+func (uc *AuthorizationUseCase) CacheStats() model.AuthorizationCacheStats {
+	if uc.authorizationCache == nil {
+		return model.AuthorizationCacheStats{}
+	}
+	return uc.authorizationCache.Stats()
+}
+
+// EffectivePermissions devuelve por formulario las acciones que permiten los
+// niveles del usuario y los niveles de los que heredan
+func (uc *AuthorizationUseCase) EffectivePermissions(claims *model.Claim) ([]model.EffectivePermission, error) {
+	privileges, err := uc.permissions.Privileges(claims.AllLevelIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]model.EffectivePermission, 0, len(privileges))
+	for _, privilege := range privileges {
+		permissions = append(permissions, model.EffectivePermission{
+			FormID:    privilege.FormID,
+			Title:     privilege.Form.Title,
+			Link:      privilege.Form.Link,
+			PathAPI:   privilege.Form.PathAPI,
+			Condition: privilege.Form.Condition,
+			Actions: model.PermissionActions{
+				Read:    privilege.Read,
+				Create:  privilege.Create,
+				Update:  privilege.Update,
+				Delete:  privilege.Delete,
+				Export:  privilege.Export,
+				Approve: privilege.Approve,
+			},
+		})
+	}
+	return permissions, nil
+}
+
+// Check responde cada pregunta con la misma decisión que
+// AuthorizationMiddleware. Devuelve también si la copia de los privilegios
+// del token está obsoleta.
+func (uc *AuthorizationUseCase) Check(claims *model.Claim, checks []model.AuthorizationCheck) ([]model.AuthorizationCheckResult, bool, error) {
+	if len(checks) > MaxAuthorizationChecks {
+		return nil, false, ErrTooManyAuthorizationChecks
+	}
+	actions := make([]string, len(checks))
+	for i, check := range checks {
+		action, ok := security.PermissionActionByName(check.Action)
+		if !ok || strings.TrimSpace(check.Resource) == "" {
+			return nil, false, ErrInvalidAuthorizationCheck
+		}
+		actions[i] = action
+	}
+
+	results := make([]model.AuthorizationCheckResult, 0, len(checks))
+	stale := false
+	for i, check := range checks {
+		decision, err := uc.permissions.Authorize(claims, check.Resource, actions[i])
+		if err != nil {
+			return nil, false, err
+		}
+		stale = stale || decision.Stale
+		results = append(results, model.AuthorizationCheckResult{
+			AuthorizationCheck: check,
+			Allowed:            decision.Allowed,
+			Reason:             decision.Denial,
+		})
+	}
+	return results, stale, nil
+}
@@ -1,30 +1,75 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 )
 
+var ErrInvalidFormCondition = errors.New("invalid form condition")
+
 type FormUseCase struct {
-	formRepository repository.FormRepository
+	formRepository  repository.FormRepository
+	levelRepository repository.LevelRepository
 }
 
-func NewFormUseCase(formRepo repository.FormRepository) *FormUseCase {
-	return &FormUseCase{formRepository: formRepo}
+func NewFormUseCase(formRepo repository.FormRepository, levelRepo repository.LevelRepository) *FormUseCase {
+	return &FormUseCase{formRepository: formRepo, levelRepository: levelRepo}
 }
 
-func (uc *FormUseCase) CreateOrUpdateForm(form *model.Form) error {
-	return uc.formRepository.CreateOrUpdate(form)
+// CreateOrUpdateForm guarda el formulario si su condición de acceso a filas es
+// válida y solo usa columnas de las tablas de sus recursos. Si ya existía
+// cambia la versión de los privilegios de los niveles que tienen acceso a él,
+// ya que su PathAPI o su condición pueden haber cambiado.
+func (uc *FormUseCase) CreateOrUpdateForm(form *model.Form, filter *security.RowFilter) error {
+	policy, err := security.ParseRowPolicy(form.Condition)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormCondition, err)
+	}
+	// Una columna que no existe haría fallar todas las consultas del recurso
+	if policy != nil {
+		unknown, err := uc.formRepository.UnknownColumns(form.PathAPI, policy.Columns())
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("%w: unknown column %s", ErrInvalidFormCondition, strings.Join(unknown, ", "))
+		}
+	}
+	if err := checkRowAccess(uc.formRepository.MatchesFilter, form.ID, filter); err != nil {
+		return err
+	}
+
+	existing := form.ID != 0
+	if err := uc.formRepository.CreateOrUpdate(form, filter); err != nil {
+		return err
+	}
+	if !existing {
+		return nil
+	}
+	return uc.levelRepository.BumpPermissionVersionByForm(form.ID)
 }
 
-func (uc *FormUseCase) GetAllForms() ([]*model.Form, error) {
-	return uc.formRepository.GetAll()
+func (uc *FormUseCase) GetAllForms(filter *security.RowFilter) ([]*model.Form, error) {
+	return uc.formRepository.GetAll(filter)
 }
 
-func (uc *FormUseCase) PaginateForms(page int, pageSize int) ([]*model.Form, int, error) {
-	return uc.formRepository.Paginate(page, pageSize)
+func (uc *FormUseCase) PaginateForms(page int, pageSize int, filter *security.RowFilter) ([]*model.Form, int, error) {
+	return uc.formRepository.Paginate(page, pageSize, filter)
 }
 
-func (uc *FormUseCase) DeleteForm(form *model.Form) error {
-	return uc.formRepository.Delete(form)
+// DeleteForm elimina el formulario y cambia la versión de los privilegios de
+// los niveles que tenían acceso a él
+func (uc *FormUseCase) DeleteForm(form *model.Form, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.formRepository.MatchesFilter, form.ID, filter); err != nil {
+		return err
+	}
+	if err := uc.formRepository.Delete(form); err != nil {
+		return err
+	}
+	return uc.levelRepository.BumpPermissionVersionByForm(form.ID)
 }
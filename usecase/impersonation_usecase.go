@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrImpersonationNotAllowed = errors.New("impersonation requires an interactive session of your own")
+	ErrCannotImpersonateSelf   = errors.New("you cannot impersonate yourself")
+	ErrCannotImpersonateStaff  = errors.New("users who can impersonate cannot be impersonated")
+	ErrCannotImpersonateHigher = errors.New("users with privileges you do not have cannot be impersonated")
+	ErrImpersonationTarget     = errors.New("user to impersonate not found")
+)
+
+// ImpersonationPath privilegio (Form.PathAPI) que permite suplantar usuarios
+const ImpersonationPath = "impersonate"
+
+// ImpersonationUseCase permite al personal de soporte ver la aplicación como
+// la ve otro usuario con un token de corta duración
+type ImpersonationUseCase struct {
+	userRepository repository.UserRepository
+	permissions    *service.PermissionResolver
+	tokenTTL       time.Duration
+}
+
+func NewImpersonationUseCase(userRepo repository.UserRepository, permissions *service.PermissionResolver, tokenTTL time.Duration) *ImpersonationUseCase {
+	return &ImpersonationUseCase{
+		userRepository: userRepo,
+		permissions:    permissions,
+		tokenTTL:       tokenTTL,
+	}
+}
+
+// Start emite un token del usuario targetID en nombre de quien hace la
+// petición. No se puede encadenar una suplantación con otra, suplantar a
+// quien también puede suplantar ni a quien tiene privilegios que no tiene
+// quien suplanta, para que nadie obtenga así más privilegios. Las condiciones
+// de acceso a filas de la ruta se aplican al usuario suplantado.
+func (uc *ImpersonationUseCase) Start(actor *model.Claim, targetID uint, filter *security.RowFilter) (*model.ImpersonationToken, error) {
+	if actor.Actor != nil || actor.APIKeyID != 0 {
+		return nil, ErrImpersonationNotAllowed
+	}
+	if actor.UserID == targetID {
+		return nil, ErrCannotImpersonateSelf
+	}
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, targetID, filter); err != nil {
+		return nil, err
+	}
+
+	target, err := uc.userRepository.GetByID(targetID)
+	if err != nil {
+		return nil, ErrImpersonationTarget
+	}
+	privileges, err := uc.permissions.Privileges(target.LevelIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	if scopeAllowed(privileges, ImpersonationPath, security.ScopeWrite) {
+		return nil, ErrCannotImpersonateStaff
+	}
+
+	// Los privilegios de quien suplanta se leen de sus niveles actuales y no
+	// del token, que puede estar obsoleto
+	actorUser, err := uc.userRepository.GetByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	actorPrivileges, err := uc.permissions.Privileges(actorUser.LevelIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	if !service.PrivilegesCover(actorPrivileges, privileges) {
+		return nil, ErrCannotImpersonateHigher
+	}
+
+	token, err := helpers.GenerateImpersonationJWT(target, &model.Actor{UserID: actor.UserID, Email: actor.Email}, uc.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Impersonation started: user %d <%s> is acting as user %d <%s> for %s", actor.UserID, actor.Email, target.ID, target.Email, uc.tokenTTL)
+
+	target.Password = ""
+	return &model.ImpersonationToken{
+		AccessToken: token,
+		ExpiresIn:   int64(uc.tokenTTL.Seconds()),
+		User:        target,
+	}, nil
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/notification"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrInvitationEmailRequired    = errors.New("invitation email is required")
+	ErrInvitationFullNameRequired = errors.New("invitation fullname is required")
+	ErrInvitationLevelNotFound    = errors.New("invitation level does not exist")
+	ErrInvitationEmailInUse       = errors.New("a user with this email already exists")
+	ErrInvitationAlreadyPending   = errors.New("there is already a pending invitation for this email")
+	ErrInvitationNotFound         = errors.New("pending invitation not found")
+	ErrInvalidInvitation          = errors.New("invalid or expired invitation")
+)
+
+const invitationTemplate = "templates/user_invitation.html"
+
+// InvitationUseCase alta de usuarios por invitación: el administrador indica
+// el email y el nivel y el invitado elige su contraseña desde el enlace
+// firmado que recibe por email.
+type InvitationUseCase struct {
+	invitationRepository repository.InvitationRepository
+	userRepository       repository.UserRepository
+	levelRepository      repository.LevelRepository
+	passwordPolicy       *PasswordPolicyUseCase
+	mailer               notification.Mailer
+	acceptURL            string
+	ttl                  time.Duration
+	permissions          *service.PermissionResolver
+}
+
+func NewInvitationUseCase(invitationRepo repository.InvitationRepository, userRepo repository.UserRepository, levelRepo repository.LevelRepository, passwordPolicy *PasswordPolicyUseCase, mailer notification.Mailer, acceptURL string, ttl time.Duration, permissions *service.PermissionResolver) *InvitationUseCase {
+	return &InvitationUseCase{
+		invitationRepository: invitationRepo,
+		userRepository:       userRepo,
+		levelRepository:      levelRepo,
+		passwordPolicy:       passwordPolicy,
+		mailer:               mailer,
+		acceptURL:            acceptURL,
+		ttl:                  ttl,
+		permissions:          permissions,
+	}
+}
+
+// Invite crea la invitación y envía el enlace. Solo se puede invitar con un
+// nivel cuyos privilegios tiene quien invita y la invitación tiene que cumplir
+// sus condiciones de acceso a filas. Si el email falla la invitación queda
+// creada y se puede reenviar.
+func (uc *InvitationUseCase) Invite(inviterID uint, request *model.InvitationRequest, filter *security.RowFilter) (*model.Invitation, error) {
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if email == "" {
+		return nil, ErrInvitationEmailRequired
+	}
+	fullName := strings.TrimSpace(request.FullName)
+	if fullName == "" {
+		return nil, ErrInvitationFullNameRequired
+	}
+	if request.LevelID == 0 {
+		return nil, ErrInvitationLevelNotFound
+	}
+	if _, err := uc.levelRepository.GetByID(request.LevelID); err != nil {
+		return nil, ErrInvitationLevelNotFound
+	}
+	if err := checkLevelsGrantable(uc.userRepository, uc.permissions, inviterID, request.LevelID); err != nil {
+		return nil, err
+	}
+	if user, err := uc.userRepository.GetByEmail(email); err == nil && user != nil {
+		return nil, ErrInvitationEmailInUse
+	}
+	if pending, err := uc.invitationRepository.GetPendingByEmail(email); err == nil && pending != nil {
+		return nil, ErrInvitationAlreadyPending
+	}
+
+	username := strings.TrimSpace(request.Username)
+	if username == "" {
+		username = email
+	}
+
+	invitation := &model.Invitation{
+		Email:     email,
+		Username:  username,
+		FullName:  fullName,
+		LevelID:   request.LevelID,
+		InvitedBy: inviterID,
+	}
+	if err := uc.invitationRepository.Create(invitation, filter); err != nil {
+		return nil, err
+	}
+
+	log.Printf("User %d invited %s with level %d", inviterID, email, invitation.LevelID)
+	return invitation, uc.send(invitation)
+}
+
+// InviteAll crea las invitaciones en una transacción y después envía los
+// enlaces. Como en Invite, quien invita tiene que tener los privilegios de
+// todos los niveles y todas tienen que cumplir sus condiciones de acceso a
+// filas. Devuelve cuántos se han enviado; los que fallan se pueden reenviar.
+func (uc *InvitationUseCase) InviteAll(inviterID uint, invitations []*model.Invitation, filter *security.RowFilter) (int, error) {
+	levelIDs := make([]uint, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitation.InvitedBy = inviterID
+		levelIDs = append(levelIDs, invitation.LevelID)
+	}
+	if err := checkLevelsGrantable(uc.userRepository, uc.permissions, inviterID, levelIDs...); err != nil {
+		return 0, err
+	}
+	if err := uc.invitationRepository.CreateBatch(invitations, filter); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, invitation := range invitations {
+		if err := uc.send(invitation); err != nil {
+			log.Printf("Failed to send invitation %d to %s: %v", invitation.ID, invitation.Email, err)
+			continue
+		}
+		sent++
+	}
+	log.Printf("User %d invited %d users, %d emails sent", inviterID, len(invitations), sent)
+	return sent, nil
+}
+
+// List devuelve las invitaciones pendientes, también las caducadas para poder
+// reenviarlas
+func (uc *InvitationUseCase) List(filter *security.RowFilter) ([]*model.Invitation, error) {
+	return uc.invitationRepository.GetPending(filter)
+}
+
+// Resend envía un enlace nuevo con la caducidad renovada; los anteriores dejan
+// de valer
+func (uc *InvitationUseCase) Resend(id uint, filter *security.RowFilter) (*model.Invitation, error) {
+	if err := checkRowAccess(uc.invitationRepository.MatchesFilter, id, filter); err != nil {
+		return nil, err
+	}
+	invitation, err := uc.invitationRepository.GetByID(id)
+	if err != nil || invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, uc.send(invitation)
+}
+
+// Revoke anula una invitación pendiente
+func (uc *InvitationUseCase) Revoke(id uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.invitationRepository.MatchesFilter, id, filter); err != nil {
+		return err
+	}
+	revoked, err := uc.invitationRepository.Revoke(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept crea la cuenta del invitado con la contraseña elegida, que tiene que
+// cumplir la política de su nivel. El enlace solo se puede usar una vez.
+func (uc *InvitationUseCase) Accept(request *model.InvitationAcceptance) (*model.User, error) {
+	claims, err := helpers.ParseJWT(request.Token)
+	if err != nil || claims.InvitationID == 0 {
+		return nil, ErrInvalidInvitation
+	}
+
+	invitation, err := uc.invitationRepository.GetByID(claims.InvitationID)
+	if err != nil || invitation.TokenHash != helpers.HashToken(request.Token) {
+		return nil, ErrInvalidInvitation
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+
+	if request.Password.Password != request.ConfirmPassword {
+		return nil, ErrPasswordMismatch
+	}
+	user := &model.User{
+		Username: invitation.Username,
+		Email:    invitation.Email,
+		FullName: invitation.FullName,
+		LevelID:  invitation.LevelID,
+	}
+	hashedPassword, err := uc.passwordPolicy.Hash(user, request.Password.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	// El email es único: si se acepta dos veces a la vez solo se crea una cuenta
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	if err := uc.userRepository.Create(user, nil); err != nil {
+		return nil, err
+	}
+	if _, err := uc.invitationRepository.Accept(invitation.ID, user.ID, now); err != nil {
+		log.Printf("Failed to mark invitation %d as accepted: %v", invitation.ID, err)
+	}
+	uc.passwordPolicy.Remember(user, hashedPassword)
+
+	log.Printf("Invitation %d accepted by user %d <%s>", invitation.ID, user.ID, user.Email)
+	return user, nil
+}
+
+// send firma un enlace nuevo, guarda su hash y lo envía por email
+func (uc *InvitationUseCase) send(invitation *model.Invitation) error {
+	token, err := helpers.GenerateInvitationToken(invitation, uc.ttl)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uc.ttl)
+	tokenHash := helpers.HashToken(token)
+	if err := uc.invitationRepository.UpdateToken(invitation.ID, tokenHash, now, expiresAt); err != nil {
+		return err
+	}
+	invitation.TokenHash = tokenHash
+	invitation.SentAt = &now
+	invitation.ExpiresAt = expiresAt
+
+	return uc.mailer.SendTemplateTo(invitation.Email, "Invitation", invitationTemplate, map[string]interface{}{
+		"Name":      invitation.FullName,
+		"Email":     invitation.Email,
+		"Link":      uc.acceptURL + "?token=" + url.QueryEscape(token),
+		"ExpiresIn": uc.ttl.String(),
+	})
+}
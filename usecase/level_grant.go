@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrCannotChangeOwnLevels = errors.New("you cannot change your own levels")
+	ErrLevelNotGrantable     = errors.New("you cannot grant a level with privileges you do not have")
+)
+
+// levelGranter devuelve la función que indica si quien actúa puede asignar
+// los niveles: tiene que tener todos sus privilegios, también los heredados,
+// para no poder dar a otro usuario más acceso del que tiene. Los privilegios
+// de quien actúa se leen de sus niveles actuales y no del token, que puede
+// estar obsoleto. El actor 0 (la importación desde la consola) puede asignar
+// cualquier nivel.
+func levelGranter(userRepository repository.UserRepository, permissions *service.PermissionResolver, actorID uint) (func(levelIDs ...uint) (bool, error), error) {
+	if actorID == 0 {
+		return func(...uint) (bool, error) { return true, nil }, nil
+	}
+	actor, err := userRepository.GetByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	actorPrivileges, err := permissions.Privileges(actor.LevelIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	return func(levelIDs ...uint) (bool, error) {
+		privileges, err := permissions.Privileges(levelIDs...)
+		if err != nil {
+			return false, err
+		}
+		return service.PrivilegesCover(actorPrivileges, privileges), nil
+	}, nil
+}
+
+// checkLevelsGrantable devuelve ErrLevelNotGrantable si quien actúa no puede
+// asignar los niveles
+func checkLevelsGrantable(userRepository repository.UserRepository, permissions *service.PermissionResolver, actorID uint, levelIDs ...uint) error {
+	canGrant, err := levelGranter(userRepository, permissions, actorID)
+	if err != nil {
+		return err
+	}
+	granted, err := canGrant(levelIDs...)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return ErrLevelNotGrantable
+	}
+	return nil
+}
@@ -3,24 +3,62 @@ package usecase
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 )
 
 type LevelPrivilegesUseCase struct {
 	levelPrivilegesRepository repository.LevelPrivilegesRepository
+	levelRepository           repository.LevelRepository
 }
 
-func NewLevelPrivilegesUseCase(levelPrivilegesRepo repository.LevelPrivilegesRepository) *LevelPrivilegesUseCase {
-	return &LevelPrivilegesUseCase{levelPrivilegesRepository: levelPrivilegesRepo}
+func NewLevelPrivilegesUseCase(levelPrivilegesRepo repository.LevelPrivilegesRepository, levelRepo repository.LevelRepository) *LevelPrivilegesUseCase {
+	return &LevelPrivilegesUseCase{levelPrivilegesRepository: levelPrivilegesRepo, levelRepository: levelRepo}
 }
 
-func (uc *LevelPrivilegesUseCase) CreateOrUpdateLevelPrivilege(levelPrivilege *model.LevelPrivileges) error {
-	return uc.levelPrivilegesRepository.CreateOrUpdate(levelPrivilege)
+// CreateOrUpdateLevelPrivilege guarda el privilegio y cambia la versión de los
+// privilegios del nivel, y del nivel anterior si el privilegio cambia de nivel.
+// Un Write sin acciones de los clientes anteriores concede crear, modificar y
+// eliminar.
+func (uc *LevelPrivilegesUseCase) CreateOrUpdateLevelPrivilege(levelPrivilege *model.LevelPrivileges, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.levelPrivilegesRepository.MatchesFilter, levelPrivilege.ID, filter); err != nil {
+		return err
+	}
+	levelPrivilege.NormalizeWrite()
+	levelIDs := uc.previousLevel(levelPrivilege.ID)
+	if err := uc.levelPrivilegesRepository.CreateOrUpdate(levelPrivilege, filter); err != nil {
+		return err
+	}
+	return uc.levelRepository.BumpPermissionVersion(append(levelIDs, levelPrivilege.LevelID)...)
 }
 
-func (uc *LevelPrivilegesUseCase) GetAllLevelPrivilege() ([]*model.LevelPrivileges, error) {
-	return uc.levelPrivilegesRepository.GetAll()
+func (uc *LevelPrivilegesUseCase) GetAllLevelPrivilege(filter *security.RowFilter) ([]*model.LevelPrivileges, error) {
+	return uc.levelPrivilegesRepository.GetAll(filter)
 }
 
-func (uc *LevelPrivilegesUseCase) DeleteLevelPrivilege(levelPrivilege *model.LevelPrivileges) error {
-	return uc.levelPrivilegesRepository.Delete(levelPrivilege)
+// DeleteLevelPrivilege elimina el privilegio y cambia la versión de los
+// privilegios de su nivel
+func (uc *LevelPrivilegesUseCase) DeleteLevelPrivilege(levelPrivilege *model.LevelPrivileges, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.levelPrivilegesRepository.MatchesFilter, levelPrivilege.ID, filter); err != nil {
+		return err
+	}
+	levelIDs := uc.previousLevel(levelPrivilege.ID)
+	if err := uc.levelPrivilegesRepository.Delete(levelPrivilege); err != nil {
+		return err
+	}
+	if levelPrivilege.LevelID != 0 {
+		levelIDs = append(levelIDs, levelPrivilege.LevelID)
+	}
+	return uc.levelRepository.BumpPermissionVersion(levelIDs...)
+}
+
+// previousLevel devuelve el nivel al que pertenecía el privilegio guardado
+func (uc *LevelPrivilegesUseCase) previousLevel(id uint) []uint {
+	if id == 0 {
+		return nil
+	}
+	previous, err := uc.levelPrivilegesRepository.GetByID(id)
+	if err != nil {
+		return nil
+	}
+	return []uint{previous.LevelID}
 }
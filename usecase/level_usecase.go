@@ -1,8 +1,16 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+)
+
+var (
+	ErrLevelParentNotFound = errors.New("parent level not found")
+	ErrLevelCycle          = errors.New("a level cannot inherit from itself or from a level that inherits from it")
 )
 
 type LevelUseCase struct {
@@ -13,22 +21,80 @@ func NewLevelUseCase(levelRepo repository.LevelRepository) *LevelUseCase {
 	return &LevelUseCase{levelRepository: levelRepo}
 }
 
-func (uc *LevelUseCase) CreateOrUpdateLevel(level *model.Level) error {
-	return uc.levelRepository.CreateOrUpdate(level)
+// CreateOrUpdateLevel guarda el nivel si su padre existe y no forma un ciclo.
+// Si cambia de padre cambian sus privilegios efectivos y los de los niveles
+// que heredan de él, así que cambia su versión.
+func (uc *LevelUseCase) CreateOrUpdateLevel(level *model.Level, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.levelRepository.MatchesFilter, level.ID, filter); err != nil {
+		return err
+	}
+	if err := uc.checkParent(level); err != nil {
+		return err
+	}
+
+	parentChanged := false
+	if level.ID != 0 {
+		if previous, err := uc.levelRepository.GetByID(level.ID); err == nil {
+			parentChanged = !sameLevel(previous.ParentID, level.ParentID)
+		}
+	}
+
+	if err := uc.levelRepository.CreateOrUpdate(level, filter); err != nil {
+		return err
+	}
+	if parentChanged {
+		return uc.levelRepository.BumpPermissionVersion(level.ID)
+	}
+	return nil
+}
+
+// checkParent recorre los ascendientes del nuevo padre para comprobar que el
+// nivel no acaba heredando de sí mismo
+func (uc *LevelUseCase) checkParent(level *model.Level) error {
+	if level.ParentID == nil {
+		return nil
+	}
+
+	visited := make(map[uint]bool)
+	for current := level.ParentID; current != nil && !visited[*current]; {
+		if level.ID != 0 && *current == level.ID {
+			return ErrLevelCycle
+		}
+		visited[*current] = true
+		ancestor, err := uc.levelRepository.GetByID(*current)
+		if err != nil {
+			if current == level.ParentID {
+				return ErrLevelParentNotFound
+			}
+			return err
+		}
+		current = ancestor.ParentID
+	}
+	return nil
+}
+
+func sameLevel(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 func (uc *LevelUseCase) GetLevelByID(id uint) (*model.Level, error) {
 	return uc.levelRepository.GetByID(id)
 }
 
-func (uc *LevelUseCase) GetAllLevels() ([]*model.Level, error) {
-	return uc.levelRepository.GetAll()
+func (uc *LevelUseCase) GetAllLevels(filter *security.RowFilter) ([]*model.Level, error) {
+	return uc.levelRepository.GetAll(filter)
 }
 
-func (uc *LevelUseCase) PaginateLevels(page int, pageSize int) ([]*model.Level, int, error) {
-	return uc.levelRepository.Paginate(page, pageSize)
+func (uc *LevelUseCase) PaginateLevels(page int, pageSize int, filter *security.RowFilter) ([]*model.Level, int, error) {
+	return uc.levelRepository.Paginate(page, pageSize, filter)
 }
 
-func (uc *LevelUseCase) DeleteLevel(level *model.Level) error {
+func (uc *LevelUseCase) DeleteLevel(level *model.Level, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.levelRepository.MatchesFilter, level.ID, filter); err != nil {
+		return err
+	}
 	return uc.levelRepository.Delete(level)
 }
@@ -3,6 +3,7 @@ package usecase
 import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
 )
 
 type MenuTreeUseCase struct {
@@ -13,18 +14,24 @@ func NewMenuTreeUseCase(repo repository.MenuTreeRepository) *MenuTreeUseCase {
 	return &MenuTreeUseCase{menuTreeRepo: repo}
 }
 
-func (uc *MenuTreeUseCase) CreateOrUpdateMenuTree(menu *model.MenuTree) error {
-	return uc.menuTreeRepo.CreateOrUpdate(menu)
+func (uc *MenuTreeUseCase) CreateOrUpdateMenuTree(menu *model.MenuTree, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.menuTreeRepo.MatchesFilter, menu.ID, filter); err != nil {
+		return err
+	}
+	return uc.menuTreeRepo.CreateOrUpdate(menu, filter)
 }
 
-func (uc *MenuTreeUseCase) GetAllMenuTrees() ([]*model.MenuTree, error) {
-	return uc.menuTreeRepo.GetAll()
+func (uc *MenuTreeUseCase) GetAllMenuTrees(filter *security.RowFilter) ([]*model.MenuTree, error) {
+	return uc.menuTreeRepo.GetAll(filter)
 }
 
-func (uc *MenuTreeUseCase) PaginateMenuTrees(page int, pageSize int) ([]*model.MenuTree, int, error) {
-	return uc.menuTreeRepo.Paginate(page, pageSize)
+func (uc *MenuTreeUseCase) PaginateMenuTrees(page int, pageSize int, filter *security.RowFilter) ([]*model.MenuTree, int, error) {
+	return uc.menuTreeRepo.Paginate(page, pageSize, filter)
 }
 
-func (uc *MenuTreeUseCase) DeleteMenuTree(menu *model.MenuTree) error {
+func (uc *MenuTreeUseCase) DeleteMenuTree(menu *model.MenuTree, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.menuTreeRepo.MatchesFilter, menu.ID, filter); err != nil {
+		return err
+	}
 	return uc.menuTreeRepo.Delete(menu)
 }
@@ -0,0 +1,339 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor authentication has not been set up")
+	ErrMFARequiredByLevel = errors.New("two-factor authentication is required for your level")
+	ErrInvalidMFACode     = errors.New("invalid authentication code")
+	ErrInvalidMFAToken    = errors.New("invalid or expired mfa token")
+)
+
+const (
+	// totpSkew acepta el código del intervalo anterior y del siguiente
+	totpSkew          = 1
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10
+)
+
+// MFARequiredError indica que la contraseña es correcta pero falta el segundo
+// factor. Contiene el token que permite completarlo.
+type MFARequiredError struct {
+	Challenge model.MFAChallenge
+}
+
+func (e *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+type MFAUseCase struct {
+	userRepository         repository.UserRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	tokenUseCase           *TokenUseCase
+	lockoutPolicy          security.LockoutPolicy
+	issuer                 string
+	pendingTokenTTL        time.Duration
+	permissions            *service.PermissionResolver
+}
+
+func NewMFAUseCase(userRepo repository.UserRepository, recoveryCodeRepo repository.RecoveryCodeRepository, tokenUseCase *TokenUseCase, lockoutPolicy security.LockoutPolicy, issuer string, pendingTokenTTL time.Duration, permissions *service.PermissionResolver) *MFAUseCase {
+	return &MFAUseCase{
+		userRepository:         userRepo,
+		recoveryCodeRepository: recoveryCodeRepo,
+		tokenUseCase:           tokenUseCase,
+		lockoutPolicy:          lockoutPolicy,
+		issuer:                 issuer,
+		pendingTokenTTL:        pendingTokenTTL,
+		permissions:            permissions,
+	}
+}
+
+// IsRequired indica si el usuario debe pasar el segundo factor para entrar,
+// bien porque lo ha activado o porque alguno de sus niveles lo exige.
+func (uc *MFAUseCase) IsRequired(user *model.User) bool {
+	return user.MFAEnabled || uc.requiredByLevel(user)
+}
+
+// requiredByLevel indica si alguno de los niveles del usuario, incluidos los
+// niveles de los que heredan, exige el 2FA. Si no se pueden leer se exige.
+func (uc *MFAUseCase) requiredByLevel(user *model.User) bool {
+	if user.Level.RequireMFA {
+		return true
+	}
+	levels, err := uc.permissions.Levels(user.LevelIDs()...)
+	if err != nil {
+		log.Printf("Failed to load levels of user %d: %v", user.ID, err)
+		return true
+	}
+	for _, level := range levels {
+		if level.RequireMFA {
+			return true
+		}
+	}
+	return false
+}
+
+// Challenge genera el token de "mfa pendiente" que se devuelve en el login
+func (uc *MFAUseCase) Challenge(user *model.User) error {
+	mfaToken, err := helpers.GenerateMFAToken(user, uc.pendingTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return &MFARequiredError{Challenge: model.MFAChallenge{
+		MFARequired:        true,
+		EnrollmentRequired: !user.MFAEnabled,
+		MFAToken:           mfaToken,
+	}}
+}
+
+// Enroll genera un secreto TOTP nuevo para el usuario. El 2FA no queda activo
+// hasta que se confirma con un código válido.
+func (uc *MFAUseCase) Enroll(userID uint) (*model.MFAEnrollment, error) {
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepository.UpdateMFA(user.ID, secret, false); err != nil {
+		return nil, err
+	}
+
+	return &model.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: helpers.TOTPProvisioningURI(uc.issuer, user.Email, secret),
+	}, nil
+}
+
+// EnrollPending permite configurar el 2FA durante el login a los usuarios
+// cuyo nivel lo exige y que aún no lo tienen.
+func (uc *MFAUseCase) EnrollPending(mfaToken string) (*model.MFAEnrollment, error) {
+	claims, err := uc.parsePendingToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return uc.Enroll(claims.UserID)
+}
+
+// Activate confirma el secreto con un código TOTP, activa el 2FA y devuelve
+// los códigos de recuperación.
+func (uc *MFAUseCase) Activate(userID uint, code string) ([]string, error) {
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if ok, err := uc.verifyTOTP(user, code); err != nil || !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	return uc.activate(user)
+}
+
+// Disable desactiva el 2FA tras comprobar un código, salvo que alguno de los
+// niveles del usuario lo exija.
+func (uc *MFAUseCase) Disable(userID uint, code string) error {
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+	if uc.requiredByLevel(user) {
+		return ErrMFARequiredByLevel
+	}
+
+	if ok, err := uc.verifyCode(user, code); err != nil || !ok {
+		return ErrInvalidMFACode
+	}
+
+	return uc.reset(user.ID)
+}
+
+// RegenerateRecoveryCodes invalida los códigos de recuperación anteriores y
+// devuelve otros nuevos.
+func (uc *MFAUseCase) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if ok, err := uc.verifyTOTP(user, code); err != nil || !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	return uc.generateRecoveryCodes(user.ID)
+}
+
+// ResetForUser quita el 2FA de un usuario que ha perdido el dispositivo. Si su
+// nivel lo exige tendrá que configurarlo de nuevo en el siguiente login.
+func (uc *MFAUseCase) ResetForUser(userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(userID); err != nil {
+		return err
+	}
+	return uc.reset(userID)
+}
+
+// VerifyLogin completa el login con un código TOTP o de recuperación y emite
+// los tokens de sesión. Si el usuario estaba configurando el 2FA se activa y
+// se devuelven sus códigos de recuperación.
+func (uc *MFAUseCase) VerifyLogin(verification *model.MFAVerification, client model.ClientInfo) (*model.MFALoginResponse, error) {
+	claims, err := uc.parsePendingToken(verification.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepository.GetByID(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, &AccountLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+	if !user.MFAEnabled && user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	ok, err := uc.verifyCode(user, verification.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, registerAuthFailure(uc.userRepository, uc.lockoutPolicy, user, now, ErrInvalidMFACode)
+	}
+
+	// El token de mfa pendiente es de un solo uso
+	if err := uc.tokenUseCase.RevokeAccessToken(claims); err != nil {
+		return nil, err
+	}
+
+	if user.Failure > 0 || user.LockedUntil != nil {
+		if err := uc.userRepository.ResetFailures(user.ID); err != nil {
+			log.Printf("Failed to reset login failures for user %d: %v", user.ID, err)
+		}
+	}
+
+	response := &model.MFALoginResponse{}
+	if !user.MFAEnabled {
+		if response.RecoveryCodes, err = uc.activate(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if response.TokenPair, err = uc.tokenUseCase.IssueTokens(user, client); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *MFAUseCase) parsePendingToken(mfaToken string) (*model.Claim, error) {
+	claims, err := helpers.ParseJWT(mfaToken)
+	if err != nil || !claims.MFAPending {
+		return nil, ErrInvalidMFAToken
+	}
+
+	revoked, err := uc.tokenUseCase.IsRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+	return claims, nil
+}
+
+// verifyCode acepta un código TOTP o, si el 2FA está activo, uno de recuperación
+func (uc *MFAUseCase) verifyCode(user *model.User, code string) (bool, error) {
+	code = normalizeMFACode(code)
+	if ok, err := uc.verifyTOTP(user, code); err != nil || ok {
+		return ok, err
+	}
+	if !user.MFAEnabled {
+		return false, nil
+	}
+	return uc.recoveryCodeRepository.Consume(user.ID, helpers.HashToken(code), time.Now())
+}
+
+// verifyTOTP comprueba el código y lo marca como usado para que no pueda
+// reutilizarse dentro de su ventana de validez.
+func (uc *MFAUseCase) verifyTOTP(user *model.User, code string) (bool, error) {
+	step, ok := helpers.ValidateTOTP(user.MFASecret, normalizeMFACode(code), time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+	return uc.userRepository.MarkMFAStepUsed(user.ID, step)
+}
+
+func (uc *MFAUseCase) activate(user *model.User) ([]string, error) {
+	if err := uc.userRepository.UpdateMFA(user.ID, user.MFASecret, true); err != nil {
+		return nil, err
+	}
+	user.MFAEnabled = true
+	return uc.generateRecoveryCodes(user.ID)
+}
+
+func (uc *MFAUseCase) reset(userID uint) error {
+	if err := uc.userRepository.UpdateMFA(userID, "", false); err != nil {
+		return err
+	}
+	return uc.recoveryCodeRepository.DeleteByUser(userID)
+}
+
+func (uc *MFAUseCase) generateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := helpers.GetToken(recoveryCodeSize)
+		if err != nil {
+			return nil, err
+		}
+		code = strings.ToLower(code)
+		codes[i] = code[:recoveryCodeSize/2] + "-" + code[recoveryCodeSize/2:]
+		hashes[i] = helpers.HashToken(code)
+	}
+
+	if err := uc.recoveryCodeRepository.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeMFACode ignora espacios, guiones y mayúsculas al teclear el código
+func normalizeMFACode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/identity"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+)
+
+var (
+	ErrInvalidOIDCState     = errors.New("invalid or expired login state")
+	ErrOIDCAuthentication   = errors.New("identity provider authentication failed")
+	ErrOIDCEmailRequired    = errors.New("identity provider did not return a verified email")
+	ErrOIDCNoLevel          = errors.New("your groups do not grant access to this application")
+	ErrOIDCLevelNotFound    = errors.New("configured level for identity provider groups does not exist")
+	ErrOIDCUsernameConflict = errors.New("a different user already uses this username")
+)
+
+const oidcSecretSize = 32
+
+// GroupLevel asigna a los miembros de un grupo del proveedor el nivel con ese
+// nombre (model.Level.Level)
+type GroupLevel struct {
+	Group string
+	Level string
+}
+
+// OIDCLevelMapping reglas para elegir el nivel a partir de los grupos. Gana la
+// primera regla cuyo grupo tenga el usuario; sin ninguna se usa DefaultLevel y,
+// si está vacío, se deniega el acceso.
+type OIDCLevelMapping struct {
+	Groups       []GroupLevel
+	DefaultLevel string
+}
+
+type OIDCUseCase struct {
+	provider            identity.Provider
+	oidcStateRepository repository.OIDCStateRepository
+	userRepository      repository.UserRepository
+	levelRepository     repository.LevelRepository
+	passwordHasher      security.PasswordHasher
+	tokenUseCase        *TokenUseCase
+	mfaUseCase          *MFAUseCase
+	levelMapping        OIDCLevelMapping
+	stateTTL            time.Duration
+}
+
+func NewOIDCUseCase(provider identity.Provider, oidcStateRepo repository.OIDCStateRepository, userRepo repository.UserRepository, levelRepo repository.LevelRepository, passwordHasher security.PasswordHasher, tokenUseCase *TokenUseCase, mfaUseCase *MFAUseCase, levelMapping OIDCLevelMapping, stateTTL time.Duration) *OIDCUseCase {
+	return &OIDCUseCase{
+		provider:            provider,
+		oidcStateRepository: oidcStateRepo,
+		userRepository:      userRepo,
+		levelRepository:     levelRepo,
+		passwordHasher:      passwordHasher,
+		tokenUseCase:        tokenUseCase,
+		mfaUseCase:          mfaUseCase,
+		levelMapping:        levelMapping,
+		stateTTL:            stateTTL,
+	}
+}
+
+// Begin inicia el login: guarda state, nonce y code verifier y devuelve la URL
+// del proveedor a la que hay que redirigir al usuario.
+func (uc *OIDCUseCase) Begin() (string, error) {
+	now := time.Now()
+	if err := uc.oidcStateRepository.DeleteExpired(now); err != nil {
+		log.Printf("Failed to delete expired oidc states: %v", err)
+	}
+
+	state, err := helpers.GenerateSecureToken(oidcSecretSize)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := helpers.GenerateSecureToken(oidcSecretSize)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := helpers.GenerateSecureToken(oidcSecretSize)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.oidcStateRepository.Create(&model.OIDCState{
+		StateHash:    helpers.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(uc.stateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return uc.provider.AuthCodeURL(state, nonce, helpers.PKCEChallenge(verifier))
+}
+
+// Complete canjea el código devuelto por el proveedor, da de alta o actualiza
+// el usuario según sus claims y emite los tokens del API. Como en el login con
+// contraseña, si el usuario tiene que pasar el segundo factor se devuelve un
+// MFARequiredError con el token para hacerlo o para activarlo.
+func (uc *OIDCUseCase) Complete(ctx context.Context, state, code string, client model.ClientInfo) (*model.TokenPair, error) {
+	if state == "" || code == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	pending, err := uc.oidcStateRepository.Consume(helpers.HashToken(state))
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	verified, err := uc.provider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		log.Printf("OIDC exchange failed: %v", err)
+		return nil, ErrOIDCAuthentication
+	}
+
+	user, err := uc.provision(verified)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.mfaUseCase.IsRequired(user) {
+		return nil, uc.mfaUseCase.Challenge(user)
+	}
+
+	return uc.tokenUseCase.IssueTokens(user, client)
+}
+
+// provision crea el usuario la primera vez que entra (just-in-time) y en los
+// siguientes accesos sincroniza su nombre y su nivel con el proveedor, que es
+// la fuente de verdad
+func (uc *OIDCUseCase) provision(verified *identity.Identity) (*model.User, error) {
+	email := strings.ToLower(strings.TrimSpace(verified.Email))
+	if email == "" || !verified.EmailVerified {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	level, err := uc.resolveLevel(verified.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := strings.TrimSpace(verified.Name)
+	if fullName == "" {
+		fullName = email
+	}
+
+	// Si no existe (o no se puede leer) se intenta crear; un fallo real de la
+	// base de datos aparecerá al guardarlo
+	user, err := uc.userRepository.GetByEmail(email)
+	if err != nil || user == nil {
+		return uc.createUser(verified, email, fullName, level)
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountSuspended
+	}
+
+	if user.LevelID != level.ID || user.FullName != fullName {
+		user.LevelID = level.ID
+		user.Level = *level
+		user.FullName = fullName
+		if err := uc.userRepository.Update(user, nil); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (uc *OIDCUseCase) createUser(verified *identity.Identity, email, fullName string, level *model.Level) (*model.User, error) {
+	// La cuenta no tiene contraseña local utilizable: se guarda el hash de un
+	// secreto aleatorio que nadie conoce
+	secret, err := helpers.GenerateSecureToken(oidcSecretSize)
+	if err != nil {
+		return nil, err
+	}
+	password, err := uc.passwordHasher.Hash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	username := strings.TrimSpace(verified.Username)
+	if username == "" {
+		username = email
+	}
+
+	user := &model.User{
+		Username: username,
+		Email:    email,
+		FullName: fullName,
+		Password: password,
+		LevelID:  level.ID,
+	}
+	if err := uc.userRepository.Create(user, nil); err != nil {
+		if username != email {
+			// El nombre de usuario puede estar cogido: se usa el email, que es único
+			user.ID = 0
+			user.Username = email
+			if retryErr := uc.userRepository.Create(user, nil); retryErr == nil {
+				user.Level = *level
+				return user, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrOIDCUsernameConflict, err)
+	}
+
+	user.Level = *level
+	return user, nil
+}
+
+func (uc *OIDCUseCase) resolveLevel(groups []string) (*model.Level, error) {
+	levelName := uc.levelMapping.DefaultLevel
+	for _, rule := range uc.levelMapping.Groups {
+		if containsString(groups, rule.Group) {
+			levelName = rule.Level
+			break
+		}
+	}
+	if levelName == "" {
+		return nil, ErrOIDCNoLevel
+	}
+
+	levels, err := uc.levelRepository.GetAll(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, level := range levels {
+		if level.Level == levelName {
+			return level, nil
+		}
+	}
+
+	log.Printf("OIDC level %q is not defined", levelName)
+	return nil, ErrOIDCLevelNotFound
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"log"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+)
+
+// passwordFieldRequired código de error cuando no se envía la contraseña
+const passwordFieldRequired = "required"
+
+var passwordViolationMessages = map[string]string{
+	passwordFieldRequired:             "password is required",
+	security.PasswordTooShort:         "password is too short",
+	security.PasswordCharacterClasses: "password needs more kinds of characters (lowercase, uppercase, digits, symbols)",
+	security.PasswordCommon:           "password is too common or has appeared in a data breach",
+	security.PasswordReused:           "password was used recently",
+}
+
+// PasswordPolicyError contraseña rechazada, con un error por cada regla que
+// no cumple para que el frontend pueda mostrarlos junto al campo
+type PasswordPolicyError struct {
+	Fields []model.FieldError
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the password policy"
+}
+
+// PasswordExpiredError indica que la contraseña es correcta pero ha caducado.
+// Contiene el token que permite cambiarla para completar el login.
+type PasswordExpiredError struct {
+	Challenge model.PasswordExpiredChallenge
+}
+
+func (e *PasswordExpiredError) Error() string {
+	return "password has expired and must be changed"
+}
+
+// PasswordPolicyUseCase aplica la política de contraseñas general o la del
+// nivel del usuario y guarda el historial para impedir reutilizarlas.
+type PasswordPolicyUseCase struct {
+	policy                    security.PasswordPolicy
+	blocklist                 security.PasswordBlocklist
+	passwordHistoryRepository repository.PasswordHistoryRepository
+	levelRepository           repository.LevelRepository
+	passwordHasher            security.PasswordHasher
+	expiredTokenTTL           time.Duration
+}
+
+func NewPasswordPolicyUseCase(policy security.PasswordPolicy, blocklist security.PasswordBlocklist, passwordHistoryRepo repository.PasswordHistoryRepository, levelRepo repository.LevelRepository, passwordHasher security.PasswordHasher, expiredTokenTTL time.Duration) *PasswordPolicyUseCase {
+	return &PasswordPolicyUseCase{
+		policy:                    policy,
+		blocklist:                 blocklist,
+		passwordHistoryRepository: passwordHistoryRepo,
+		levelRepository:           levelRepo,
+		passwordHasher:            passwordHasher,
+		expiredTokenTTL:           expiredTokenTTL,
+	}
+}
+
+// PolicyFor devuelve la política del nivel, o la general si no lo tiene o no existe
+func (uc *PasswordPolicyUseCase) PolicyFor(levelID uint) security.PasswordPolicy {
+	if levelID == 0 {
+		return uc.policy
+	}
+	level, err := uc.levelRepository.GetByID(levelID)
+	if err != nil {
+		return uc.policy
+	}
+	return uc.policy.Merge(security.PasswordPolicy{
+		MinLength:  level.PasswordMinLength,
+		MinClasses: level.PasswordMinClasses,
+		History:    level.PasswordHistory,
+		MaxAge:     time.Duration(level.PasswordMaxAgeDays) * 24 * time.Hour,
+	})
+}
+
+// Hash valida la nueva contraseña del usuario (que puede no existir todavía)
+// y devuelve su hash. No la guarda: después hay que llamar a Remember.
+func (uc *PasswordPolicyUseCase) Hash(user *model.User, password string) (string, error) {
+	if password == "" {
+		return "", passwordPolicyError(passwordFieldRequired)
+	}
+
+	policy := uc.PolicyFor(user.LevelID)
+	violations := policy.Check(password)
+	if uc.blocklist != nil && uc.blocklist.Contains(password) {
+		violations = append(violations, security.PasswordCommon)
+	}
+	if len(violations) == 0 && user.ID != 0 && uc.reused(user.ID, password, policy.History) {
+		violations = append(violations, security.PasswordReused)
+	}
+	if len(violations) > 0 {
+		return "", passwordPolicyError(violations...)
+	}
+
+	return uc.passwordHasher.Hash(password)
+}
+
+// Remember añade el hash al historial del usuario y borra los que ya no hacen
+// falta. Un fallo aquí no debe deshacer el cambio de contraseña ya guardado.
+func (uc *PasswordPolicyUseCase) Remember(user *model.User, hashedPassword string) {
+	history := uc.PolicyFor(user.LevelID).History
+	if history <= 0 {
+		return
+	}
+
+	if err := uc.passwordHistoryRepository.Create(&model.PasswordHistory{UserID: user.ID, PasswordHash: hashedPassword}); err != nil {
+		log.Printf("Failed to store password history for user %d: %v", user.ID, err)
+		return
+	}
+	if err := uc.passwordHistoryRepository.Prune(user.ID, history); err != nil {
+		log.Printf("Failed to prune password history for user %d: %v", user.ID, err)
+	}
+}
+
+// IsExpired indica si el usuario debe cambiar la contraseña antes de entrar.
+// Las cuentas sin fecha de cambio cuentan desde su creación.
+func (uc *PasswordPolicyUseCase) IsExpired(user *model.User) bool {
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return uc.PolicyFor(user.LevelID).Expired(changedAt, time.Now())
+}
+
+// Challenge genera el token para cambiar la contraseña caducada y lo devuelve
+// dentro de un PasswordExpiredError
+func (uc *PasswordPolicyUseCase) Challenge(user *model.User) error {
+	token, err := helpers.GeneratePasswordExpiredToken(user, uc.expiredTokenTTL)
+	if err != nil {
+		return err
+	}
+	return &PasswordExpiredError{Challenge: model.PasswordExpiredChallenge{
+		PasswordExpired: true,
+		PasswordToken:   token,
+	}}
+}
+
+func (uc *PasswordPolicyUseCase) reused(userID uint, password string, history int) bool {
+	if history <= 0 {
+		return false
+	}
+
+	previous, err := uc.passwordHistoryRepository.GetRecent(userID, history)
+	if err != nil {
+		log.Printf("Failed to read password history for user %d: %v", userID, err)
+		return false
+	}
+	for _, entry := range previous {
+		if ok, err := uc.passwordHasher.Verify(entry.PasswordHash, password); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func passwordPolicyError(codes ...string) *PasswordPolicyError {
+	fields := make([]model.FieldError, len(codes))
+	for i, code := range codes {
+		fields[i] = model.FieldError{Field: "password", Code: code, Message: passwordViolationMessages[code]}
+	}
+	return &PasswordPolicyError{Fields: fields}
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/notification"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/helpers"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired token")
+	ErrPasswordRequired  = errors.New("password is required")
+	ErrPasswordMismatch  = errors.New("password and confirmPassword do not match")
+)
+
+const (
+	resetTokenBytes       = 32
+	passwordResetTemplate = "templates/password_reset.html"
+)
+
+type PasswordResetUseCase struct {
+	userRepository repository.UserRepository
+	passwordPolicy *PasswordPolicyUseCase
+	tokenUseCase   *TokenUseCase
+	mailer         notification.Mailer
+	resetURL       string
+	tokenTTL       time.Duration
+	pending        sync.WaitGroup
+}
+
+func NewPasswordResetUseCase(userRepo repository.UserRepository, passwordPolicy *PasswordPolicyUseCase, tokenUseCase *TokenUseCase, mailer notification.Mailer, resetURL string, tokenTTL time.Duration) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		userRepository: userRepo,
+		passwordPolicy: passwordPolicy,
+		tokenUseCase:   tokenUseCase,
+		mailer:         mailer,
+		resetURL:       resetURL,
+		tokenTTL:       tokenTTL,
+	}
+}
+
+// RequestPasswordReset genera un token de un solo uso y envía el enlace por
+// email en segundo plano: la respuesta tarda lo mismo exista o no la cuenta,
+// así que no revela cuáles existen. Si el email no existe no hace nada.
+func (uc *PasswordResetUseCase) RequestPasswordReset(email string) {
+	uc.pending.Add(1)
+	go func() {
+		defer uc.pending.Done()
+		if err := uc.sendResetLink(email); err != nil {
+			log.Printf("Failed to send password reset link: %v", err)
+		}
+	}()
+}
+
+// Wait espera a que terminen los envíos de enlaces en curso
+func (uc *PasswordResetUseCase) Wait() {
+	uc.pending.Wait()
+}
+
+func (uc *PasswordResetUseCase) sendResetLink(email string) error {
+	user, err := uc.userRepository.GetByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := helpers.GenerateSecureToken(resetTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	// Solo se guarda el hash: quien lea la base de datos no puede usar el token
+	expiresAt := time.Now().Add(uc.tokenTTL)
+	user.Token = helpers.HashToken(token)
+	user.TokenExpiresAt = &expiresAt
+	if err := uc.userRepository.Update(user, nil); err != nil {
+		return err
+	}
+
+	return uc.mailer.SendTemplateTo(user.Email, "Password reset", passwordResetTemplate, map[string]interface{}{
+		"Name":      user.FullName,
+		"Email":     user.Email,
+		"Link":      uc.resetURL + "?token=" + url.QueryEscape(token),
+		"ExpiresIn": uc.tokenTTL.String(),
+	})
+}
+
+// ResetPassword cambia la contraseña si el token es válido, lo invalida y
+// revoca todas las sesiones abiertas del usuario. La nueva contraseña tiene que
+// cumplir la política de su nivel.
+func (uc *PasswordResetUseCase) ResetPassword(request *model.RecoverPass) error {
+	if request.Token == "" {
+		return ErrInvalidResetToken
+	}
+	if request.Password.Password == "" {
+		return ErrPasswordRequired
+	}
+	if request.Password.Password != request.ConfirmPassword {
+		return ErrPasswordMismatch
+	}
+
+	token := helpers.HashToken(request.Token)
+	user, err := uc.userRepository.GetByToken(token)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if user.TokenExpiresAt == nil || time.Now().After(*user.TokenExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := uc.passwordPolicy.Hash(user, request.Password.Password)
+	if err != nil {
+		return err
+	}
+
+	// El token se consume al guardar la contraseña: si otra petición lo ha
+	// usado mientras tanto, esta no cambia nada
+	reset, err := uc.userRepository.ResetPassword(user.ID, token, hashedPassword, time.Now())
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidResetToken
+	}
+	uc.passwordPolicy.Remember(user, hashedPassword)
+
+	return uc.tokenUseCase.RevokeAllForUser(user.ID)
+}
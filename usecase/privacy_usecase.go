@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/domain/storage"
+)
+
+var ErrCannotEraseSelf = errors.New("you cannot erase your own account")
+
+// PrivacyUseCase atiende las solicitudes de acceso y de supresión de datos
+// personales (RGPD). Cada exportación y cada supresión queda en la auditoría.
+type PrivacyUseCase struct {
+	userRepository       repository.UserRepository
+	sessionRepository    repository.SessionRepository
+	apiKeyRepository     repository.APIKeyRepository
+	invitationRepository repository.InvitationRepository
+	auditRepository      repository.AuditRepository
+	tokenUseCase         *TokenUseCase
+	fileStorage          storage.FileStorage
+}
+
+func NewPrivacyUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, apiKeyRepo repository.APIKeyRepository, invitationRepo repository.InvitationRepository, auditRepo repository.AuditRepository, tokenUseCase *TokenUseCase, fileStorage storage.FileStorage) *PrivacyUseCase {
+	return &PrivacyUseCase{
+		userRepository:       userRepo,
+		sessionRepository:    sessionRepo,
+		apiKeyRepository:     apiKeyRepo,
+		invitationRepository: invitationRepo,
+		auditRepository:      auditRepo,
+		tokenUseCase:         tokenUseCase,
+		fileStorage:          fileStorage,
+	}
+}
+
+// Export reúne todo lo que se guarda del usuario. Los secretos (hash de la
+// contraseña, del 2FA o de las claves) no se incluyen.
+func (uc *PrivacyUseCase) Export(actorID uint, userID uint, filter *security.RowFilter) (*model.UserDataExport, error) {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return nil, err
+	}
+	// Los usuarios eliminados que aún no se han purgado también pueden pedir
+	// sus datos
+	user, err := uc.userRepository.GetByIDUnscoped(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user.Password = ""
+
+	export := &model.UserDataExport{ExportedAt: time.Now(), Profile: user}
+	if export.Sessions, err = uc.sessionRepository.GetByUser(userID); err != nil {
+		return nil, err
+	}
+	if export.APIKeys, err = uc.apiKeyRepository.GetByUser(userID); err != nil {
+		return nil, err
+	}
+	if export.Invitations, err = uc.invitationRepository.GetByUser(userID, user.Email); err != nil {
+		return nil, err
+	}
+	if export.AuditEntries, err = uc.auditRepository.GetByUser(userID); err != nil {
+		return nil, err
+	}
+
+	entry := &model.AuditEntry{ActorID: actorID, SubjectID: userID, Action: model.AuditUserDataExport}
+	if err := uc.auditRepository.Create(entry); err != nil {
+		return nil, err
+	}
+	log.Printf("User %d exported the data of user %d", actorID, userID)
+	return export, nil
+}
+
+// Erase borra el avatar del usuario, lo anonimiza y le cierra todas las sesiones.
+// No se puede deshacer; la fila se conserva para no romper las referencias.
+func (uc *PrivacyUseCase) Erase(actorID uint, request *model.UserErasureRequest, filter *security.RowFilter) error {
+	if request.ID == actorID {
+		return ErrCannotEraseSelf
+	}
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, request.ID, filter); err != nil {
+		return err
+	}
+
+	// La supresión también se aplica a usuarios ya eliminados
+	user, err := uc.userRepository.GetByIDUnscoped(request.ID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	// El avatar se borra antes de anonimizar, que deja vacía la URL que lo
+	// localiza
+	uc.deletePicture(request.ID, user.Picture)
+
+	entry := &model.AuditEntry{
+		ActorID:   actorID,
+		SubjectID: request.ID,
+		Action:    model.AuditUserErasure,
+		Details:   strings.TrimSpace(request.Reason),
+	}
+	if err := uc.userRepository.Erase(request.ID, time.Now(), entry); err != nil {
+		return err
+	}
+	log.Printf("User %d erased the personal data of user %d", actorID, request.ID)
+
+	if err := uc.tokenUseCase.RevokeAllForUser(request.ID); err != nil {
+		log.Printf("Failed to revoke the tokens of erased user %d: %v", request.ID, err)
+	}
+	return nil
+}
+
+// deletePicture borra el avatar y sus miniaturas si se subieron a este
+// almacenamiento; las fotos externas (del proveedor de identidad) se ignoran
+func (uc *PrivacyUseCase) deletePicture(userID uint, picture string) {
+	prefix := fmt.Sprintf("avatars/%d/", userID)
+	index := strings.LastIndex(picture, prefix)
+	if index < 0 {
+		return
+	}
+	name := strings.TrimSuffix(picture[index+len(prefix):], fmt.Sprintf("-%d.jpg", pictureSizes[0]))
+	if name == "" || strings.Contains(name, "/") {
+		return
+	}
+
+	for _, size := range pictureSizes {
+		key := fmt.Sprintf("%s%s-%d.jpg", prefix, name, size)
+		if err := uc.fileStorage.Delete(key); err != nil {
+			log.Printf("Failed to delete picture %s of erased user %d: %v", key, userID, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/domain/storage"
+	"github.com/drossan/core-api/helpers"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrFullNameRequired       = errors.New("fullname is required")
+	ErrInvalidLanguage        = errors.New("language must be a language code such as es or es-ES")
+	ErrInvalidCurrentPassword = errors.New("current password is not correct")
+	ErrPictureTooLarge        = errors.New("picture is too large")
+	ErrUnsupportedPictureType = errors.New("picture must be a JPEG, PNG, GIF or WebP image")
+	ErrInvalidPicture         = errors.New("picture could not be decoded")
+)
+
+const (
+	// maxPicturePixels evita decodificar imágenes enormes muy comprimidas
+	maxPicturePixels = 40000000
+	pictureQuality   = 85
+)
+
+// pictureSizes lado en píxeles de cada versión del avatar; la primera es la
+// que se guarda en User.Picture y el resto son miniaturas
+var pictureSizes = []int{512, 128, 64}
+
+var pictureContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// languagePattern acepta códigos de idioma como "es" o "es-ES"
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// ProfileUseCase gestiona los datos que cada usuario puede cambiar de sí
+// mismo. Siempre trabaja sobre el usuario del token, nunca sobre un ID
+// recibido en la petición.
+type ProfileUseCase struct {
+	userRepository repository.UserRepository
+	passwordHasher security.PasswordHasher
+	passwordPolicy *PasswordPolicyUseCase
+	tokenUseCase   *TokenUseCase
+	fileStorage    storage.FileStorage
+	maxPictureSize int64
+}
+
+func NewProfileUseCase(userRepo repository.UserRepository, passwordHasher security.PasswordHasher, passwordPolicy *PasswordPolicyUseCase, tokenUseCase *TokenUseCase, fileStorage storage.FileStorage, maxPictureSize int64) *ProfileUseCase {
+	return &ProfileUseCase{
+		userRepository: userRepo,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		tokenUseCase:   tokenUseCase,
+		fileStorage:    fileStorage,
+		maxPictureSize: maxPictureSize,
+	}
+}
+
+// MaxPictureSize tamaño máximo en bytes de la imagen subida como avatar
+func (uc *ProfileUseCase) MaxPictureSize() int64 {
+	return uc.maxPictureSize
+}
+
+func (uc *ProfileUseCase) GetProfile(userID uint) (*model.User, error) {
+	return uc.userRepository.GetByID(userID)
+}
+
+// UpdateProfile cambia el nombre, la foto y el idioma del usuario
+func (uc *ProfileUseCase) UpdateProfile(userID uint, profile *model.Profile) (*model.User, error) {
+	profile.FullName = strings.TrimSpace(profile.FullName)
+	if profile.FullName == "" {
+		return nil, ErrFullNameRequired
+	}
+	if profile.Language != "" && !languagePattern.MatchString(profile.Language) {
+		return nil, ErrInvalidLanguage
+	}
+
+	if err := uc.userRepository.UpdateProfile(userID, profile); err != nil {
+		return nil, err
+	}
+	return uc.userRepository.GetByID(userID)
+}
+
+// ChangePassword cambia la contraseña tras comprobar la actual. Se cierran
+// todas las sesiones del usuario y se devuelven tokens nuevos para la actual.
+// La nueva contraseña tiene que cumplir la política de su nivel.
+func (uc *ProfileUseCase) ChangePassword(userID uint, change *model.PasswordChange, client model.ClientInfo) (*model.TokenPair, error) {
+	if change.Password.Password == "" {
+		return nil, ErrPasswordRequired
+	}
+	if change.Password.Password != change.ConfirmPassword {
+		return nil, ErrPasswordMismatch
+	}
+
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := uc.passwordHasher.Verify(user.Password, change.CurrentPassword)
+	if err != nil || !ok {
+		return nil, ErrInvalidCurrentPassword
+	}
+
+	hashedPassword, err := uc.passwordPolicy.Hash(user, change.Password.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepository.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
+	uc.passwordPolicy.Remember(user, hashedPassword)
+
+	if err := uc.tokenUseCase.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	return uc.tokenUseCase.IssueTokens(user, client)
+}
+
+// UpdatePicture valida la imagen subida, genera el avatar y sus miniaturas
+// cuadradas en JPEG, las guarda y actualiza User.Picture. El tipo se detecta
+// por el contenido, no por la cabecera que envía el cliente.
+func (uc *ProfileUseCase) UpdatePicture(userID uint, content io.Reader) (*model.ProfilePicture, error) {
+	data, err := io.ReadAll(io.LimitReader(content, uc.maxPictureSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > uc.maxPictureSize {
+		return nil, ErrPictureTooLarge
+	}
+	if !pictureContentTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedPictureType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidPicture
+	}
+	if config.Width*config.Height > maxPicturePixels {
+		return nil, ErrPictureTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidPicture
+	}
+
+	user, err := uc.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un nombre nuevo en cada subida evita que cachés y CDN sirvan la foto anterior
+	name, err := helpers.GenerateSecureToken(9)
+	if err != nil {
+		return nil, err
+	}
+
+	picture := &model.ProfilePicture{Thumbnails: make(map[int]string, len(pictureSizes)-1)}
+	for i, size := range pictureSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, helpers.SquareThumbnail(img, size), &jpeg.Options{Quality: pictureQuality}); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("avatars/%d/%s-%d.jpg", user.ID, name, size)
+		url, err := uc.fileStorage.Save(key, &buf, "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			picture.Picture = url
+		} else {
+			picture.Thumbnails[size] = url
+		}
+	}
+
+	profile := &model.Profile{FullName: user.FullName, Picture: picture.Picture, Language: user.Language}
+	if err := uc.userRepository.UpdateProfile(user.ID, profile); err != nil {
+		return nil, err
+	}
+	return picture, nil
+}
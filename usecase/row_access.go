@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"github.com/drossan/core-api/domain/security"
+)
+
+var ErrRowAccessDenied = security.ErrRowAccessDenied
+
+// checkRowAccess comprueba que el registro guardado cumple las condiciones de
+// acceso a filas antes de cambiarlo. Los registros nuevos no existen todavía:
+// los repositorios comprueban al guardar que los datos que llegan también las
+// cumplen. Las rutas sin condiciones no se consultan.
+func checkRowAccess(matches func(id uint, filter *security.RowFilter) (bool, error), id uint, filter *security.RowFilter) error {
+	if filter == nil || id == 0 {
+		return nil
+	}
+	allowed, err := matches(id, filter)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrRowAccessDenied
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"log"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+)
+
+// sessionTouchInterval evita escribir la última actividad en cada petición
+const sessionTouchInterval = time.Minute
+
+type SessionUseCase struct {
+	sessionRepository repository.SessionRepository
+	userRepository    repository.UserRepository
+	tokenUseCase      *TokenUseCase
+	// sessionTTL tiempo sin actividad tras el que el refresh token caduca y la
+	// sesión deja de listarse
+	sessionTTL time.Duration
+}
+
+func NewSessionUseCase(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, tokenUseCase *TokenUseCase, sessionTTL time.Duration) *SessionUseCase {
+	return &SessionUseCase{
+		sessionRepository: sessionRepo,
+		userRepository:    userRepo,
+		tokenUseCase:      tokenUseCase,
+		sessionTTL:        sessionTTL,
+	}
+}
+
+// List devuelve las sesiones abiertas del usuario marcando la actual
+func (uc *SessionUseCase) List(userID uint, currentSessionID uint) ([]*model.Session, error) {
+	sessions, err := uc.sessionRepository.GetActiveByUser(userID, time.Now().Add(-uc.sessionTTL))
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// ListForUser devuelve las sesiones abiertas de otro usuario (uso administrativo)
+func (uc *SessionUseCase) ListForUser(userID uint, filter *security.RowFilter) ([]*model.Session, error) {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return nil, err
+	}
+	if _, err := uc.userRepository.GetByID(userID); err != nil {
+		return nil, err
+	}
+	return uc.List(userID, 0)
+}
+
+// Revoke cierra una sesión del usuario
+func (uc *SessionUseCase) Revoke(userID uint, sessionID uint) error {
+	return uc.tokenUseCase.RevokeSession(userID, sessionID)
+}
+
+// RevokeForUser cierra una sesión de otro usuario (uso administrativo)
+func (uc *SessionUseCase) RevokeForUser(userID uint, sessionID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	return uc.Revoke(userID, sessionID)
+}
+
+// IsSessionActive comprueba que la sesión del token no se ha cerrado y anota
+// su última actividad como mucho una vez por minuto. Los tokens sin sesión
+// (API keys, tokens anteriores a las sesiones) no se comprueban aquí.
+func (uc *SessionUseCase) IsSessionActive(claims *model.Claim) (bool, error) {
+	if claims.SessionID == 0 {
+		return true, nil
+	}
+
+	session, err := uc.sessionRepository.GetByID(claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+	if session.RevokedAt != nil || session.UserID != claims.UserID {
+		return false, nil
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := uc.sessionRepository.Touch(session.ID, now); err != nil {
+			log.Printf("Failed to update session last seen: %v", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,140 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupAPIKeyUseCase crea un usuario cuyo nivel puede leer y escribir usuarios
+// y solo leer niveles
+func setupAPIKeyUseCase(t *testing.T) (*usecase.APIKeyUseCase, *gorm.DB, *model.User) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	level := &model.Level{Level: "Operator", Description: "Operator"}
+	assert.NoError(t, database.Create(level).Error)
+	userForm := &model.Form{Title: "Users", PathAPI: "user|users"}
+	levelForm := &model.Form{Title: "Levels", PathAPI: "level|levels"}
+	assert.NoError(t, database.Create(userForm).Error)
+	assert.NoError(t, database.Create(levelForm).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: level.ID, FormID: userForm.ID, Read: true, Write: true, Create: true, Update: true, Delete: true}).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: level.ID, FormID: levelForm.ID, Read: true}).Error)
+
+	user := &model.User{Username: "operator", Email: "operator@example.com", FullName: "Operator", Password: "hash", LevelID: level.ID}
+	assert.NoError(t, database.Create(user).Error)
+
+	userRepo := db.NewUserRepository(database)
+	return usecase.NewAPIKeyUseCase(db.NewAPIKeyRepository(database), userRepo, service.NewPermissionResolver(db.NewLevelRepository(database)), 30*24*time.Hour), database, user
+}
+
+func TestAPIKeyUseCase_CreateAndAuthenticate(t *testing.T) {
+	uc, database, user := setupAPIKeyUseCase(t)
+
+	created, err := uc.Create(user.ID, &model.APIKeyRequest{Name: " sync ", Scopes: []string{"users:read", "user:write"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "sync", created.Name)
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix+"_"))
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), created.ExpiresAt, time.Minute)
+
+	// Solo se guarda el hash de la clave
+	var stored model.APIKey
+	assert.NoError(t, database.First(&stored, created.ID).Error)
+	assert.NotEqual(t, created.Key, stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, created.Prefix)
+
+	claims, err := uc.Authenticate(created.Key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, user.LevelID, claims.LevelID)
+	assert.Equal(t, created.ID, claims.APIKeyID)
+	assert.Equal(t, []string{"users:read", "user:write"}, claims.Scopes)
+
+	assert.NoError(t, database.First(&stored, created.ID).Error)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = uc.Authenticate(created.Key + "x")
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+}
+
+func TestAPIKeyUseCase_CreateValidation(t *testing.T) {
+	uc, _, user := setupAPIKeyUseCase(t)
+
+	_, err := uc.Create(user.ID, &model.APIKeyRequest{Name: "", Scopes: []string{"user:read"}})
+	assert.Equal(t, usecase.ErrAPIKeyNameRequired, err)
+
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync"})
+	assert.Equal(t, usecase.ErrAPIKeyScopesRequired, err)
+
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync", Scopes: []string{"user:admin"}})
+	assert.Equal(t, usecase.ErrInvalidAPIKeyScope, err)
+
+	// El nivel solo puede leer niveles, así que la clave tampoco puede escribirlos
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync", Scopes: []string{"level:write"}})
+	assert.Equal(t, usecase.ErrAPIKeyScopeNotAllowed, err)
+
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync", Scopes: []string{"form:read"}})
+	assert.Equal(t, usecase.ErrAPIKeyScopeNotAllowed, err)
+
+	past := time.Now().Add(-time.Minute)
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync", Scopes: []string{"user:read"}, ExpiresAt: &past})
+	assert.Equal(t, usecase.ErrInvalidAPIKeyExpiry, err)
+
+	tooLong := time.Now().Add(60 * 24 * time.Hour)
+	_, err = uc.Create(user.ID, &model.APIKeyRequest{Name: "sync", Scopes: []string{"user:read"}, ExpiresAt: &tooLong})
+	assert.Equal(t, usecase.ErrInvalidAPIKeyExpiry, err)
+}
+
+func TestAPIKeyUseCase_RevokedAndExpiredKeysAreRejected(t *testing.T) {
+	uc, database, user := setupAPIKeyUseCase(t)
+
+	revoked, err := uc.Create(user.ID, &model.APIKeyRequest{Name: "revoked", Scopes: []string{"user:read"}})
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrAPIKeyNotFound, uc.Revoke(user.ID+1, revoked.ID))
+	assert.NoError(t, uc.Revoke(user.ID, revoked.ID))
+	_, err = uc.Authenticate(revoked.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	expired, err := uc.Create(user.ID, &model.APIKeyRequest{Name: "expired", Scopes: []string{"user:read"}})
+	assert.NoError(t, err)
+	assert.NoError(t, database.Model(&model.APIKey{}).Where("id = ?", expired.ID).Update("expires_at", time.Now().Add(-time.Second)).Error)
+	_, err = uc.Authenticate(expired.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	// Si el propietario se elimina la clave deja de funcionar
+	active, err := uc.Create(user.ID, &model.APIKeyRequest{Name: "active", Scopes: []string{"user:read"}})
+	assert.NoError(t, err)
+	assert.NoError(t, database.Delete(user).Error)
+	_, err = uc.Authenticate(active.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+}
+
+func TestAPIKeyUseCase_RevokeAllForUser(t *testing.T) {
+	uc, _, user := setupAPIKeyUseCase(t)
+
+	created, err := uc.Create(user.ID, &model.APIKeyRequest{Name: "ci", Scopes: []string{"user:read"}})
+	assert.NoError(t, err)
+
+	// Un filtro sin condiciones no deja revocar las claves de nadie
+	assert.Equal(t, usecase.ErrRowAccessDenied, uc.RevokeAllForUser(user.ID, security.NewRowFilter(nil)))
+	_, err = uc.Authenticate(created.Key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.RevokeAllForUser(user.ID, nil))
+	_, err = uc.Authenticate(created.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+}
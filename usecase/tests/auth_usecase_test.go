@@ -4,13 +4,58 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/memory"
 	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
 	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
+	"time"
 )
 
+// newLoginUseCase prepara un UserUseCase cuyos repositorios de refresh tokens y sesiones aceptan cualquier alta
+func newLoginUseCase(userRepo *mocks.MockUserRepository, hasher security.PasswordHasher) *usecase.UserUseCase {
+	refreshTokenRepo := new(mocks.MockRefreshTokenRepository)
+	refreshTokenRepo.On("Create", mock.Anything).Return(nil)
+	sessionRepo := new(mocks.MockSessionRepository)
+	sessionRepo.On("Create", mock.Anything).Return(nil)
+	tokenUseCase := usecase.NewTokenUseCase(refreshTokenRepo, sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", mock.Anything).Return(&model.Level{}, nil).Maybe()
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, new(mocks.MockRecoveryCodeRepository), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(levelRepo))
+	passwordPolicy := utils.NewTestPasswordPolicy(new(mocks.MockPasswordHistoryRepository), levelRepo, hasher)
+	return usecase.NewUserUseCase(userRepo, hasher, passwordPolicy, tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), service.NewPermissionResolver(levelRepo))
+}
+
 func TestUserUseCase_Login(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	hasher := utils.NewTestPasswordHasher()
+	password := "password"
+	pwd, _ := hasher.Hash(password)
+
+	mockUser := &model.User{
+		Email:    "test@example.com",
+		Password: pwd,
+	}
+
+	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+
+	uc := newLoginUseCase(mockRepo, hasher)
+
+	tokens, err := uc.Login("test@example.com", password, model.ClientInfo{})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserUseCase_LoginRehashesLegacyPassword(t *testing.T) {
 	mockRepo := new(mocks.MockUserRepository)
 	password := "password"
 	ps := sha256.Sum256([]byte(password))
@@ -22,13 +67,17 @@ func TestUserUseCase_Login(t *testing.T) {
 	}
 
 	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(user *model.User) bool {
+		return strings.HasPrefix(user.Password, "bcrypt$")
+	}), mock.Anything).Return(nil)
 
-	uc := usecase.NewUserUseCase(mockRepo)
+	uc := newLoginUseCase(mockRepo, utils.NewTestPasswordHasher())
 
-	token, err := uc.Login("test@example.com", password)
+	tokens, err := uc.Login("test@example.com", password, model.ClientInfo{})
 
 	assert.Nil(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -44,12 +93,81 @@ func TestAuthUseCase_LoginInvalidPassword(t *testing.T) {
 	}
 
 	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+	mockRepo.On("IncrementFailure", mockUser.ID).Return(1, nil)
+
+	uc := newLoginUseCase(mockRepo, utils.NewTestPasswordHasher())
+
+	tokens, err := uc.Login("test@example.com", "wrongpassword", model.ClientInfo{})
+
+	assert.Equal(t, usecase.ErrInvalidCredentials, err)
+	assert.Nil(t, tokens)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockRepo.AssertNotCalled(t, "LockUntil", mock.Anything, mock.Anything)
+}
+
+func TestAuthUseCase_LoginLocksAccountAfterMaxFailures(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	hasher := utils.NewTestPasswordHasher()
+	pwd, _ := hasher.Hash("password")
+
+	mockUser := &model.User{Email: "test@example.com", Password: pwd}
+	mockUser.ID = 7
+
+	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+	mockRepo.On("IncrementFailure", uint(7)).Return(3, nil)
+	mockRepo.On("LockUntil", uint(7), mock.AnythingOfType("time.Time")).Return(nil)
+
+	uc := newLoginUseCase(mockRepo, hasher)
+
+	tokens, err := uc.Login("test@example.com", "wrongpassword", model.ClientInfo{})
+
+	var lockedErr *usecase.AccountLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.Equal(t, time.Minute, lockedErr.RetryAfter)
+	assert.Nil(t, tokens)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthUseCase_LoginRejectsLockedAccount(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	hasher := utils.NewTestPasswordHasher()
+	pwd, _ := hasher.Hash("password")
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	mockUser := &model.User{Email: "test@example.com", Password: pwd, LockedUntil: &lockedUntil}
+
+	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+
+	uc := newLoginUseCase(mockRepo, hasher)
+
+	// Ni siquiera la contraseña correcta entra mientras dure el bloqueo
+	tokens, err := uc.Login("test@example.com", "password", model.ClientInfo{})
+
+	var lockedErr *usecase.AccountLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.Greater(t, lockedErr.RetryAfter, 9*time.Minute)
+	assert.Nil(t, tokens)
+	mockRepo.AssertNotCalled(t, "IncrementFailure", mock.Anything)
+}
+
+func TestAuthUseCase_LoginResetsFailuresOnSuccess(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	hasher := utils.NewTestPasswordHasher()
+	pwd, _ := hasher.Hash("password")
+	expiredLock := time.Now().Add(-time.Minute)
+
+	mockUser := &model.User{Email: "test@example.com", Password: pwd, Failure: 4, LockedUntil: &expiredLock}
+	mockUser.ID = 7
+
+	mockRepo.On("GetByEmail", "test@example.com").Return(mockUser, nil)
+	mockRepo.On("ResetFailures", uint(7)).Return(nil)
 
-	uc := usecase.NewUserUseCase(mockRepo)
+	uc := newLoginUseCase(mockRepo, hasher)
 
-	token, err := uc.Login("test@example.com", "wrongpassword")
+	tokens, err := uc.Login("test@example.com", "password", model.ClientInfo{})
 
-	assert.NotNil(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
 	mockRepo.AssertExpectations(t)
 }
@@ -0,0 +1,71 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newAuthorizationUseCase() *usecase.AuthorizationUseCase {
+	parentID := uint(2)
+	users := model.Form{Model: gorm.Model{ID: 1}, Title: "Users", Link: "/users", PathAPI: "user|users", Condition: "id == user.id"}
+	levels := model.Form{Model: gorm.Model{ID: 2}, Title: "Levels", Link: "/levels", PathAPI: "level|levels"}
+
+	levelRepo := new(mocks.MockLevelRepository)
+	levelRepo.On("GetByID", uint(1)).Return(&model.Level{Model: gorm.Model{ID: 1}, ParentID: &parentID, LevelPrivileges: []model.LevelPrivileges{
+		{FormID: 1, Form: users, Read: true, Update: true},
+	}}, nil)
+	levelRepo.On("GetByID", uint(2)).Return(&model.Level{Model: gorm.Model{ID: 2}, LevelPrivileges: []model.LevelPrivileges{
+		{FormID: 1, Form: users, Export: true},
+		{FormID: 2, Form: levels, Read: true},
+	}}, nil)
+	levelRepo.On("GetPermissionVersion", uint(1)).Return(uint(3), nil)
+	return usecase.NewAuthorizationUseCase(nil, service.NewPermissionResolver(levelRepo))
+}
+
+func TestAuthorizationUseCase_EffectivePermissions(t *testing.T) {
+	uc := newAuthorizationUseCase()
+
+	permissions, err := uc.EffectivePermissions(&model.Claim{LevelID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EffectivePermission{
+		{FormID: 1, Title: "Users", Link: "/users", PathAPI: "user|users", Condition: "id == user.id", Actions: model.PermissionActions{Read: true, Update: true, Export: true}},
+		{FormID: 2, Title: "Levels", Link: "/levels", PathAPI: "level|levels", Actions: model.PermissionActions{Read: true}},
+	}, permissions)
+
+	// Sin nivel no hay privilegios
+	permissions, err = uc.EffectivePermissions(&model.Claim{})
+	assert.NoError(t, err)
+	assert.Empty(t, permissions)
+}
+
+func TestAuthorizationUseCase_Check(t *testing.T) {
+	uc := newAuthorizationUseCase()
+
+	results, stale, err := uc.Check(&model.Claim{LevelID: 1, PermissionVersion: 1}, []model.AuthorizationCheck{
+		{Resource: "user", Action: "update"},
+		{Resource: "level", Action: "read"},
+		{Resource: "level", Action: "delete"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, stale)
+	assert.Equal(t, []model.AuthorizationCheckResult{
+		{AuthorizationCheck: model.AuthorizationCheck{Resource: "user", Action: "update"}, Allowed: true},
+		{AuthorizationCheck: model.AuthorizationCheck{Resource: "level", Action: "read"}, Allowed: true},
+		{AuthorizationCheck: model.AuthorizationCheck{Resource: "level", Action: "delete"}, Reason: service.DenialPrivilege},
+	}, results)
+
+	// Las acciones se indican por su nombre
+	_, _, err = uc.Check(&model.Claim{LevelID: 1}, []model.AuthorizationCheck{{Resource: "user", Action: "r"}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidAuthorizationCheck)
+	_, _, err = uc.Check(&model.Claim{LevelID: 1}, []model.AuthorizationCheck{{Action: "read"}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidAuthorizationCheck)
+
+	_, _, err = uc.Check(&model.Claim{LevelID: 1}, make([]model.AuthorizationCheck, usecase.MaxAuthorizationChecks+1))
+	assert.ErrorIs(t, err, usecase.ErrTooManyAuthorizationChecks)
+}
@@ -5,19 +5,22 @@ import (
 	"testing"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestFormUseCase_CreateOrUpdateForm(t *testing.T) {
 	mockRepo := new(mocks.MockFormRepository)
 	mockForm := &model.Form{Title: "Test Form"}
 
-	mockRepo.On("CreateOrUpdate", mockForm).Return(nil)
+	mockRepo.On("CreateOrUpdate", mockForm, mock.Anything).Return(nil)
 
-	uc := usecase.NewFormUseCase(mockRepo)
+	uc := usecase.NewFormUseCase(mockRepo, new(mocks.MockLevelRepository))
 
-	err := uc.CreateOrUpdateForm(mockForm)
+	err := uc.CreateOrUpdateForm(mockForm, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
@@ -30,11 +33,11 @@ func TestFormUseCase_GetAllForms(t *testing.T) {
 		{Title: "Form2"},
 	}
 
-	mockRepo.On("GetAll").Return(mockForms, nil)
+	mockRepo.On("GetAll", mock.Anything).Return(mockForms, nil)
 
-	uc := usecase.NewFormUseCase(mockRepo)
+	uc := usecase.NewFormUseCase(mockRepo, new(mocks.MockLevelRepository))
 
-	forms, err := uc.GetAllForms()
+	forms, err := uc.GetAllForms(nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockForms, forms)
@@ -46,11 +49,86 @@ func TestFormUseCase_DeleteForm(t *testing.T) {
 	mockForm := &model.Form{Title: "Test Form"}
 
 	mockRepo.On("Delete", mockForm).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersionByForm", mockForm.ID).Return(nil)
 
-	uc := usecase.NewFormUseCase(mockRepo)
+	uc := usecase.NewFormUseCase(mockRepo, mockLevelRepo)
 
-	err := uc.DeleteForm(mockForm)
+	err := uc.DeleteForm(mockForm, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
+	mockLevelRepo.AssertExpectations(t)
+}
+
+func TestFormUseCase_UpdateFormBumpsPermissionVersion(t *testing.T) {
+	mockRepo := new(mocks.MockFormRepository)
+	mockForm := &model.Form{Model: gorm.Model{ID: 3}, Title: "Test Form", PathAPI: "user|users"}
+
+	mockRepo.On("CreateOrUpdate", mockForm, mock.Anything).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersionByForm", uint(3)).Return(nil)
+
+	uc := usecase.NewFormUseCase(mockRepo, mockLevelRepo)
+
+	err := uc.CreateOrUpdateForm(mockForm, nil)
+
+	assert.Nil(t, err)
+	mockRepo.AssertExpectations(t)
+	mockLevelRepo.AssertExpectations(t)
+}
+
+func TestFormUseCase_CreateOrUpdateFormValidatesCondition(t *testing.T) {
+	mockRepo := new(mocks.MockFormRepository)
+	uc := usecase.NewFormUseCase(mockRepo, new(mocks.MockLevelRepository))
+
+	for _, condition := range []string{"owner_id = user.id", "owner_id == user.password", "1 == 1", "owner_id == user.id || "} {
+		err := uc.CreateOrUpdateForm(&model.Form{Title: "Test Form", Condition: condition}, nil)
+		assert.ErrorIs(t, err, usecase.ErrInvalidFormCondition, condition)
+	}
+
+	// Las columnas tienen que existir en la tabla de los recursos del formulario
+	unknown := &model.Form{Title: "Test Form", PathAPI: "user|users", Condition: "owner_id == user.id && status != 'deleted'"}
+	mockRepo.On("UnknownColumns", "user|users", []string{"owner_id", "status"}).Return([]string{"owner_id"}, nil)
+	err := uc.CreateOrUpdateForm(unknown, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvalidFormCondition)
+	assert.ErrorContains(t, err, "owner_id")
+	mockRepo.AssertNotCalled(t, "CreateOrUpdate", mock.Anything, mock.Anything)
+
+	valid := &model.Form{Title: "Test Form", PathAPI: "ticket|tickets", Condition: "owner_id == user.id && status != 'deleted'"}
+	mockRepo.On("UnknownColumns", "ticket|tickets", []string{"owner_id", "status"}).Return([]string(nil), nil)
+	mockRepo.On("CreateOrUpdate", valid, mock.Anything).Return(nil)
+	assert.Nil(t, uc.CreateOrUpdateForm(valid, nil))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFormUseCase_RowAccess(t *testing.T) {
+	policy, _ := security.ParseRowPolicy("id == user.id")
+	filter := security.NewRowFilter(security.RowSubject{security.RowSubjectID: uint(1)}, policy)
+	allowed := &model.Form{Model: gorm.Model{ID: 1}, Title: "Allowed"}
+	denied := &model.Form{Model: gorm.Model{ID: 2}, Title: "Denied"}
+
+	mockRepo := new(mocks.MockFormRepository)
+	mockRepo.On("MatchesFilter", uint(1), filter).Return(true, nil)
+	mockRepo.On("MatchesFilter", uint(2), filter).Return(false, nil)
+	mockRepo.On("Delete", allowed).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersionByForm", uint(1)).Return(nil)
+	uc := usecase.NewFormUseCase(mockRepo, mockLevelRepo)
+
+	assert.Equal(t, usecase.ErrRowAccessDenied, uc.CreateOrUpdateForm(denied, filter))
+	assert.Equal(t, usecase.ErrRowAccessDenied, uc.DeleteForm(denied, filter))
+	assert.Nil(t, uc.DeleteForm(allowed, filter))
+
+	// Los formularios nuevos no existen todavía: el repositorio comprueba las
+	// condiciones al guardarlos
+	created := &model.Form{Title: "New"}
+	mockRepo.On("CreateOrUpdate", created, filter).Return(nil)
+	assert.Nil(t, uc.CreateOrUpdateForm(created, filter))
+	rejected := &model.Form{Title: "Rejected"}
+	mockRepo.On("CreateOrUpdate", rejected, filter).Return(security.ErrRowAccessDenied)
+	assert.Equal(t, usecase.ErrRowAccessDenied, uc.CreateOrUpdateForm(rejected, filter))
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Delete", denied)
 }
@@ -0,0 +1,111 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupImpersonationUseCase crea un usuario de soporte que puede suplantar y
+// un usuario normal
+func setupImpersonationUseCase(t *testing.T) (*usecase.ImpersonationUseCase, *gorm.DB, *model.User, *model.User) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	supportLevel := &model.Level{Level: "Support", Description: "Support"}
+	guestLevel := &model.Level{Level: "Guest", Description: "Guest"}
+	assert.NoError(t, database.Create(supportLevel).Error)
+	assert.NoError(t, database.Create(guestLevel).Error)
+	form := &model.Form{Title: "Impersonate", PathAPI: "impersonate|impersonations"}
+	assert.NoError(t, database.Create(form).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: supportLevel.ID, FormID: form.ID, Read: true, Write: true, Create: true, Update: true, Delete: true}).Error)
+
+	support := &model.User{Username: "support", Email: "support@example.com", FullName: "Support", Password: "hash", LevelID: supportLevel.ID}
+	user := &model.User{Username: "user", Email: "user@example.com", FullName: "User", Password: "hash", LevelID: guestLevel.ID}
+	assert.NoError(t, database.Create(support).Error)
+	assert.NoError(t, database.Create(user).Error)
+
+	return usecase.NewImpersonationUseCase(db.NewUserRepository(database), service.NewPermissionResolver(db.NewLevelRepository(database)), 10*time.Minute), database, support, user
+}
+
+func TestImpersonationUseCase_Start(t *testing.T) {
+	uc, _, support, user := setupImpersonationUseCase(t)
+
+	token, err := uc.Start(&model.Claim{UserID: support.ID, Email: support.Email}, user.ID, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(600), token.ExpiresIn)
+	assert.Equal(t, user.ID, token.User.ID)
+	assert.Empty(t, token.User.Password)
+
+	claims, err := helpers.ParseJWT(token.AccessToken)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, user.LevelID, claims.LevelID)
+	assert.Zero(t, claims.SessionID)
+	if assert.NotNil(t, claims.Actor) {
+		assert.Equal(t, support.ID, claims.Actor.UserID)
+		assert.Equal(t, support.Email, claims.Actor.Email)
+	}
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), claims.ExpiresAt.Time, time.Minute)
+}
+
+func TestImpersonationUseCase_Restrictions(t *testing.T) {
+	uc, _, support, user := setupImpersonationUseCase(t)
+	actor := &model.Claim{UserID: support.ID, Email: support.Email}
+
+	_, err := uc.Start(actor, support.ID, nil)
+	assert.Equal(t, usecase.ErrCannotImpersonateSelf, err)
+
+	_, err = uc.Start(&model.Claim{UserID: user.ID}, support.ID, nil)
+	assert.Equal(t, usecase.ErrCannotImpersonateStaff, err)
+
+	_, err = uc.Start(actor, 9999, nil)
+	assert.Equal(t, usecase.ErrImpersonationTarget, err)
+
+	// Las condiciones de acceso a filas se aplican al usuario suplantado
+	policy, _ := security.ParseRowPolicy("level_id == user.level_id")
+	_, err = uc.Start(actor, user.ID, security.NewRowFilter(security.RowSubject{security.RowSubjectLevelID: support.LevelID}, policy))
+	assert.Equal(t, usecase.ErrRowAccessDenied, err)
+
+	// Ni desde otra suplantación ni con una API key
+	_, err = uc.Start(&model.Claim{UserID: support.ID, Actor: &model.Actor{UserID: 99}}, user.ID, nil)
+	assert.Equal(t, usecase.ErrImpersonationNotAllowed, err)
+	_, err = uc.Start(&model.Claim{UserID: support.ID, APIKeyID: 1}, user.ID, nil)
+	assert.Equal(t, usecase.ErrImpersonationNotAllowed, err)
+}
+
+func TestImpersonationUseCase_RejectsTargetsWithMorePrivileges(t *testing.T) {
+	uc, database, support, user := setupImpersonationUseCase(t)
+	actor := &model.Claim{UserID: support.ID, Email: support.Email}
+
+	// Un nivel adicional del usuario le permite eliminar usuarios
+	users := &model.Form{Title: "Users", PathAPI: "user|users"}
+	admin := &model.Level{Level: "Admin", Description: "Admin"}
+	assert.NoError(t, database.Create(users).Error)
+	assert.NoError(t, database.Create(admin).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: admin.ID, FormID: users.ID, Read: true, Delete: true}).Error)
+	assert.NoError(t, db.NewUserRepository(database).SetLevels(user.ID, []uint{admin.ID}))
+
+	_, err := uc.Start(actor, user.ID, nil)
+	assert.Equal(t, usecase.ErrCannotImpersonateHigher, err)
+
+	// Con las mismas acciones sobre el formulario sí puede
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: support.LevelID, FormID: users.ID, Read: true, Update: true, Delete: true}).Error)
+	_, err = uc.Start(actor, user.ID, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,217 @@
+package usecase_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupInvitationUseCase(t *testing.T) (*usecase.InvitationUseCase, *mocks.MockMailer, *gorm.DB, *model.Level) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	level := &model.Level{Level: "Usuario", Description: "Usuario"}
+	assert.NoError(t, database.Create(level).Error)
+	// Quien invita, con ID 1, tiene el nivel de los invitados
+	assert.NoError(t, database.Create(&model.User{Username: "admin", Email: "admin@example.com", Password: "x", LevelID: level.ID}).Error)
+
+	hasher := utils.NewTestPasswordHasher()
+	passwordPolicy := utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher)
+	mailer := new(mocks.MockMailer)
+	mailer.On("SendTemplateTo", mock.Anything, "Invitation", "templates/user_invitation.html", mock.Anything).Return(nil)
+
+	uc := usecase.NewInvitationUseCase(db.NewInvitationRepository(database), db.NewUserRepository(database), db.NewLevelRepository(database), passwordPolicy, mailer, "https://intranet.test/accept-invitation", time.Hour, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	return uc, mailer, database, level
+}
+
+// invitationTokenFromMail extrae el token del último enlace enviado
+func invitationTokenFromMail(t *testing.T, mailer *mocks.MockMailer) string {
+	data := mailer.Calls[len(mailer.Calls)-1].Arguments.Get(3).(map[string]interface{})
+	link, err := url.Parse(data["Link"].(string))
+	assert.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func acceptance(token, password string) *model.InvitationAcceptance {
+	return &model.InvitationAcceptance{Token: token, Password: model.Password{Password: password, ConfirmPassword: password}}
+}
+
+func TestInvitationUseCase_InviteAndAccept(t *testing.T) {
+	uc, mailer, database, level := setupInvitationUseCase(t)
+
+	invitation, err := uc.Invite(1, &model.InvitationRequest{Email: " New@Example.com ", FullName: "New User", LevelID: level.ID}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "new@example.com", invitation.Email)
+	assert.Equal(t, "new@example.com", invitation.Username)
+	assert.Equal(t, uint(1), invitation.InvitedBy)
+	mailer.AssertCalled(t, "SendTemplateTo", "new@example.com", "Invitation", "templates/user_invitation.html", mock.Anything)
+	token := invitationTokenFromMail(t, mailer)
+
+	pending, err := uc.List(nil)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// La contraseña tiene que cumplir la política
+	_, err = uc.Accept(acceptance(token, "123"))
+	assert.IsType(t, &usecase.PasswordPolicyError{}, err)
+
+	user, err := uc.Accept(acceptance(token, "new-password"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, level.ID, user.LevelID)
+	assert.Equal(t, "New User", user.FullName)
+
+	var stored model.User
+	assert.NoError(t, database.First(&stored, user.ID).Error)
+	ok, err := utils.NewTestPasswordHasher().Verify(stored.Password, "new-password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// El enlace solo vale una vez y la invitación deja de estar pendiente
+	_, err = uc.Accept(acceptance(token, "new-password"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+	pending, err = uc.List(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "Again", LevelID: level.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationEmailInUse)
+}
+
+func TestInvitationUseCase_Validation(t *testing.T) {
+	uc, _, _, level := setupInvitationUseCase(t)
+
+	_, err := uc.Invite(1, &model.InvitationRequest{FullName: "New User", LevelID: level.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationEmailRequired)
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", LevelID: level.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationFullNameRequired)
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: 999}, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationLevelNotFound)
+
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: level.ID}, nil)
+	assert.NoError(t, err)
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: level.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationAlreadyPending)
+}
+
+func TestInvitationUseCase_ResendAndRevoke(t *testing.T) {
+	uc, mailer, database, level := setupInvitationUseCase(t)
+
+	invitation, err := uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: level.ID}, nil)
+	assert.NoError(t, err)
+	firstToken := invitationTokenFromMail(t, mailer)
+
+	_, err = uc.Resend(invitation.ID, nil)
+	assert.NoError(t, err)
+	secondToken := invitationTokenFromMail(t, mailer)
+	assert.NotEqual(t, firstToken, secondToken)
+
+	// Al reenviar el enlace anterior deja de valer
+	_, err = uc.Accept(acceptance(firstToken, "new-password"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+
+	assert.NoError(t, uc.Revoke(invitation.ID, nil))
+	assert.ErrorIs(t, uc.Revoke(invitation.ID, nil), usecase.ErrInvitationNotFound)
+	_, err = uc.Resend(invitation.ID, nil)
+	assert.ErrorIs(t, err, usecase.ErrInvitationNotFound)
+	_, err = uc.Accept(acceptance(secondToken, "new-password"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+
+	var count int64
+	database.Model(&model.User{}).Where("email = ?", "new@example.com").Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestInvitationUseCase_ExpiredLink(t *testing.T) {
+	uc, mailer, database, level := setupInvitationUseCase(t)
+
+	invitation, err := uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: level.ID}, nil)
+	assert.NoError(t, err)
+	token := invitationTokenFromMail(t, mailer)
+
+	database.Model(&model.Invitation{}).Where("id = ?", invitation.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	_, err = uc.Accept(acceptance(token, "new-password"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+
+	_, err = uc.Accept(acceptance("not-a-token", "new-password"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+}
+
+func TestInvitationUseCase_RequiresLevelPrivileges(t *testing.T) {
+	uc, mailer, database, level := setupInvitationUseCase(t)
+
+	// Quien invita no tiene los privilegios sobre usuarios del nivel de soporte
+	form := &model.Form{Title: "Usuarios", PathAPI: "user|users"}
+	assert.NoError(t, database.Create(form).Error)
+	support := &model.Level{Level: "Soporte", Description: "Soporte"}
+	assert.NoError(t, database.Create(support).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: support.ID, FormID: form.ID, Read: true, Delete: true}).Error)
+
+	_, err := uc.Invite(1, &model.InvitationRequest{Email: "new@example.com", FullName: "New User", LevelID: support.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrLevelNotGrantable)
+
+	// En bloque se rechazan todas si alguna tiene un nivel que no puede asignar
+	_, err = uc.InviteAll(1, []*model.Invitation{
+		{Email: "ana@example.com", Username: "ana", FullName: "Ana", LevelID: level.ID},
+		{Email: "luis@example.com", Username: "luis", FullName: "Luis", LevelID: support.ID},
+	}, nil)
+	assert.ErrorIs(t, err, usecase.ErrLevelNotGrantable)
+
+	var count int64
+	database.Model(&model.Invitation{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	mailer.AssertNotCalled(t, "SendTemplateTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInvitationUseCase_RowAccess(t *testing.T) {
+	uc, _, database, level := setupInvitationUseCase(t)
+
+	other := &model.Level{Level: "Otro", Description: "Otro"}
+	assert.NoError(t, database.Create(other).Error)
+	foreign, err := uc.Invite(1, &model.InvitationRequest{Email: "luis@example.com", FullName: "Luis", LevelID: other.ID}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// Quien invita solo gestiona los usuarios de su nivel
+	policy, _ := security.ParseRowPolicy("level_id == user.level_id")
+	filter := security.NewRowFilter(security.RowSubject{security.RowSubjectLevelID: level.ID}, policy)
+
+	_, err = uc.Invite(1, &model.InvitationRequest{Email: "otro@example.com", FullName: "Otro", LevelID: other.ID}, filter)
+	assert.ErrorIs(t, err, usecase.ErrRowAccessDenied)
+	_, err = uc.InviteAll(1, []*model.Invitation{{Email: "eva@example.com", Username: "eva", FullName: "Eva", LevelID: other.ID}}, filter)
+	assert.ErrorIs(t, err, usecase.ErrRowAccessDenied)
+
+	own, err := uc.Invite(1, &model.InvitationRequest{Email: "ana@example.com", FullName: "Ana", LevelID: level.ID}, filter)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	pending, err := uc.List(filter)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, own.ID, pending[0].ID)
+	}
+
+	_, err = uc.Resend(foreign.ID, filter)
+	assert.ErrorIs(t, err, usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, uc.Revoke(foreign.ID, filter), usecase.ErrRowAccessDenied)
+	_, err = uc.Resend(own.ID, filter)
+	assert.NoError(t, err)
+	assert.NoError(t, uc.Revoke(own.ID, filter))
+}
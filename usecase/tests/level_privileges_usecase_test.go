@@ -7,20 +7,65 @@ import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestLevelPrivilegesUseCase_CreateOrUpdateLevelPrivilege(t *testing.T) {
 	mockRepo := new(mocks.MockLevelPrivilegesRepository)
 	mockLevelPrivilege := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
 
-	mockRepo.On("CreateOrUpdate", mockLevelPrivilege).Return(nil)
+	mockRepo.On("CreateOrUpdate", mockLevelPrivilege, mock.Anything).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", []uint{mockLevelPrivilege.LevelID}).Return(nil)
 
-	uc := usecase.NewLevelPrivilegesUseCase(mockRepo)
+	uc := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
 
-	err := uc.CreateOrUpdateLevelPrivilege(mockLevelPrivilege)
+	err := uc.CreateOrUpdateLevelPrivilege(mockLevelPrivilege, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
+	mockLevelRepo.AssertExpectations(t)
+}
+
+func TestLevelPrivilegesUseCase_CreateOrUpdateNormalizesWrite(t *testing.T) {
+	mockRepo := new(mocks.MockLevelPrivilegesRepository)
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", mock.Anything).Return(nil)
+	uc := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
+
+	// Write de los clientes anteriores concede crear, modificar y eliminar
+	legacy := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
+	assert.Nil(t, uc.CreateOrUpdateLevelPrivilege(legacy, nil))
+	assert.True(t, legacy.Create && legacy.Update && legacy.Delete)
+	assert.False(t, legacy.Export || legacy.Approve)
+	assert.Equal(t, "rcud", legacy.Actions())
+
+	// Con acciones separadas Write solo indica si tiene las tres
+	granular := &model.LevelPrivileges{FormID: 1, Read: true, Write: true, Update: true}
+	assert.Nil(t, uc.CreateOrUpdateLevelPrivilege(granular, nil))
+	assert.False(t, granular.Write)
+	assert.False(t, granular.Delete)
+	assert.Equal(t, "ru", granular.Actions())
+}
+
+func TestLevelPrivilegesUseCase_MoveLevelPrivilegeBumpsBothLevels(t *testing.T) {
+	mockRepo := new(mocks.MockLevelPrivilegesRepository)
+	mockLevelPrivilege := &model.LevelPrivileges{Model: gorm.Model{ID: 5}, LevelID: 2, FormID: 1, Read: true}
+
+	mockRepo.On("GetByID", uint(5)).Return(&model.LevelPrivileges{Model: gorm.Model{ID: 5}, LevelID: 1, FormID: 1}, nil)
+	mockRepo.On("CreateOrUpdate", mockLevelPrivilege, mock.Anything).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", []uint{1, 2}).Return(nil)
+
+	uc := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
+
+	err := uc.CreateOrUpdateLevelPrivilege(mockLevelPrivilege, nil)
+
+	assert.Nil(t, err)
+	mockRepo.AssertExpectations(t)
+	mockLevelRepo.AssertExpectations(t)
 }
 
 func TestLevelPrivilegesUseCase_GetAllLevelPrivileges(t *testing.T) {
@@ -30,11 +75,11 @@ func TestLevelPrivilegesUseCase_GetAllLevelPrivileges(t *testing.T) {
 		{FormID: 2, Read: true, Write: false},
 	}
 
-	mockRepo.On("GetAll").Return(mockLevelPrivileges, nil)
+	mockRepo.On("GetAll", mock.Anything).Return(mockLevelPrivileges, nil)
 
-	uc := usecase.NewLevelPrivilegesUseCase(mockRepo)
+	uc := usecase.NewLevelPrivilegesUseCase(mockRepo, new(mocks.MockLevelRepository))
 
-	levelPrivileges, err := uc.GetAllLevelPrivilege()
+	levelPrivileges, err := uc.GetAllLevelPrivilege(nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockLevelPrivileges, levelPrivileges)
@@ -43,14 +88,18 @@ func TestLevelPrivilegesUseCase_GetAllLevelPrivileges(t *testing.T) {
 
 func TestLevelPrivilegesUseCase_DeleteLevelPrivilege(t *testing.T) {
 	mockRepo := new(mocks.MockLevelPrivilegesRepository)
-	mockLevelPrivilege := &model.LevelPrivileges{FormID: 1, Read: true, Write: true}
+	mockLevelPrivilege := &model.LevelPrivileges{Model: gorm.Model{ID: 5}}
 
+	mockRepo.On("GetByID", uint(5)).Return(&model.LevelPrivileges{Model: gorm.Model{ID: 5}, LevelID: 1, FormID: 1}, nil)
 	mockRepo.On("Delete", mockLevelPrivilege).Return(nil)
+	mockLevelRepo := new(mocks.MockLevelRepository)
+	mockLevelRepo.On("BumpPermissionVersion", []uint{1}).Return(nil)
 
-	uc := usecase.NewLevelPrivilegesUseCase(mockRepo)
+	uc := usecase.NewLevelPrivilegesUseCase(mockRepo, mockLevelRepo)
 
-	err := uc.DeleteLevelPrivilege(mockLevelPrivilege)
+	err := uc.DeleteLevelPrivilege(mockLevelPrivilege, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
+	mockLevelRepo.AssertExpectations(t)
 }
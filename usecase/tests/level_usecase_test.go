@@ -7,22 +7,60 @@ import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestLevelUseCase_CreateOrUpdateLevel(t *testing.T) {
 	mockRepo := new(mocks.MockLevelRepository)
 	mockLevel := &model.Level{Level: "Test Level"}
 
-	mockRepo.On("CreateOrUpdate", mockLevel).Return(nil)
+	mockRepo.On("CreateOrUpdate", mockLevel, mock.Anything).Return(nil)
 
 	uc := usecase.NewLevelUseCase(mockRepo)
 
-	err := uc.CreateOrUpdateLevel(mockLevel)
+	err := uc.CreateOrUpdateLevel(mockLevel, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestLevelUseCase_CreateOrUpdateLevelRejectsCycles(t *testing.T) {
+	mockRepo := new(mocks.MockLevelRepository)
+	root, child := uint(1), uint(2)
+	// 1 <- 2 <- 3: el nivel 1 no puede heredar de 3
+	mockRepo.On("GetByID", uint(3)).Return(&model.Level{Model: gorm.Model{ID: 3}, ParentID: &child}, nil)
+	mockRepo.On("GetByID", uint(2)).Return(&model.Level{Model: gorm.Model{ID: 2}, ParentID: &root}, nil)
+	mockRepo.On("GetByID", uint(9)).Return(&model.Level{}, gorm.ErrRecordNotFound)
+	uc := usecase.NewLevelUseCase(mockRepo)
+
+	grandChild := uint(3)
+	assert.Equal(t, usecase.ErrLevelCycle, uc.CreateOrUpdateLevel(&model.Level{Model: gorm.Model{ID: 1}, ParentID: &grandChild}, nil))
+	self := uint(4)
+	assert.Equal(t, usecase.ErrLevelCycle, uc.CreateOrUpdateLevel(&model.Level{Model: gorm.Model{ID: 4}, ParentID: &self}, nil))
+	missing := uint(9)
+	assert.Equal(t, usecase.ErrLevelParentNotFound, uc.CreateOrUpdateLevel(&model.Level{ParentID: &missing}, nil))
+	mockRepo.AssertNotCalled(t, "CreateOrUpdate", mock.Anything)
+}
+
+func TestLevelUseCase_CreateOrUpdateLevelBumpsVersionWhenParentChanges(t *testing.T) {
+	mockRepo := new(mocks.MockLevelRepository)
+	parent := uint(3)
+	mockRepo.On("GetByID", uint(3)).Return(&model.Level{Model: gorm.Model{ID: 3}}, nil)
+	mockRepo.On("GetByID", uint(2)).Return(&model.Level{Model: gorm.Model{ID: 2}}, nil).Once()
+	mockRepo.On("CreateOrUpdate", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("BumpPermissionVersion", []uint{2}).Return(nil).Once()
+	uc := usecase.NewLevelUseCase(mockRepo)
+
+	assert.Nil(t, uc.CreateOrUpdateLevel(&model.Level{Model: gorm.Model{ID: 2}, ParentID: &parent}, nil))
+
+	// Sin cambiar de padre no cambia la versión
+	mockRepo.On("GetByID", uint(2)).Return(&model.Level{Model: gorm.Model{ID: 2}, ParentID: &parent}, nil)
+	assert.Nil(t, uc.CreateOrUpdateLevel(&model.Level{Model: gorm.Model{ID: 2}, ParentID: &parent}, nil))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "BumpPermissionVersion", 1)
+}
+
 func TestLevelUseCase_GetLevelByID(t *testing.T) {
 	mockRepo := new(mocks.MockLevelRepository)
 	mockLevel := &model.Level{Level: "Test Level"}
@@ -45,11 +83,11 @@ func TestLevelUseCase_GetAllLevels(t *testing.T) {
 		{Level: "Level2"},
 	}
 
-	mockRepo.On("GetAll").Return(mockLevels, nil)
+	mockRepo.On("GetAll", mock.Anything).Return(mockLevels, nil)
 
 	uc := usecase.NewLevelUseCase(mockRepo)
 
-	levels, err := uc.GetAllLevels()
+	levels, err := uc.GetAllLevels(nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockLevels, levels)
@@ -63,11 +101,11 @@ func TestLevelUseCase_PaginateLevels(t *testing.T) {
 		{Level: "Level2"},
 	}
 
-	mockRepo.On("Paginate", 1, 10).Return(mockLevels, 2, nil)
+	mockRepo.On("Paginate", 1, 10, mock.Anything).Return(mockLevels, 2, nil)
 
 	uc := usecase.NewLevelUseCase(mockRepo)
 
-	levels, total, err := uc.PaginateLevels(1, 10)
+	levels, total, err := uc.PaginateLevels(1, 10, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockLevels, levels)
@@ -83,7 +121,7 @@ func TestLevelUseCase_DeleteLevel(t *testing.T) {
 
 	uc := usecase.NewLevelUseCase(mockRepo)
 
-	err := uc.DeleteLevel(mockLevel)
+	err := uc.DeleteLevel(mockLevel, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
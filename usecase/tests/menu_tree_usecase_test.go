@@ -7,17 +7,18 @@ import (
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestMenuTreeUseCase_CreateOrUpdateMenuTree(t *testing.T) {
 	mockRepo := new(mocks.MockMenuTreeRepository)
 	mockMenu := &model.MenuTree{Title: "Test Menu"}
 
-	mockRepo.On("CreateOrUpdate", mockMenu).Return(nil)
+	mockRepo.On("CreateOrUpdate", mockMenu, mock.Anything).Return(nil)
 
 	uc := usecase.NewMenuTreeUseCase(mockRepo)
 
-	err := uc.CreateOrUpdateMenuTree(mockMenu)
+	err := uc.CreateOrUpdateMenuTree(mockMenu, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
@@ -30,11 +31,11 @@ func TestMenuTreeUseCase_GetAllMenuTrees(t *testing.T) {
 		{Title: "Menu2"},
 	}
 
-	mockRepo.On("GetAll").Return(mockMenus, nil)
+	mockRepo.On("GetAll", mock.Anything).Return(mockMenus, nil)
 
 	uc := usecase.NewMenuTreeUseCase(mockRepo)
 
-	menus, err := uc.GetAllMenuTrees()
+	menus, err := uc.GetAllMenuTrees(nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, mockMenus, menus)
@@ -49,7 +50,7 @@ func TestMenuTreeUseCase_DeleteMenuTree(t *testing.T) {
 
 	uc := usecase.NewMenuTreeUseCase(mockRepo)
 
-	err := uc.DeleteMenuTree(mockMenu)
+	err := uc.DeleteMenuTree(mockMenu, nil)
 
 	assert.Nil(t, err)
 	mockRepo.AssertExpectations(t)
@@ -0,0 +1,240 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const mfaTestPassword = "password"
+
+func setupMFAUseCase(t *testing.T) (*usecase.MFAUseCase, *usecase.UserUseCase, *gorm.DB, *model.User) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	hasher := utils.NewTestPasswordHasher()
+	password, err := hasher.Hash(mfaTestPassword)
+	assert.NoError(t, err)
+
+	level := &model.Level{Level: "Administrador", Description: "Administrador"}
+	assert.NoError(t, database.Create(level).Error)
+	user := &model.User{Username: "testuser", Email: "test@example.com", Password: password, LevelID: level.ID}
+	assert.NoError(t, database.Create(user).Error)
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(database), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	userUseCase := usecase.NewUserUseCase(userRepo, hasher, utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher), tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), service.NewPermissionResolver(db.NewLevelRepository(database)))
+
+	return mfaUseCase, userUseCase, database, user
+}
+
+// enableMFA activa el 2FA del usuario y devuelve su secreto y los códigos de recuperación
+func enableMFA(t *testing.T, uc *usecase.MFAUseCase, userID uint) (string, []string) {
+	enrollment, err := uc.Enroll(userID)
+	assert.NoError(t, err)
+
+	code, err := helpers.TOTPCode(enrollment.Secret, time.Now().Add(-30*time.Second))
+	assert.NoError(t, err)
+
+	recoveryCodes, err := uc.Activate(userID, code)
+	assert.NoError(t, err)
+	return enrollment.Secret, recoveryCodes
+}
+
+// loginChallenge hace login con la contraseña y devuelve el reto del segundo factor
+func loginChallenge(t *testing.T, uc *usecase.UserUseCase) model.MFAChallenge {
+	tokens, err := uc.Login("test@example.com", mfaTestPassword, model.ClientInfo{})
+	assert.Nil(t, tokens)
+
+	var mfaErr *usecase.MFARequiredError
+	if !assert.ErrorAs(t, err, &mfaErr) {
+		t.FailNow()
+	}
+	return mfaErr.Challenge
+}
+
+func TestMFAUseCase_EnrollAndActivate(t *testing.T) {
+	uc, _, database, user := setupMFAUseCase(t)
+
+	enrollment, err := uc.Enroll(user.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/"))
+
+	// Sin confirmar el código el 2FA no está activo
+	var stored model.User
+	database.First(&stored, user.ID)
+	assert.False(t, stored.MFAEnabled)
+	assert.Equal(t, enrollment.Secret, stored.MFASecret)
+
+	_, err = uc.Activate(user.ID, "000000")
+	assert.Equal(t, usecase.ErrInvalidMFACode, err)
+
+	code, _ := helpers.TOTPCode(enrollment.Secret, time.Now())
+	recoveryCodes, err := uc.Activate(user.ID, code)
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+
+	database.First(&stored, user.ID)
+	assert.True(t, stored.MFAEnabled)
+
+	var count int64
+	database.Model(&model.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(10), count)
+
+	_, err = uc.Enroll(user.ID)
+	assert.Equal(t, usecase.ErrMFAAlreadyEnabled, err)
+}
+
+func TestMFAUseCase_TwoStepLogin(t *testing.T) {
+	uc, userUseCase, _, user := setupMFAUseCase(t)
+	secret, _ := enableMFA(t, uc, user.ID)
+
+	challenge := loginChallenge(t, userUseCase)
+	assert.True(t, challenge.MFARequired)
+	assert.False(t, challenge.EnrollmentRequired)
+
+	code, _ := helpers.TOTPCode(secret, time.Now())
+	response, err := uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: code}, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.AccessToken)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Empty(t, response.RecoveryCodes)
+
+	// El token de mfa pendiente solo vale una vez
+	_, err = uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: code}, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidMFAToken, err)
+
+	// El mismo código TOTP no puede reutilizarse con otro token
+	challenge = loginChallenge(t, userUseCase)
+	_, err = uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: code}, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidMFACode, err)
+}
+
+func TestMFAUseCase_RecoveryCodeIsSingleUse(t *testing.T) {
+	uc, userUseCase, _, user := setupMFAUseCase(t)
+	_, recoveryCodes := enableMFA(t, uc, user.ID)
+
+	challenge := loginChallenge(t, userUseCase)
+	response, err := uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: strings.ToUpper(recoveryCodes[0])}, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.AccessToken)
+
+	challenge = loginChallenge(t, userUseCase)
+	_, err = uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]}, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidMFACode, err)
+}
+
+func TestMFAUseCase_LevelRequiresEnrollment(t *testing.T) {
+	uc, userUseCase, database, user := setupMFAUseCase(t)
+	assert.NoError(t, database.Model(&model.Level{}).Where("id = ?", user.LevelID).Update("require_mfa", true).Error)
+
+	challenge := loginChallenge(t, userUseCase)
+	assert.True(t, challenge.EnrollmentRequired)
+
+	enrollment, err := uc.EnrollPending(challenge.MFAToken)
+	assert.NoError(t, err)
+
+	code, _ := helpers.TOTPCode(enrollment.Secret, time.Now())
+	response, err := uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: code}, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.AccessToken)
+	assert.Len(t, response.RecoveryCodes, 10)
+
+	// El nivel exige 2FA, así que el usuario no puede desactivarlo
+	assert.Equal(t, usecase.ErrMFARequiredByLevel, uc.Disable(user.ID, response.RecoveryCodes[0]))
+}
+
+func TestMFAUseCase_AdditionalLevelRequiresEnrollment(t *testing.T) {
+	uc, userUseCase, database, user := setupMFAUseCase(t)
+
+	// El nivel principal no exige 2FA, pero un nivel adicional hereda de uno
+	// que sí lo exige
+	admin := &model.Level{Level: "Admin", Description: "Admin", RequireMFA: true}
+	assert.NoError(t, database.Create(admin).Error)
+	support := &model.Level{Level: "Soporte", Description: "Soporte", ParentID: &admin.ID}
+	assert.NoError(t, database.Create(support).Error)
+	assert.NoError(t, db.NewUserRepository(database).SetLevels(user.ID, []uint{support.ID}))
+
+	challenge := loginChallenge(t, userUseCase)
+	assert.True(t, challenge.EnrollmentRequired)
+
+	enrollment, err := uc.EnrollPending(challenge.MFAToken)
+	assert.NoError(t, err)
+	code, _ := helpers.TOTPCode(enrollment.Secret, time.Now())
+	response, err := uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: code}, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrMFARequiredByLevel, uc.Disable(user.ID, response.RecoveryCodes[0]))
+}
+
+func TestMFAUseCase_FailedCodesLockAccount(t *testing.T) {
+	uc, userUseCase, _, user := setupMFAUseCase(t)
+	enableMFA(t, uc, user.ID)
+
+	var err error
+	for i := 0; i < 3; i++ {
+		challenge := loginChallenge(t, userUseCase)
+		_, err = uc.VerifyLogin(&model.MFAVerification{MFAToken: challenge.MFAToken, Code: "000000"}, model.ClientInfo{})
+	}
+
+	var lockedErr *usecase.AccountLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+
+	_, err = userUseCase.Login("test@example.com", mfaTestPassword, model.ClientInfo{})
+	assert.ErrorAs(t, err, &lockedErr)
+}
+
+func TestMFAUseCase_DisableAndReset(t *testing.T) {
+	uc, userUseCase, database, user := setupMFAUseCase(t)
+	_, recoveryCodes := enableMFA(t, uc, user.ID)
+
+	assert.Equal(t, usecase.ErrInvalidMFACode, uc.Disable(user.ID, "000000"))
+	assert.NoError(t, uc.Disable(user.ID, recoveryCodes[1]))
+
+	tokens, err := userUseCase.Login("test@example.com", mfaTestPassword, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	enableMFA(t, uc, user.ID)
+	assert.ErrorIs(t, uc.ResetForUser(user.ID, security.NewRowFilter(nil)), usecase.ErrRowAccessDenied)
+	assert.NoError(t, uc.ResetForUser(user.ID, nil))
+
+	var stored model.User
+	database.First(&stored, user.ID)
+	assert.False(t, stored.MFAEnabled)
+	assert.Empty(t, stored.MFASecret)
+
+	var count int64
+	database.Model(&model.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMFAUseCase_UpdateUserKeepsMFA(t *testing.T) {
+	uc, userUseCase, database, user := setupMFAUseCase(t)
+	secret, _ := enableMFA(t, uc, user.ID)
+
+	// Los datos del formulario de usuario no traen los campos del 2FA
+	update := &model.User{Username: "renamed", Email: user.Email, FullName: "Renamed", LevelID: user.LevelID}
+	update.ID = user.ID
+	assert.NoError(t, userUseCase.UpdateUser(update, nil))
+
+	var stored model.User
+	database.First(&stored, user.ID)
+	assert.Equal(t, "renamed", stored.Username)
+	assert.True(t, stored.MFAEnabled)
+	assert.Equal(t, secret, stored.MFASecret)
+}
@@ -0,0 +1,162 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupOIDCUseCase(t *testing.T, mapping usecase.OIDCLevelMapping) (*usecase.OIDCUseCase, *gorm.DB, *utils.FakeIdP) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	assert.NoError(t, database.Create(&model.Level{Level: "Administrador", Description: "Administrador"}).Error)
+	assert.NoError(t, database.Create(&model.Level{Level: "Invitado", Description: "Invitado"}).Error)
+
+	idp := utils.NewFakeIdP(t, "core-api")
+	provider := adapters.NewOIDCProvider(adapters.OIDCConfig{
+		Issuer:      idp.Issuer(),
+		ClientID:    idp.ClientID,
+		RedirectURL: "http://localhost/callback",
+	})
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(database), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	uc := usecase.NewOIDCUseCase(provider, db.NewOIDCStateRepository(database), userRepo, db.NewLevelRepository(database), utils.NewTestPasswordHasher(), tokenUseCase, mfaUseCase, mapping, 10*time.Minute)
+
+	return uc, database, idp
+}
+
+func oidcLogin(t *testing.T, uc *usecase.OIDCUseCase, idp *utils.FakeIdP) (*model.TokenPair, error) {
+	authURL, err := uc.Begin()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	code, state := idp.Authorize(t, authURL)
+	return uc.Complete(context.Background(), state, code, model.ClientInfo{})
+}
+
+var oidcMapping = usecase.OIDCLevelMapping{
+	Groups:       []usecase.GroupLevel{{Group: "intranet-admins", Level: "Administrador"}},
+	DefaultLevel: "Invitado",
+}
+
+func TestOIDCUseCase_ProvisionsAndSyncsUsers(t *testing.T) {
+	uc, database, idp := setupOIDCUseCase(t, oidcMapping)
+
+	idp.Claims = jwt.MapClaims{
+		"email":              "Jane@Example.com",
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "jane",
+		"groups":             []string{"staff", "intranet-admins"},
+	}
+	tokens, err := oidcLogin(t, uc, idp)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	claims, err := helpers.ParseJWT(tokens.AccessToken)
+	assert.NoError(t, err)
+
+	var user model.User
+	assert.NoError(t, database.Preload("Level").First(&user, claims.UserID).Error)
+	assert.Equal(t, "jane@example.com", user.Email)
+	assert.Equal(t, "jane", user.Username)
+	assert.Equal(t, "Jane Doe", user.FullName)
+	assert.Equal(t, "Administrador", user.Level.Level)
+	assert.NotEmpty(t, user.Password)
+
+	// En el siguiente login el proveedor manda: pierde el grupo y el nivel
+	idp.Claims["groups"] = []string{"staff"}
+	idp.Claims["name"] = "Jane Smith"
+	tokens, err = oidcLogin(t, uc, idp)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	claims, _ = helpers.ParseJWT(tokens.AccessToken)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, database.Preload("Level").First(&user, user.ID).Error)
+	assert.Equal(t, "Invitado", user.Level.Level)
+	assert.Equal(t, "Jane Smith", user.FullName)
+}
+
+func TestOIDCUseCase_StateIsSingleUse(t *testing.T) {
+	uc, _, idp := setupOIDCUseCase(t, oidcMapping)
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "email_verified": true}
+
+	authURL, err := uc.Begin()
+	assert.NoError(t, err)
+	code, state := idp.Authorize(t, authURL)
+
+	_, err = uc.Complete(context.Background(), "unknown-state", code, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidOIDCState, err)
+
+	_, err = uc.Complete(context.Background(), state, code, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	_, err = uc.Complete(context.Background(), state, code, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidOIDCState, err)
+}
+
+func TestOIDCUseCase_RejectsUnmappedOrUnverifiedUsers(t *testing.T) {
+	uc, _, idp := setupOIDCUseCase(t, usecase.OIDCLevelMapping{Groups: oidcMapping.Groups})
+
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "email_verified": false, "groups": []string{"intranet-admins"}}
+	_, err := oidcLogin(t, uc, idp)
+	assert.Equal(t, usecase.ErrOIDCEmailRequired, err)
+
+	// Sin nivel por defecto, quien no está en un grupo mapeado no entra
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "email_verified": true, "groups": []string{"staff"}}
+	_, err = oidcLogin(t, uc, idp)
+	assert.Equal(t, usecase.ErrOIDCNoLevel, err)
+
+	// Código inválido en el proveedor
+	authURL, err := uc.Begin()
+	assert.NoError(t, err)
+	_, state := idp.Authorize(t, authURL)
+	_, err = uc.Complete(context.Background(), state, "wrong-code", model.ClientInfo{})
+	assert.Equal(t, usecase.ErrOIDCAuthentication, err)
+}
+
+func TestOIDCUseCase_RequiresMFA(t *testing.T) {
+	uc, database, idp := setupOIDCUseCase(t, oidcMapping)
+	idp.Claims = jwt.MapClaims{"email": "jane@example.com", "email_verified": true, "groups": []string{"intranet-admins"}}
+
+	// Si el nivel exige 2FA el proveedor no basta: hay que activarlo
+	assert.NoError(t, database.Model(&model.Level{}).Where("level = ?", "Administrador").Update("require_mfa", true).Error)
+	tokens, err := oidcLogin(t, uc, idp)
+	assert.Nil(t, tokens)
+	var mfaErr *usecase.MFARequiredError
+	if assert.ErrorAs(t, err, &mfaErr) {
+		assert.True(t, mfaErr.Challenge.EnrollmentRequired)
+		assert.NotEmpty(t, mfaErr.Challenge.MFAToken)
+	}
+
+	// Con el 2FA activo se pide el código, aunque el nivel no lo exija
+	assert.NoError(t, database.Model(&model.Level{}).Where("level = ?", "Administrador").Update("require_mfa", false).Error)
+	assert.NoError(t, database.Model(&model.User{}).Where("email = ?", "jane@example.com").Updates(map[string]interface{}{"mfa_enabled": true, "mfa_secret": "SECRET"}).Error)
+	_, err = oidcLogin(t, uc, idp)
+	if assert.ErrorAs(t, err, &mfaErr) {
+		assert.False(t, mfaErr.Challenge.EnrollmentRequired)
+	}
+}
@@ -0,0 +1,182 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const policyTestPassword = "Primera-clave-1"
+
+// setupPasswordPolicy prepara la política (10 caracteres, 2 tipos, historial
+// de 2 y caducidad de 30 días) y un usuario cuya contraseña ya ha caducado
+func setupPasswordPolicy(t *testing.T) (*usecase.PasswordPolicyUseCase, *usecase.UserUseCase, *gorm.DB, *model.User) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	hasher := utils.NewTestPasswordHasher()
+	password, err := hasher.Hash(policyTestPassword)
+	assert.NoError(t, err)
+
+	level := &model.Level{Level: "Usuario", Description: "Usuario"}
+	assert.NoError(t, database.Create(level).Error)
+	changedAt := time.Now().Add(-31 * 24 * time.Hour)
+	user := &model.User{Username: "testuser", Email: "test@example.com", Password: password, LevelID: level.ID, PasswordChangedAt: &changedAt}
+	assert.NoError(t, database.Create(user).Error)
+
+	policy := security.PasswordPolicy{MinLength: 10, MinClasses: 2, History: 2, MaxAge: 30 * 24 * time.Hour}
+	passwordPolicy := usecase.NewPasswordPolicyUseCase(policy, adapters.NewPasswordBlocklist("contraseña1"), db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher, 10*time.Minute)
+	passwordPolicy.Remember(user, password)
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(database), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(database)))
+	userUseCase := usecase.NewUserUseCase(userRepo, hasher, passwordPolicy, tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), service.NewPermissionResolver(db.NewLevelRepository(database)))
+
+	return passwordPolicy, userUseCase, database, user
+}
+
+// policyCodes devuelve los códigos de las reglas incumplidas
+func policyCodes(t *testing.T, err error) []string {
+	var policyErr *usecase.PasswordPolicyError
+	if !assert.True(t, errors.As(err, &policyErr), "expected a password policy error, got %v", err) {
+		return nil
+	}
+	codes := make([]string, len(policyErr.Fields))
+	for i, field := range policyErr.Fields {
+		assert.Equal(t, "password", field.Field)
+		assert.NotEmpty(t, field.Message)
+		codes[i] = field.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicyUseCase_Rules(t *testing.T) {
+	uc, _, _, user := setupPasswordPolicy(t)
+
+	_, err := uc.Hash(user, "")
+	assert.Equal(t, []string{"required"}, policyCodes(t, err))
+	_, err = uc.Hash(user, "Corta-1")
+	assert.Equal(t, []string{security.PasswordTooShort}, policyCodes(t, err))
+	_, err = uc.Hash(user, "solominusculas")
+	assert.Equal(t, []string{security.PasswordCharacterClasses}, policyCodes(t, err))
+	_, err = uc.Hash(user, "Contraseña1")
+	assert.Equal(t, []string{security.PasswordCommon}, policyCodes(t, err))
+
+	hash, err := uc.Hash(user, "Segunda-clave-2")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hash)
+}
+
+func TestPasswordPolicyUseCase_History(t *testing.T) {
+	uc, _, _, user := setupPasswordPolicy(t)
+
+	_, err := uc.Hash(user, policyTestPassword)
+	assert.Equal(t, []string{security.PasswordReused}, policyCodes(t, err))
+
+	// Con un historial de 2, tras dos cambios la primera ya se puede repetir
+	for _, password := range []string{"Segunda-clave-2", "Tercera-clave-3"} {
+		hash, err := uc.Hash(user, password)
+		assert.NoError(t, err)
+		uc.Remember(user, hash)
+	}
+	_, err = uc.Hash(user, "Segunda-clave-2")
+	assert.Equal(t, []string{security.PasswordReused}, policyCodes(t, err))
+	_, err = uc.Hash(user, policyTestPassword)
+	assert.NoError(t, err)
+
+	// Un usuario nuevo no tiene historial
+	_, err = uc.Hash(&model.User{LevelID: user.LevelID}, policyTestPassword)
+	assert.NoError(t, err)
+}
+
+func TestPasswordPolicyUseCase_LevelOverride(t *testing.T) {
+	uc, _, database, user := setupPasswordPolicy(t)
+
+	_, err := uc.Hash(user, "Admin-clave-1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, database.Model(&model.Level{}).Where("id = ?", user.LevelID).Updates(map[string]interface{}{
+		"password_min_length":   16,
+		"password_max_age_days": 7,
+	}).Error)
+	assert.Equal(t, 16, uc.PolicyFor(user.LevelID).MinLength)
+	assert.Equal(t, 2, uc.PolicyFor(user.LevelID).MinClasses)
+	assert.Equal(t, 7*24*time.Hour, uc.PolicyFor(user.LevelID).MaxAge)
+
+	_, err = uc.Hash(user, "Admin-clave-1")
+	assert.Equal(t, []string{security.PasswordTooShort}, policyCodes(t, err))
+
+	recent := time.Now().Add(-8 * 24 * time.Hour)
+	user.PasswordChangedAt = &recent
+	assert.True(t, uc.IsExpired(user))
+}
+
+func TestUserUseCase_LoginWithExpiredPassword(t *testing.T) {
+	_, userUseCase, _, user := setupPasswordPolicy(t)
+
+	tokens, err := userUseCase.Login(user.Email, policyTestPassword, model.ClientInfo{})
+	assert.Nil(t, tokens)
+	var expiredErr *usecase.PasswordExpiredError
+	if !assert.True(t, errors.As(err, &expiredErr)) {
+		t.FailNow()
+	}
+	assert.True(t, expiredErr.Challenge.PasswordExpired)
+	passwordToken := expiredErr.Challenge.PasswordToken
+
+	// No se puede repetir la contraseña caducada
+	change := &model.ExpiredPasswordChange{PasswordToken: passwordToken}
+	change.Password.Password = policyTestPassword
+	change.ConfirmPassword = policyTestPassword
+	_, err = userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.Equal(t, []string{security.PasswordReused}, policyCodes(t, err))
+
+	change.Password.Password = "Segunda-clave-2"
+	_, err = userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.ErrorIs(t, err, usecase.ErrPasswordMismatch)
+
+	change.ConfirmPassword = "Segunda-clave-2"
+	tokens, err = userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.NoError(t, err)
+	if assert.NotNil(t, tokens) {
+		assert.NotEmpty(t, tokens.AccessToken)
+	}
+
+	// El token deja de valer en cuanto la contraseña se ha cambiado
+	_, err = userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.ErrorIs(t, err, usecase.ErrInvalidPasswordToken)
+
+	tokens, err = userUseCase.Login(user.Email, "Segunda-clave-2", model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotNil(t, tokens)
+}
+
+func TestUserUseCase_ChangeExpiredPasswordRejectsSessionTokens(t *testing.T) {
+	_, userUseCase, _, user := setupPasswordPolicy(t)
+
+	change := &model.ExpiredPasswordChange{PasswordToken: "not-a-token"}
+	change.Password.Password = "Segunda-clave-2"
+	change.ConfirmPassword = "Segunda-clave-2"
+	_, err := userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.ErrorIs(t, err, usecase.ErrInvalidPasswordToken)
+
+	sessionToken, err := helpers.GenerateJWT(user, time.Minute)
+	assert.NoError(t, err)
+	change.PasswordToken = sessionToken
+	_, err = userUseCase.ChangeExpiredPassword(change, model.ClientInfo{})
+	assert.ErrorIs(t, err, usecase.ErrInvalidPasswordToken)
+}
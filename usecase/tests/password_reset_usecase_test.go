@@ -0,0 +1,108 @@
+package usecase_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupPasswordResetUseCase(t *testing.T) (*usecase.PasswordResetUseCase, *usecase.TokenUseCase, *mocks.MockMailer, *gorm.DB) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mailer := new(mocks.MockMailer)
+	uc := usecase.NewPasswordResetUseCase(userRepo, utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), utils.NewTestPasswordHasher()), tokenUseCase, mailer, "https://intranet.test/reset-password", time.Hour)
+
+	return uc, tokenUseCase, mailer, database
+}
+
+// resetTokenFromMail extrae el token del enlace enviado por email
+func resetTokenFromMail(t *testing.T, mailer *mocks.MockMailer) string {
+	data := mailer.Calls[0].Arguments.Get(3).(map[string]interface{})
+	link, err := url.Parse(data["Link"].(string))
+	assert.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func TestPasswordResetUseCase_UnknownEmailSendsNothing(t *testing.T) {
+	uc, _, mailer, _ := setupPasswordResetUseCase(t)
+
+	uc.RequestPasswordReset("unknown@example.com")
+	uc.Wait()
+
+	mailer.AssertNotCalled(t, "SendTemplateTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordResetUseCase_ResetPassword(t *testing.T) {
+	uc, tokenUseCase, mailer, database := setupPasswordResetUseCase(t)
+
+	user := &model.User{Username: "testuser", Email: "test@example.com", FullName: "Test User", Password: "old"}
+	assert.NoError(t, database.Create(user).Error)
+	sessionTokens, err := tokenUseCase.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	mailer.On("SendTemplateTo", "test@example.com", mock.Anything, "templates/password_reset.html", mock.Anything).Return(nil)
+	uc.RequestPasswordReset("test@example.com")
+	uc.Wait()
+	mailer.AssertExpectations(t)
+
+	token := resetTokenFromMail(t, mailer)
+	assert.NotEmpty(t, token)
+
+	// En la base de datos solo se guarda el hash del token
+	var stored model.User
+	database.First(&stored, user.ID)
+	assert.NotEqual(t, token, stored.Token)
+	assert.NotNil(t, stored.TokenExpiresAt)
+
+	err = uc.ResetPassword(&model.RecoverPass{Token: token, Password: model.Password{Password: "new-password", ConfirmPassword: "other"}})
+	assert.ErrorIs(t, err, usecase.ErrPasswordMismatch)
+
+	err = uc.ResetPassword(&model.RecoverPass{Token: token, Password: model.Password{Password: "new-password", ConfirmPassword: "new-password"}})
+	assert.NoError(t, err)
+
+	database.First(&stored, user.ID)
+	ok, err := utils.NewTestPasswordHasher().Verify(stored.Password, "new-password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, stored.Token)
+
+	// El token es de un solo uso
+	err = uc.ResetPassword(&model.RecoverPass{Token: token, Password: model.Password{Password: "again", ConfirmPassword: "again"}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidResetToken)
+
+	// Las sesiones anteriores quedan revocadas
+	_, err = tokenUseCase.Refresh(sessionTokens.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestPasswordResetUseCase_ExpiredToken(t *testing.T) {
+	uc, _, mailer, database := setupPasswordResetUseCase(t)
+
+	user := &model.User{Username: "testuser", Email: "test@example.com", FullName: "Test User", Password: "old"}
+	assert.NoError(t, database.Create(user).Error)
+
+	mailer.On("SendTemplateTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	uc.RequestPasswordReset("test@example.com")
+	uc.Wait()
+	token := resetTokenFromMail(t, mailer)
+	assert.NotEmpty(t, token)
+
+	database.Model(&model.User{}).Where("id = ?", user.ID).Update("token_expires_at", time.Now().Add(-time.Minute))
+
+	err := uc.ResetPassword(&model.RecoverPass{Token: token, Password: model.Password{Password: "new-password", ConfirmPassword: "new-password"}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidResetToken)
+}
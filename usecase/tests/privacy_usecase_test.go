@@ -0,0 +1,161 @@
+package usecase_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupPrivacyUseCase(t *testing.T) (*usecase.PrivacyUseCase, *gorm.DB, string) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	userRepo := db.NewUserRepository(database)
+	sessionRepo := db.NewSessionRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), sessionRepo, userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	storageDir := t.TempDir()
+	uc := usecase.NewPrivacyUseCase(userRepo, sessionRepo, db.NewAPIKeyRepository(database), db.NewInvitationRepository(database), db.NewAuditRepository(database), tokenUseCase, adapters.NewLocalStorage(storageDir, "/uploads"))
+	return uc, database, storageDir
+}
+
+func TestPrivacyUseCase_Export(t *testing.T) {
+	uc, database, _ := setupPrivacyUseCase(t)
+
+	level := &model.Level{Level: "Usuario", Description: "Usuario"}
+	assert.NoError(t, database.Create(level).Error)
+	user := &model.User{Username: "ana", Email: "ana@example.com", FullName: "Ana", Password: "hash", LevelID: level.ID}
+	assert.NoError(t, database.Create(user).Error)
+	assert.NoError(t, database.Create(&model.Session{UserID: user.ID, FamilyID: "family", IP: "10.0.0.1", LastSeenAt: time.Now()}).Error)
+	assert.NoError(t, database.Create(&model.APIKey{UserID: user.ID, Name: "ci", KeyHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	assert.NoError(t, database.Create(&model.Invitation{Email: "ana@example.com", Username: "ana", FullName: "Ana", LevelID: level.ID, UserID: &user.ID}).Error)
+
+	export, err := uc.Export(1, user.ID, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "ana@example.com", export.Profile.Email)
+	assert.Empty(t, export.Profile.Password)
+	assert.Equal(t, "Usuario", export.Profile.Level.Level)
+	assert.Len(t, export.Sessions, 1)
+	assert.Len(t, export.APIKeys, 1)
+	assert.Len(t, export.Invitations, 1)
+
+	// La exportación queda registrada y aparece en la siguiente
+	export, err = uc.Export(1, user.ID, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, export.AuditEntries, 1) {
+		assert.Equal(t, model.AuditUserDataExport, export.AuditEntries[0].Action)
+		assert.Equal(t, uint(1), export.AuditEntries[0].ActorID)
+	}
+
+	_, err = uc.Export(1, 9999, nil)
+	assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+
+	// Un filtro sin condiciones no deja acceder a ningún usuario
+	_, err = uc.Export(1, user.ID, security.NewRowFilter(nil))
+	assert.ErrorIs(t, err, usecase.ErrRowAccessDenied)
+}
+
+func TestPrivacyUseCase_Erase(t *testing.T) {
+	uc, database, storageDir := setupPrivacyUseCase(t)
+
+	user := &model.User{Username: "ana", Email: "ana@example.com", FullName: "Ana López", Password: "hash", MFASecret: "SECRET"}
+	assert.NoError(t, database.Create(user).Error)
+	avatarDir := filepath.Join(storageDir, "avatars", fmt.Sprint(user.ID))
+	assert.NoError(t, database.Model(user).Update("picture", fmt.Sprintf("/uploads/avatars/%d/abc-512.jpg", user.ID)).Error)
+	session := &model.Session{UserID: user.ID, FamilyID: "family", IP: "10.0.0.1", UserAgent: "Firefox", LastSeenAt: time.Now()}
+	assert.NoError(t, database.Create(session).Error)
+	assert.NoError(t, database.Create(&model.APIKey{UserID: user.ID, Name: "ci", KeyHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	assert.NoError(t, database.Create(&model.Invitation{Email: "ana@example.com", Username: "ana", FullName: "Ana López", UserID: &user.ID}).Error)
+
+	assert.NoError(t, os.MkdirAll(avatarDir, 0o755))
+	for _, name := range []string{"abc-512.jpg", "abc-128.jpg", "abc-64.jpg"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(avatarDir, name), []byte("jpg"), 0o644))
+	}
+
+	assert.ErrorIs(t, uc.Erase(user.ID, &model.UserErasureRequest{ID: user.ID}, nil), usecase.ErrCannotEraseSelf)
+	assert.ErrorIs(t, uc.Erase(1000, &model.UserErasureRequest{ID: 9999}, nil), usecase.ErrUserNotFound)
+
+	assert.NoError(t, uc.Erase(1000, &model.UserErasureRequest{ID: user.ID, Reason: "Solicitud de supresión 42"}, nil))
+
+	// La fila se conserva sin datos personales
+	var erased model.User
+	assert.NoError(t, database.Unscoped().First(&erased, user.ID).Error)
+	assert.True(t, erased.DeletedAt.Valid)
+	assert.Equal(t, model.UserStatusDeleted, erased.Status)
+	for _, value := range []string{erased.Username, erased.Email, erased.FullName} {
+		assert.NotContains(t, strings.ToLower(value), "ana")
+	}
+	assert.Empty(t, erased.Picture)
+	assert.Empty(t, erased.Password)
+	assert.Empty(t, erased.MFASecret)
+
+	var erasedSession model.Session
+	assert.NoError(t, database.First(&erasedSession, session.ID).Error)
+	assert.Empty(t, erasedSession.IP)
+	assert.Empty(t, erasedSession.UserAgent)
+	assert.NotNil(t, erasedSession.RevokedAt)
+
+	var count int64
+	database.Unscoped().Model(&model.APIKey{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	database.Model(&model.Invitation{}).Where("email = ?", "ana@example.com").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	entries, err := db.NewAuditRepository(database).GetByUser(user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, model.AuditUserErasure, entries[0].Action)
+		assert.Equal(t, uint(1000), entries[0].ActorID)
+		assert.Equal(t, "Solicitud de supresión 42", entries[0].Details)
+	}
+
+	files, err := os.ReadDir(avatarDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Un usuario ya eliminado también se puede suprimir de nuevo sin error
+	assert.NoError(t, uc.Erase(1000, &model.UserErasureRequest{ID: user.ID}, nil))
+}
+
+func TestPrivacyUseCase_DeletedUser(t *testing.T) {
+	uc, database, storageDir := setupPrivacyUseCase(t)
+
+	user := &model.User{Username: "ana", Email: "ana@example.com", FullName: "Ana López", Password: "hash"}
+	assert.NoError(t, database.Create(user).Error)
+	assert.NoError(t, database.Model(user).Update("picture", fmt.Sprintf("/uploads/avatars/%d/abc-512.jpg", user.ID)).Error)
+	avatarDir := filepath.Join(storageDir, "avatars", fmt.Sprint(user.ID))
+	assert.NoError(t, os.MkdirAll(avatarDir, 0o755))
+	for _, name := range []string{"abc-512.jpg", "abc-128.jpg", "abc-64.jpg"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(avatarDir, name), []byte("jpg"), 0o644))
+	}
+	assert.NoError(t, database.Model(user).Update("status", model.UserStatusDeleted).Error)
+	assert.NoError(t, database.Delete(user).Error)
+
+	// Mientras no se purga, el usuario eliminado puede pedir sus datos
+	export, err := uc.Export(1000, user.ID, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "ana@example.com", export.Profile.Email)
+
+	// Y al suprimirlo también se borra su avatar
+	assert.NoError(t, uc.Erase(1000, &model.UserErasureRequest{ID: user.ID}, nil))
+	files, err := os.ReadDir(avatarDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
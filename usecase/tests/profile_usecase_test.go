@@ -0,0 +1,145 @@
+package usecase_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupProfileUseCase(t *testing.T) (*usecase.ProfileUseCase, *gorm.DB, *model.User, string) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	hasher := utils.NewTestPasswordHasher()
+	password, _ := hasher.Hash("password")
+	user := &model.User{Username: "testuser", Email: "test@example.com", FullName: "Test User", Password: password, LevelID: 2}
+	assert.NoError(t, database.Create(user).Error)
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+
+	storageDir := t.TempDir()
+	fileStorage := adapters.NewLocalStorage(storageDir, "/uploads")
+
+	return usecase.NewProfileUseCase(userRepo, hasher, utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), db.NewLevelRepository(database), hasher), tokenUseCase, fileStorage, 1024*1024), database, user, storageDir
+}
+
+func TestProfileUseCase_UpdateProfile(t *testing.T) {
+	uc, _, user, _ := setupProfileUseCase(t)
+
+	updated, err := uc.UpdateProfile(user.ID, &model.Profile{FullName: " New Name ", Picture: "/pictures/1.png", Language: "es-ES"})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Name", updated.FullName)
+	assert.Equal(t, "/pictures/1.png", updated.Picture)
+	assert.Equal(t, "es-ES", updated.Language)
+	assert.Equal(t, uint(2), updated.LevelID)
+	assert.Equal(t, "testuser", updated.Username)
+
+	_, err = uc.UpdateProfile(user.ID, &model.Profile{FullName: ""})
+	assert.Equal(t, usecase.ErrFullNameRequired, err)
+
+	_, err = uc.UpdateProfile(user.ID, &model.Profile{FullName: "Name", Language: "spanish"})
+	assert.Equal(t, usecase.ErrInvalidLanguage, err)
+}
+
+func TestProfileUseCase_ChangePassword(t *testing.T) {
+	uc, database, user, _ := setupProfileUseCase(t)
+
+	_, err := uc.ChangePassword(user.ID, &model.PasswordChange{
+		CurrentPassword: "wrong",
+		Password:        model.Password{Password: "newpassword", ConfirmPassword: "newpassword"},
+	}, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrInvalidCurrentPassword, err)
+
+	_, err = uc.ChangePassword(user.ID, &model.PasswordChange{
+		CurrentPassword: "password",
+		Password:        model.Password{Password: "newpassword", ConfirmPassword: "other"},
+	}, model.ClientInfo{})
+	assert.Equal(t, usecase.ErrPasswordMismatch, err)
+
+	tokens, err := uc.ChangePassword(user.ID, &model.PasswordChange{
+		CurrentPassword: "password",
+		Password:        model.Password{Password: "newpassword", ConfirmPassword: "newpassword"},
+	}, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	var stored model.User
+	database.First(&stored, user.ID)
+	ok, err := utils.NewTestPasswordHasher().Verify(stored.Password, "newpassword")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// pngImage genera un PNG de width x height píxeles
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProfileUseCase_UpdatePicture(t *testing.T) {
+	uc, database, user, storageDir := setupProfileUseCase(t)
+
+	picture, err := uc.UpdatePicture(user.ID, bytes.NewReader(pngImage(t, 800, 600)))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(picture.Picture, "/uploads/avatars/"))
+	assert.True(t, strings.HasSuffix(picture.Picture, "-512.jpg"))
+	assert.Len(t, picture.Thumbnails, 2)
+
+	var stored model.User
+	database.First(&stored, user.ID)
+	assert.Equal(t, picture.Picture, stored.Picture)
+	assert.Equal(t, "Test User", stored.FullName)
+
+	// Las versiones se recortan en cuadrado y se guardan como JPEG
+	for size, url := range map[int]string{512: picture.Picture, 128: picture.Thumbnails[128], 64: picture.Thumbnails[64]} {
+		file, err := os.Open(filepath.Join(storageDir, strings.TrimPrefix(url, "/uploads/")))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		config, format, err := image.DecodeConfig(file)
+		file.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, size, config.Width)
+		assert.Equal(t, size, config.Height)
+	}
+}
+
+func TestProfileUseCase_UpdatePictureValidation(t *testing.T) {
+	uc, _, user, _ := setupProfileUseCase(t)
+
+	_, err := uc.UpdatePicture(user.ID, strings.NewReader("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.Equal(t, usecase.ErrUnsupportedPictureType, err)
+
+	_, err = uc.UpdatePicture(user.ID, bytes.NewReader(make([]byte, 1024*1024+1)))
+	assert.Equal(t, usecase.ErrPictureTooLarge, err)
+
+	// Cabecera PNG válida con el contenido truncado
+	_, err = uc.UpdatePicture(user.ID, bytes.NewReader(pngImage(t, 10, 10)[:40]))
+	assert.Equal(t, usecase.ErrInvalidPicture, err)
+}
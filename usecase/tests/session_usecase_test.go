@@ -0,0 +1,107 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionUseCase_ListAndRevoke(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	tokenUseCase, database, user := setupTokenUseCase(t)
+	uc := usecase.NewSessionUseCase(db.NewSessionRepository(database), db.NewUserRepository(database), tokenUseCase, 24*time.Hour)
+
+	laptop, err := tokenUseCase.IssueTokens(user, model.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	phone, err := tokenUseCase.IssueTokens(user, model.ClientInfo{UserAgent: "Safari", IP: "10.0.0.2"})
+	assert.NoError(t, err)
+
+	laptopClaims, err := helpers.ParseJWT(laptop.AccessToken)
+	assert.NoError(t, err)
+	phoneClaims, err := helpers.ParseJWT(phone.AccessToken)
+	assert.NoError(t, err)
+	assert.NotZero(t, laptopClaims.SessionID)
+	assert.NotEqual(t, laptopClaims.SessionID, phoneClaims.SessionID)
+
+	sessions, err := uc.List(user.ID, laptopClaims.SessionID)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		for _, session := range sessions {
+			assert.Equal(t, session.ID == laptopClaims.SessionID, session.Current)
+			if session.Current {
+				assert.Equal(t, "Firefox", session.UserAgent)
+				assert.Equal(t, "10.0.0.1", session.IP)
+			}
+		}
+	}
+
+	// Otro usuario no puede cerrar la sesión
+	assert.Equal(t, usecase.ErrSessionNotFound, uc.Revoke(user.ID+1, phoneClaims.SessionID))
+
+	assert.NoError(t, uc.Revoke(user.ID, phoneClaims.SessionID))
+	active, err := uc.IsSessionActive(phoneClaims)
+	assert.NoError(t, err)
+	assert.False(t, active)
+	_, err = tokenUseCase.Refresh(phone.RefreshToken)
+	assert.Error(t, err)
+
+	active, err = uc.IsSessionActive(laptopClaims)
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	sessions, err = uc.ListForUser(user.ID, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, usecase.ErrSessionNotFound, uc.Revoke(user.ID, phoneClaims.SessionID))
+
+	// Un filtro sin condiciones no deja ver ni cerrar las sesiones de nadie
+	denied := security.NewRowFilter(nil)
+	_, err = uc.ListForUser(user.ID, denied)
+	assert.Equal(t, usecase.ErrRowAccessDenied, err)
+	assert.Equal(t, usecase.ErrRowAccessDenied, uc.RevokeForUser(user.ID, laptopClaims.SessionID, denied))
+	active, err = uc.IsSessionActive(laptopClaims)
+	assert.NoError(t, err)
+	assert.True(t, active)
+	assert.NoError(t, uc.RevokeForUser(user.ID, laptopClaims.SessionID, nil))
+}
+
+func TestSessionUseCase_RefreshKeepsSessionAndLogoutEndsIt(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	tokenUseCase, database, user := setupTokenUseCase(t)
+	uc := usecase.NewSessionUseCase(db.NewSessionRepository(database), db.NewUserRepository(database), tokenUseCase, 24*time.Hour)
+
+	tokens, err := tokenUseCase.IssueTokens(user, model.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	claims, _ := helpers.ParseJWT(tokens.AccessToken)
+
+	refreshed, err := tokenUseCase.Refresh(tokens.RefreshToken)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	refreshedClaims, _ := helpers.ParseJWT(refreshed.AccessToken)
+	assert.Equal(t, claims.SessionID, refreshedClaims.SessionID)
+
+	assert.NoError(t, tokenUseCase.Logout(refreshedClaims, ""))
+	active, err := uc.IsSessionActive(claims)
+	assert.NoError(t, err)
+	assert.False(t, active)
+
+	// Revocar todo cierra también el resto de sesiones
+	other, err := tokenUseCase.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+	otherClaims, _ := helpers.ParseJWT(other.AccessToken)
+	assert.NoError(t, tokenUseCase.RevokeAllForUser(user.ID))
+	active, err = uc.IsSessionActive(otherClaims)
+	assert.NoError(t, err)
+	assert.False(t, active)
+
+	sessions, err := uc.List(user.ID, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
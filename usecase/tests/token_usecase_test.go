@@ -0,0 +1,207 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTokenUseCase(t *testing.T) (*usecase.TokenUseCase, *gorm.DB, *model.User) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	user := &model.User{Username: "testuser", Email: "test@example.com", Password: "password"}
+	assert.NoError(t, database.Create(user).Error)
+
+	userRepo := db.NewUserRepository(database)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(database), db.NewSessionRepository(database), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+
+	return tokenUseCase, database, user
+}
+
+func TestTokenUseCase_IssueTokens(t *testing.T) {
+	uc, database, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(900), tokens.ExpiresIn)
+
+	// Solo se guarda el hash del refresh token
+	var stored model.RefreshToken
+	assert.NoError(t, database.First(&stored).Error)
+	assert.Equal(t, helpers.HashToken(tokens.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+	assert.Equal(t, user.ID, stored.UserID)
+}
+
+func TestTokenUseCase_RefreshRotatesToken(t *testing.T) {
+	uc, database, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	rotated, err := uc.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+
+	var previous, next model.RefreshToken
+	database.Where("token_hash = ?", helpers.HashToken(tokens.RefreshToken)).First(&previous)
+	database.Where("token_hash = ?", helpers.HashToken(rotated.RefreshToken)).First(&next)
+	assert.NotNil(t, previous.RevokedAt)
+	assert.Equal(t, next.ID, *previous.ReplacedBy)
+	assert.Equal(t, previous.FamilyID, next.FamilyID)
+	assert.Nil(t, next.RevokedAt)
+}
+
+func TestTokenUseCase_RefreshReuseRevokesFamily(t *testing.T) {
+	uc, database, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	rotated, err := uc.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+
+	// Reutilizar el token ya rotado revoca toda la familia
+	_, err = uc.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, usecase.ErrRefreshTokenReused)
+
+	_, err = uc.Refresh(rotated.RefreshToken)
+	assert.ErrorIs(t, err, usecase.ErrRefreshTokenReused)
+
+	var active int64
+	database.Model(&model.RefreshToken{}).Where("revoked_at IS NULL").Count(&active)
+	assert.Equal(t, int64(0), active)
+}
+
+func TestTokenUseCase_RefreshRejectsExpiredAndUnknownTokens(t *testing.T) {
+	uc, database, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	database.Model(&model.RefreshToken{}).Where("user_id = ?", user.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	_, err = uc.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+
+	_, err = uc.Refresh("unknown-token")
+	assert.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+}
+
+func TestTokenUseCase_LogoutRevokesAccessAndRefreshTokens(t *testing.T) {
+	uc, _, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	claims := &model.Claim{UserID: user.ID}
+	_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(""), nil
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+
+	revoked, err := uc.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, uc.Logout(claims, tokens.RefreshToken))
+
+	revoked, err = uc.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = uc.Refresh(tokens.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestTokenUseCase_RevokeAllForUser(t *testing.T) {
+	uc, _, user := setupTokenUseCase(t)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	claims := &model.Claim{
+		UserID: user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       "jti",
+			IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Second)),
+		},
+	}
+
+	assert.NoError(t, uc.RevokeAllForUser(user.ID))
+
+	revoked, err := uc.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = uc.Refresh(tokens.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestTokenUseCase_TokensIssuedAfterRevokeAllForUser(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	uc, _, user := setupTokenUseCase(t)
+
+	// El iat solo tiene segundos: el token emitido en el mismo segundo que el
+	// corte no debe quedar revocado
+	assert.NoError(t, uc.RevokeAllForUser(user.ID))
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+
+	claims, err := helpers.ParseJWT(tokens.AccessToken)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	revoked, err := uc.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = uc.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenUseCase_PermissionSnapshot(t *testing.T) {
+	uc, database, user := setupTokenUseCase(t)
+	levelRepo := db.NewLevelRepository(database)
+	uc.EnablePermissionSnapshots(levelRepo)
+
+	level := &model.Level{Level: "Snapshot", Description: "Snapshot"}
+	assert.NoError(t, levelRepo.CreateOrUpdate(level, nil))
+	form := &model.Form{Title: "Usuarios", PathAPI: "user|users"}
+	assert.NoError(t, database.Create(form).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: level.ID, FormID: form.ID, Read: true, Write: true, Create: true, Update: true, Delete: true}).Error)
+	user.LevelID = level.ID
+	assert.NoError(t, database.Save(user).Error)
+
+	tokens, err := uc.IssueTokens(user, model.ClientInfo{})
+	assert.NoError(t, err)
+	claims, err := helpers.ParseJWT(tokens.AccessToken)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"user|users:rcud"}, claims.Permissions)
+	assert.Equal(t, uint(1), claims.PermissionVersion)
+
+	// Al refrescar el token recoge la nueva versión de los privilegios
+	assert.NoError(t, levelRepo.BumpPermissionVersion(level.ID))
+	refreshed, err := uc.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+	claims, err = helpers.ParseJWT(refreshed.AccessToken)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(2), claims.PermissionVersion)
+	}
+}
@@ -0,0 +1,188 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drossan/core-api/adapters"
+	"github.com/drossan/core-api/domain/importer"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/mocks"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupUserImportUseCase(t *testing.T) (*usecase.UserImportUseCase, *mocks.MockMailer, *gorm.DB) {
+	t.Setenv("JWT_SECRET", "test_secret")
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+
+	assert.NoError(t, database.Create(&model.Level{Level: "Usuario", Description: "Usuario"}).Error)
+	assert.NoError(t, database.Create(&model.User{Username: "taken", Email: "taken@example.com", Password: "x"}).Error)
+
+	hasher := utils.NewTestPasswordHasher()
+	userRepo := db.NewUserRepository(database)
+	levelRepo := db.NewLevelRepository(database)
+	invitationRepo := db.NewInvitationRepository(database)
+	permissions := service.NewPermissionResolver(levelRepo)
+	passwordPolicy := utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(database), levelRepo, hasher)
+	mailer := new(mocks.MockMailer)
+	mailer.On("SendTemplateTo", mock.Anything, "Invitation", "templates/user_invitation.html", mock.Anything).Return(nil)
+	invitationUseCase := usecase.NewInvitationUseCase(invitationRepo, userRepo, levelRepo, passwordPolicy, mailer, "https://intranet.test/accept-invitation", time.Hour, permissions)
+
+	readers := map[string]importer.Reader{"csv": adapters.NewCSVReader()}
+	uc := usecase.NewUserImportUseCase(userRepo, levelRepo, invitationRepo, invitationUseCase, hasher, readers, 10, 1024*1024, permissions)
+	return uc, mailer, database
+}
+
+func TestUserImportUseCase_DryRunReportsRowErrors(t *testing.T) {
+	uc, _, database := setupUserImportUseCase(t)
+
+	file := "Username,E-mail,Full Name,Level\n" +
+		"ana,ana@example.com,Ana López,usuario\n" +
+		"taken,TAKEN@example.com,Taken,Usuario\n" +
+		"ana,ana@example.com,Ana Bis,Usuario\n" +
+		",not-an-email,,Jefe\n" +
+		",,,\n"
+
+	result, err := uc.Import(1, ".csv", strings.NewReader(file), model.UserImportOptions{DryRun: true}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, []model.UserImportError{
+		{Row: 3, Field: "email", Error: "already in use"},
+		{Row: 3, Field: "username", Error: "already in use"},
+		{Row: 4, Field: "email", Error: "repeated in row 2"},
+		{Row: 4, Field: "username", Error: "repeated in row 2"},
+		{Row: 5, Field: "email", Error: "invalid email address"},
+		{Row: 5, Field: "fullname", Error: "required"},
+		{Row: 5, Field: "level", Error: `level "Jefe" does not exist`},
+	}, result.Errors)
+
+	// Ni la prueba ni un fichero con errores crean nada
+	_, err = uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportInvalidRows)
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestUserImportUseCase_CreatesUsers(t *testing.T) {
+	uc, mailer, database := setupUserImportUseCase(t)
+
+	file := "email;fullname;level\nAna@Example.com;Ana López;Usuario\nluis@example.com;Luis;USUARIO\n"
+	result, err := uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, result.Created)
+	assert.Empty(t, result.Errors)
+	mailer.AssertNotCalled(t, "SendTemplateTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	var user model.User
+	assert.NoError(t, database.Where("email = ?", "ana@example.com").First(&user).Error)
+	assert.Equal(t, "ana@example.com", user.Username)
+	assert.NotEmpty(t, user.Password)
+	assert.NotZero(t, user.LevelID)
+
+	// Importar el mismo fichero otra vez no crea duplicados
+	_, err = uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportInvalidRows)
+}
+
+func TestUserImportUseCase_RowAccess(t *testing.T) {
+	uc, _, database := setupUserImportUseCase(t)
+
+	// Solo se puede crear la cuenta de ana@example.com; si una fila no cumple la
+	// condición no se crea ninguna
+	policy, _ := security.ParseRowPolicy("email == 'ana@example.com'")
+	filter := security.NewRowFilter(security.RowSubject{}, policy)
+
+	file := "email;fullname;level\nana@example.com;Ana;Usuario\nluis@example.com;Luis;Usuario\n"
+	_, err := uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{}, filter)
+	assert.ErrorIs(t, err, usecase.ErrRowAccessDenied)
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	result, err := uc.Import(1, "csv", strings.NewReader("email;fullname;level\nana@example.com;Ana;Usuario\n"), model.UserImportOptions{}, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+}
+
+func TestUserImportUseCase_SendsInvitations(t *testing.T) {
+	uc, mailer, database := setupUserImportUseCase(t)
+
+	file := "email,fullname,level\nana@example.com,Ana López,Usuario\nluis@example.com,Luis,Usuario\n"
+	result, err := uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{Invite: true}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, result.Invited)
+	assert.Equal(t, 0, result.Created)
+	mailer.AssertNumberOfCalls(t, "SendTemplateTo", 2)
+
+	var invitations []model.Invitation
+	assert.NoError(t, database.Find(&invitations).Error)
+	if assert.Len(t, invitations, 2) {
+		assert.Equal(t, uint(1), invitations[0].InvitedBy)
+	}
+	var count int64
+	database.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Las invitaciones pendientes cuentan como emails ocupados
+	result, err = uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{DryRun: true}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, result.Errors, 2)
+}
+
+func TestUserImportUseCase_RejectsInvalidFiles(t *testing.T) {
+	uc, _, _ := setupUserImportUseCase(t)
+
+	_, err := uc.Import(1, "ods", strings.NewReader("email"), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrUnsupportedImportFormat)
+
+	_, err = uc.Import(1, "csv", strings.NewReader("email,level\nana@example.com,Usuario\n"), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportMissingColumn)
+
+	_, err = uc.Import(1, "csv", strings.NewReader("email,fullname,level\n"), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportEmpty)
+
+	file := "email,fullname,level\n" + strings.Repeat("a@example.com,A,Usuario\n", 11)
+	_, err = uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportTooManyRows)
+}
+
+func TestUserImportUseCase_RequiresLevelPrivileges(t *testing.T) {
+	uc, _, database := setupUserImportUseCase(t)
+
+	// El usuario 1 no tiene los privilegios del nivel de soporte
+	form := &model.Form{Title: "Usuarios", PathAPI: "user|users"}
+	assert.NoError(t, database.Create(form).Error)
+	support := &model.Level{Level: "Soporte", Description: "Soporte"}
+	assert.NoError(t, database.Create(support).Error)
+	assert.NoError(t, database.Create(&model.LevelPrivileges{LevelID: support.ID, FormID: form.ID, Read: true}).Error)
+
+	file := "email,fullname,level\nana@example.com,Ana,Usuario\nluis@example.com,Luis,Soporte\n"
+	result, err := uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{DryRun: true}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []model.UserImportError{
+		{Row: 3, Field: "level", Error: `level "Soporte" has privileges you do not have`},
+	}, result.Errors)
+
+	_, err = uc.Import(1, "csv", strings.NewReader(file), model.UserImportOptions{Invite: true}, nil)
+	assert.ErrorIs(t, err, usecase.ErrImportInvalidRows)
+}
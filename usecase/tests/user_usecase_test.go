@@ -3,10 +3,15 @@ package usecase_test
 import (
 	"gorm.io/gorm/logger"
 	"testing"
+	"time"
 
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/security"
 	"github.com/drossan/core-api/infrastructure/db"
+	"github.com/drossan/core-api/infrastructure/memory"
+	"github.com/drossan/core-api/service"
 	"github.com/drossan/core-api/usecase"
+	"github.com/drossan/core-api/utils"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -22,22 +27,28 @@ func setupTestDB() *gorm.DB {
 	}
 
 	// Migrar esquemas
-	_ = testDB.AutoMigrate(&model.User{})
+	_ = testDB.AutoMigrate(&model.User{}, &model.RefreshToken{})
 	return testDB
 }
 
 func resetTestDB(testDB *gorm.DB) {
 	testDB.Exec("DROP TABLE IF EXISTS users")
-	_ = testDB.AutoMigrate(&model.User{})
+	testDB.Exec("DROP TABLE IF EXISTS refresh_tokens")
+	_ = testDB.AutoMigrate(&model.User{}, &model.RefreshToken{})
+}
+
+func newUserUseCase(testDB *gorm.DB) *usecase.UserUseCase {
+	userRepo := db.NewUserRepository(testDB)
+	tokenUseCase := usecase.NewTokenUseCase(db.NewRefreshTokenRepository(testDB), db.NewSessionRepository(testDB), userRepo, memory.NewRevocationStore(), 15*time.Minute, 24*time.Hour)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, db.NewRecoveryCodeRepository(testDB), tokenUseCase, utils.NewTestLockoutPolicy(), "Intranet", 5*time.Minute, service.NewPermissionResolver(db.NewLevelRepository(testDB)))
+	return usecase.NewUserUseCase(userRepo, utils.NewTestPasswordHasher(), utils.NewTestPasswordPolicy(db.NewPasswordHistoryRepository(testDB), db.NewLevelRepository(testDB), utils.NewTestPasswordHasher()), tokenUseCase, mfaUseCase, utils.NewTestLockoutPolicy(), service.NewPermissionResolver(db.NewLevelRepository(testDB)))
 }
 
 func TestCreateUser(t *testing.T) {
 	testDB := setupTestDB()
 	defer resetTestDB(testDB)
 
-	userRepo := db.NewUserRepository(testDB)
-
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := newUserUseCase(testDB)
 
 	user := &model.User{
 		Username: "testuser",
@@ -46,7 +57,7 @@ func TestCreateUser(t *testing.T) {
 		Password: "password",
 	}
 
-	err := userUseCase.CreateUser(user)
+	err := userUseCase.CreateUser(user, nil)
 
 	assert.Nil(t, err)
 
@@ -62,8 +73,7 @@ func TestGetUserByID(t *testing.T) {
 	testDB := setupTestDB()
 	defer resetTestDB(testDB)
 
-	userRepo := db.NewUserRepository(testDB)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := newUserUseCase(testDB)
 
 	user := &model.User{
 		Username: "testuser",
@@ -73,7 +83,7 @@ func TestGetUserByID(t *testing.T) {
 	}
 
 	// Crear el usuario antes de intentar obtenerlo
-	err := userUseCase.CreateUser(user)
+	err := userUseCase.CreateUser(user, nil)
 	assert.Nil(t, err)
 
 	fetchedUser, err := userUseCase.GetUserByID(user.ID)
@@ -88,8 +98,7 @@ func TestUpdateUser(t *testing.T) {
 	testDB := setupTestDB()
 	defer resetTestDB(testDB)
 
-	userRepo := db.NewUserRepository(testDB)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := newUserUseCase(testDB)
 
 	user := &model.User{
 		Username: "testuser",
@@ -98,11 +107,11 @@ func TestUpdateUser(t *testing.T) {
 		Password: "password",
 	}
 
-	err := userUseCase.CreateUser(user)
+	err := userUseCase.CreateUser(user, nil)
 	assert.Nil(t, err)
 
 	user.FullName = "Updated Test User"
-	err = userUseCase.UpdateUser(user)
+	err = userUseCase.UpdateUser(user, nil)
 	assert.Nil(t, err)
 
 	var updatedUser model.User
@@ -115,8 +124,7 @@ func TestDeleteUser(t *testing.T) {
 	testDB := setupTestDB()
 	defer resetTestDB(testDB)
 
-	userRepo := db.NewUserRepository(testDB)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := newUserUseCase(testDB)
 
 	user := &model.User{
 		Username: "testuser",
@@ -125,10 +133,10 @@ func TestDeleteUser(t *testing.T) {
 		Password: "password",
 	}
 
-	err := userUseCase.CreateUser(user)
+	err := userUseCase.CreateUser(user, nil)
 	assert.Nil(t, err)
 
-	err = userUseCase.DeleteUser(user)
+	err = userUseCase.DeleteUser(user, nil)
 	assert.Nil(t, err)
 
 	var deletedUser model.User
@@ -138,3 +146,173 @@ func TestDeleteUser(t *testing.T) {
 	assert.Error(t, result.Error)
 	assert.Equal(t, gorm.ErrRecordNotFound, result.Error)
 }
+
+func TestUserUseCase_SuspendAndReactivate(t *testing.T) {
+	testDB := setupTestDB()
+	defer resetTestDB(testDB)
+	_ = testDB.AutoMigrate(&model.Session{})
+
+	userUseCase := newUserUseCase(testDB)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		FullName: "Test User",
+		Password: "password",
+	}
+	assert.Nil(t, userUseCase.CreateUser(user, nil))
+
+	// Nadie puede suspender su propia cuenta
+	err := userUseCase.SuspendUser(user.ID, &model.UserStatusChange{ID: user.ID}, nil)
+	assert.ErrorIs(t, err, usecase.ErrCannotSuspendSelf)
+	err = userUseCase.SuspendUser(1000, &model.UserStatusChange{ID: 9999}, nil)
+	assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+
+	assert.Nil(t, userUseCase.SuspendUser(1000, &model.UserStatusChange{ID: user.ID, Reason: " abuse "}, nil))
+
+	active, err := userUseCase.IsAccountActive(user.ID)
+	assert.Nil(t, err)
+	assert.False(t, active)
+	_, err = userUseCase.Login("test@example.com", "password", model.ClientInfo{})
+	assert.ErrorIs(t, err, usecase.ErrAccountSuspended)
+
+	suspended, err := userUseCase.GetUserByID(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.UserStatusSuspended, suspended.Status)
+	assert.Equal(t, "abuse", suspended.StatusReason)
+
+	assert.Nil(t, userUseCase.ReactivateUser(1000, user.ID, nil))
+	active, err = userUseCase.IsAccountActive(user.ID)
+	assert.Nil(t, err)
+	assert.True(t, active)
+}
+
+func TestUserUseCase_RestoreDeletedUser(t *testing.T) {
+	testDB := setupTestDB()
+	defer resetTestDB(testDB)
+	_ = testDB.AutoMigrate(&model.Session{})
+
+	userUseCase := newUserUseCase(testDB)
+
+	user := &model.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		FullName: "Test User",
+		Password: "password",
+	}
+	assert.Nil(t, userUseCase.CreateUser(user, nil))
+	assert.ErrorIs(t, userUseCase.RestoreUser(1000, user.ID, nil), usecase.ErrUserNotDeleted)
+
+	assert.Nil(t, userUseCase.DeleteUser(user, nil))
+	active, err := userUseCase.IsAccountActive(user.ID)
+	assert.Nil(t, err)
+	assert.False(t, active)
+
+	deleted, total, err := userUseCase.PaginateDeletedUsers(1, 10, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, deleted, 1)
+
+	assert.Nil(t, userUseCase.RestoreUser(1000, user.ID, nil))
+	active, err = userUseCase.IsAccountActive(user.ID)
+	assert.Nil(t, err)
+	assert.True(t, active)
+}
+
+func TestUserUseCase_RowAccessOnSingleUserActions(t *testing.T) {
+	testDB := setupTestDB()
+	defer resetTestDB(testDB)
+	_ = testDB.AutoMigrate(&model.Session{}, &model.Level{})
+	defer testDB.Exec("DROP TABLE IF EXISTS levels")
+
+	userUseCase := newUserUseCase(testDB)
+
+	level := &model.Level{Level: "Usuario", Description: "Usuario"}
+	assert.Nil(t, testDB.Create(level).Error)
+	allowed := &model.User{Username: "allowed", Email: "allowed@example.com", Password: "password", LevelID: 1}
+	denied := &model.User{Username: "denied", Email: "denied@example.com", Password: "password", LevelID: 2}
+
+	// Solo se pueden gestionar los usuarios del nivel 1
+	policy, _ := security.ParseRowPolicy("level_id == 1")
+	filter := security.NewRowFilter(security.RowSubject{security.RowSubjectID: uint(1000)}, policy)
+
+	assert.ErrorIs(t, userUseCase.CreateUser(denied, filter), usecase.ErrRowAccessDenied)
+	assert.Nil(t, userUseCase.CreateUser(allowed, filter))
+	denied.ID = 0
+	assert.Nil(t, userUseCase.CreateUser(denied, nil))
+
+	// Un usuario accesible no se puede llevar fuera de las condiciones
+	moved := *allowed
+	moved.LevelID = 2
+	moved.Password = ""
+	assert.ErrorIs(t, userUseCase.UpdateUser(&moved, filter), usecase.ErrRowAccessDenied)
+	stored, err := userUseCase.GetUserByID(allowed.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), stored.LevelID)
+
+	assert.ErrorIs(t, userUseCase.SuspendUser(1000, &model.UserStatusChange{ID: denied.ID}, filter), usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, userUseCase.ReactivateUser(1000, denied.ID, filter), usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, userUseCase.SetUserLevels(1000, &model.UserLevelsChange{ID: denied.ID, LevelIDs: []uint{level.ID}}, filter), usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, userUseCase.RevokeUserTokens(denied.ID, filter), usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, userUseCase.UnlockUser(denied.ID, filter), usecase.ErrRowAccessDenied)
+	active, err := userUseCase.IsAccountActive(denied.ID)
+	assert.Nil(t, err)
+	assert.True(t, active)
+
+	assert.Nil(t, userUseCase.SuspendUser(1000, &model.UserStatusChange{ID: allowed.ID}, filter))
+	assert.Nil(t, userUseCase.ReactivateUser(1000, allowed.ID, filter))
+	admin := &model.User{Username: "admin", Email: "admin@example.com", Password: "password", LevelID: level.ID}
+	assert.Nil(t, userUseCase.CreateUser(admin, nil))
+	assert.Nil(t, userUseCase.SetUserLevels(admin.ID, &model.UserLevelsChange{ID: allowed.ID, LevelIDs: []uint{level.ID}}, filter))
+	assert.Nil(t, userUseCase.RevokeUserTokens(allowed.ID, filter))
+	assert.Nil(t, userUseCase.UnlockUser(allowed.ID, filter))
+
+	// Las condiciones también se aplican a los usuarios eliminados
+	assert.Nil(t, userUseCase.DeleteUser(allowed, nil))
+	assert.Nil(t, userUseCase.DeleteUser(denied, nil))
+	deleted, total, err := userUseCase.PaginateDeletedUsers(1, 10, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, deleted, 1) {
+		assert.Equal(t, allowed.ID, deleted[0].ID)
+	}
+	assert.ErrorIs(t, userUseCase.RestoreUser(1000, denied.ID, filter), usecase.ErrRowAccessDenied)
+	assert.ErrorIs(t, userUseCase.PurgeUser(1000, denied.ID, filter), usecase.ErrRowAccessDenied)
+	assert.Nil(t, userUseCase.RestoreUser(1000, allowed.ID, filter))
+
+	var count int64
+	testDB.Unscoped().Model(&model.User{}).Where("id = ?", denied.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestUserUseCase_SetUserLevelsRequiresPrivileges(t *testing.T) {
+	database := utils.SetupTestDB(t)
+	utils.ResetTestDB(database, t)
+	t.Cleanup(func() { utils.ResetTestDB(database, t) })
+	userUseCase := newUserUseCase(database)
+
+	// El gestor solo lee usuarios; el administrador además gestiona niveles
+	users := &model.Form{Title: "Usuarios", PathAPI: "user|users"}
+	levels := &model.Form{Title: "Niveles", PathAPI: "level|levels"}
+	assert.Nil(t, database.Create([]*model.Form{users, levels}).Error)
+	manager := &model.Level{Level: "Gestor", Description: "Gestor"}
+	admin := &model.Level{Level: "Administrador", Description: "Administrador"}
+	assert.Nil(t, database.Create([]*model.Level{manager, admin}).Error)
+	assert.Nil(t, database.Create(&model.LevelPrivileges{LevelID: manager.ID, FormID: users.ID, Read: true, Update: true}).Error)
+	assert.Nil(t, database.Create(&model.LevelPrivileges{LevelID: admin.ID, FormID: users.ID, Read: true, Update: true}).Error)
+	assert.Nil(t, database.Create(&model.LevelPrivileges{LevelID: admin.ID, FormID: levels.ID, Read: true, Update: true}).Error)
+
+	actor := &model.User{Username: "gestor", Email: "gestor@example.com", Password: "password", LevelID: manager.ID}
+	target := &model.User{Username: "ana", Email: "ana@example.com", Password: "password", LevelID: manager.ID}
+	assert.Nil(t, database.Create([]*model.User{actor, target}).Error)
+
+	assert.ErrorIs(t, userUseCase.SetUserLevels(actor.ID, &model.UserLevelsChange{ID: actor.ID, LevelIDs: []uint{manager.ID}}, nil), usecase.ErrCannotChangeOwnLevels)
+	assert.ErrorIs(t, userUseCase.SetUserLevels(actor.ID, &model.UserLevelsChange{ID: target.ID, LevelIDs: []uint{manager.ID, admin.ID}}, nil), usecase.ErrLevelNotGrantable)
+	assert.Nil(t, userUseCase.SetUserLevels(actor.ID, &model.UserLevelsChange{ID: target.ID, LevelIDs: []uint{manager.ID}}, nil))
+
+	stored, err := userUseCase.GetUserByID(target.ID)
+	assert.Nil(t, err)
+	if assert.Len(t, stored.Levels, 1) {
+		assert.Equal(t, manager.ID, stored.Levels[0].ID)
+	}
+}
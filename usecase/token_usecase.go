@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+const (
+	refreshTokenBytes = 32
+	// maxUserAgentLength tamaño de la columna user_agent de las sesiones
+	maxUserAgentLength = 255
+)
+
+type TokenUseCase struct {
+	refreshTokenRepository repository.RefreshTokenRepository
+	sessionRepository      repository.SessionRepository
+	userRepository         repository.UserRepository
+	revocationStore        repository.RevocationStore
+	accessTokenTTL         time.Duration
+	refreshTokenTTL        time.Duration
+	// permissions solo se usa si los access tokens llevan los privilegios
+	permissions *service.PermissionResolver
+}
+
+func NewTokenUseCase(refreshTokenRepo repository.RefreshTokenRepository, sessionRepo repository.SessionRepository, userRepo repository.UserRepository, revocationStore repository.RevocationStore, accessTokenTTL, refreshTokenTTL time.Duration) *TokenUseCase {
+	return &TokenUseCase{
+		refreshTokenRepository: refreshTokenRepo,
+		sessionRepository:      sessionRepo,
+		userRepository:         userRepo,
+		revocationStore:        revocationStore,
+		accessTokenTTL:         accessTokenTTL,
+		refreshTokenTTL:        refreshTokenTTL,
+	}
+}
+
+// EnablePermissionSnapshots hace que los access tokens lleven la copia
+// versionada de los privilegios efectivos del usuario
+func (uc *TokenUseCase) EnablePermissionSnapshots(levelRepo repository.LevelRepository) {
+	uc.permissions = service.NewPermissionResolver(levelRepo)
+}
+
+// IssueTokens abre una sesión para el dispositivo y genera un access token de
+// vida corta y un refresh token que inicia una nueva familia de rotación.
+func (uc *TokenUseCase) IssueTokens(user *model.User, client model.ClientInfo) (*model.TokenPair, error) {
+	familyID, err := helpers.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	session := &model.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		LastSeenAt: time.Now(),
+	}
+	if err := uc.sessionRepository.Create(session); err != nil {
+		return nil, err
+	}
+
+	pair, _, err := uc.issue(user, familyID, session.ID)
+	return pair, err
+}
+
+// Refresh rota el refresh token: el recibido queda revocado y se devuelve un
+// par nuevo de la misma familia. Presentar un token ya rotado se considera un
+// robo y revoca todos los tokens de la familia.
+func (uc *TokenUseCase) Refresh(rawToken string) (*model.TokenPair, error) {
+	current, err := uc.refreshTokenRepository.GetByHash(helpers.HashToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if current.RevokedAt != nil {
+		uc.revokeFamily(current.FamilyID, now)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if now.After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userRepository.GetByID(current.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Los refresh tokens anteriores a las sesiones no tienen sesión asociada
+	var sessionID uint
+	if session, err := uc.sessionRepository.GetByFamily(current.FamilyID); err == nil {
+		if session.RevokedAt != nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		sessionID = session.ID
+		if err := uc.sessionRepository.Touch(session.ID, now); err != nil {
+			log.Printf("Failed to update session last seen: %v", err)
+		}
+	}
+
+	pair, next, err := uc.issue(user, current.FamilyID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Si otra petición rotó el mismo token a la vez, solo una puede ganar
+	rotated, err := uc.refreshTokenRepository.Revoke(current.ID, now, &next.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		uc.revokeFamily(current.FamilyID, now)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return pair, nil
+}
+
+// Logout revoca el access token actual y su sesión y, si se envía, la familia
+// del refresh token asociado para que no pueda seguir renovándose.
+func (uc *TokenUseCase) Logout(claims *model.Claim, rawRefreshToken string) error {
+	if err := uc.RevokeAccessToken(claims); err != nil {
+		return err
+	}
+
+	if claims.SessionID != 0 {
+		if err := uc.RevokeSession(claims.UserID, claims.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if rawRefreshToken == "" {
+		return nil
+	}
+
+	refreshToken, err := uc.refreshTokenRepository.GetByHash(helpers.HashToken(rawRefreshToken))
+	if err != nil || refreshToken.UserID != claims.UserID {
+		return nil
+	}
+
+	return uc.refreshTokenRepository.RevokeFamily(refreshToken.FamilyID, time.Now())
+}
+
+// RevokeSession cierra una sesión del usuario: sus access tokens dejan de
+// aceptarse y su familia de refresh tokens queda revocada
+func (uc *TokenUseCase) RevokeSession(userID uint, sessionID uint) error {
+	session, err := uc.sessionRepository.GetByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	now := time.Now()
+	revoked, err := uc.sessionRepository.Revoke(session.ID, userID, now)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return uc.refreshTokenRepository.RevokeFamily(session.FamilyID, now)
+}
+
+// RevokeAccessToken revoca un access token concreto hasta que expire
+func (uc *TokenUseCase) RevokeAccessToken(claims *model.Claim) error {
+	expiresAt := time.Now().Add(uc.accessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	// Los tokens emitidos antes de incluir jti no se pueden revocar de forma
+	// individual, así que se invalidan todos los del usuario
+	if claims.ID == "" {
+		return uc.revocationStore.RevokeUser(claims.UserID, time.Now())
+	}
+	return uc.revocationStore.Revoke(claims.ID, claims.UserID, expiresAt)
+}
+
+// RevokeAllForUser invalida todos los access y refresh tokens emitidos hasta
+// ahora para el usuario.
+func (uc *TokenUseCase) RevokeAllForUser(userID uint) error {
+	now := time.Now()
+	if err := uc.revocationStore.RevokeUser(userID, now); err != nil {
+		return err
+	}
+	if err := uc.sessionRepository.RevokeByUser(userID, now); err != nil {
+		return err
+	}
+	return uc.refreshTokenRepository.RevokeByUser(userID, now)
+}
+
+// IsRevoked indica si el access token ha sido revocado antes de expirar
+func (uc *TokenUseCase) IsRevoked(claims *model.Claim) (bool, error) {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	return uc.revocationStore.IsRevoked(claims.ID, claims.UserID, issuedAt)
+}
+
+func (uc *TokenUseCase) issue(user *model.User, familyID string, sessionID uint) (*model.TokenPair, *model.RefreshToken, error) {
+	accessToken, err := helpers.GenerateSessionJWT(user, uc.accessTokenTTL, sessionID, uc.permissionSnapshot(user.LevelIDs()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawToken, err := helpers.GenerateSecureToken(refreshTokenBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken := &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: helpers.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(uc.refreshTokenTTL),
+	}
+	if err := uc.refreshTokenRepository.Create(refreshToken); err != nil {
+		return nil, nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresIn:    int64(uc.accessTokenTTL.Seconds()),
+	}, refreshToken, nil
+}
+
+// permissionSnapshot copia los privilegios efectivos de los niveles para el
+// access token. Si no se pueden leer el token se emite sin ellos y se autoriza
+// contra la base de datos. La versión se lee antes que los privilegios para
+// que un cambio entre ambas lecturas deje el token obsoleto y no al revés.
+func (uc *TokenUseCase) permissionSnapshot(levelIDs []uint) *security.PermissionSnapshot {
+	if uc.permissions == nil {
+		return nil
+	}
+	version, err := uc.permissions.Version(levelIDs...)
+	if err != nil {
+		log.Printf("Failed to load privileges of levels %v: %v", levelIDs, err)
+		return nil
+	}
+	privileges, err := uc.permissions.Privileges(levelIDs...)
+	if err != nil {
+		log.Printf("Failed to load privileges of levels %v: %v", levelIDs, err)
+		return nil
+	}
+
+	snapshot := &security.PermissionSnapshot{Version: version, Permissions: []string{}}
+	for _, privilege := range privileges {
+		if permission := security.FormatPermission(privilege.Form.PathAPI, privilege.Actions()); permission != "" {
+			snapshot.Permissions = append(snapshot.Permissions, permission)
+		}
+	}
+	return snapshot
+}
+
+// revokeFamily revoca los refresh tokens de la familia y la sesión a la que
+// pertenecen
+func (uc *TokenUseCase) revokeFamily(familyID string, at time.Time) {
+	if err := uc.refreshTokenRepository.RevokeFamily(familyID, at); err != nil {
+		log.Printf("Failed to revoke refresh token family %s: %v", familyID, err)
+	}
+	if session, err := uc.sessionRepository.GetByFamily(familyID); err == nil {
+		if _, err := uc.sessionRepository.Revoke(session.ID, session.UserID, at); err != nil {
+			log.Printf("Failed to revoke session %d: %v", session.ID, err)
+		}
+	}
+}
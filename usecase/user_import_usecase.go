@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/drossan/core-api/domain/importer"
+	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format, use csv or xlsx")
+	ErrImportMissingColumn     = errors.New("the file is missing a required column")
+	ErrImportEmpty             = errors.New("the file has no users")
+	ErrImportTooLarge          = errors.New("the file is too large")
+	ErrImportTooManyRows       = errors.New("the file has too many rows")
+	ErrImportInvalidRows       = errors.New("the file has invalid rows, nothing was imported")
+)
+
+// importSecretSize bytes del secreto aleatorio con el que se crean las cuentas
+// importadas sin invitación: no tienen contraseña utilizable hasta que la
+// restablecen
+const importSecretSize = 32
+
+// importColumns nombres de columna admitidos en la cabecera, sin distinguir
+// mayúsculas, espacios ni guiones
+var importColumns = map[string]string{
+	"username": "username",
+	"usuario":  "username",
+	"email":    "email",
+	"correo":   "email",
+	"fullname": "fullname",
+	"name":     "fullname",
+	"nombre":   "fullname",
+	"level":    "level",
+	"nivel":    "level",
+}
+
+// UserImportUseCase alta masiva de usuarios desde una hoja de cálculo. Valida
+// todas las filas antes de aplicar nada y las aplica en una sola transacción.
+type UserImportUseCase struct {
+	userRepository       repository.UserRepository
+	levelRepository      repository.LevelRepository
+	invitationRepository repository.InvitationRepository
+	invitationUseCase    *InvitationUseCase
+	passwordHasher       security.PasswordHasher
+	readers              map[string]importer.Reader
+	maxRows              int
+	maxFileSize          int64
+	permissions          *service.PermissionResolver
+}
+
+// NewUserImportUseCase readers asocia cada formato (la extensión del fichero
+// sin el punto) con su lector
+func NewUserImportUseCase(userRepo repository.UserRepository, levelRepo repository.LevelRepository, invitationRepo repository.InvitationRepository, invitationUseCase *InvitationUseCase, passwordHasher security.PasswordHasher, readers map[string]importer.Reader, maxRows int, maxFileSize int64, permissions *service.PermissionResolver) *UserImportUseCase {
+	return &UserImportUseCase{
+		userRepository:       userRepo,
+		levelRepository:      levelRepo,
+		invitationRepository: invitationRepo,
+		invitationUseCase:    invitationUseCase,
+		passwordHasher:       passwordHasher,
+		readers:              readers,
+		maxRows:              maxRows,
+		maxFileSize:          maxFileSize,
+		permissions:          permissions,
+	}
+}
+
+func (uc *UserImportUseCase) MaxFileSize() int64 {
+	return uc.maxFileSize
+}
+
+// Import lee el fichero y valida cada fila. Si hay errores, o es una prueba,
+// devuelve el resultado sin aplicar nada; si no, crea las cuentas o, con
+// Invite, las invitaciones. Las cuentas que se crean tienen que cumplir las
+// condiciones de acceso a filas y quien importa tiene que tener los
+// privilegios de sus niveles.
+func (uc *UserImportUseCase) Import(actorID uint, format string, input io.Reader, options model.UserImportOptions, filter *security.RowFilter) (*model.UserImportResult, error) {
+	reader, ok := uc.readers[strings.TrimPrefix(strings.ToLower(format), ".")]
+	if !ok {
+		return nil, ErrUnsupportedImportFormat
+	}
+	data, err := io.ReadAll(io.LimitReader(input, uc.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > uc.maxFileSize {
+		return nil, ErrImportTooLarge
+	}
+	records, err := reader.ReadRows(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := uc.parseRows(records)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.UserImportResult{DryRun: options.DryRun, Total: len(rows)}
+	levels, errs, err := uc.validate(actorID, rows)
+	if err != nil {
+		return nil, err
+	}
+	result.Errors = errs
+	if options.DryRun {
+		return result, nil
+	}
+	if len(result.Errors) > 0 {
+		return result, ErrImportInvalidRows
+	}
+
+	if options.Invite {
+		invitations := make([]*model.Invitation, 0, len(rows))
+		for _, row := range rows {
+			invitations = append(invitations, &model.Invitation{
+				Email:    row.Email,
+				Username: row.Username,
+				FullName: row.FullName,
+				LevelID:  levels[strings.ToLower(row.Level)],
+			})
+		}
+		sent, err := uc.invitationUseCase.InviteAll(actorID, invitations, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Invited = sent
+		return result, nil
+	}
+
+	now := time.Now()
+	users := make([]*model.User, 0, len(rows))
+	for _, row := range rows {
+		secret, err := helpers.GenerateSecureToken(importSecretSize)
+		if err != nil {
+			return nil, err
+		}
+		password, err := uc.passwordHasher.Hash(secret)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &model.User{
+			Username:          row.Username,
+			Email:             row.Email,
+			FullName:          row.FullName,
+			Password:          password,
+			PasswordChangedAt: &now,
+			LevelID:           levels[strings.ToLower(row.Level)],
+		})
+	}
+	if err := uc.userRepository.CreateBatch(users, filter); err != nil {
+		return nil, err
+	}
+
+	result.Created = len(users)
+	log.Printf("User %d imported %d users", actorID, len(users))
+	return result, nil
+}
+
+// parseRows asocia las columnas por el nombre de la cabecera y descarta las
+// filas vacías. Sin usuario se usa el email, como en las invitaciones.
+func (uc *UserImportUseCase) parseRows(records [][]string) ([]model.UserImportRow, error) {
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if field, ok := importColumns[key]; ok {
+			if _, repeated := columns[field]; !repeated {
+				columns[field] = i
+			}
+		}
+	}
+	for _, field := range []string{"email", "fullname", "level"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrImportMissingColumn, field)
+		}
+	}
+
+	cell := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []model.UserImportRow
+	for i, record := range records[1:] {
+		row := model.UserImportRow{
+			Row:      i + 2,
+			Username: cell(record, "username"),
+			Email:    strings.ToLower(cell(record, "email")),
+			FullName: cell(record, "fullname"),
+			Level:    cell(record, "level"),
+		}
+		if row.Username == "" && row.Email == "" && row.FullName == "" && row.Level == "" {
+			continue
+		}
+		if row.Username == "" {
+			row.Username = row.Email
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if uc.maxRows > 0 && len(rows) > uc.maxRows {
+		return nil, fmt.Errorf("%w: the limit is %d", ErrImportTooManyRows, uc.maxRows)
+	}
+	return rows, nil
+}
+
+// validate comprueba cada fila contra los índices únicos de usuario y email,
+// el resto de filas, las invitaciones pendientes y los niveles existentes que
+// quien importa puede asignar. Devuelve el ID de cada nivel por su nombre en
+// minúsculas.
+func (uc *UserImportUseCase) validate(actorID uint, rows []model.UserImportRow) (map[string]uint, []model.UserImportError, error) {
+	levelList, err := uc.levelRepository.GetAll(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	canGrant, err := levelGranter(uc.userRepository, uc.permissions, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	levels := make(map[string]uint, len(levelList))
+	grantable := make(map[uint]bool, len(levelList))
+	for _, level := range levelList {
+		levels[strings.ToLower(level.Level)] = level.ID
+		if grantable[level.ID], err = canGrant(level.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	usernames := make([]string, 0, len(rows))
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		usernames = append(usernames, strings.ToLower(row.Username))
+		emails = append(emails, row.Email)
+	}
+	takenUsernames, takenEmails, err := uc.userRepository.GetTakenIdentifiers(usernames, emails)
+	if err != nil {
+		return nil, nil, err
+	}
+	invitations, err := uc.invitationRepository.GetPending(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usernameRows := toSet(takenUsernames)
+	emailRows := toSet(takenEmails)
+	invited := make(map[string]struct{}, len(invitations))
+	for _, invitation := range invitations {
+		invited[strings.ToLower(invitation.Email)] = struct{}{}
+	}
+	seenUsernames := make(map[string]int, len(rows))
+	seenEmails := make(map[string]int, len(rows))
+
+	errs := []model.UserImportError{}
+	fail := func(row model.UserImportRow, field string, format string, args ...interface{}) {
+		errs = append(errs, model.UserImportError{Row: row.Row, Field: field, Error: fmt.Sprintf(format, args...)})
+	}
+
+	for _, row := range rows {
+		switch address, err := mail.ParseAddress(row.Email); {
+		case row.Email == "":
+			fail(row, "email", "required")
+		case err != nil || address.Address != row.Email:
+			fail(row, "email", "invalid email address")
+		case contains(emailRows, row.Email):
+			fail(row, "email", "already in use")
+		case contains(invited, row.Email):
+			fail(row, "email", "has a pending invitation")
+		case seenEmails[row.Email] != 0:
+			fail(row, "email", "repeated in row %d", seenEmails[row.Email])
+		default:
+			seenEmails[row.Email] = row.Row
+		}
+
+		username := strings.ToLower(row.Username)
+		switch {
+		case row.Username == "":
+			// Sin usuario ni email ya se informa del email
+		case contains(usernameRows, username):
+			fail(row, "username", "already in use")
+		case seenUsernames[username] != 0:
+			fail(row, "username", "repeated in row %d", seenUsernames[username])
+		default:
+			seenUsernames[username] = row.Row
+		}
+
+		if row.FullName == "" {
+			fail(row, "fullname", "required")
+		}
+
+		if row.Level == "" {
+			fail(row, "level", "required")
+		} else if levelID, ok := levels[strings.ToLower(row.Level)]; !ok {
+			fail(row, "level", "level %q does not exist", row.Level)
+		} else if !grantable[levelID] {
+			fail(row, "level", "level %q has privileges you do not have", row.Level)
+		}
+	}
+	return levels, errs, nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+func contains(set map[string]struct{}, value string) bool {
+	_, ok := set[value]
+	return ok
+}
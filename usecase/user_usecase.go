@@ -1,72 +1,405 @@
 package usecase
 
 import (
-	"crypto/sha256"
-	"fmt"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/drossan/core-api/domain/model"
 	"github.com/drossan/core-api/domain/repository"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/helpers"
+	"github.com/drossan/core-api/service"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("invalid email or password")
+	ErrInvalidPasswordToken = errors.New("invalid or expired password change token")
+	ErrAccountSuspended     = errors.New("account is suspended")
+	ErrCannotSuspendSelf    = errors.New("you cannot suspend your own account")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUserNotDeleted       = errors.New("deleted user not found")
+	ErrUserLevelNotFound    = errors.New("level not found")
 )
 
+// AccountLockedError indica que la cuenta está bloqueada por demasiados fallos de login
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked due to too many failed login attempts"
+}
+
 type UserUseCase struct {
 	userRepository repository.UserRepository
+	passwordHasher security.PasswordHasher
+	passwordPolicy *PasswordPolicyUseCase
+	tokenUseCase   *TokenUseCase
+	mfaUseCase     *MFAUseCase
+	lockoutPolicy  security.LockoutPolicy
+	permissions    *service.PermissionResolver
 }
 
-func NewUserUseCase(userRepo repository.UserRepository) *UserUseCase {
+func NewUserUseCase(userRepo repository.UserRepository, passwordHasher security.PasswordHasher, passwordPolicy *PasswordPolicyUseCase, tokenUseCase *TokenUseCase, mfaUseCase *MFAUseCase, lockoutPolicy security.LockoutPolicy, permissions *service.PermissionResolver) *UserUseCase {
 	return &UserUseCase{
 		userRepository: userRepo,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		tokenUseCase:   tokenUseCase,
+		mfaUseCase:     mfaUseCase,
+		lockoutPolicy:  lockoutPolicy,
+		permissions:    permissions,
 	}
 }
 
-func (uc *UserUseCase) CreateUser(user *model.User) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+// CreateUser da de alta el usuario si la contraseña cumple la política y el
+// usuario cumple las condiciones de acceso a filas
+func (uc *UserUseCase) CreateUser(user *model.User, filter *security.RowFilter) error {
+	hashedPassword, err := uc.passwordPolicy.Hash(user, user.Password)
 	if err != nil {
 		return err
 	}
 
-	user.Password = string(hashedPassword)
-	err = uc.userRepository.Create(user)
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	err = uc.userRepository.Create(user, filter)
 	if err != nil {
 		return err
 	}
 
+	uc.passwordPolicy.Remember(user, hashedPassword)
 	return nil
 }
 
-func (uc *UserUseCase) UpdateUser(user *model.User) error {
-	if user.Password != "" {
-		pw := sha256.Sum256([]byte("1234admin"))
-		user.Password = fmt.Sprintf("%x", pw)
-	} else {
-		existingUser, err := uc.userRepository.GetByID(user.ID)
-		if err != nil {
-			return err
-		}
+// UpdateUser guarda el usuario; la contraseña solo cambia si se envía y
+// cumple la política. El usuario tiene que cumplir las condiciones de acceso a
+// filas antes y después del cambio.
+func (uc *UserUseCase) UpdateUser(user *model.User, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, user.ID, filter); err != nil {
+		return err
+	}
+	existingUser, err := uc.userRepository.GetByID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password == "" {
 		user.Password = existingUser.Password
+		user.PasswordChangedAt = existingUser.PasswordChangedAt
+		return uc.userRepository.Update(user, filter)
+	}
+
+	hashedPassword, err := uc.passwordPolicy.Hash(user, user.Password)
+	if err != nil {
+		return err
 	}
-	return uc.userRepository.Update(user)
+
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	if err := uc.userRepository.Update(user, filter); err != nil {
+		return err
+	}
+
+	uc.passwordPolicy.Remember(user, hashedPassword)
+	return nil
 }
 
 func (uc *UserUseCase) GetUserByID(id uint) (*model.User, error) {
 	return uc.userRepository.GetByID(id)
 }
 
+// GetUserWithPrivileges devuelve el usuario con sus privilegios efectivos: los
+// de todos sus niveles y los de los niveles de los que heredan
+func (uc *UserUseCase) GetUserWithPrivileges(id uint) (*model.User, error) {
+	user, err := uc.userRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Privileges, err = uc.permissions.Privileges(user.LevelIDs()...); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetUserLevels sustituye los niveles adicionales del usuario. Nadie puede
+// cambiar sus propios niveles ni asignar niveles con privilegios que no tiene.
+// Los tokens ya emitidos conservan los niveles anteriores hasta que se refrescan.
+func (uc *UserUseCase) SetUserLevels(actorID uint, change *model.UserLevelsChange, filter *security.RowFilter) error {
+	if change.ID == actorID {
+		return ErrCannotChangeOwnLevels
+	}
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, change.ID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(change.ID); err != nil {
+		return ErrUserNotFound
+	}
+	if _, err := uc.permissions.Levels(change.LevelIDs...); err != nil {
+		return ErrUserLevelNotFound
+	}
+	if err := checkLevelsGrantable(uc.userRepository, uc.permissions, actorID, change.LevelIDs...); err != nil {
+		return err
+	}
+	return uc.userRepository.SetLevels(change.ID, change.LevelIDs)
+}
+
 func (uc *UserUseCase) GetUserByEmail(email string) (*model.User, error) {
 	return uc.userRepository.GetByEmail(email)
 }
 
-func (uc *UserUseCase) GetAllUsers() ([]*model.User, error) {
-	return uc.userRepository.GetAll()
+func (uc *UserUseCase) GetAllUsers(filter *security.RowFilter) ([]*model.User, error) {
+	return uc.userRepository.GetAll(filter)
+}
+
+func (uc *UserUseCase) PaginateUsers(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	return uc.userRepository.Paginate(page, pageSize, filter)
+}
+
+// DeleteUser elimina el usuario y revoca todos sus tokens. El borrado es
+// lógico: se puede recuperar con RestoreUser hasta que se purgue.
+func (uc *UserUseCase) DeleteUser(user *model.User, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, user.ID, filter); err != nil {
+		return err
+	}
+	if err := uc.userRepository.Delete(user); err != nil {
+		return err
+	}
+	return uc.tokenUseCase.RevokeAllForUser(user.ID)
+}
+
+// SuspendUser bloquea la cuenta sin borrarla: no puede entrar y sus tokens y
+// API keys dejan de valer hasta que se reactive
+func (uc *UserUseCase) SuspendUser(actorID uint, change *model.UserStatusChange, filter *security.RowFilter) error {
+	if change.ID == actorID {
+		return ErrCannotSuspendSelf
+	}
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, change.ID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(change.ID); err != nil {
+		return ErrUserNotFound
+	}
+
+	reason := strings.TrimSpace(change.Reason)
+	if err := uc.userRepository.SetStatus(change.ID, model.UserStatusSuspended, reason, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("User %d suspended user %d: %s", actorID, change.ID, reason)
+	return uc.tokenUseCase.RevokeAllForUser(change.ID)
+}
+
+// ReactivateUser devuelve la cuenta suspendida al estado activo
+func (uc *UserUseCase) ReactivateUser(actorID uint, userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := uc.userRepository.SetStatus(userID, model.UserStatusActive, "", time.Now()); err != nil {
+		return err
+	}
+	log.Printf("User %d reactivated user %d", actorID, userID)
+	return nil
+}
+
+func (uc *UserUseCase) PaginateDeletedUsers(page int, pageSize int, filter *security.RowFilter) ([]*model.User, int, error) {
+	return uc.userRepository.PaginateDeleted(page, pageSize, filter)
+}
+
+// RestoreUser recupera un usuario eliminado, que vuelve a estar activo
+func (uc *UserUseCase) RestoreUser(actorID uint, userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	restored, err := uc.userRepository.Restore(userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrUserNotDeleted
+	}
+	log.Printf("User %d restored user %d", actorID, userID)
+	return nil
+}
+
+// PurgeUser borra definitivamente un usuario eliminado; no se puede deshacer
+func (uc *UserUseCase) PurgeUser(actorID uint, userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	purged, err := uc.userRepository.Purge(userID)
+	if err != nil {
+		return err
+	}
+	if !purged {
+		return ErrUserNotDeleted
+	}
+	log.Printf("User %d purged user %d", actorID, userID)
+	return uc.tokenUseCase.RevokeAllForUser(userID)
+}
+
+// IsAccountActive indica si la cuenta puede seguir usando sus tokens: las
+// suspendidas, eliminadas o inexistentes no
+func (uc *UserUseCase) IsAccountActive(userID uint) (bool, error) {
+	status, err := uc.userRepository.GetStatus(userID)
+	if err != nil {
+		return false, err
+	}
+	return status == model.UserStatusActive, nil
+}
+
+func (uc *UserUseCase) RevokeUserTokens(userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(userID); err != nil {
+		return err
+	}
+	return uc.tokenUseCase.RevokeAllForUser(userID)
+}
+
+// UnlockUser borra los fallos de login y el bloqueo de la cuenta
+func (uc *UserUseCase) UnlockUser(userID uint, filter *security.RowFilter) error {
+	if err := checkRowAccess(uc.userRepository.MatchesFilter, userID, filter); err != nil {
+		return err
+	}
+	if _, err := uc.userRepository.GetByID(userID); err != nil {
+		return err
+	}
+	return uc.userRepository.ResetFailures(userID)
+}
+
+// Login verifica las credenciales y devuelve un access token junto a su refresh
+// token. Si la contraseña está guardada con un algoritmo o parámetros antiguos
+// se vuelve a generar el hash con el algoritmo actual sin que el usuario tenga
+// que hacer nada.
+//
+// Los fallos se cuentan por cuenta en la base de datos, así que el bloqueo se
+// respeta aunque haya varias instancias del API. Si el usuario tiene que pasar
+// el segundo factor se devuelve un MFARequiredError con el token para hacerlo,
+// y si la contraseña ha caducado un PasswordExpiredError para cambiarla antes.
+func (uc *UserUseCase) Login(email, password string, client model.ClientInfo) (*model.TokenPair, error) {
+	user, err := uc.userRepository.GetByEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, &AccountLockedError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	ok, err := uc.passwordHasher.Verify(user.Password, password)
+	if err != nil || !ok {
+		return nil, registerAuthFailure(uc.userRepository, uc.lockoutPolicy, user, now, ErrInvalidCredentials)
+	}
+
+	// Solo se informa de la suspensión a quien conoce la contraseña
+	if !user.IsActive() {
+		return nil, ErrAccountSuspended
+	}
+
+	if uc.passwordPolicy.IsExpired(user) {
+		return nil, uc.passwordPolicy.Challenge(user)
+	}
+
+	if uc.passwordHasher.NeedsRehash(user.Password) {
+		uc.rehashPassword(user, password)
+	}
+
+	// Con 2FA los fallos no se reinician hasta completar el segundo factor; si
+	// no, repetir el login permitiría probar códigos sin límite
+	if uc.mfaUseCase.IsRequired(user) {
+		return nil, uc.mfaUseCase.Challenge(user)
+	}
+
+	if user.Failure > 0 || user.LockedUntil != nil {
+		if err := uc.userRepository.ResetFailures(user.ID); err != nil {
+			log.Printf("Failed to reset login failures for user %d: %v", user.ID, err)
+		}
+	}
+
+	return uc.tokenUseCase.IssueTokens(user, client)
 }
 
-func (uc *UserUseCase) PaginateUsers(page int, pageSize int) ([]*model.User, int, error) {
-	return uc.userRepository.Paginate(page, pageSize)
+// ChangeExpiredPassword fija la nueva contraseña con el token recibido en el
+// login y lo completa: si el usuario tiene 2FA se devuelve el MFARequiredError
+// para pasar el segundo factor y si no, los tokens de la sesión.
+func (uc *UserUseCase) ChangeExpiredPassword(request *model.ExpiredPasswordChange, client model.ClientInfo) (*model.TokenPair, error) {
+	claims, err := helpers.ParseJWT(request.PasswordToken)
+	if err != nil || !claims.PasswordExpired {
+		return nil, ErrInvalidPasswordToken
+	}
+
+	user, err := uc.userRepository.GetByID(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidPasswordToken
+	}
+	// Una vez cambiada la contraseña el token deja de servir
+	if !uc.passwordPolicy.IsExpired(user) {
+		return nil, ErrInvalidPasswordToken
+	}
+
+	if request.Password.Password != request.ConfirmPassword {
+		return nil, ErrPasswordMismatch
+	}
+	hashedPassword, err := uc.passwordPolicy.Hash(user, request.Password.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepository.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
+	uc.passwordPolicy.Remember(user, hashedPassword)
+
+	if uc.mfaUseCase.IsRequired(user) {
+		return nil, uc.mfaUseCase.Challenge(user)
+	}
+
+	if user.Failure > 0 || user.LockedUntil != nil {
+		if err := uc.userRepository.ResetFailures(user.ID); err != nil {
+			log.Printf("Failed to reset login failures for user %d: %v", user.ID, err)
+		}
+	}
+
+	return uc.tokenUseCase.IssueTokens(user, client)
 }
 
-func (uc *UserUseCase) DeleteUser(user *model.User) error {
-	return uc.userRepository.Delete(user)
+// registerAuthFailure suma un fallo de autenticación y bloquea la cuenta si
+// se alcanza el límite de la política.
+func registerAuthFailure(userRepo repository.UserRepository, policy security.LockoutPolicy, user *model.User, now time.Time, failureErr error) error {
+	failures, err := userRepo.IncrementFailure(user.ID)
+	if err != nil {
+		log.Printf("Failed to register login failure for user %d: %v", user.ID, err)
+		return failureErr
+	}
+
+	lockDuration := policy.LockDuration(failures)
+	if lockDuration == 0 {
+		return failureErr
+	}
+
+	if err := userRepo.LockUntil(user.ID, now.Add(lockDuration)); err != nil {
+		log.Printf("Failed to lock user %d: %v", user.ID, err)
+	}
+
+	return &AccountLockedError{RetryAfter: lockDuration}
 }
 
-func (uc *UserUseCase) Login(email, password string) (string, error) {
-	return uc.userRepository.Login(email, password)
+func (uc *UserUseCase) rehashPassword(user *model.User, password string) {
+	hashedPassword, err := uc.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := uc.userRepository.Update(user, nil); err != nil {
+		log.Printf("Failed to store rehashed password for user %d: %v", user.ID, err)
+	}
 }
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fakeIdPKeyID = "test-key"
+
+type fakeAuthRequest struct {
+	nonce     string
+	challenge string
+}
+
+// FakeIdP proveedor OpenID Connect mínimo para tests: publica discovery y JWKS,
+// valida PKCE en el endpoint de token y firma los id_token con RS256. Claims
+// son los claims extra (email, groups...) del próximo id_token emitido.
+type FakeIdP struct {
+	Server   *httptest.Server
+	ClientID string
+	Claims   jwt.MapClaims
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]fakeAuthRequest
+}
+
+func NewFakeIdP(t *testing.T, clientID string) *FakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate idp key: %v", err)
+	}
+
+	idp := &FakeIdP{ClientID: clientID, Claims: jwt.MapClaims{}, key: key, codes: map[string]fakeAuthRequest{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Server.Close)
+
+	return idp
+}
+
+func (f *FakeIdP) Issuer() string {
+	return f.Server.URL
+}
+
+// Authorize simula que el usuario se autentica en el proveedor: recibe la URL
+// de autorización generada por el API y devuelve el code y el state con los
+// que el proveedor redirigiría al callback
+func (f *FakeIdP) Authorize(t *testing.T, authURL string) (string, string) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("client_id") != f.ClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("Unexpected authorization request: %s", authURL)
+	}
+
+	code, err := GenerateTestSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	f.mu.Lock()
+	f.codes[code] = fakeAuthRequest{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	f.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// SignIDToken firma un id_token con la clave publicada en el JWKS
+func (f *FakeIdP) SignIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fakeIdPKeyID
+	signed, _ := token.SignedString(f.key)
+	return signed
+}
+
+func (f *FakeIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 f.Issuer(),
+		"authorization_endpoint": f.Issuer() + "/authorize",
+		"token_endpoint":         f.Issuer() + "/token",
+		"jwks_uri":               f.Issuer() + "/jwks",
+	})
+}
+
+func (f *FakeIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := f.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": fakeIdPKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func (f *FakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.Form.Get("code")
+	f.mu.Lock()
+	request, ok := f.codes[code]
+	delete(f.codes, code)
+	f.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != request.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   f.Issuer(),
+		"aud":   f.ClientID,
+		"sub":   "subject-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": request.nonce,
+	}
+	for name, value := range f.Claims {
+		claims[name] = value
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"id_token":     f.SignIDToken(claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// GenerateTestSecret cadena aleatoria para códigos y secretos de los tests
+func GenerateTestSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
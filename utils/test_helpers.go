@@ -2,8 +2,15 @@ package utils
 
 import (
 	"testing"
+	"time"
 
+	"github.com/drossan/core-api/adapters"
 	"github.com/drossan/core-api/domain/model"
+	"github.com/drossan/core-api/domain/repository"
+	"github.com/drossan/core-api/domain/security"
+	"github.com/drossan/core-api/service"
+	"github.com/drossan/core-api/usecase"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -22,6 +29,17 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&model.MenuTree{},
 		&model.Level{},
 		&model.LevelPrivileges{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.UserTokenRevocation{},
+		&model.RecoveryCode{},
+		&model.APIKey{},
+		&model.OIDCState{},
+		&model.Session{},
+		&model.PasswordHistory{},
+		&model.Invitation{},
+		&model.AuditEntry{},
+		&model.SchemaMigration{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
@@ -31,11 +49,23 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 
 func ResetTestDB(db *gorm.DB, t *testing.T) {
 	err := db.Migrator().DropTable(
+		"user_levels",
 		&model.User{},
 		&model.Form{},
 		&model.MenuTree{},
 		&model.Level{},
 		&model.LevelPrivileges{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.UserTokenRevocation{},
+		&model.RecoveryCode{},
+		&model.APIKey{},
+		&model.OIDCState{},
+		&model.Session{},
+		&model.PasswordHistory{},
+		&model.Invitation{},
+		&model.AuditEntry{},
+		&model.SchemaMigration{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to drop tables: %v", err)
@@ -47,8 +77,46 @@ func ResetTestDB(db *gorm.DB, t *testing.T) {
 		&model.MenuTree{},
 		&model.Level{},
 		&model.LevelPrivileges{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.UserTokenRevocation{},
+		&model.RecoveryCode{},
+		&model.APIKey{},
+		&model.OIDCState{},
+		&model.Session{},
+		&model.PasswordHistory{},
+		&model.Invitation{},
+		&model.AuditEntry{},
+		&model.SchemaMigration{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
 }
+
+// NewTestPasswordHasher devuelve un hasher bcrypt de coste mínimo para que los
+// tests sean rápidos, capaz de verificar también los hashes SHA-256 heredados.
+func NewTestPasswordHasher() *service.PasswordService {
+	return service.NewPasswordService(adapters.NewBcryptHasher(bcrypt.MinCost), adapters.NewSHA256Hasher())
+}
+
+// NewTestLockoutPolicy bloquea la cuenta al tercer fallo de login
+func NewTestLockoutPolicy() security.LockoutPolicy {
+	return security.LockoutPolicy{MaxFailures: 3, BaseDuration: time.Minute, MaxDuration: time.Hour}
+}
+
+// NewTestPasswordPolicy política de contraseñas poco exigente para los tests:
+// 6 caracteres, sin historial ni caducidad y con una lista mínima de
+// contraseñas comunes. Cada nivel puede endurecerla como en producción.
+func NewTestPasswordPolicy(historyRepo repository.PasswordHistoryRepository, levelRepo repository.LevelRepository, hasher security.PasswordHasher) *usecase.PasswordPolicyUseCase {
+	policy := security.PasswordPolicy{MinLength: 6}
+	blocklist := adapters.NewPasswordBlocklist("123456", "qwerty")
+	return usecase.NewPasswordPolicyUseCase(policy, blocklist, historyRepo, levelRepo, hasher, 10*time.Minute)
+}
+
+// NewTestTokenKeys claves HS256 con el secreto de los tests, equivalentes a
+// firmar con JWT_SECRET
+func NewTestTokenKeys(secret string) *service.KeyManager {
+	keyManager, _ := service.NewKeyManager(adapters.NewHMACSigningKey(secret))
+	return keyManager
+}